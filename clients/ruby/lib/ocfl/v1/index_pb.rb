@@ -3,11 +3,13 @@
 
 require 'google/protobuf'
 
+require 'google/protobuf/duration_pb'
 require 'google/protobuf/timestamp_pb'
 
 Google::Protobuf::DescriptorPool.generated_pool.build do
   add_file("ocfl/v1/index.proto", :syntax => :proto3) do
     add_message "ocfl.v1.GetStatusRequest" do
+      optional :storage_root, :string, 1, json_name: "storageRoot"
     end
     add_message "ocfl.v1.GetStatusResponse" do
       optional :status, :string, 1, json_name: "status"
@@ -16,20 +18,39 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       optional :store_description, :string, 4, json_name: "storeDescription"
       optional :num_object_paths, :int32, 5, json_name: "numObjectPaths"
       optional :num_inventories, :int32, 6, json_name: "numInventories"
+      optional :storage_root, :string, 7, json_name: "storageRoot"
+      repeated :storage_roots, :message, 8, "ocfl.v1.GetStatusResponse.StorageRoot", json_name: "storageRoots"
+    end
+    add_message "ocfl.v1.GetStatusResponse.StorageRoot" do
+      optional :name, :string, 1, json_name: "name"
+      optional :root_path, :string, 2, json_name: "rootPath"
+      optional :spec, :string, 3, json_name: "spec"
+      optional :description, :string, 4, json_name: "description"
+      optional :num_object_paths, :int32, 5, json_name: "numObjectPaths"
+      optional :num_inventories, :int32, 6, json_name: "numInventories"
     end
     add_message "ocfl.v1.IndexAllRequest" do
+      optional :storage_root, :string, 1, json_name: "storageRoot"
+      optional :force, :bool, 2, json_name: "force"
+      optional :timeout, :message, 3, "google.protobuf.Duration", json_name: "timeout"
     end
     add_message "ocfl.v1.IndexAllResponse" do
+      optional :task_id, :int64, 1, json_name: "taskId"
     end
     add_message "ocfl.v1.IndexIDsRequest" do
       repeated :object_ids, :string, 1, json_name: "objectIds"
+      optional :storage_root, :string, 2, json_name: "storageRoot"
+      optional :force, :bool, 3, json_name: "force"
+      optional :timeout, :message, 4, "google.protobuf.Duration", json_name: "timeout"
     end
     add_message "ocfl.v1.IndexIDsResponse" do
+      optional :task_id, :int64, 1, json_name: "taskId"
     end
     add_message "ocfl.v1.ListObjectsRequest" do
       optional :page_token, :string, 1, json_name: "pageToken"
       optional :page_size, :int32, 2, json_name: "pageSize"
       optional :id_prefix, :string, 3, json_name: "idPrefix"
+      optional :storage_root, :string, 4, json_name: "storageRoot"
     end
     add_message "ocfl.v1.ListObjectsResponse" do
       repeated :objects, :message, 1, "ocfl.v1.ListObjectsResponse.Object", json_name: "objects"
@@ -41,8 +62,16 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       optional :v1_created, :message, 3, "google.protobuf.Timestamp", json_name: "v1Created"
       optional :head_created, :message, 4, "google.protobuf.Timestamp", json_name: "headCreated"
     end
+    add_message "ocfl.v1.StreamObjectsRequest" do
+      optional :id_prefix, :string, 1, json_name: "idPrefix"
+      optional :storage_root, :string, 2, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.StreamObjectsResponse" do
+      repeated :objects, :message, 1, "ocfl.v1.ListObjectsResponse.Object", json_name: "objects"
+    end
     add_message "ocfl.v1.GetObjectRequest" do
       optional :object_id, :string, 1, json_name: "objectId"
+      optional :storage_root, :string, 2, json_name: "storageRoot"
     end
     add_message "ocfl.v1.GetObjectResponse" do
       optional :object_id, :string, 1, json_name: "objectId"
@@ -51,6 +80,9 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       optional :digest_algorithm, :string, 4, json_name: "digestAlgorithm"
       repeated :versions, :message, 5, "ocfl.v1.GetObjectResponse.Version", json_name: "versions"
       optional :indexed_at, :message, 6, "google.protobuf.Timestamp", json_name: "indexedAt"
+      proto3_optional :fixity, :message, 7, "ocfl.v1.FixityCheck", json_name: "fixity"
+      optional :inventory_digest, :string, 8, json_name: "inventoryDigest"
+      optional :sidecar_algorithm, :string, 9, json_name: "sidecarAlgorithm"
     end
     add_message "ocfl.v1.GetObjectResponse.Version" do
       optional :num, :string, 1, json_name: "num"
@@ -59,6 +91,11 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       proto3_optional :user, :message, 4, "ocfl.v1.GetObjectResponse.Version.User", json_name: "user"
       optional :size, :int64, 5, json_name: "size"
       optional :has_size, :bool, 6, json_name: "hasSize"
+      optional :num_files, :int64, 7, json_name: "numFiles"
+      optional :num_added, :int64, 8, json_name: "numAdded"
+      optional :num_removed, :int64, 9, json_name: "numRemoved"
+      optional :num_modified, :int64, 10, json_name: "numModified"
+      optional :has_stats, :bool, 11, json_name: "hasStats"
     end
     add_message "ocfl.v1.GetObjectResponse.Version.User" do
       optional :name, :string, 1, json_name: "name"
@@ -71,6 +108,7 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       optional :recursive, :bool, 4, json_name: "recursive"
       optional :page_token, :string, 5, json_name: "pageToken"
       optional :page_size, :int32, 6, json_name: "pageSize"
+      optional :storage_root, :string, 7, json_name: "storageRoot"
     end
     add_message "ocfl.v1.GetObjectStateResponse" do
       optional :digest, :string, 1, json_name: "digest"
@@ -87,10 +125,180 @@ Google::Protobuf::DescriptorPool.generated_pool.build do
       optional :has_size, :bool, 4, json_name: "hasSize"
       optional :digest, :string, 5, json_name: "digest"
     end
+    add_message "ocfl.v1.StreamObjectStateRequest" do
+      optional :object_id, :string, 1, json_name: "objectId"
+      optional :version, :string, 2, json_name: "version"
+      optional :base_path, :string, 3, json_name: "basePath"
+      optional :recursive, :bool, 4, json_name: "recursive"
+      optional :storage_root, :string, 5, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.StreamObjectStateResponse" do
+      optional :digest, :string, 1, json_name: "digest"
+      optional :isdir, :bool, 2, json_name: "isdir"
+      optional :size, :int64, 3, json_name: "size"
+      optional :has_size, :bool, 4, json_name: "hasSize"
+      repeated :children, :message, 5, "ocfl.v1.GetObjectStateResponse.Item", json_name: "children"
+    end
+    add_message "ocfl.v1.SearchRequest" do
+      optional :query, :string, 1, json_name: "query"
+      optional :page_token, :string, 2, json_name: "pageToken"
+      optional :page_size, :int32, 3, json_name: "pageSize"
+      optional :storage_root, :string, 4, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.SearchResponse" do
+      repeated :results, :message, 1, "ocfl.v1.SearchResponse.Result", json_name: "results"
+      optional :next_page_token, :string, 2, json_name: "nextPageToken"
+    end
+    add_message "ocfl.v1.SearchResponse.Result" do
+      optional :object_id, :string, 1, json_name: "objectId"
+      optional :version, :string, 2, json_name: "version"
+      optional :path, :string, 3, json_name: "path"
+    end
+    add_message "ocfl.v1.FindByDigestRequest" do
+      optional :digest, :string, 1, json_name: "digest"
+      optional :page_token, :string, 2, json_name: "pageToken"
+      optional :page_size, :int32, 3, json_name: "pageSize"
+      optional :storage_root, :string, 4, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.FindByDigestResponse" do
+      repeated :objects, :message, 1, "ocfl.v1.FindByDigestResponse.Object", json_name: "objects"
+      optional :next_page_token, :string, 2, json_name: "nextPageToken"
+    end
+    add_message "ocfl.v1.FindByDigestResponse.Object" do
+      optional :object_id, :string, 1, json_name: "objectId"
+      repeated :paths, :message, 2, "ocfl.v1.FindByDigestResponse.Object.Path", json_name: "paths"
+    end
+    add_message "ocfl.v1.FindByDigestResponse.Object.Path" do
+      optional :version, :string, 1, json_name: "version"
+      optional :path, :string, 2, json_name: "path"
+      optional :isdir, :bool, 3, json_name: "isdir"
+    end
+    add_message "ocfl.v1.DiffVersionsRequest" do
+      optional :object_id, :string, 1, json_name: "objectId"
+      optional :from_version, :string, 2, json_name: "fromVersion"
+      optional :to_version, :string, 3, json_name: "toVersion"
+      optional :to_object_id, :string, 4, json_name: "toObjectId"
+      optional :base_path, :string, 5, json_name: "basePath"
+      optional :page_token, :string, 6, json_name: "pageToken"
+      optional :page_size, :int32, 7, json_name: "pageSize"
+      optional :storage_root, :string, 8, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.DiffVersionsResponse" do
+      repeated :changes, :message, 1, "ocfl.v1.DiffVersionsResponse.Change", json_name: "changes"
+      optional :next_page_token, :string, 2, json_name: "nextPageToken"
+    end
+    add_message "ocfl.v1.DiffVersionsResponse.Change" do
+      optional :type, :enum, 1, "ocfl.v1.DiffVersionsResponse.ChangeType", json_name: "type"
+      optional :path, :string, 2, json_name: "path"
+      optional :old_path, :string, 3, json_name: "oldPath"
+      optional :digest, :string, 4, json_name: "digest"
+      optional :old_digest, :string, 5, json_name: "oldDigest"
+    end
+    add_enum "ocfl.v1.DiffVersionsResponse.ChangeType" do
+      value :CHANGE_TYPE_UNSPECIFIED, 0
+      value :CHANGE_TYPE_ADDED, 1
+      value :CHANGE_TYPE_REMOVED, 2
+      value :CHANGE_TYPE_MODIFIED, 3
+      value :CHANGE_TYPE_RENAMED, 4
+    end
+    add_message "ocfl.v1.ListInvalidObjectsRequest" do
+      optional :page_token, :string, 1, json_name: "pageToken"
+      optional :page_size, :int32, 2, json_name: "pageSize"
+      optional :storage_root, :string, 3, json_name: "storageRoot"
+    end
+    add_message "ocfl.v1.ListInvalidObjectsResponse" do
+      repeated :objects, :message, 1, "ocfl.v1.ListInvalidObjectsResponse.Object", json_name: "objects"
+      optional :next_page_token, :string, 2, json_name: "nextPageToken"
+    end
+    add_message "ocfl.v1.ListInvalidObjectsResponse.Issue" do
+      optional :severity, :string, 1, json_name: "severity"
+      optional :code, :string, 2, json_name: "code"
+      optional :message, :string, 3, json_name: "message"
+      optional :inventory_path, :string, 4, json_name: "inventoryPath"
+      optional :checked_at, :message, 5, "google.protobuf.Timestamp", json_name: "checkedAt"
+    end
+    add_message "ocfl.v1.ListInvalidObjectsResponse.Object" do
+      optional :object_path, :string, 1, json_name: "objectPath"
+      repeated :issues, :message, 2, "ocfl.v1.ListInvalidObjectsResponse.Issue", json_name: "issues"
+    end
     add_message "ocfl.v1.FollowLogsRequest" do
+      optional :task_id, :int64, 1, json_name: "taskId"
+      optional :resume_after, :int64, 2, json_name: "resumeAfter"
     end
     add_message "ocfl.v1.FollowLogsResponse" do
       optional :message, :string, 1, json_name: "message"
+      optional :task_id, :int64, 2, json_name: "taskId"
+      optional :progress, :message, 3, "ocfl.v1.Progress", json_name: "progress"
+      optional :seq, :int64, 4, json_name: "seq"
+    end
+    add_message "ocfl.v1.Progress" do
+      optional :storage_root, :string, 1, json_name: "storageRoot"
+      optional :phase, :enum, 2, "ocfl.v1.Progress.Phase", json_name: "phase"
+      optional :found, :int64, 3, json_name: "found"
+      optional :processed, :int64, 4, json_name: "processed"
+      optional :skipped, :int64, 5, json_name: "skipped"
+      optional :errors, :int64, 6, json_name: "errors"
+      optional :eta, :message, 7, "google.protobuf.Duration", json_name: "eta"
+    end
+    add_enum "ocfl.v1.Progress.Phase" do
+      value :PHASE_UNSPECIFIED, 0
+      value :PHASE_SCAN_ROOTS, 1
+      value :PHASE_PARSE_INVENTORIES, 2
+      value :PHASE_REMOVE_STALE, 3
+      value :PHASE_VALIDATE_OBJECTS, 4
+    end
+    add_message "ocfl.v1.Task" do
+      optional :id, :int64, 1, json_name: "id"
+      optional :name, :string, 2, json_name: "name"
+      optional :storage_root, :string, 3, json_name: "storageRoot"
+      map :options, :string, :string, 4
+      optional :status, :string, 5, json_name: "status"
+      optional :started_at, :message, 6, "google.protobuf.Timestamp", json_name: "startedAt"
+      optional :ended_at, :message, 7, "google.protobuf.Timestamp", json_name: "endedAt"
+      map :counts, :string, :int64, 8
+      optional :error, :string, 9, json_name: "error"
+    end
+    add_message "ocfl.v1.ListTasksRequest" do
+      optional :page_token, :string, 1, json_name: "pageToken"
+      optional :page_size, :int32, 2, json_name: "pageSize"
+    end
+    add_message "ocfl.v1.ListTasksResponse" do
+      repeated :tasks, :message, 1, "ocfl.v1.Task", json_name: "tasks"
+      optional :next_page_token, :string, 2, json_name: "nextPageToken"
+    end
+    add_message "ocfl.v1.GetTaskRequest" do
+      optional :task_id, :int64, 1, json_name: "taskId"
+    end
+    add_message "ocfl.v1.GetTaskResponse" do
+      optional :task, :message, 1, "ocfl.v1.Task", json_name: "task"
+    end
+    add_message "ocfl.v1.CancelTaskRequest" do
+      optional :task_id, :int64, 1, json_name: "taskId"
+    end
+    add_message "ocfl.v1.CancelTaskResponse" do
+    end
+    add_message "ocfl.v1.FixityCheck" do
+      optional :valid, :bool, 1, json_name: "valid"
+      repeated :codes, :string, 2, json_name: "codes"
+      optional :error, :string, 3, json_name: "error"
+      optional :checked_at, :message, 4, "google.protobuf.Timestamp", json_name: "checkedAt"
+    end
+    add_message "ocfl.v1.ValidateObjectsRequest" do
+      optional :storage_root, :string, 1, json_name: "storageRoot"
+      repeated :object_ids, :string, 2, json_name: "objectIds"
+      optional :oldest, :int32, 3, json_name: "oldest"
+      optional :timeout, :message, 4, "google.protobuf.Duration", json_name: "timeout"
+    end
+    add_message "ocfl.v1.ValidateObjectsResponse" do
+      optional :task_id, :int64, 1, json_name: "taskId"
+    end
+    add_message "ocfl.v1.ErrorDetail" do
+      optional :reason, :string, 1, json_name: "reason"
+      optional :storage_root, :string, 2, json_name: "storageRoot"
+      optional :object_id, :string, 3, json_name: "objectId"
+      optional :version, :string, 4, json_name: "version"
+      optional :path, :string, 5, json_name: "path"
+      optional :digest, :string, 6, json_name: "digest"
     end
   end
 end
@@ -99,6 +307,7 @@ module Ocfl
   module V1
     GetStatusRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetStatusRequest").msgclass
     GetStatusResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetStatusResponse").msgclass
+    GetStatusResponse::StorageRoot = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetStatusResponse.StorageRoot").msgclass
     IndexAllRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.IndexAllRequest").msgclass
     IndexAllResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.IndexAllResponse").msgclass
     IndexIDsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.IndexIDsRequest").msgclass
@@ -106,6 +315,8 @@ module Ocfl
     ListObjectsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListObjectsRequest").msgclass
     ListObjectsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListObjectsResponse").msgclass
     ListObjectsResponse::Object = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListObjectsResponse.Object").msgclass
+    StreamObjectsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.StreamObjectsRequest").msgclass
+    StreamObjectsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.StreamObjectsResponse").msgclass
     GetObjectRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectRequest").msgclass
     GetObjectResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectResponse").msgclass
     GetObjectResponse::Version = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectResponse.Version").msgclass
@@ -113,7 +324,37 @@ module Ocfl
     GetObjectStateRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectStateRequest").msgclass
     GetObjectStateResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectStateResponse").msgclass
     GetObjectStateResponse::Item = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetObjectStateResponse.Item").msgclass
+    StreamObjectStateRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.StreamObjectStateRequest").msgclass
+    StreamObjectStateResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.StreamObjectStateResponse").msgclass
+    SearchRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.SearchRequest").msgclass
+    SearchResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.SearchResponse").msgclass
+    SearchResponse::Result = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.SearchResponse.Result").msgclass
+    FindByDigestRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FindByDigestRequest").msgclass
+    FindByDigestResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FindByDigestResponse").msgclass
+    FindByDigestResponse::Object = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FindByDigestResponse.Object").msgclass
+    FindByDigestResponse::Object::Path = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FindByDigestResponse.Object.Path").msgclass
+    DiffVersionsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.DiffVersionsRequest").msgclass
+    DiffVersionsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.DiffVersionsResponse").msgclass
+    DiffVersionsResponse::Change = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.DiffVersionsResponse.Change").msgclass
+    DiffVersionsResponse::ChangeType = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.DiffVersionsResponse.ChangeType").enummodule
+    ListInvalidObjectsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListInvalidObjectsRequest").msgclass
+    ListInvalidObjectsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListInvalidObjectsResponse").msgclass
+    ListInvalidObjectsResponse::Issue = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListInvalidObjectsResponse.Issue").msgclass
+    ListInvalidObjectsResponse::Object = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListInvalidObjectsResponse.Object").msgclass
     FollowLogsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FollowLogsRequest").msgclass
     FollowLogsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FollowLogsResponse").msgclass
+    Progress = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.Progress").msgclass
+    Progress::Phase = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.Progress.Phase").enummodule
+    Task = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.Task").msgclass
+    ListTasksRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListTasksRequest").msgclass
+    ListTasksResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ListTasksResponse").msgclass
+    GetTaskRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetTaskRequest").msgclass
+    GetTaskResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.GetTaskResponse").msgclass
+    CancelTaskRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.CancelTaskRequest").msgclass
+    CancelTaskResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.CancelTaskResponse").msgclass
+    FixityCheck = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.FixityCheck").msgclass
+    ValidateObjectsRequest = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ValidateObjectsRequest").msgclass
+    ValidateObjectsResponse = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ValidateObjectsResponse").msgclass
+    ErrorDetail = ::Google::Protobuf::DescriptorPool.generated_pool.lookup("ocfl.v1.ErrorDetail").msgclass
   end
 end
@@ -7,7 +7,10 @@ require 'ocfl/v1/index_pb'
 module Ocfl
   module V1
     module IndexService
-      # IndexService is used to index and query OCFL objects in a repository.
+      # IndexService is used to index and query OCFL objects in a repository. The
+      # index may include objects from several named storage roots. Requests with a
+      # storage_root field are scoped to the storage root with that name; if it is
+      # empty, the server's default storage root is used.
       class Service
 
         include ::GRPC::GenericService
@@ -16,15 +19,16 @@ module Ocfl
         self.unmarshal_class_method = :decode
         self.service_name = 'ocfl.v1.IndexService'
 
-        # Get index status, counts, and storage root details
+        # Get index status, counts, and details for each storage root
         rpc :GetStatus, ::Ocfl::V1::GetStatusRequest, ::Ocfl::V1::GetStatusResponse
-        # Start an asynchronous indexing process to scan the storage root and ingest
+        # Queue an asynchronous indexing task to scan the storage root and ingest
         # index inventories. Indexed objects not found during the storage root scan
-        # are removed from the index. IndexAll returns immediately with a status
-        # indicating whether the indexing process was started.
+        # are removed from the index. IndexAll returns immediately with the task's
+        # ID.
         rpc :IndexAll, ::Ocfl::V1::IndexAllRequest, ::Ocfl::V1::IndexAllResponse
         # Index inventories for the specified object ids. Unlike IndexAll, IndexIDs
-        # returns after the object ids have been indexed.
+        # returns after the object ids have been indexed. The task is queued ahead
+        # of full storage root scans and may run between batches of a running scan.
         rpc :IndexIDs, ::Ocfl::V1::IndexIDsRequest, ::Ocfl::V1::IndexIDsResponse
         # List all objects in the index in lexigraphical order by ID.
         rpc :ListObjects, ::Ocfl::V1::ListObjectsRequest, ::Ocfl::V1::ListObjectsResponse
@@ -32,8 +36,38 @@ module Ocfl
         rpc :GetObject, ::Ocfl::V1::GetObjectRequest, ::Ocfl::V1::GetObjectResponse
         # Query the logical state of an OCFL object version
         rpc :GetObjectState, ::Ocfl::V1::GetObjectStateRequest, ::Ocfl::V1::GetObjectStateResponse
+        # Stream all objects in the index in lexigraphical order by ID. Unlike
+        # ListObjects, results aren't paginated: objects are sent in batches from
+        # a single database query.
+        rpc :StreamObjects, ::Ocfl::V1::StreamObjectsRequest, stream(::Ocfl::V1::StreamObjectsResponse)
+        # Stream the logical state of an OCFL object version. Unlike
+        # GetObjectState, results aren't paginated: children of the base path are
+        # sent in batches from a single database query.
+        rpc :StreamObjectState, ::Ocfl::V1::StreamObjectStateRequest, stream(::Ocfl::V1::StreamObjectStateResponse)
+        # Full-text search of object IDs, logical paths, and version metadata
+        rpc :Search, ::Ocfl::V1::SearchRequest, ::Ocfl::V1::SearchResponse
+        # List objects, versions, and logical paths with a file or directory digest
+        rpc :FindByDigest, ::Ocfl::V1::FindByDigestRequest, ::Ocfl::V1::FindByDigestResponse
+        # Compare files in two object versions, which may belong to different
+        # objects
+        rpc :DiffVersions, ::Ocfl::V1::DiffVersionsRequest, ::Ocfl::V1::DiffVersionsResponse
+        # List objects with validation errors or warnings, in lexigraphical order
+        # by object path. Issues are recorded when an object's inventory is
+        # validated during indexing and are replaced when it is reindexed with a
+        # changed inventory (or with force).
+        rpc :ListInvalidObjects, ::Ocfl::V1::ListInvalidObjectsRequest, ::Ocfl::V1::ListInvalidObjectsResponse
         # Stream log messages from indexing tasks
         rpc :FollowLogs, ::Ocfl::V1::FollowLogsRequest, stream(::Ocfl::V1::FollowLogsResponse)
+        # List asynchronous tasks (e.g., indexing), most recent first
+        rpc :ListTasks, ::Ocfl::V1::ListTasksRequest, ::Ocfl::V1::ListTasksResponse
+        # Get details for a specific task
+        rpc :GetTask, ::Ocfl::V1::GetTaskRequest, ::Ocfl::V1::GetTaskResponse
+        # Cancel a queued or running task
+        rpc :CancelTask, ::Ocfl::V1::CancelTaskRequest, ::Ocfl::V1::CancelTaskResponse
+        # Queue an asynchronous task to fully validate objects, including content
+        # digests. Results are recorded for each object and included in GetObject
+        # responses. ValidateObjects returns immediately with the task's ID.
+        rpc :ValidateObjects, ::Ocfl::V1::ValidateObjectsRequest, ::Ocfl::V1::ValidateObjectsResponse
       end
 
       Stub = Service.rpc_stub_class
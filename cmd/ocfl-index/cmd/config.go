@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
@@ -11,6 +16,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/srerickson/ocfl"
 	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/postgres"
+	"github.com/srerickson/ocfl-index/internal/sqlite"
 	"github.com/srerickson/ocfl/backend/cloud"
 	"gocloud.dev/blob"
 	"gocloud.dev/blob/s3blob"
@@ -22,10 +29,16 @@ const (
 	envDriver     = "OCFL_INDEX_BACKEND" // "fs" (default), "s3", or "azure"
 	envBucket     = "OCFL_INDEX_BUCKET"  // cloud bucket for s3 or azure backend ("" default)
 	envPath       = "OCFL_INDEX_STOREDIR"
+	envRoots      = "OCFL_INDEX_ROOTS" // JSON file listing named storage roots (overrides backend, bucket, and storedir)
 	envDBFile     = "OCFL_INDEX_SQLITE"
+	envDB         = "OCFL_INDEX_DB" // postgres connection string (overrides OCFL_INDEX_SQLITE)
 	envAddr       = "OCFL_INDEX_LISTEN"
 	envScanConc   = "OCFL_INDEX_SCANWORKERS"  // number of workers for object scan
 	envParseConc  = "OCFL_INDEX_PARSEWORKERS" // numer of workers for parsing inventories
+	envAuth       = "OCFL_INDEX_AUTH"         // JSON file with authentication settings and policy
+	envTLSCert    = "OCFL_INDEX_TLS_CERT"     // PEM certificate for serving TLS
+	envTLSKey     = "OCFL_INDEX_TLS_KEY"      // PEM key for serving TLS
+	envClientCA   = "OCFL_INDEX_CLIENT_CA"    // PEM CA certificates for verifying client certificates
 
 	sqliteSettings = "_busy_timeout=10000&_journal=WAL&_sync=NORMAL&cache=shared"
 )
@@ -34,20 +47,39 @@ type config struct {
 	Logger *slog.Logger
 
 	// Server
-	Addr string // port
+	Addr     string // port
+	AuthFile string // JSON file with authentication settings and policy; if empty, requests aren't authenticated
+	TLSCert  string // PEM certificate file; if empty, the server doesn't use TLS
+	TLSKey   string // PEM key file
+	ClientCA string // PEM CA certificates file; if set, client certificates are verified
 
 	// Backend configuration
 	Driver     string // backend driver (supported: "fs", "s3", "azure")
 	Bucket     string // Bucket/Container for s3 of azure fs types
 	Path       string // Path to storage root (default: ".")
+	RootsFile  string // JSON file with multiple storage roots; if set, Driver, Bucket, and Path are not used
 	S3Endpoint string // custom s3 endpoint
 
-	// SQLITE file
+	// Index database
 	DBFile string // sqlite file
+	DB     string // postgres connection string; if set, DBFile is not used
 
 	// Concurrency Settings
 	ScanConc  int // number of object scanning workers
 	ParseConc int // number of inventory parsing workers
+
+	// Indexing Settings
+	FileSizes bool // index content file sizes
+	Force     bool // reindex objects with unchanged inventories
+}
+
+// rootConfig is the backend configuration for a named storage root. The roots
+// file is a JSON array of rootConfig values.
+type rootConfig struct {
+	Name   string `json:"name"`   // storage root name in the index
+	Driver string `json:"driver"` // backend driver (default: "fs")
+	Bucket string `json:"bucket"` // Bucket/Container for s3 of azure fs types
+	Path   string `json:"path"`   // Path to storage root (default: ".")
 }
 
 func NewLogger() *slog.Logger {
@@ -63,9 +95,15 @@ func NewConfig(logger *slog.Logger) config {
 	c.Bucket = getenvDefault(envBucket, "")
 	c.Driver = getenvDefault(envDriver, "fs")
 	c.Path = getenvDefault(envPath, ".")
+	c.RootsFile = getenvDefault(envRoots, "")
 	c.S3Endpoint = getenvDefault(envS3Endpoint, "")
 	c.DBFile = getenvDefault(envDBFile, "index.sqlite")
+	c.DB = getenvDefault(envDB, "")
 	c.Addr = getenvDefault(envAddr, ":8080")
+	c.AuthFile = getenvDefault(envAuth, "")
+	c.TLSCert = getenvDefault(envTLSCert, "")
+	c.TLSKey = getenvDefault(envTLSKey, "")
+	c.ClientCA = getenvDefault(envClientCA, "")
 	if conc, err := strconv.Atoi(getenvDefault(envScanConc, "0")); err == nil {
 		c.ScanConc = conc
 	}
@@ -85,23 +123,109 @@ func NewConfig(logger *slog.Logger) config {
 func (c config) Attrs() []any {
 	attrs := []any{
 		"addr", c.Addr,
-		"driver", c.Driver,
-		"bucket", c.Bucket,
-		"path", c.Path,
-		"dbfile", c.DBFile,
 		"scan_workers", c.ScanConc,
 		"parse_workers", c.ParseConc,
 	}
+	if c.RootsFile != "" {
+		attrs = append(attrs, "roots_file", c.RootsFile)
+	} else {
+		attrs = append(attrs, "driver", c.Driver, "bucket", c.Bucket, "path", c.Path)
+	}
+	if c.DB != "" {
+		// don't log connection string: it may include a password
+		attrs = append(attrs, "db", "postgres")
+	} else {
+		attrs = append(attrs, "dbfile", c.DBFile)
+	}
 	if c.S3Endpoint != "" {
 		attrs = append(attrs, "s3_endpoint", c.S3Endpoint)
 	}
+	if c.AuthFile != "" {
+		attrs = append(attrs, "auth_file", c.AuthFile)
+	}
+	if c.TLSCert != "" {
+		attrs = append(attrs, "tls_cert", c.TLSCert)
+	}
+	if c.ClientCA != "" {
+		attrs = append(attrs, "client_ca", c.ClientCA)
+	}
 	return attrs
 }
 
-func (c config) FS(ctx context.Context) (ocfl.FS, string, error) {
-	switch c.Driver {
+// rootConfigs returns the configured storage roots: those listed in the roots
+// file, if set, or a single storage root named index.DefaultStorageRoot.
+func (c config) rootConfigs() ([]rootConfig, error) {
+	if c.RootsFile == "" {
+		return []rootConfig{{
+			Name:   index.DefaultStorageRoot,
+			Driver: c.Driver,
+			Bucket: c.Bucket,
+			Path:   c.Path,
+		}}, nil
+	}
+	byts, err := os.ReadFile(c.RootsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading storage roots config: %w", err)
+	}
+	var roots []rootConfig
+	if err := json.Unmarshal(byts, &roots); err != nil {
+		return nil, fmt.Errorf("parsing storage roots config: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no storage roots in %s", c.RootsFile)
+	}
+	names := map[string]bool{}
+	for i := range roots {
+		if roots[i].Name == "" {
+			return nil, fmt.Errorf("storage root in %s is missing a name", c.RootsFile)
+		}
+		if names[roots[i].Name] {
+			return nil, fmt.Errorf("duplicate storage root name in %s: %s", c.RootsFile, roots[i].Name)
+		}
+		names[roots[i].Name] = true
+		if roots[i].Driver == "" {
+			roots[i].Driver = "fs"
+		}
+		if roots[i].Path == "" {
+			roots[i].Path = "."
+		}
+	}
+	return roots, nil
+}
+
+// StorageRoots connects to all configured storage roots. The first storage
+// root is the default. The returned function closes the storage root
+// backends.
+func (c config) StorageRoots(ctx context.Context) ([]index.StorageRoot, func(), error) {
+	confs, err := c.rootConfigs()
+	if err != nil {
+		return nil, nil, err
+	}
+	var roots []index.StorageRoot
+	closeAll := func() {
+		for _, r := range roots {
+			if closer, ok := r.FS.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+	for _, conf := range confs {
+		fsys, dir, err := c.FS(ctx, conf)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("storage root '%s': %w", conf.Name, err)
+		}
+		roots = append(roots, index.StorageRoot{Name: conf.Name, FS: fsys, Path: dir})
+	}
+	return roots, closeAll, nil
+}
+
+// FS returns the backend and storage root directory for the storage root
+// configuration r.
+func (c config) FS(ctx context.Context, r rootConfig) (ocfl.FS, string, error) {
+	switch r.Driver {
 	case "fs":
-		return ocfl.NewFS(os.DirFS(c.Path)), ".", nil
+		return ocfl.NewFS(os.DirFS(r.Path)), ".", nil
 	case "s3":
 		sess, err := session.NewSession()
 		if err != nil {
@@ -111,22 +235,148 @@ func (c config) FS(ctx context.Context) (ocfl.FS, string, error) {
 		if c.S3Endpoint != "" {
 			sess.Config.Endpoint = aws.String(c.S3Endpoint)
 		}
-		bucket, err := s3blob.OpenBucket(ctx, sess, c.Bucket, nil)
+		bucket, err := s3blob.OpenBucket(ctx, sess, r.Bucket, nil)
 		if err != nil {
 			return nil, "", fmt.Errorf("opening s3 bucket: %w", err)
 		}
 		fsys := cloud.NewFS(bucket, cloud.WithLogger(c.Logger))
-		return fsys, c.Path, nil
+		return fsys, r.Path, nil
 	case "azure":
-		bucket, err := blob.OpenBucket(ctx, "azblob://"+c.Bucket)
+		bucket, err := blob.OpenBucket(ctx, "azblob://"+r.Bucket)
 		if err != nil {
 			return nil, "", fmt.Errorf("configuring azure: %w", err)
 		}
 		fsys := cloud.NewFS(bucket, cloud.WithLogger(c.Logger))
-		return fsys, c.Path, nil
+		return fsys, r.Path, nil
 	default:
-		return nil, "", fmt.Errorf("unsupported storage driver %s", c.Driver)
+		return nil, "", fmt.Errorf("unsupported storage driver %s", r.Driver)
+	}
+}
+
+// authConfig is the format of the auth file.
+type authConfig struct {
+	Tokens      map[string]string `json:"tokens"`       // static bearer tokens by name
+	JWKSFile    string            `json:"jwks_file"`    // JSON Web Key Set for verifying JWTs
+	JWTIssuer   string            `json:"jwt_issuer"`   // required JWT issuer
+	JWTAudience string            `json:"jwt_audience"` // required JWT audience
+	ClientCerts bool              `json:"client_certs"` // identify clients by TLS certificate
+	Policy      index.Policy      `json:"policy"`
+}
+
+// Auth returns the authentication and authorization settings from the auth
+// file. It returns nil if the auth file isn't set.
+func (c config) Auth() (*index.Auth, error) {
+	if c.AuthFile == "" {
+		return nil, nil
+	}
+	byts, err := os.ReadFile(c.AuthFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading auth config: %w", err)
+	}
+	var conf authConfig
+	if err := json.Unmarshal(byts, &conf); err != nil {
+		return nil, fmt.Errorf("parsing auth config: %w", err)
+	}
+	auth := &index.Auth{Policy: conf.Policy}
+	if len(conf.Tokens) > 0 {
+		for name, token := range conf.Tokens {
+			if token == "" {
+				return nil, fmt.Errorf("empty token '%s' in %s", name, c.AuthFile)
+			}
+		}
+		auth.Authenticators = append(auth.Authenticators, index.BearerTokens(conf.Tokens))
+	}
+	if conf.JWKSFile != "" {
+		byts, err := os.ReadFile(conf.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWKS: %w", err)
+		}
+		keys, err := index.ParseJWKS(byts)
+		if err != nil {
+			return nil, err
+		}
+		auth.Authenticators = append(auth.Authenticators, &index.JWTAuth{
+			Keys:     keys,
+			Issuer:   conf.JWTIssuer,
+			Audience: conf.JWTAudience,
+		})
+	}
+	if conf.ClientCerts {
+		auth.Authenticators = append(auth.Authenticators, index.ClientCerts{})
+	}
+	return auth, nil
+}
+
+// TLSConfig returns the server's TLS configuration. It returns nil if TLS
+// isn't configured. If client CA certificates are set, client certificates
+// are verified if they are given; they aren't required.
+func (c config) TLSConfig() (*tls.Config, error) {
+	if c.TLSCert == "" && c.TLSKey == "" {
+		if c.ClientCA != "" {
+			return nil, errors.New("client CA certificates require a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCA != "" {
+		pem, err := os.ReadFile(c.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.ClientCA)
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return conf, nil
+}
+
+// indexDB is an index.Backend with a managed schema
+type indexDB interface {
+	index.Backend
+	InitSchema(context.Context) (bool, error)
+	GetSchemaVersion(context.Context) (int, int, error)
+	PendingMigrations(context.Context) ([]index.Migration, error)
+	Migrate(context.Context) ([]index.Migration, error)
+	Close() error
+}
+
+// OpenDB opens the index database: postgres if a connection string is set,
+// otherwise the sqlite file. The returned database's schema is not
+// initialized.
+func (c config) OpenDB() (indexDB, error) {
+	if c.DB != "" {
+		db, err := postgres.Open(c.DB)
+		if err != nil {
+			return nil, fmt.Errorf("opening postgres database: %w", err)
+		}
+		return db, nil
+	}
+	db, err := sqlite.Open("file:" + c.DBFile + "?" + sqliteSettings)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// initSchema initializes the database schema if necessary.
+func initSchema(ctx context.Context, db indexDB) error {
+	if _, err := db.InitSchema(ctx); err != nil {
+		if errors.Is(err, index.ErrSchemaOld) {
+			return fmt.Errorf("%w: use 'ocfl-index migrate' to upgrade it", err)
+		}
+		return err
 	}
+	return nil
 }
 
 func getenvDefault(key, def string) string {
@@ -5,34 +5,37 @@ package cmd
 
 import (
 	"context"
-	"io"
+	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/srerickson/ocfl"
 	"github.com/srerickson/ocfl-index/internal/index"
-	"github.com/srerickson/ocfl-index/internal/sqlite"
 	_ "gocloud.dev/blob/azureblob"
 )
 
+var indexFlags struct {
+	sizes bool // index file sizes
+	force bool // reindex unchanged objects
+}
+
 // indexCmd represents the index command
 var indexCmd = &cobra.Command{
 	Use:   "index",
-	Short: "index an OCFL storage root",
-	Long: `The index command indexes all objects in a specified OCFL storage root. The
-index file will be created if it does not exist.`,
+	Short: "index OCFL storage roots",
+	Long: `The index command indexes all objects in the configured OCFL storage roots.
+The index file will be created if it does not exist.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
 		logger := NewLogger()
 		conf := NewConfig(logger)
-		fsys, rootDir, err := conf.FS(ctx)
+		conf.FileSizes = indexFlags.sizes
+		conf.Force = indexFlags.force
+		roots, closeRoots, err := conf.StorageRoots(ctx)
 		if err != nil {
 			logger.Error("can't connect to backend", "err", err)
 			return
 		}
-		if closer, ok := fsys.(io.Closer); ok {
-			defer closer.Close()
-		}
-		if err := DoIndex(ctx, &conf, fsys, rootDir); err != nil {
+		defer closeRoots()
+		if err := DoIndex(ctx, &conf, roots); err != nil {
 			logger.Error("index failed", "err", err)
 		}
 	},
@@ -40,24 +43,34 @@ index file will be created if it does not exist.`,
 
 func init() {
 	rootCmd.AddCommand(indexCmd)
+	indexCmd.Flags().BoolVar(&indexFlags.sizes, "sizes", false, "index content file sizes")
+	indexCmd.Flags().BoolVar(&indexFlags.force, "force", false, "reindex objects even if their inventory sidecars are unchanged")
 }
 
-func DoIndex(ctx context.Context, conf *config, fsys ocfl.FS, rootDir string) error {
-	db, err := sqlite.Open("file:" + conf.DBFile + "?" + sqliteSettings)
+func DoIndex(ctx context.Context, conf *config, roots []index.StorageRoot) error {
+	db, err := conf.OpenDB()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
-	if _, err := db.InitSchema(ctx); err != nil {
+	if err := initSchema(ctx, db); err != nil {
 		return err
 	}
 	idx := &index.Indexer{Backend: db}
-	opts := &index.IndexOptions{
-		FS:        fsys,
-		RootPath:  rootDir,
-		ScanConc:  conf.ScanConc,
-		ParseConc: conf.ParseConc,
-		Log:       conf.Logger,
+	for _, root := range roots {
+		opts := &index.IndexOptions{
+			FS:          root.FS,
+			RootPath:    root.Path,
+			StorageRoot: root.Name,
+			ScanConc:    conf.ScanConc,
+			ParseConc:   conf.ParseConc,
+			FileSizes:   conf.FileSizes,
+			Force:       conf.Force,
+			Log:         conf.Logger,
+		}
+		if err := idx.Index(ctx, opts); err != nil {
+			return fmt.Errorf("storage root '%s': %w", root.Name, err)
+		}
 	}
-	return idx.Index(ctx, opts)
+	return nil
 }
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateFlags struct {
+	dryRun bool // print pending migrations without applying them
+}
+
+// backuper is implemented by index databases that can copy themselves to a
+// file.
+type backuper interface {
+	Backup(ctx context.Context, name string) error
+}
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade the index database schema",
+	Long: `The migrate command upgrades the schema of an existing index database to the
+version required by this version of ocfl-index. For sqlite, the index file is
+backed up before any changes are made. Migrations are applied in a single
+transaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		logger := NewLogger()
+		conf := NewConfig(logger)
+		if err := DoMigrate(ctx, &conf, migrateFlags.dryRun); err != nil {
+			logger.Error("migrate failed", "err", err)
+			// exit status is checked by deploy scripts
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVar(&migrateFlags.dryRun, "dry-run", false, "print pending migrations without applying them")
+}
+
+func DoMigrate(ctx context.Context, conf *config, dryRun bool) error {
+	db, err := conf.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	maj, min, err := db.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	pending, err := db.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Printf("index schema v%d.%d is up to date\n", maj, min)
+		return nil
+	}
+	fmt.Printf("index schema is v%d.%d; pending migrations:\n", maj, min)
+	for _, m := range pending {
+		fmt.Printf("  %s: %s\n", m.Name, m.Description)
+	}
+	if dryRun {
+		return nil
+	}
+	if b, ok := db.(backuper); ok {
+		name := fmt.Sprintf("%s.v%d.%d-%s.bak", conf.DBFile, maj, min, time.Now().Format("20060102T150405"))
+		if err := b.Backup(ctx, name); err != nil {
+			return err
+		}
+		fmt.Println("backup:", name)
+	}
+	applied, err := db.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		fmt.Println("applied:", m.Name)
+	}
+	maj, min, err = db.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("index schema is now v%d.%d\n", maj, min)
+	return nil
+}
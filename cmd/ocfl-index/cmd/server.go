@@ -6,20 +6,27 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/srerickson/ocfl"
 	"github.com/srerickson/ocfl-index/internal/index"
-	"github.com/srerickson/ocfl-index/internal/sqlite"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
 var serverFlags struct {
-	skipIndexing bool // skip indexing on startup
-	inventories  bool // indexing level
+	skipIndexing    bool          // skip indexing on startup
+	inventories     bool          // indexing level
+	sizes           bool          // index file sizes
+	watch           bool          // watch fs storage roots for changes
+	webhook         bool          // accept S3 event notifications
+	reconcile       time.Duration // time between full scans when following changes
+	taskTimeout     time.Duration // default timeout for indexing tasks
+	validateEvery   time.Duration // time between scheduled validation tasks
+	validateBatch   int           // objects validated per storage root in scheduled tasks
+	shutdownTimeout time.Duration // max time to wait for requests to finish during shutdown
 }
 
 var serveCmd = &cobra.Command{
@@ -30,15 +37,14 @@ var serveCmd = &cobra.Command{
 		ctx := cmd.Context()
 		logger := NewLogger()
 		conf := NewConfig(logger)
-		fsys, rootDir, err := conf.FS(ctx)
+		conf.FileSizes = serverFlags.sizes
+		roots, closeRoots, err := conf.StorageRoots(ctx)
 		if err != nil {
 			logger.Error("can't connect to backend", "err", err)
 			return
 		}
-		if closer, ok := fsys.(io.Closer); ok {
-			defer closer.Close()
-		}
-		if err := startServer(ctx, &conf, fsys, rootDir); err != nil {
+		defer closeRoots()
+		if err := startServer(ctx, &conf, roots); err != nil {
 			logger.Error("server stopped", "err", err)
 		}
 	},
@@ -48,15 +54,23 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 	serveCmd.Flags().BoolVar(&serverFlags.skipIndexing, "skip-indexing", false, "skip indexing step on startup")
 	serveCmd.Flags().BoolVar(&serverFlags.inventories, "inventories", false, "index inventories during reindex")
+	serveCmd.Flags().BoolVar(&serverFlags.sizes, "sizes", false, "index content file sizes during reindex")
+	serveCmd.Flags().BoolVar(&serverFlags.watch, "watch", false, "reindex changed objects in storage roots using the fs driver")
+	serveCmd.Flags().BoolVar(&serverFlags.webhook, "webhook", false, "reindex changed objects using S3 event notifications sent to /events/s3")
+	serveCmd.Flags().DurationVar(&serverFlags.reconcile, "reconcile", 24*time.Hour, "time between full storage root scans with --watch or --webhook")
+	serveCmd.Flags().DurationVar(&serverFlags.taskTimeout, "task-timeout", 0, "cancel indexing tasks that run longer than this (0: no timeout)")
+	serveCmd.Flags().DurationVar(&serverFlags.validateEvery, "validate-every", 0, "time between scheduled validation of the least recently validated objects (0: no scheduled validation)")
+	serveCmd.Flags().IntVar(&serverFlags.validateBatch, "validate-batch", 1000, "number of objects validated in each storage root by scheduled validation")
+	serveCmd.Flags().DurationVar(&serverFlags.shutdownTimeout, "shutdown-timeout", 30*time.Second, "max time to wait for in-flight requests during shutdown")
 }
 
-func startServer(ctx context.Context, c *config, fsys ocfl.FS, rootDir string) error {
+func startServer(ctx context.Context, c *config, roots []index.StorageRoot) error {
 
-	db, err := sqlite.Open("file:" + c.DBFile + "?" + sqliteSettings)
+	db, err := c.OpenDB()
 	if err != nil {
 		return err
 	}
-	if _, err := db.InitSchema(ctx); err != nil {
+	if err := initSchema(ctx, db); err != nil {
 		return fmt.Errorf("while initializing index tables: %w", err)
 	}
 	maj, min, err := db.GetSchemaVersion(ctx)
@@ -65,8 +79,15 @@ func startServer(ctx context.Context, c *config, fsys ocfl.FS, rootDir string) e
 	}
 	defer db.Close()
 	schemaV := fmt.Sprintf("%d.%d", maj, min)
-	c.Logger.Info("using index file", "file", c.DBFile, "schema", schemaV)
+	if c.DB != "" {
+		c.Logger.Info("using postgres index", "schema", schemaV)
+	} else {
+		c.Logger.Info("using index file", "file", c.DBFile, "schema", schemaV)
+	}
 	idx := &index.Indexer{Backend: db}
+	for _, root := range roots {
+		c.Logger.Info("serving storage root", "name", root.Name, "path", root.Path)
+	}
 
 	// summary, err := idx.GetStoreSummary(ctx)
 	// if err != nil {
@@ -77,19 +98,175 @@ func startServer(ctx context.Context, c *config, fsys ocfl.FS, rootDir string) e
 	// 	"object_count", summary.NumObjects,
 	// 	"ocfl spec", summary.Spec,
 	// 	"last_indexed", summary.IndexedAt)
+	auth, err := c.Auth()
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		c.Logger.Warn("authentication is not configured: all requests are allowed")
+	}
+	tlsConf, err := c.TLSConfig()
+	if err != nil {
+		return err
+	}
 	service := index.Service{
-		Indexer:   idx,
-		Async:     index.NewAsync(ctx),
-		FS:        fsys,
-		RootPath:  rootDir,
-		ScanConc:  c.ScanConc,
-		ParseConc: c.ParseConc,
-		Log:       c.Logger,
-	}
-	c.Logger.Info("starting http/grpc server", "port", c.Addr)
-	if err := http.ListenAndServe(c.Addr, h2c.NewHandler(service.HTTPHandler(), &http2.Server{})); err != nil {
+		Indexer:     idx,
+		Async:       index.NewAsync(context.Background(), idx), // closed explicitly during shutdown
+		Roots:       roots,
+		ScanConc:    c.ScanConc,
+		ParseConc:   c.ParseConc,
+		FileSizes:   c.FileSizes,
+		TaskTimeout: serverFlags.taskTimeout,
+		Log:         c.Logger,
+		Auth:        auth,
+	}
+	defer func() {
+		// cancel any running or queued indexing tasks and wait for them to
+		// return before the database is closed.
+		service.Async.Close()
+		service.Async.Wait()
+	}()
+	if serverFlags.watch || serverFlags.webhook {
+		feed, err := changeFeed(c, &service)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := feed.Run(ctx); err != nil && ctx.Err() == nil {
+				c.Logger.Error("change feed stopped", "err", err)
+			}
+		}()
+	}
+	if serverFlags.validateEvery > 0 {
+		sched := &index.ValidationSchedule{
+			Service: &service,
+			Every:   serverFlags.validateEvery,
+			Batch:   serverFlags.validateBatch,
+		}
+		c.Logger.Info("scheduled object validation", "every", serverFlags.validateEvery.String(), "batch", serverFlags.validateBatch)
+		go func() {
+			if err := sched.Run(ctx); err != nil && ctx.Err() == nil {
+				c.Logger.Error("validation schedule stopped", "err", err)
+			}
+		}()
+	}
+	httpSrv := &http.Server{
+		Addr:      c.Addr,
+		Handler:   service.HTTPHandler(),
+		TLSConfig: tlsConf,
+	}
+	shutdown := httpSrv.Shutdown
+	if tlsConf == nil {
+		h2cSrv, err := newH2CServer(httpSrv)
+		if err != nil {
+			return err
+		}
+		shutdown = h2cSrv.Shutdown
+	}
+	srvErr := make(chan error, 1)
+	go func() {
+		if tlsConf != nil {
+			c.Logger.Info("starting http/grpc server with TLS", "port", c.Addr)
+			srvErr <- httpSrv.ListenAndServeTLS("", "")
+			return
+		}
+		c.Logger.Info("starting http/grpc server", "port", c.Addr)
+		srvErr <- httpSrv.ListenAndServe()
+	}()
+	select {
+	case err := <-srvErr:
 		return err
+	case <-ctx.Done():
+	}
+	// Indexing tasks are canceled first so that requests waiting on them, and
+	// log streams, can finish.
+	c.Logger.Info("shutting down: canceling indexing tasks and draining requests", "timeout", serverFlags.shutdownTimeout.String())
+	service.Async.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverFlags.shutdownTimeout)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down server: %w", err)
 	}
 	c.Logger.Info("http/grpc server stopped")
 	return nil
 }
+
+// h2cServer serves HTTP/2 without TLS (h2c), which connect and gRPC clients
+// use for plaintext connections, as well as HTTP/1. h2c connections are
+// hijacked from the http.Server, so http.Server.Shutdown doesn't wait for
+// them; h2cServer's Shutdown does.
+type h2cServer struct {
+	*http.Server
+	active sync.WaitGroup // HTTP/1 requests and h2c connections being served
+}
+
+// newH2CServer configures srv to serve h2c. It must be called before srv is
+// started.
+func newH2CServer(srv *http.Server) (*h2cServer, error) {
+	h2s := &http2.Server{}
+	// srv.Shutdown sends GOAWAY to h2c connections served by h2s
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return nil, err
+	}
+	h2cSrv := &h2cServer{Server: srv}
+	handler := h2c.NewHandler(srv.Handler, h2s)
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// for h2c, ServeHTTP returns when the connection is closed, after
+		// its streams have finished.
+		h2cSrv.active.Add(1)
+		defer h2cSrv.active.Done()
+		handler.ServeHTTP(w, r)
+	})
+	return h2cSrv, nil
+}
+
+// Shutdown gracefully shuts down the server, waiting for h2c connections to
+// finish their streams and close, or for ctx to be done.
+func (srv *h2cServer) Shutdown(ctx context.Context) error {
+	if err := srv.Server.Shutdown(ctx); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		srv.active.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// changeFeed returns a change feed for the service using sources enabled by
+// the server flags. If the S3 webhook is enabled, it is added to the service.
+func changeFeed(c *config, service *index.Service) (*index.ChangeFeed, error) {
+	feed := &index.ChangeFeed{
+		Service:   service,
+		Reconcile: serverFlags.reconcile,
+	}
+	if serverFlags.watch {
+		roots, err := c.rootConfigs()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roots {
+			if r.Driver != "fs" {
+				continue
+			}
+			c.Logger.Info("watching storage root for changes", "name", r.Name, "path", r.Path)
+			feed.Sources = append(feed.Sources, &index.DirWatcher{
+				Dir:         r.Path,
+				StorageRoot: r.Name,
+				Since:       time.Now(),
+			})
+		}
+	}
+	if serverFlags.webhook {
+		service.Webhook = index.NewS3Webhook()
+		feed.Sources = append(feed.Sources, service.Webhook)
+		c.Logger.Info("accepting S3 event notifications", "path", "/events/s3")
+	}
+	return feed, nil
+}
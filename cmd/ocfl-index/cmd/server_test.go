@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2CServerShutdown(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	srv, err := newH2CServer(&http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("first\n"))
+			w.(http.Flusher).Flush()
+			close(started)
+			<-finish
+			w.Write([]byte("last\n"))
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	// h2c client with prior knowledge, like connect/gRPC clients without TLS
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err := client.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- srv.Shutdown(ctx) }()
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the stream finished: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(finish)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal("reading stream during shutdown:", err)
+	}
+	if got := string(body); got != "first\nlast\n" {
+		t.Fatalf("stream body: got %q", got)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Fatal("Shutdown:", err)
+	}
+}
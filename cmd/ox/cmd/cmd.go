@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/go-logr/logr"
 	"github.com/iand/logfmtr"
 	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/diff"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/export"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/ls"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/reindex"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/search"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/status"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/tasks"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/validate"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/which"
 )
 
 var rootCmd = root.Cmd{
@@ -18,6 +24,8 @@ var rootCmd = root.Cmd{
 		Use:          "ox {command}",
 		Short:        "ocfl-index client",
 		SilenceUsage: true,
+		// errors are printed by Execute
+		SilenceErrors: true,
 	},
 	Log: defaultLogger(),
 }
@@ -29,9 +37,15 @@ func Execute() {
 		&ls.Cmd{},
 		&export.Cmd{},
 		&reindex.Cmd{},
+		&search.Cmd{},
+		&which.Cmd{},
+		&diff.Cmd{},
+		&tasks.Cmd{},
+		&validate.Cmd{},
 	)
 	err := rootCmd.Execute()
 	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", rootCmd.ErrorMessage(err))
 		os.Exit(1)
 	}
 }
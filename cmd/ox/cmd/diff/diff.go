@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+type Cmd struct {
+	root        *root.Cmd
+	objectID    string
+	otherID     string
+	fromVersion string
+	toVersion   string
+	dir         string
+}
+
+func (d *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
+	d.root = r
+	cmd := &cobra.Command{
+		Use:   `diff [--other {object_id}] {object_id} {from_version} {to_version} [dir]`,
+		Short: "list changed files between two object versions",
+		Long: `Diff lists files that were added (A), removed (D), modified (M), or renamed
+(R) between two versions of an object. With the --other flag, the second
+version refers to a version of the other object. A path may be given as an
+optional fourth argument to compare files in the directory only.`,
+	}
+	cmd.Flags().StringVar(&d.otherID, "other", "", "compare with a version of another object")
+	return cmd
+}
+
+// ParseArgs is always run before Run
+func (d *Cmd) ParseArgs(args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return errors.New("an object ID and two versions are required")
+	}
+	d.objectID = args[0]
+	d.fromVersion = args[1]
+	d.toVersion = args[2]
+	if len(args) > 3 {
+		d.dir = args[3]
+	}
+	return nil
+}
+
+func (d *Cmd) Run(ctx context.Context, args []string) error {
+	client := d.root.ServiceClient()
+	cursor := ""
+	for {
+		req := connect.NewRequest(&ocflv1.DiffVersionsRequest{
+			ObjectId:    d.objectID,
+			FromVersion: d.fromVersion,
+			ToVersion:   d.toVersion,
+			ToObjectId:  d.otherID,
+			BasePath:    d.dir,
+			PageToken:   cursor,
+			PageSize:    1000,
+			StorageRoot: d.root.StorageRoot,
+		})
+		resp, err := client.DiffVersions(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, c := range resp.Msg.Changes {
+			switch c.Type {
+			case ocflv1.DiffVersionsResponse_CHANGE_TYPE_ADDED:
+				fmt.Println("A", c.Path)
+			case ocflv1.DiffVersionsResponse_CHANGE_TYPE_REMOVED:
+				fmt.Println("D", c.Path)
+			case ocflv1.DiffVersionsResponse_CHANGE_TYPE_MODIFIED:
+				fmt.Println("M", c.Path)
+			case ocflv1.DiffVersionsResponse_CHANGE_TYPE_RENAMED:
+				fmt.Println("R", c.OldPath, "->", c.Path)
+			}
+		}
+		if resp.Msg.NextPageToken == "" {
+			break
+		}
+		cursor = resp.Msg.NextPageToken
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/spf13/cobra"
@@ -24,21 +25,26 @@ type Cmd struct {
 	objectID string
 	dst      string
 	version  string
+	archive  string // archive format
+	manifest bool   // include a manifest in the archive
 }
 
 // export export files from an object's version state, copying them to the local
 // filesystem. The first argument must be an object id. The second argument is a
 // local filesystem path to a directory where the object's files will be copied.
 // If the directory exists, it must be empty; if it does not exist, it will be
-// created.
+// created. With --archive, the files are downloaded as a single archive, which
+// is written to the destination file (or stdout, if it is "-").
 func (exp *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
 	exp.root = r
 	cmd := &cobra.Command{
-		Use:   `export [-V|--version=] {object_id} {dst}`,
+		Use:   `export [-V|--version=] [--archive=zip|tar.gz] {object_id} {dst}`,
 		Short: "export object's files to the local filesystem",
 		Long:  "export object's files to the local filesystem",
 	}
 	cmd.Flags().StringVarP(&exp.version, "version", "V", "", "use the specified object version (default value refers to HEAD)")
+	cmd.Flags().StringVar(&exp.archive, "archive", "", "download the files as a single archive ('zip' or 'tar.gz') and write it to dst ('-' for stdout)")
+	cmd.Flags().BoolVar(&exp.manifest, "manifest", false, "with --archive, include a manifest of file digests in the archive")
 	return cmd
 }
 
@@ -48,6 +54,9 @@ func (exp *Cmd) ParseArgs(args []string) error {
 	}
 	exp.objectID = args[0]
 	exp.dst = filepath.Clean(args[1])
+	if exp.archive != "" && exp.archive != "zip" && exp.archive != "tar.gz" {
+		return fmt.Errorf("unsupported archive format: '%s'", exp.archive)
+	}
 	return nil
 }
 
@@ -65,24 +74,22 @@ func (exp *Cmd) Run(ctx context.Context, args []string) error {
 	if err != nil {
 		return exportCanceled(err)
 	}
+	if exp.archive != "" {
+		if dstmod != dstNotExist && exp.dst != "-" {
+			return exportCanceled(errors.New("archive destination already exists"))
+		}
+		return exp.downloadArchive(ctx)
+	}
 	if dstmod == dstExistFile {
 		err := errors.New("destination must be a directory")
 		return exportCanceled(err)
 	}
-	// files to copy, with destination path as key
-	copies := map[string]srcFile{}
-	state, err := exp.getFullObjectState(ctx, ".")
+	// object state is streamed: check for errors before creating dst
+	stream, err := exp.streamObjectState(ctx, ".")
 	if err != nil {
 		return exportCanceled(err)
 	}
-	for _, child := range state.Children {
-		dst := filepath.Join(exp.dst, filepath.FromSlash(child.Name))
-		copies[dst] = srcFile{
-			name: child.Name,
-			sum:  child.Digest,
-		}
-	}
-
+	defer stream.Close()
 	switch dstmod {
 	case dstExistDir:
 		// must be empty
@@ -102,42 +109,97 @@ func (exp *Cmd) Run(ctx context.Context, args []string) error {
 	}
 	htcl := exp.root.HTTPClient()
 	// base http for file transfer
-	for dst, src := range copies {
-		if err := exp.download(htcl, src, dst); err != nil {
-			return fmt.Errorf("during export: %w", err)
+	for {
+		for _, child := range stream.Msg().Children {
+			src := srcFile{name: child.Name, sum: child.Digest}
+			dst := filepath.Join(exp.dst, filepath.FromSlash(child.Name))
+			if err := exp.download(htcl, src, dst); err != nil {
+				return fmt.Errorf("during export: %w", err)
+			}
+		}
+		if !stream.Receive() {
+			break
 		}
 	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("while getting object state: %w", err)
+	}
 	return nil
 }
 
-func (exp *Cmd) getFullObjectState(ctx context.Context, src string) (*ocflv1.GetObjectStateResponse, error) {
-	client := exp.root.ServiceClient()
-	cursor := ""
-	var state *ocflv1.GetObjectStateResponse
-	for {
-		req := connect.NewRequest(&ocflv1.GetObjectStateRequest{
-			ObjectId:  exp.objectID,
-			Version:   exp.version,
-			BasePath:  src,
-			Recursive: true,
-			PageToken: cursor,
-			PageSize:  1000,
-		})
-		resp, err := client.GetObjectState(ctx, req)
-		if err != nil {
-			return nil, fmt.Errorf("while getting object state: %w", err)
-		}
-		if state == nil {
-			state = resp.Msg
-		} else {
-			state.Children = append(state.Children, resp.Msg.Children...)
-		}
-		if resp.Msg.NextPageToken == "" {
-			break
+// downloadArchive downloads the object version's files as an archive and
+// writes it to the destination.
+func (exp *Cmd) downloadArchive(ctx context.Context) error {
+	version := exp.version
+	if version == "" {
+		version = "head"
+	}
+	dlurl, err := url.JoinPath(exp.root.RemoteURL, "archive", url.PathEscape(exp.objectID), version)
+	if err != nil {
+		return err
+	}
+	params := url.Values{"format": {exp.archive}}
+	if exp.manifest {
+		params.Set("manifest", "true")
+	}
+	if exp.root.StorageRoot != "" {
+		params.Set("root", exp.root.StorageRoot)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dlurl+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := exp.root.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		bod, _ := io.ReadAll(resp.Body)
+		exp.root.Log.Info("server response", "body", string(bod))
+		return fmt.Errorf("server response: %d", resp.StatusCode)
+	}
+	if exp.dst == "-" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	exp.root.Log.Info("downloading archive", "object", exp.objectID, "to", exp.dst)
+	f, err := os.Create(exp.dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(exp.dst)
+		return fmt.Errorf("during export: %w", err)
+	}
+	return f.Close()
+}
+
+// streamObjectState starts streaming all files below src in the object
+// version state. The first message has been received from the returned
+// stream.
+func (exp *Cmd) streamObjectState(ctx context.Context, src string) (*connect.ServerStreamForClient[ocflv1.StreamObjectStateResponse], error) {
+	req := connect.NewRequest(&ocflv1.StreamObjectStateRequest{
+		ObjectId:    exp.objectID,
+		Version:     exp.version,
+		BasePath:    src,
+		Recursive:   true,
+		StorageRoot: exp.root.StorageRoot,
+	})
+	stream, err := exp.root.ServiceClient().StreamObjectState(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("while getting object state: %w", err)
+	}
+	if !stream.Receive() {
+		err := stream.Err()
+		stream.Close()
+		if err == nil {
+			err = errors.New("empty response")
 		}
-		cursor = resp.Msg.NextPageToken
+		return nil, fmt.Errorf("while getting object state: %w", err)
 	}
-	return state, nil
+	return stream, nil
 }
 
 func exportCanceled(err error) error {
@@ -181,11 +243,22 @@ func (exp *Cmd) download(htcl *http.Client, src srcFile, dst string) error {
 		return err
 	}
 	defer f.Close()
-	dlurl, err := url.JoinPath(exp.root.RemoteURL, "download", src.sum)
-	//dlurl, err := url.JoinPath(exp.root.RemoteURL, "download", src.sum, path.Base(src.name))
+	// files are downloaded by logical path so they are read from the object
+	version := exp.version
+	if version == "" {
+		version = "head"
+	}
+	segments := strings.Split(src.name, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	dlurl, err := url.JoinPath(exp.root.RemoteURL, "objects", url.PathEscape(exp.objectID), "versions", version, "files", strings.Join(segments, "/"))
 	if err != nil {
 		return err
 	}
+	if exp.root.StorageRoot != "" {
+		dlurl += "?" + url.Values{"root": {exp.root.StorageRoot}}.Encode()
+	}
 	req, err := http.NewRequest(http.MethodGet, dlurl, nil)
 	if err != nil {
 		return err
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,7 +31,7 @@ func (ls *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               `ls [(--recursive | -r)] [--versions] [(--version= | -V) {""}] [object_id] [dir]`,
 		Short:             "list objects, object versions, or object contents",
-		Long:              "Without any arguments, ls lists all object IDs in the index. If an object ID is given, ls lists files from the head version state. A path may be given as an optional second argument to specifiy the directory of files to list. With the --versions flag, ls lists the object's versions.",
+		Long:              "Without any arguments, ls lists all object IDs in the index. If an object ID is given, ls lists files from the head version state. A path may be given as an optional second argument to specifiy the directory of files to list. With the --versions flag, ls lists the object's versions with their creation dates, file counts, sizes, changes from the previous version (added/removed/modified), and messages.",
 		ValidArgsFunction: ls.ValidArgsFunction(),
 	}
 	cmd.Flags().BoolVarP(&ls.recursive, "recursive", "r", false, "list files within a directory recursively")
@@ -67,37 +68,39 @@ func (ls *Cmd) Run(ctx context.Context, args []string) error {
 	if ls.versions {
 		return ls.listObjectVersions(ctx)
 	}
-	cursor := ""
-	for {
-		req := connect.NewRequest(&ocflv1.GetObjectStateRequest{
-			ObjectId:  ls.objectID,
-			Version:   ls.version,
-			BasePath:  ls.dir,
-			Recursive: ls.recursive,
-			PageToken: cursor,
-			PageSize:  1000,
-		})
-		resp, err := client.GetObjectState(ctx, req)
-		if err != nil {
-			return err
-		}
-		if !resp.Msg.Isdir {
-			fmt.Println(resp.Msg.Digest)
+	req := connect.NewRequest(&ocflv1.StreamObjectStateRequest{
+		ObjectId:    ls.objectID,
+		Version:     ls.version,
+		BasePath:    ls.dir,
+		Recursive:   ls.recursive,
+		StorageRoot: ls.root.StorageRoot,
+	})
+	stream, err := client.StreamObjectState(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	first := true
+	for stream.Receive() {
+		msg := stream.Msg()
+		if first && !msg.Isdir {
+			fmt.Println(msg.Digest)
 			return nil
 		}
-		for _, child := range resp.Msg.Children {
+		first = false
+		for _, child := range msg.Children {
 			n := child.Name
 			if child.Isdir {
 				n += "/"
 			}
-			fmt.Printf("[%s] %d %s\n", child.Digest[0:8], child.Size, n)
-		}
-		if resp.Msg.NextPageToken == "" {
-			break
+			size := "-"
+			if child.HasSize {
+				size = strconv.FormatInt(child.Size, 10)
+			}
+			fmt.Printf("[%s] %s %s\n", child.Digest[0:8], size, n)
 		}
-		cursor = resp.Msg.NextPageToken
 	}
-	return nil
+	return stream.Err()
 }
 
 // Tab completion
@@ -118,7 +121,8 @@ func (ls Cmd) ValidArgsFunction() func(*cobra.Command, []string, string) ([]stri
 }
 
 func (ls Cmd) listObjects(ctx context.Context) error {
-	iter := ls.root.ListObjects("", 1000)
+	iter := ls.root.ListObjects("")
+	defer iter.Close()
 	for {
 		obj, err := iter.Next(ctx)
 		if err == io.EOF {
@@ -134,13 +138,33 @@ func (ls Cmd) listObjects(ctx context.Context) error {
 
 func (ls Cmd) listObjectVersions(ctx context.Context) error {
 	client := ls.root.ServiceClient()
-	req := connect.NewRequest(&ocflv1.GetObjectRequest{ObjectId: ls.objectID})
+	req := connect.NewRequest(&ocflv1.GetObjectRequest{
+		ObjectId:    ls.objectID,
+		StorageRoot: ls.root.StorageRoot,
+	})
 	resp, err := client.GetObject(ctx, req)
 	if err != nil {
 		return err
 	}
-	for _, v := range resp.Msg.Versions {
-		fmt.Println(v.Num, v.Message, v.Created.AsTime().Local().Format(time.RFC822))
+	obj := resp.Msg
+	indexed := "-"
+	if obj.IndexedAt != nil {
+		indexed = obj.IndexedAt.AsTime().Local().Format(time.RFC822)
+	}
+	fmt.Printf("# inventory %s: %s (indexed %s)\n", obj.SidecarAlgorithm, obj.InventoryDigest, indexed)
+	// columns: version, created, files, size, added/removed/modified, message
+	for _, v := range obj.Versions {
+		files, changes := "-", "-"
+		if v.HasStats {
+			files = strconv.FormatInt(v.NumFiles, 10)
+			changes = fmt.Sprintf("+%d/-%d/~%d", v.NumAdded, v.NumRemoved, v.NumModified)
+		}
+		size := "-"
+		if v.HasSize {
+			size = strconv.FormatInt(v.Size, 10)
+		}
+		created := v.Created.AsTime().Local().Format(time.RFC822)
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n", v.Num, created, files, size, changes, v.Message)
 	}
 	return nil
 }
@@ -149,8 +173,9 @@ func (ls Cmd) listObjectVersions(ctx context.Context) error {
 func (ls Cmd) completeObjectIDsPrefix(ctx context.Context, prefix string) ([]string, error) {
 	cli := ls.root.ServiceClient()
 	req := ocflv1.ListObjectsRequest{
-		PageSize: 1000,
-		IdPrefix: prefix,
+		PageSize:    1000,
+		IdPrefix:    prefix,
+		StorageRoot: ls.root.StorageRoot,
 	}
 	resp, err := cli.ListObjects(ctx, connect.NewRequest(&req))
 	if err != nil {
@@ -178,24 +203,19 @@ func (ls Cmd) completePathPrefix(ctx context.Context, id string, prefix string)
 	if prefix == "." {
 		prefix = ""
 	}
-	cursor := ""
-	for {
-		req := connect.NewRequest(&ocflv1.GetObjectStateRequest{
-			ObjectId:  id,
-			Version:   ls.version,
-			BasePath:  dir,
-			PageToken: cursor,
-			PageSize:  1000,
-		})
-		resp, err := client.GetObjectState(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		if !resp.Msg.Isdir {
-			fmt.Println(resp.Msg.Digest)
-			return nil, nil
-		}
-		for _, child := range resp.Msg.Children {
+	req := connect.NewRequest(&ocflv1.StreamObjectStateRequest{
+		ObjectId:    id,
+		Version:     ls.version,
+		BasePath:    dir,
+		StorageRoot: ls.root.StorageRoot,
+	})
+	stream, err := client.StreamObjectState(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	for stream.Receive() {
+		for _, child := range stream.Msg().Children {
 			p := path.Join(dir, child.Name)
 			if !strings.HasPrefix(p, prefix) {
 				continue
@@ -205,10 +225,9 @@ func (ls Cmd) completePathPrefix(ctx context.Context, id string, prefix string)
 			}
 			entries = append(entries, p)
 		}
-		if resp.Msg.NextPageToken == "" {
-			break
-		}
-		cursor = resp.Msg.NextPageToken
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
 	}
 	return entries, nil
 }
@@ -216,7 +235,8 @@ func (ls Cmd) completePathPrefix(ctx context.Context, id string, prefix string)
 func (ls Cmd) doReindex(ctx context.Context, ids ...string) error {
 	client := ls.root.ServiceClient()
 	rq := &ocflv1.IndexIDsRequest{
-		ObjectIds: ids,
+		ObjectIds:   ids,
+		StorageRoot: ls.root.StorageRoot,
 	}
 	// without an object id, list object ids in the index
 	_, err := client.IndexIDs(ctx, connect.NewRequest(rq))
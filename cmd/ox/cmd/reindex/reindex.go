@@ -2,16 +2,22 @@ package reindex
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/spf13/cobra"
 	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
 	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type Cmd struct {
 	root     *root.Cmd
 	logs     bool
+	follow   bool
+	force    bool
+	timeout  time.Duration
 	objectID string
 }
 
@@ -23,7 +29,10 @@ func (idx *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
 		Long:  "reindex",
 	}
 	cmd.Flags().StringVar(&idx.objectID, "id", "", "reindex the given object ID only")
+	cmd.Flags().BoolVar(&idx.force, "force", false, "reindex objects even if their inventories are unchanged")
+	cmd.Flags().DurationVar(&idx.timeout, "timeout", 0, "cancel the reindexing task if it runs longer than this (default: the server's task timeout)")
 	cmd.Flags().BoolVar(&idx.logs, "logs", false, "follow logs of an existing reindexing process")
+	cmd.Flags().BoolVar(&idx.follow, "follow", false, "follow logs of the new reindexing task until it is complete")
 	return cmd
 }
 
@@ -36,17 +45,44 @@ func (idx *Cmd) Run(ctx context.Context, args []string) error {
 	client := idx.root.ServiceClient()
 
 	if idx.logs {
-		return idx.root.FollowLogs(ctx)
+		return idx.root.FollowLogs(ctx, 0)
 	}
 	if idx.objectID != "" {
 		rq := ocflv1.IndexIDsRequest{
-			ObjectIds: []string{idx.objectID},
+			ObjectIds:   []string{idx.objectID},
+			StorageRoot: idx.root.StorageRoot,
+			Force:       idx.force,
+			Timeout:     idx.timeoutpb(),
 		}
-		_, err := client.IndexIDs(ctx, connect.NewRequest(&rq))
-		return err
+		rsp, err := client.IndexIDs(ctx, connect.NewRequest(&rq))
+		if err != nil {
+			return err
+		}
+		fmt.Println("task:", rsp.Msg.TaskId)
+		return nil
 	}
 	// without an object id, list object ids in the index
-	rq := ocflv1.IndexAllRequest{}
-	_, err := client.IndexAll(ctx, connect.NewRequest(&rq))
-	return err
+	rq := ocflv1.IndexAllRequest{
+		StorageRoot: idx.root.StorageRoot,
+		Force:       idx.force,
+		Timeout:     idx.timeoutpb(),
+	}
+	rsp, err := client.IndexAll(ctx, connect.NewRequest(&rq))
+	if err != nil {
+		return err
+	}
+	fmt.Println("task:", rsp.Msg.TaskId)
+	if idx.follow {
+		return idx.root.FollowLogs(ctx, rsp.Msg.TaskId)
+	}
+	return nil
+}
+
+// timeoutpb returns the --timeout flag value for requests, or nil if it isn't
+// set.
+func (idx *Cmd) timeoutpb() *durationpb.Duration {
+	if idx.timeout <= 0 {
+		return nil
+	}
+	return durationpb.New(idx.timeout)
 }
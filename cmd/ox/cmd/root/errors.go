@@ -0,0 +1,80 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+// ErrorMessage returns a message describing err for users. Errors from the
+// server are described using their codes and error details.
+func (ox *Cmd) ErrorMessage(err error) string {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return err.Error()
+	}
+	msg := connectErr.Message()
+	detail := errorDetail(connectErr)
+	switch connectErr.Code() {
+	case connect.CodeNotFound:
+		if about := describeDetail(detail); about != "" {
+			return fmt.Sprintf("not found: %s (%s)", about, msg)
+		}
+		return "not found: " + msg
+	case connect.CodeInvalidArgument:
+		return "invalid request: " + msg
+	case connect.CodeDataLoss:
+		hint := "reindexing may fix it"
+		if detail != nil && detail.ObjectId != "" {
+			hint = fmt.Sprintf("try 'ox ls --reindex %s'", detail.ObjectId)
+		}
+		return fmt.Sprintf("the index has an unexpected value, possibly due to corruption; %s (%s)", hint, msg)
+	case connect.CodeUnauthenticated:
+		return fmt.Sprintf("authentication required: set %s with a token for the server (%s)", envToken, msg)
+	case connect.CodePermissionDenied:
+		return "permission denied: " + msg
+	case connect.CodeUnavailable:
+		return fmt.Sprintf("server at %s is unavailable: %s", ox.RemoteURL, msg)
+	case connect.CodeFailedPrecondition, connect.CodeResourceExhausted:
+		return msg
+	}
+	return err.Error()
+}
+
+// errorDetail returns the first ErrorDetail in err, or nil if it doesn't have
+// one.
+func errorDetail(err *connect.Error) *ocflv1.ErrorDetail {
+	for _, d := range err.Details() {
+		val, err := d.Value()
+		if err != nil {
+			continue
+		}
+		if detail, ok := val.(*ocflv1.ErrorDetail); ok {
+			return detail
+		}
+	}
+	return nil
+}
+
+// describeDetail describes the request values in detail: e.g., "object 'x',
+// version 'v1', path 'a/b'".
+func describeDetail(detail *ocflv1.ErrorDetail) string {
+	if detail == nil {
+		return ""
+	}
+	var parts []string
+	add := func(name, val string) {
+		if val != "" {
+			parts = append(parts, fmt.Sprintf("%s '%s'", name, val))
+		}
+	}
+	add("object", detail.ObjectId)
+	add("version", detail.Version)
+	add("path", detail.Path)
+	add("digest", detail.Digest)
+	add("storage root", detail.StorageRoot)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,86 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+const progressBarWidth = 30
+
+var phaseNames = map[ocflv1.Progress_Phase]string{
+	ocflv1.Progress_PHASE_SCAN_ROOTS:        "scanning object roots",
+	ocflv1.Progress_PHASE_PARSE_INVENTORIES: "indexing inventories",
+	ocflv1.Progress_PHASE_REMOVE_STALE:      "removing stale objects",
+	ocflv1.Progress_PHASE_VALIDATE_OBJECTS:  "validating objects",
+}
+
+// progressBar renders progress updates from FollowLogs as a single line that
+// is redrawn with each update.
+type progressBar struct {
+	w    io.Writer
+	line bool // the progress line is displayed
+}
+
+// newProgressBar returns a progressBar that writes to stderr, or nil if stderr
+// isn't a terminal.
+func newProgressBar() *progressBar {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{w: os.Stderr}
+}
+
+// update redraws the progress line.
+func (bar *progressBar) update(p *ocflv1.Progress) {
+	fmt.Fprintf(bar.w, "\r\033[K%s", progressLine(p))
+	bar.line = true
+}
+
+// clear removes the progress line so other messages can be printed.
+func (bar *progressBar) clear() {
+	if bar.line {
+		fmt.Fprint(bar.w, "\r\033[K")
+		bar.line = false
+	}
+}
+
+func progressLine(p *ocflv1.Progress) string {
+	var b strings.Builder
+	name := phaseNames[p.Phase]
+	if name == "" {
+		name = "indexing"
+	}
+	if p.StorageRoot != "" {
+		fmt.Fprintf(&b, "%s: ", p.StorageRoot)
+	}
+	b.WriteString(name)
+	if p.Phase == ocflv1.Progress_PHASE_SCAN_ROOTS || p.Found == 0 {
+		// the total isn't known
+		fmt.Fprintf(&b, " %d", p.Processed)
+		return b.String()
+	}
+	done := p.Processed
+	if done > p.Found {
+		done = p.Found
+	}
+	filled := int(done * progressBarWidth / p.Found)
+	fmt.Fprintf(&b, " [%s%s] %d/%d",
+		strings.Repeat("#", filled),
+		strings.Repeat("-", progressBarWidth-filled),
+		p.Processed, p.Found)
+	if p.Skipped > 0 {
+		fmt.Fprintf(&b, " skipped: %d", p.Skipped)
+	}
+	if p.Errors > 0 {
+		fmt.Fprintf(&b, " errors: %d", p.Errors)
+	}
+	if p.Eta != nil {
+		fmt.Fprintf(&b, " eta: %s", p.Eta.AsDuration())
+	}
+	return b.String()
+}
@@ -6,6 +6,7 @@ package root
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,18 +23,25 @@ import (
 
 const (
 	envRemote     = "OCFL_INDEX"
+	envToken      = "OCFL_INDEX_TOKEN" // bearer token sent with requests
 	defaultRemote = "http://localhost:8080"
+
+	// number of times FollowLogs reconnects after falling behind
+	maxFollowResumes = 3
 )
 
 // RootCmd is the type of the root command
 type Cmd struct {
 	cobra.Command
-	Log        logr.Logger
-	RemoteURL  string
-	httpClient *http.Client
-	certFile   string
-	keyFile    string
-	rpcClient  ocflv1connect.IndexServiceClient
+	Log         logr.Logger
+	RemoteURL   string
+	StorageRoot string // storage root name used in requests
+	token       string
+	httpClient  *http.Client
+	certFile    string
+	keyFile     string
+	caFile      string
+	rpcClient   ocflv1connect.IndexServiceClient
 }
 
 type OxCmd interface {
@@ -45,7 +53,10 @@ type OxCmd interface {
 func (ox *Cmd) Init() {
 	ox.PersistentFlags().StringVar(&ox.certFile, "cert", "", "PEM certificate for client")
 	ox.PersistentFlags().StringVar(&ox.keyFile, "key", "", "PEM key for client")
+	ox.PersistentFlags().StringVar(&ox.caFile, "cacert", "", "PEM CA certificates for verifying the server")
+	ox.PersistentFlags().StringVar(&ox.StorageRoot, "root", "", "name of the storage root to use (default: the server's default storage root)")
 	ox.RemoteURL = getenvDefault(envRemote, defaultRemote)
+	ox.token = getenvDefault(envToken, "")
 }
 
 func (ox *Cmd) AddSub(subs ...OxCmd) {
@@ -67,17 +78,32 @@ func (ox *Cmd) AddSub(subs ...OxCmd) {
 func (ox *Cmd) HTTPClient() *http.Client {
 	if ox.httpClient == nil {
 		var trans http.RoundTripper
-		if ox.certFile != "" && ox.keyFile != "" {
-			cert, err := tls.LoadX509KeyPair(ox.certFile, ox.keyFile)
-			if err != nil {
-				ox.Log.Error(err, "client config error")
-				os.Exit(1)
+		if (ox.certFile != "" && ox.keyFile != "") || ox.caFile != "" {
+			tlsConf := &tls.Config{}
+			if ox.certFile != "" && ox.keyFile != "" {
+				cert, err := tls.LoadX509KeyPair(ox.certFile, ox.keyFile)
+				if err != nil {
+					ox.Log.Error(err, "client config error")
+					os.Exit(1)
+				}
+				tlsConf.Certificates = []tls.Certificate{cert}
 			}
-			trans = &http.Transport{
-				TLSClientConfig: &tls.Config{
-					Certificates: []tls.Certificate{cert},
-				},
+			if ox.caFile != "" {
+				pem, err := os.ReadFile(ox.caFile)
+				if err != nil {
+					ox.Log.Error(err, "client config error")
+					os.Exit(1)
+				}
+				tlsConf.RootCAs = x509.NewCertPool()
+				if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+					ox.Log.Error(fmt.Errorf("no certificates found in %s", ox.caFile), "client config error")
+					os.Exit(1)
+				}
 			}
+			trans = &http.Transport{TLSClientConfig: tlsConf}
+		}
+		if ox.token != "" {
+			trans = &tokenTransport{base: trans, token: ox.token}
 		}
 		ox.httpClient = &http.Client{
 			Transport: trans,
@@ -85,6 +111,23 @@ func (ox *Cmd) HTTPClient() *http.Client {
 	}
 	return ox.httpClient
 }
+
+// tokenTransport adds a bearer token to requests
+type tokenTransport struct {
+	base  http.RoundTripper // if nil, http.DefaultTransport is used
+	token string
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return base.RoundTrip(r)
+}
+
 func (ox *Cmd) ServiceClient() ocflv1connect.IndexServiceClient {
 	if ox.rpcClient == nil {
 		ox.rpcClient = ocflv1connect.NewIndexServiceClient(ox.HTTPClient(), ox.RemoteURL)
@@ -99,65 +142,101 @@ func getenvDefault(key, def string) string {
 	return def
 }
 
-func (ox Cmd) FollowLogs(ctx context.Context) error {
+// FollowLogs logs messages from indexing tasks, starting with recent messages
+// kept by the server. If taskID is non-zero, only messages from that task are
+// logged and FollowLogs returns when the task is complete. Progress updates
+// are shown as a progress bar if stderr is a terminal. If the server ends the
+// stream because the client fell behind, FollowLogs reconnects and resumes
+// after the last message received.
+func (ox Cmd) FollowLogs(ctx context.Context, taskID int64) error {
 	cli := ox.ServiceClient()
-	rq := ocflv1.FollowLogsRequest{}
-	stream, err := cli.FollowLogs(ctx, connect.NewRequest(&rq))
-	if err != nil {
-		return err
-	}
-	for stream.Receive() {
-		msg := stream.Msg().Message
-		ox.Log.Info(msg)
-	}
-	if err := stream.Err(); err != nil {
+	bar := newProgressBar()
+	defer func() {
+		if bar != nil {
+			bar.clear()
+		}
+	}()
+	var lastSeq int64
+	for resumes := 0; ; resumes++ {
+		rq := ocflv1.FollowLogsRequest{TaskId: taskID, ResumeAfter: lastSeq}
+		stream, err := cli.FollowLogs(ctx, connect.NewRequest(&rq))
+		if err != nil {
+			return err
+		}
+		for stream.Receive() {
+			msg := stream.Msg()
+			lastSeq = msg.Seq
+			if p := msg.Progress; p != nil {
+				if bar != nil {
+					bar.update(p)
+					continue
+				}
+				ox.Log.Info(progressLine(p))
+				continue
+			}
+			if bar != nil {
+				bar.clear()
+			}
+			ox.Log.Info(msg.Message)
+		}
+		err = stream.Err()
+		stream.Close()
+		if connect.CodeOf(err) == connect.CodeResourceExhausted && resumes < maxFollowResumes {
+			continue
+		}
 		return err
 	}
-	return nil
 }
 
-func (ox Cmd) ListObjects(prefix string, pageSize int) *ListObjectsIterator {
+// ListObjects returns an iterator over objects in the storage root with IDs
+// that begin with prefix. Objects are received from a single StreamObjects
+// call.
+func (ox Cmd) ListObjects(prefix string) *ListObjectsIterator {
 	return &ListObjectsIterator{
-		client: ox.ServiceClient(),
-		limit:  pageSize,
-		prefix: prefix,
+		client:      ox.ServiceClient(),
+		prefix:      prefix,
+		storageRoot: ox.StorageRoot,
 	}
 }
 
 type ListObjectsIterator struct {
-	client   ocflv1connect.IndexServiceClient
-	prefix   string
-	limit    int
-	nextPage string
-	results  *ocflv1.ListObjectsResponse
-	i        int // index of the object returned by call to Next()
+	client      ocflv1connect.IndexServiceClient
+	prefix      string
+	storageRoot string
+	stream      *connect.ServerStreamForClient[ocflv1.StreamObjectsResponse]
+	objects     []*ocflv1.ListObjectsResponse_Object // from the last message received
+	i           int                                  // index of the object returned by call to Next()
 }
 
-func (pager *ListObjectsIterator) Next(ctx context.Context) (*index.ObjectListItem, error) {
-	if pager.needNextPage() {
-		pager.i = 0 // resets
-		req := ocflv1.ListObjectsRequest{
-			PageToken: pager.nextPage,
-			PageSize:  int32(pager.limit),
-			IdPrefix:  pager.prefix,
+// Next returns the next object. It returns io.EOF after the last object.
+func (iter *ListObjectsIterator) Next(ctx context.Context) (*index.ObjectListItem, error) {
+	if iter.stream == nil {
+		req := ocflv1.StreamObjectsRequest{
+			IdPrefix:    iter.prefix,
+			StorageRoot: iter.storageRoot,
 		}
-		resp, err := pager.client.ListObjects(ctx, connect.NewRequest(&req))
+		stream, err := iter.client.StreamObjects(ctx, connect.NewRequest(&req))
 		if err != nil {
 			return nil, err
 		}
-		pager.results = resp.Msg
-		pager.nextPage = pager.results.NextPageToken
+		iter.stream = stream
 	}
-	if pager.i >= len(pager.results.Objects) {
-		return nil, io.EOF
+	for iter.i >= len(iter.objects) {
+		if !iter.stream.Receive() {
+			if err := iter.stream.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		iter.objects = iter.stream.Msg().Objects
+		iter.i = 0
 	}
-	item := pager.results.Objects[pager.i]
-	pager.i += 1
+	item := iter.objects[iter.i]
+	iter.i += 1
 	obj := &index.ObjectListItem{
-		//RootPath: item.,
 		ID:          item.ObjectId,
 		V1Created:   item.V1Created.AsTime(),
-		HeadCreated: item.V1Created.AsTime(),
+		HeadCreated: item.HeadCreated.AsTime(),
 	}
 	if err := ocfl.ParseVNum(item.Head, &obj.Head); err != nil {
 		return nil, fmt.Errorf("received object has invalid head: %w", err)
@@ -165,12 +244,10 @@ func (pager *ListObjectsIterator) Next(ctx context.Context) (*index.ObjectListIt
 	return obj, nil
 }
 
-func (pager *ListObjectsIterator) needNextPage() bool {
-	if pager.results == nil {
-		return true
-	}
-	if pager.nextPage != "" && pager.i == len(pager.results.Objects) && pager.i > 0 {
-		return true
+// Close closes the iterator's stream.
+func (iter *ListObjectsIterator) Close() error {
+	if iter.stream == nil {
+		return nil
 	}
-	return false
+	return iter.stream.Close()
 }
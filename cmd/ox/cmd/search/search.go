@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+type Cmd struct {
+	root  *root.Cmd
+	query string
+}
+
+func (s *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
+	s.root = r
+	cmd := &cobra.Command{
+		Use:   `search {query}`,
+		Short: "search object IDs, logical paths, and version metadata",
+		Long: `Search lists object versions and logical paths that match all terms in the
+query. Terms are separated by spaces; a "quoted phrase" is a single term. A term
+ending with '*' matches as a prefix. Each result is printed as the object ID,
+the version, and the matching logical path, if any.`,
+	}
+	return cmd
+}
+
+// ParseArgs is always run before Run
+func (s *Cmd) ParseArgs(args []string) error {
+	s.query = strings.Join(args, " ")
+	if strings.TrimSpace(s.query) == "" {
+		return errors.New("a search query is required")
+	}
+	return nil
+}
+
+func (s *Cmd) Run(ctx context.Context, args []string) error {
+	client := s.root.ServiceClient()
+	cursor := ""
+	for {
+		req := connect.NewRequest(&ocflv1.SearchRequest{
+			Query:       s.query,
+			PageToken:   cursor,
+			PageSize:    1000,
+			StorageRoot: s.root.StorageRoot,
+		})
+		resp, err := client.Search(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, r := range resp.Msg.Results {
+			if r.Path == "" {
+				fmt.Println(r.ObjectId, r.Version)
+				continue
+			}
+			fmt.Println(r.ObjectId, r.Version, r.Path)
+		}
+		if resp.Msg.NextPageToken == "" {
+			break
+		}
+		cursor = resp.Msg.NextPageToken
+	}
+	return nil
+}
@@ -14,16 +14,18 @@ import (
 )
 
 type Cmd struct {
-	root *root.Cmd
+	root   *root.Cmd
+	errors bool
 }
 
 func (status *Cmd) NewCommand(root *root.Cmd) *cobra.Command {
 	status.root = root
 	cmd := &cobra.Command{
 		Use:   "status",
-		Short: "print summary info about the index and its storage root",
-		Long:  `print summary info about the index and its storage root`,
+		Short: "print summary info about the index and its storage roots",
+		Long:  `print summary info about the index and its storage roots. With --errors, status lists objects that failed validation, with one line for each error or warning: object path, severity, validation code, and message.`,
 	}
+	cmd.Flags().BoolVar(&status.errors, "errors", false, "list validation errors and warnings for objects in the storage root")
 	return cmd
 }
 
@@ -32,18 +34,65 @@ func (status *Cmd) ParseArgs(args []string) error {
 }
 
 func (status Cmd) Run(ctx context.Context, args []string) error {
+	if status.errors {
+		return status.listInvalid(ctx)
+	}
 	client := status.root.ServiceClient()
-	req := connect.NewRequest(&ocflv1.GetStatusRequest{})
+	req := connect.NewRequest(&ocflv1.GetStatusRequest{StorageRoot: status.root.StorageRoot})
 	resp, err := client.GetStatus(ctx, req)
 	if err != nil {
 		return err
 	}
 	// TODO: different format options
 	fmt.Println("indexer status:", resp.Msg.Status)
-	fmt.Println("# found objects:", resp.Msg.NumObjectPaths)
-	fmt.Println("# indexed inventories:", resp.Msg.NumInventories)
-	fmt.Println("storage root OCFL spec:", resp.Msg.StoreSpec)
-	fmt.Println("storage root description:", resp.Msg.StoreDescription)
-	fmt.Println("storage root path:", resp.Msg.StoreRootPath)
+	if status.root.StorageRoot != "" {
+		// details for the selected storage root only
+		fmt.Println("storage root:", resp.Msg.StorageRoot)
+		fmt.Println("# found objects:", resp.Msg.NumObjectPaths)
+		fmt.Println("# indexed inventories:", resp.Msg.NumInventories)
+		fmt.Println("storage root OCFL spec:", resp.Msg.StoreSpec)
+		fmt.Println("storage root description:", resp.Msg.StoreDescription)
+		fmt.Println("storage root path:", resp.Msg.StoreRootPath)
+		return nil
+	}
+	for _, root := range resp.Msg.StorageRoots {
+		name := root.Name
+		if name == resp.Msg.StorageRoot {
+			name += " (default)"
+		}
+		fmt.Println("storage root:", name)
+		fmt.Println("  # found objects:", root.NumObjectPaths)
+		fmt.Println("  # indexed inventories:", root.NumInventories)
+		fmt.Println("  storage root OCFL spec:", root.Spec)
+		fmt.Println("  storage root description:", root.Description)
+		fmt.Println("  storage root path:", root.RootPath)
+	}
 	return nil
 }
+
+// listInvalid prints validation errors and warnings for objects in the
+// storage root
+func (status Cmd) listInvalid(ctx context.Context) error {
+	client := status.root.ServiceClient()
+	cursor := ""
+	for {
+		req := connect.NewRequest(&ocflv1.ListInvalidObjectsRequest{
+			StorageRoot: status.root.StorageRoot,
+			PageSize:    1000,
+			PageToken:   cursor,
+		})
+		resp, err := client.ListInvalidObjects(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, obj := range resp.Msg.Objects {
+			for _, issue := range obj.Issues {
+				fmt.Printf("%s\t%s\t%s\t%s\n", obj.ObjectPath, issue.Severity, issue.Code, issue.Message)
+			}
+		}
+		cursor = resp.Msg.NextPageToken
+		if cursor == "" {
+			return nil
+		}
+	}
+}
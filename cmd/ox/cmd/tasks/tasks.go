@@ -0,0 +1,175 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+type Cmd struct {
+	root   *root.Cmd
+	taskID int64
+	limit  int
+	follow bool
+}
+
+func (tc *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
+	tc.root = r
+	cmd := &cobra.Command{
+		Use:   `tasks [task_id]`,
+		Short: "list indexing tasks or show details for a task",
+		Long: `Tasks lists recent indexing tasks, most recent first. With a task ID, it
+prints details for the task, including its options, counts, and error. Use
+'tasks cancel {task_id}' to cancel a queued or running task.`,
+	}
+	cmd.Flags().IntVarP(&tc.limit, "num", "n", 20, "number of tasks to list")
+	cmd.Flags().BoolVar(&tc.follow, "follow", false, "follow logs of the task until it is complete")
+	cmd.AddCommand(&cobra.Command{
+		Use:   "cancel {task_id}",
+		Short: "cancel a queued or running task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return tc.cancel(c.Context(), id)
+		},
+	})
+	return cmd
+}
+
+// ParseArgs is always run before Run
+func (tc *Cmd) ParseArgs(args []string) error {
+	switch len(args) {
+	case 0:
+		if tc.follow {
+			return errors.New("a task ID is required with --follow")
+		}
+		return nil
+	case 1:
+		id, err := parseID(args[0])
+		if err != nil {
+			return err
+		}
+		tc.taskID = id
+		return nil
+	}
+	return errors.New("too many arguments")
+}
+
+func parseID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid task ID: %q", arg)
+	}
+	return id, nil
+}
+
+func (tc *Cmd) cancel(ctx context.Context, id int64) error {
+	req := connect.NewRequest(&ocflv1.CancelTaskRequest{TaskId: id})
+	if _, err := tc.root.ServiceClient().CancelTask(ctx, req); err != nil {
+		return err
+	}
+	fmt.Println("canceled task:", id)
+	return nil
+}
+
+func (tc *Cmd) Run(ctx context.Context, args []string) error {
+	client := tc.root.ServiceClient()
+	if tc.taskID != 0 {
+		if tc.follow {
+			if err := tc.root.FollowLogs(ctx, tc.taskID); err != nil {
+				return err
+			}
+		}
+		resp, err := client.GetTask(ctx, connect.NewRequest(&ocflv1.GetTaskRequest{TaskId: tc.taskID}))
+		if err != nil {
+			return err
+		}
+		printTask(resp.Msg.Task)
+		return nil
+	}
+	cursor := ""
+	for n := 0; n < tc.limit; {
+		req := connect.NewRequest(&ocflv1.ListTasksRequest{
+			PageToken: cursor,
+			PageSize:  int32(tc.limit - n),
+		})
+		resp, err := client.ListTasks(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, task := range resp.Msg.Tasks {
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\t%s\n",
+				task.Id,
+				task.Status,
+				task.StartedAt.AsTime().Local().Format(time.RFC3339),
+				duration(task),
+				task.Name,
+				task.StorageRoot)
+		}
+		n += len(resp.Msg.Tasks)
+		if resp.Msg.NextPageToken == "" {
+			break
+		}
+		cursor = resp.Msg.NextPageToken
+	}
+	return nil
+}
+
+func printTask(task *ocflv1.Task) {
+	fmt.Println("task:", task.Id)
+	fmt.Println("name:", task.Name)
+	if task.StorageRoot != "" {
+		fmt.Println("storage root:", task.StorageRoot)
+	}
+	fmt.Println("status:", task.Status)
+	fmt.Println("started:", task.StartedAt.AsTime().Local().Format(time.RFC3339))
+	if task.EndedAt != nil {
+		fmt.Println("ended:", task.EndedAt.AsTime().Local().Format(time.RFC3339))
+	}
+	fmt.Println("duration:", duration(task))
+	if len(task.Options) > 0 {
+		fmt.Println("options:", keyValues(task.Options))
+	}
+	if len(task.Counts) > 0 {
+		counts := make(map[string]string, len(task.Counts))
+		for k, v := range task.Counts {
+			counts[k] = strconv.FormatInt(v, 10)
+		}
+		fmt.Println("counts:", keyValues(counts))
+	}
+	if task.Error != "" {
+		fmt.Println("error:", task.Error)
+	}
+}
+
+// duration returns the task's run time, or the time since it started if it is
+// still running.
+func duration(task *ocflv1.Task) time.Duration {
+	end := time.Now()
+	if task.EndedAt != nil {
+		end = task.EndedAt.AsTime()
+	}
+	return end.Sub(task.StartedAt.AsTime()).Round(time.Second)
+}
+
+// keyValues formats the map as sorted key=value pairs
+func keyValues(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
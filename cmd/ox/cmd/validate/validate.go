@@ -0,0 +1,64 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type Cmd struct {
+	root    *root.Cmd
+	follow  bool
+	oldest  int
+	timeout time.Duration
+}
+
+func (val *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
+	val.root = r
+	cmd := &cobra.Command{
+		Use:   `validate [object_id ...]`,
+		Short: "validate objects, including content digests",
+		Long:  "Fully validate the given objects, or all objects in the storage root, in a background task. Each object's result and validation time are recorded in the index.",
+	}
+	cmd.Flags().IntVar(&val.oldest, "oldest", 0, "without object ids, validate this many objects, least recently validated first")
+	cmd.Flags().DurationVar(&val.timeout, "timeout", 0, "cancel the validation task if it runs longer than this (default: the server's task timeout)")
+	cmd.Flags().BoolVar(&val.follow, "follow", false, "follow logs of the new validation task until it is complete")
+	return cmd
+}
+
+// ParseArgs is always run before Run
+func (val *Cmd) ParseArgs(args []string) error {
+	if val.oldest < 0 {
+		return fmt.Errorf("--oldest must not be negative")
+	}
+	if len(args) > 0 && val.oldest > 0 {
+		return fmt.Errorf("--oldest can't be used with object ids")
+	}
+	return nil
+}
+
+func (val *Cmd) Run(ctx context.Context, args []string) error {
+	rq := ocflv1.ValidateObjectsRequest{
+		StorageRoot: val.root.StorageRoot,
+		ObjectIds:   args,
+		Oldest:      int32(val.oldest),
+	}
+	if val.timeout > 0 {
+		rq.Timeout = durationpb.New(val.timeout)
+	}
+	rsp, err := val.root.ServiceClient().ValidateObjects(ctx, connect.NewRequest(&rq))
+	if err != nil {
+		return err
+	}
+	fmt.Println("task:", rsp.Msg.TaskId)
+	if val.follow {
+		return val.root.FollowLogs(ctx, rsp.Msg.TaskId)
+	}
+	return nil
+}
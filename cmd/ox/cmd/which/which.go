@@ -0,0 +1,71 @@
+package which
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/spf13/cobra"
+	"github.com/srerickson/ocfl-index/cmd/ox/cmd/root"
+	ocflv1 "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+type Cmd struct {
+	root   *root.Cmd
+	digest string
+}
+
+func (w *Cmd) NewCommand(r *root.Cmd) *cobra.Command {
+	w.root = r
+	cmd := &cobra.Command{
+		Use:   `which {digest}`,
+		Short: "list objects, versions, and paths with a file or directory digest",
+		Long: `Which lists every object version and logical path where a file or directory
+with the given digest appears. File digests use the object's digest algorithm;
+directory digests are recursive digests of the directory's contents. Each
+result is printed as the object ID, the version, and the logical path.
+Directory paths end with '/'.`,
+	}
+	return cmd
+}
+
+// ParseArgs is always run before Run
+func (w *Cmd) ParseArgs(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a single digest argument is required")
+	}
+	w.digest = args[0]
+	return nil
+}
+
+func (w *Cmd) Run(ctx context.Context, args []string) error {
+	client := w.root.ServiceClient()
+	cursor := ""
+	for {
+		req := connect.NewRequest(&ocflv1.FindByDigestRequest{
+			Digest:      w.digest,
+			PageToken:   cursor,
+			PageSize:    1000,
+			StorageRoot: w.root.StorageRoot,
+		})
+		resp, err := client.FindByDigest(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, obj := range resp.Msg.Objects {
+			for _, p := range obj.Paths {
+				name := p.Path
+				if p.Isdir && name != "." {
+					name += "/"
+				}
+				fmt.Println(obj.ObjectId, p.Version, name)
+			}
+		}
+		if resp.Msg.NextPageToken == "" {
+			break
+		}
+		cursor = resp.Msg.NextPageToken
+	}
+	return nil
+}
@@ -27,15 +27,16 @@ const (
 
 // IndexServiceClient is a client for the ocfl.v1.IndexService service.
 type IndexServiceClient interface {
-	// Get index status, counts, and storage root details
+	// Get index status, counts, and details for each storage root
 	GetStatus(context.Context, *connect_go.Request[v1.GetStatusRequest]) (*connect_go.Response[v1.GetStatusResponse], error)
-	// Start an asynchronous indexing process to scan the storage root and ingest
+	// Queue an asynchronous indexing task to scan the storage root and ingest
 	// index inventories. Indexed objects not found during the storage root scan
-	// are removed from the index. IndexAll returns immediately with a status
-	// indicating whether the indexing process was started.
+	// are removed from the index. IndexAll returns immediately with the task's
+	// ID.
 	IndexAll(context.Context, *connect_go.Request[v1.IndexAllRequest]) (*connect_go.Response[v1.IndexAllResponse], error)
 	// Index inventories for the specified object ids. Unlike IndexAll, IndexIDs
-	// returns after the object ids have been indexed.
+	// returns after the object ids have been indexed. The task is queued ahead
+	// of full storage root scans and may run between batches of a running scan.
 	IndexIDs(context.Context, *connect_go.Request[v1.IndexIDsRequest]) (*connect_go.Response[v1.IndexIDsResponse], error)
 	// List all objects in the index in lexigraphical order by ID.
 	ListObjects(context.Context, *connect_go.Request[v1.ListObjectsRequest]) (*connect_go.Response[v1.ListObjectsResponse], error)
@@ -43,8 +44,38 @@ type IndexServiceClient interface {
 	GetObject(context.Context, *connect_go.Request[v1.GetObjectRequest]) (*connect_go.Response[v1.GetObjectResponse], error)
 	// Query the logical state of an OCFL object version
 	GetObjectState(context.Context, *connect_go.Request[v1.GetObjectStateRequest]) (*connect_go.Response[v1.GetObjectStateResponse], error)
+	// Stream all objects in the index in lexigraphical order by ID. Unlike
+	// ListObjects, results aren't paginated: objects are sent in batches from
+	// a single database query.
+	StreamObjects(context.Context, *connect_go.Request[v1.StreamObjectsRequest]) (*connect_go.ServerStreamForClient[v1.StreamObjectsResponse], error)
+	// Stream the logical state of an OCFL object version. Unlike
+	// GetObjectState, results aren't paginated: children of the base path are
+	// sent in batches from a single database query.
+	StreamObjectState(context.Context, *connect_go.Request[v1.StreamObjectStateRequest]) (*connect_go.ServerStreamForClient[v1.StreamObjectStateResponse], error)
+	// Full-text search of object IDs, logical paths, and version metadata
+	Search(context.Context, *connect_go.Request[v1.SearchRequest]) (*connect_go.Response[v1.SearchResponse], error)
+	// List objects, versions, and logical paths with a file or directory digest
+	FindByDigest(context.Context, *connect_go.Request[v1.FindByDigestRequest]) (*connect_go.Response[v1.FindByDigestResponse], error)
+	// Compare files in two object versions, which may belong to different
+	// objects
+	DiffVersions(context.Context, *connect_go.Request[v1.DiffVersionsRequest]) (*connect_go.Response[v1.DiffVersionsResponse], error)
+	// List objects with validation errors or warnings, in lexigraphical order
+	// by object path. Issues are recorded when an object's inventory is
+	// validated during indexing and are replaced when it is reindexed with a
+	// changed inventory (or with force).
+	ListInvalidObjects(context.Context, *connect_go.Request[v1.ListInvalidObjectsRequest]) (*connect_go.Response[v1.ListInvalidObjectsResponse], error)
 	// Stream log messages from indexing tasks
 	FollowLogs(context.Context, *connect_go.Request[v1.FollowLogsRequest]) (*connect_go.ServerStreamForClient[v1.FollowLogsResponse], error)
+	// List asynchronous tasks (e.g., indexing), most recent first
+	ListTasks(context.Context, *connect_go.Request[v1.ListTasksRequest]) (*connect_go.Response[v1.ListTasksResponse], error)
+	// Get details for a specific task
+	GetTask(context.Context, *connect_go.Request[v1.GetTaskRequest]) (*connect_go.Response[v1.GetTaskResponse], error)
+	// Cancel a queued or running task
+	CancelTask(context.Context, *connect_go.Request[v1.CancelTaskRequest]) (*connect_go.Response[v1.CancelTaskResponse], error)
+	// Queue an asynchronous task to fully validate objects, including content
+	// digests. Results are recorded for each object and included in GetObject
+	// responses. ValidateObjects returns immediately with the task's ID.
+	ValidateObjects(context.Context, *connect_go.Request[v1.ValidateObjectsRequest]) (*connect_go.Response[v1.ValidateObjectsResponse], error)
 }
 
 // NewIndexServiceClient constructs a client for the ocfl.v1.IndexService service. By default, it
@@ -87,23 +118,83 @@ func NewIndexServiceClient(httpClient connect_go.HTTPClient, baseURL string, opt
 			baseURL+"/ocfl.v1.IndexService/GetObjectState",
 			opts...,
 		),
+		streamObjects: connect_go.NewClient[v1.StreamObjectsRequest, v1.StreamObjectsResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/StreamObjects",
+			opts...,
+		),
+		streamObjectState: connect_go.NewClient[v1.StreamObjectStateRequest, v1.StreamObjectStateResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/StreamObjectState",
+			opts...,
+		),
+		search: connect_go.NewClient[v1.SearchRequest, v1.SearchResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/Search",
+			opts...,
+		),
+		findByDigest: connect_go.NewClient[v1.FindByDigestRequest, v1.FindByDigestResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/FindByDigest",
+			opts...,
+		),
+		diffVersions: connect_go.NewClient[v1.DiffVersionsRequest, v1.DiffVersionsResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/DiffVersions",
+			opts...,
+		),
+		listInvalidObjects: connect_go.NewClient[v1.ListInvalidObjectsRequest, v1.ListInvalidObjectsResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/ListInvalidObjects",
+			opts...,
+		),
 		followLogs: connect_go.NewClient[v1.FollowLogsRequest, v1.FollowLogsResponse](
 			httpClient,
 			baseURL+"/ocfl.v1.IndexService/FollowLogs",
 			opts...,
 		),
+		listTasks: connect_go.NewClient[v1.ListTasksRequest, v1.ListTasksResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/ListTasks",
+			opts...,
+		),
+		getTask: connect_go.NewClient[v1.GetTaskRequest, v1.GetTaskResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/GetTask",
+			opts...,
+		),
+		cancelTask: connect_go.NewClient[v1.CancelTaskRequest, v1.CancelTaskResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/CancelTask",
+			opts...,
+		),
+		validateObjects: connect_go.NewClient[v1.ValidateObjectsRequest, v1.ValidateObjectsResponse](
+			httpClient,
+			baseURL+"/ocfl.v1.IndexService/ValidateObjects",
+			opts...,
+		),
 	}
 }
 
 // indexServiceClient implements IndexServiceClient.
 type indexServiceClient struct {
-	getStatus      *connect_go.Client[v1.GetStatusRequest, v1.GetStatusResponse]
-	indexAll       *connect_go.Client[v1.IndexAllRequest, v1.IndexAllResponse]
-	indexIDs       *connect_go.Client[v1.IndexIDsRequest, v1.IndexIDsResponse]
-	listObjects    *connect_go.Client[v1.ListObjectsRequest, v1.ListObjectsResponse]
-	getObject      *connect_go.Client[v1.GetObjectRequest, v1.GetObjectResponse]
-	getObjectState *connect_go.Client[v1.GetObjectStateRequest, v1.GetObjectStateResponse]
-	followLogs     *connect_go.Client[v1.FollowLogsRequest, v1.FollowLogsResponse]
+	getStatus          *connect_go.Client[v1.GetStatusRequest, v1.GetStatusResponse]
+	indexAll           *connect_go.Client[v1.IndexAllRequest, v1.IndexAllResponse]
+	indexIDs           *connect_go.Client[v1.IndexIDsRequest, v1.IndexIDsResponse]
+	listObjects        *connect_go.Client[v1.ListObjectsRequest, v1.ListObjectsResponse]
+	getObject          *connect_go.Client[v1.GetObjectRequest, v1.GetObjectResponse]
+	getObjectState     *connect_go.Client[v1.GetObjectStateRequest, v1.GetObjectStateResponse]
+	streamObjects      *connect_go.Client[v1.StreamObjectsRequest, v1.StreamObjectsResponse]
+	streamObjectState  *connect_go.Client[v1.StreamObjectStateRequest, v1.StreamObjectStateResponse]
+	search             *connect_go.Client[v1.SearchRequest, v1.SearchResponse]
+	findByDigest       *connect_go.Client[v1.FindByDigestRequest, v1.FindByDigestResponse]
+	diffVersions       *connect_go.Client[v1.DiffVersionsRequest, v1.DiffVersionsResponse]
+	listInvalidObjects *connect_go.Client[v1.ListInvalidObjectsRequest, v1.ListInvalidObjectsResponse]
+	followLogs         *connect_go.Client[v1.FollowLogsRequest, v1.FollowLogsResponse]
+	listTasks          *connect_go.Client[v1.ListTasksRequest, v1.ListTasksResponse]
+	getTask            *connect_go.Client[v1.GetTaskRequest, v1.GetTaskResponse]
+	cancelTask         *connect_go.Client[v1.CancelTaskRequest, v1.CancelTaskResponse]
+	validateObjects    *connect_go.Client[v1.ValidateObjectsRequest, v1.ValidateObjectsResponse]
 }
 
 // GetStatus calls ocfl.v1.IndexService.GetStatus.
@@ -136,22 +227,73 @@ func (c *indexServiceClient) GetObjectState(ctx context.Context, req *connect_go
 	return c.getObjectState.CallUnary(ctx, req)
 }
 
+// StreamObjects calls ocfl.v1.IndexService.StreamObjects.
+func (c *indexServiceClient) StreamObjects(ctx context.Context, req *connect_go.Request[v1.StreamObjectsRequest]) (*connect_go.ServerStreamForClient[v1.StreamObjectsResponse], error) {
+	return c.streamObjects.CallServerStream(ctx, req)
+}
+
+// StreamObjectState calls ocfl.v1.IndexService.StreamObjectState.
+func (c *indexServiceClient) StreamObjectState(ctx context.Context, req *connect_go.Request[v1.StreamObjectStateRequest]) (*connect_go.ServerStreamForClient[v1.StreamObjectStateResponse], error) {
+	return c.streamObjectState.CallServerStream(ctx, req)
+}
+
+// Search calls ocfl.v1.IndexService.Search.
+func (c *indexServiceClient) Search(ctx context.Context, req *connect_go.Request[v1.SearchRequest]) (*connect_go.Response[v1.SearchResponse], error) {
+	return c.search.CallUnary(ctx, req)
+}
+
+// FindByDigest calls ocfl.v1.IndexService.FindByDigest.
+func (c *indexServiceClient) FindByDigest(ctx context.Context, req *connect_go.Request[v1.FindByDigestRequest]) (*connect_go.Response[v1.FindByDigestResponse], error) {
+	return c.findByDigest.CallUnary(ctx, req)
+}
+
+// DiffVersions calls ocfl.v1.IndexService.DiffVersions.
+func (c *indexServiceClient) DiffVersions(ctx context.Context, req *connect_go.Request[v1.DiffVersionsRequest]) (*connect_go.Response[v1.DiffVersionsResponse], error) {
+	return c.diffVersions.CallUnary(ctx, req)
+}
+
+// ListInvalidObjects calls ocfl.v1.IndexService.ListInvalidObjects.
+func (c *indexServiceClient) ListInvalidObjects(ctx context.Context, req *connect_go.Request[v1.ListInvalidObjectsRequest]) (*connect_go.Response[v1.ListInvalidObjectsResponse], error) {
+	return c.listInvalidObjects.CallUnary(ctx, req)
+}
+
 // FollowLogs calls ocfl.v1.IndexService.FollowLogs.
 func (c *indexServiceClient) FollowLogs(ctx context.Context, req *connect_go.Request[v1.FollowLogsRequest]) (*connect_go.ServerStreamForClient[v1.FollowLogsResponse], error) {
 	return c.followLogs.CallServerStream(ctx, req)
 }
 
+// ListTasks calls ocfl.v1.IndexService.ListTasks.
+func (c *indexServiceClient) ListTasks(ctx context.Context, req *connect_go.Request[v1.ListTasksRequest]) (*connect_go.Response[v1.ListTasksResponse], error) {
+	return c.listTasks.CallUnary(ctx, req)
+}
+
+// GetTask calls ocfl.v1.IndexService.GetTask.
+func (c *indexServiceClient) GetTask(ctx context.Context, req *connect_go.Request[v1.GetTaskRequest]) (*connect_go.Response[v1.GetTaskResponse], error) {
+	return c.getTask.CallUnary(ctx, req)
+}
+
+// CancelTask calls ocfl.v1.IndexService.CancelTask.
+func (c *indexServiceClient) CancelTask(ctx context.Context, req *connect_go.Request[v1.CancelTaskRequest]) (*connect_go.Response[v1.CancelTaskResponse], error) {
+	return c.cancelTask.CallUnary(ctx, req)
+}
+
+// ValidateObjects calls ocfl.v1.IndexService.ValidateObjects.
+func (c *indexServiceClient) ValidateObjects(ctx context.Context, req *connect_go.Request[v1.ValidateObjectsRequest]) (*connect_go.Response[v1.ValidateObjectsResponse], error) {
+	return c.validateObjects.CallUnary(ctx, req)
+}
+
 // IndexServiceHandler is an implementation of the ocfl.v1.IndexService service.
 type IndexServiceHandler interface {
-	// Get index status, counts, and storage root details
+	// Get index status, counts, and details for each storage root
 	GetStatus(context.Context, *connect_go.Request[v1.GetStatusRequest]) (*connect_go.Response[v1.GetStatusResponse], error)
-	// Start an asynchronous indexing process to scan the storage root and ingest
+	// Queue an asynchronous indexing task to scan the storage root and ingest
 	// index inventories. Indexed objects not found during the storage root scan
-	// are removed from the index. IndexAll returns immediately with a status
-	// indicating whether the indexing process was started.
+	// are removed from the index. IndexAll returns immediately with the task's
+	// ID.
 	IndexAll(context.Context, *connect_go.Request[v1.IndexAllRequest]) (*connect_go.Response[v1.IndexAllResponse], error)
 	// Index inventories for the specified object ids. Unlike IndexAll, IndexIDs
-	// returns after the object ids have been indexed.
+	// returns after the object ids have been indexed. The task is queued ahead
+	// of full storage root scans and may run between batches of a running scan.
 	IndexIDs(context.Context, *connect_go.Request[v1.IndexIDsRequest]) (*connect_go.Response[v1.IndexIDsResponse], error)
 	// List all objects in the index in lexigraphical order by ID.
 	ListObjects(context.Context, *connect_go.Request[v1.ListObjectsRequest]) (*connect_go.Response[v1.ListObjectsResponse], error)
@@ -159,8 +301,38 @@ type IndexServiceHandler interface {
 	GetObject(context.Context, *connect_go.Request[v1.GetObjectRequest]) (*connect_go.Response[v1.GetObjectResponse], error)
 	// Query the logical state of an OCFL object version
 	GetObjectState(context.Context, *connect_go.Request[v1.GetObjectStateRequest]) (*connect_go.Response[v1.GetObjectStateResponse], error)
+	// Stream all objects in the index in lexigraphical order by ID. Unlike
+	// ListObjects, results aren't paginated: objects are sent in batches from
+	// a single database query.
+	StreamObjects(context.Context, *connect_go.Request[v1.StreamObjectsRequest], *connect_go.ServerStream[v1.StreamObjectsResponse]) error
+	// Stream the logical state of an OCFL object version. Unlike
+	// GetObjectState, results aren't paginated: children of the base path are
+	// sent in batches from a single database query.
+	StreamObjectState(context.Context, *connect_go.Request[v1.StreamObjectStateRequest], *connect_go.ServerStream[v1.StreamObjectStateResponse]) error
+	// Full-text search of object IDs, logical paths, and version metadata
+	Search(context.Context, *connect_go.Request[v1.SearchRequest]) (*connect_go.Response[v1.SearchResponse], error)
+	// List objects, versions, and logical paths with a file or directory digest
+	FindByDigest(context.Context, *connect_go.Request[v1.FindByDigestRequest]) (*connect_go.Response[v1.FindByDigestResponse], error)
+	// Compare files in two object versions, which may belong to different
+	// objects
+	DiffVersions(context.Context, *connect_go.Request[v1.DiffVersionsRequest]) (*connect_go.Response[v1.DiffVersionsResponse], error)
+	// List objects with validation errors or warnings, in lexigraphical order
+	// by object path. Issues are recorded when an object's inventory is
+	// validated during indexing and are replaced when it is reindexed with a
+	// changed inventory (or with force).
+	ListInvalidObjects(context.Context, *connect_go.Request[v1.ListInvalidObjectsRequest]) (*connect_go.Response[v1.ListInvalidObjectsResponse], error)
 	// Stream log messages from indexing tasks
 	FollowLogs(context.Context, *connect_go.Request[v1.FollowLogsRequest], *connect_go.ServerStream[v1.FollowLogsResponse]) error
+	// List asynchronous tasks (e.g., indexing), most recent first
+	ListTasks(context.Context, *connect_go.Request[v1.ListTasksRequest]) (*connect_go.Response[v1.ListTasksResponse], error)
+	// Get details for a specific task
+	GetTask(context.Context, *connect_go.Request[v1.GetTaskRequest]) (*connect_go.Response[v1.GetTaskResponse], error)
+	// Cancel a queued or running task
+	CancelTask(context.Context, *connect_go.Request[v1.CancelTaskRequest]) (*connect_go.Response[v1.CancelTaskResponse], error)
+	// Queue an asynchronous task to fully validate objects, including content
+	// digests. Results are recorded for each object and included in GetObject
+	// responses. ValidateObjects returns immediately with the task's ID.
+	ValidateObjects(context.Context, *connect_go.Request[v1.ValidateObjectsRequest]) (*connect_go.Response[v1.ValidateObjectsResponse], error)
 }
 
 // NewIndexServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -200,11 +372,61 @@ func NewIndexServiceHandler(svc IndexServiceHandler, opts ...connect_go.HandlerO
 		svc.GetObjectState,
 		opts...,
 	))
+	mux.Handle("/ocfl.v1.IndexService/StreamObjects", connect_go.NewServerStreamHandler(
+		"/ocfl.v1.IndexService/StreamObjects",
+		svc.StreamObjects,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/StreamObjectState", connect_go.NewServerStreamHandler(
+		"/ocfl.v1.IndexService/StreamObjectState",
+		svc.StreamObjectState,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/Search", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/Search",
+		svc.Search,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/FindByDigest", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/FindByDigest",
+		svc.FindByDigest,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/DiffVersions", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/DiffVersions",
+		svc.DiffVersions,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/ListInvalidObjects", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/ListInvalidObjects",
+		svc.ListInvalidObjects,
+		opts...,
+	))
 	mux.Handle("/ocfl.v1.IndexService/FollowLogs", connect_go.NewServerStreamHandler(
 		"/ocfl.v1.IndexService/FollowLogs",
 		svc.FollowLogs,
 		opts...,
 	))
+	mux.Handle("/ocfl.v1.IndexService/ListTasks", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/ListTasks",
+		svc.ListTasks,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/GetTask", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/GetTask",
+		svc.GetTask,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/CancelTask", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/CancelTask",
+		svc.CancelTask,
+		opts...,
+	))
+	mux.Handle("/ocfl.v1.IndexService/ValidateObjects", connect_go.NewUnaryHandler(
+		"/ocfl.v1.IndexService/ValidateObjects",
+		svc.ValidateObjects,
+		opts...,
+	))
 	return "/ocfl.v1.IndexService/", mux
 }
 
@@ -235,6 +457,46 @@ func (UnimplementedIndexServiceHandler) GetObjectState(context.Context, *connect
 	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.GetObjectState is not implemented"))
 }
 
+func (UnimplementedIndexServiceHandler) StreamObjects(context.Context, *connect_go.Request[v1.StreamObjectsRequest], *connect_go.ServerStream[v1.StreamObjectsResponse]) error {
+	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.StreamObjects is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) StreamObjectState(context.Context, *connect_go.Request[v1.StreamObjectStateRequest], *connect_go.ServerStream[v1.StreamObjectStateResponse]) error {
+	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.StreamObjectState is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) Search(context.Context, *connect_go.Request[v1.SearchRequest]) (*connect_go.Response[v1.SearchResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.Search is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) FindByDigest(context.Context, *connect_go.Request[v1.FindByDigestRequest]) (*connect_go.Response[v1.FindByDigestResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.FindByDigest is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) DiffVersions(context.Context, *connect_go.Request[v1.DiffVersionsRequest]) (*connect_go.Response[v1.DiffVersionsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.DiffVersions is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) ListInvalidObjects(context.Context, *connect_go.Request[v1.ListInvalidObjectsRequest]) (*connect_go.Response[v1.ListInvalidObjectsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.ListInvalidObjects is not implemented"))
+}
+
 func (UnimplementedIndexServiceHandler) FollowLogs(context.Context, *connect_go.Request[v1.FollowLogsRequest], *connect_go.ServerStream[v1.FollowLogsResponse]) error {
 	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.FollowLogs is not implemented"))
 }
+
+func (UnimplementedIndexServiceHandler) ListTasks(context.Context, *connect_go.Request[v1.ListTasksRequest]) (*connect_go.Response[v1.ListTasksResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.ListTasks is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) GetTask(context.Context, *connect_go.Request[v1.GetTaskRequest]) (*connect_go.Response[v1.GetTaskResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.GetTask is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) CancelTask(context.Context, *connect_go.Request[v1.CancelTaskRequest]) (*connect_go.Response[v1.CancelTaskResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.CancelTask is not implemented"))
+}
+
+func (UnimplementedIndexServiceHandler) ValidateObjects(context.Context, *connect_go.Request[v1.ValidateObjectsRequest]) (*connect_go.Response[v1.ValidateObjectsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("ocfl.v1.IndexService.ValidateObjects is not implemented"))
+}
@@ -7,7 +7,9 @@ require (
 	github.com/bufbuild/connect-go v1.4.0
 	github.com/go-chi/chi v1.5.4
 	github.com/go-logr/logr v1.2.3
+	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/iand/logfmtr v0.2.1
+	github.com/jackc/pgx/v5 v5.4.3
 	github.com/spf13/cobra v1.6.1
 	github.com/srerickson/ocfl v0.0.15
 	gocloud.dev v0.30.0
@@ -47,7 +49,6 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sts v1.19.2 // indirect
 	github.com/aws/smithy-go v1.13.5 // indirect
 	github.com/carlmjohnson/deque v0.22.0 // indirect
-	github.com/golang-jwt/jwt/v4 v4.5.0 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/google/s2a-go v0.1.4 // indirect
@@ -56,6 +57,8 @@ require (
 	github.com/googleapis/enterprise-certificate-proxy v0.2.5 // indirect
 	github.com/googleapis/gax-go/v2 v2.11.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
 	github.com/kylelemons/godebug v1.1.0 // indirect
@@ -86,5 +89,3 @@ require (
 	modernc.org/strutil v1.1.3 // indirect
 	modernc.org/token v1.0.0 // indirect
 )
-
-replace github.com/srerickson/ocfl v0.0.12 => ../ocfl
@@ -29,4 +29,4 @@ export OCFL_INDEX_PARSEWORKERS=6
 # export AWS_S3_ENDPOINT
 
 # start server and index filesizes
-$($app server $@)
+$($app server --sizes $@)
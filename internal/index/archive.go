@@ -0,0 +1,278 @@
+package index
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/srerickson/ocfl"
+)
+
+const archivePrefix = "/archive"
+
+// archive formats supported by the archive handler
+const (
+	archiveZip   = "zip"
+	archiveTarGz = "tar.gz"
+)
+
+// archiveWriter writes files to a zip or tar.gz archive stream
+type archiveWriter interface {
+	// add adds a file to the archive with the given name, size, and
+	// modification time, copying its content from r.
+	add(name string, size int64, modTime time.Time, r io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(format string, w io.Writer) (archiveWriter, error) {
+	switch format {
+	case archiveZip:
+		return &zipArchive{zw: zip.NewWriter(w)}, nil
+	case archiveTarGz:
+		gz := gzip.NewWriter(w)
+		return &tarArchive{gz: gz, tw: tar.NewWriter(gz)}, nil
+	}
+	return nil, fmt.Errorf("unsupported archive format '%s': %w", format, ErrInvalidArgs)
+}
+
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) add(name string, _ int64, modTime time.Time, r io.Reader) error {
+	w, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (a *zipArchive) Close() error { return a.zw.Close() }
+
+type tarArchive struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (a *tarArchive) add(name string, size int64, modTime time.Time, r io.Reader) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(a.tw, r)
+	return err
+}
+
+func (a *tarArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// archiveHandler streams files in an object version's logical state as a zip
+// or tar.gz archive. Archive entries are named with logical paths relative to
+// the requested directory (the version state, by default). The version may be
+// "head". Query parameters:
+//
+//   - format: "zip" (default) or "tar.gz"
+//   - manifest: if "true", a manifest of digests for the archived files, named
+//     "manifest-{algorithm}.txt", is added to the end of the archive.
+//   - root: storage root name
+//
+// Object IDs in the request path must be path-escaped.
+func (srv Service) archiveHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		objectID, err := pathParam(r, "object_id")
+		if err != nil || objectID == "" {
+			http.NotFound(w, r)
+			return
+		}
+		version, err := pathParam(r, "version")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		base, err := pathParam(r, "*")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		base = path.Clean("/" + base)[1:]
+		if base == "" {
+			base = "."
+		}
+		format := query.Get("format")
+		if format == "" {
+			format = archiveZip
+		}
+		if format != archiveZip && format != archiveTarGz {
+			http.Error(w, fmt.Sprintf("unsupported archive format: '%s'", format), http.StatusBadRequest)
+			return
+		}
+		var vnum ocfl.VNum
+		if version != "head" {
+			if err := ocfl.ParseVNum(version, &vnum); err != nil {
+				http.Error(w, fmt.Sprintf("invalid version: '%s'", version), http.StatusBadRequest)
+				return
+			}
+		}
+		root, err := srv.storageRoot(query.Get("root"))
+		if err != nil {
+			srv.httpError(w, r, err, "archive")
+			return
+		}
+		obj, err := srv.Indexer.GetObject(ctx, root.Name, objectID)
+		if err != nil {
+			srv.httpError(w, r, err, "archive", "object_id", objectID)
+			return
+		}
+		state, err := srv.Indexer.GetObjectState(ctx, root.Name, objectID, vnum, base, true, 0, "")
+		if err != nil {
+			srv.httpError(w, r, err, "archive", "object_id", objectID, "version", version)
+			return
+		}
+		if vnum.IsZero() {
+			vnum = obj.Head
+		}
+		var created time.Time
+		for _, v := range obj.Versions {
+			if v.Num == vnum {
+				created = v.Created
+			}
+		}
+		name := fmt.Sprintf("%s-%s.%s", path.Base(obj.RootPath), vnum, format)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		if format == archiveZip {
+			w.Header().Set("Content-Type", "application/zip")
+		} else {
+			w.Header().Set("Content-Type", "application/gzip")
+		}
+		arch, _ := newArchiveWriter(format, w)
+		// content paths are resolved through the index, as in filesHandler
+		addFile := func(name, sum string) error {
+			content, err := srv.Indexer.GetObjectContentPath(ctx, root.Name, objectID, sum)
+			if err != nil {
+				return fmt.Errorf("content for '%s': %w", name, err)
+			}
+			f, err := root.FS.OpenFile(ctx, path.Join(root.Path, content.Path))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			size := content.Size
+			if !content.HasSize {
+				info, err := f.Stat()
+				if err != nil {
+					return err
+				}
+				size = info.Size()
+			}
+			return arch.add(name, size, created, f)
+		}
+		var manifest strings.Builder
+		err = eachStateFile(ctx, srv.Indexer, root.Name, objectID, vnum, base, state, func(name, sum string) error {
+			if err := addFile(name, sum); err != nil {
+				return err
+			}
+			fmt.Fprintf(&manifest, "%s  %s\n", sum, name)
+			return nil
+		})
+		if err == nil && query.Get("manifest") == "true" {
+			manifestName := "manifest-" + obj.DigestAlgorithm + ".txt"
+			body := manifest.String()
+			err = arch.add(manifestName, int64(len(body)), created, strings.NewReader(body))
+		}
+		if err == nil {
+			err = arch.Close()
+		}
+		if err != nil {
+			// headers have been sent: abort the response so the client
+			// doesn't receive a truncated archive as if it were complete.
+			srv.Log.Error("streaming archive", "object_id", objectID, "version", vnum.String(), "err", err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// httpError writes the response for an error that occurred before any of the
+// response was sent: 404 for ErrNotFound and 400 for ErrInvalidArgs. Other
+// errors are logged with msg and args, and the client gets a generic 500
+// response.
+func (srv Service) httpError(w http.ResponseWriter, r *http.Request, err error, msg string, args ...any) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, ErrInvalidArgs):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		srv.Log.Error(msg, append(args, "err", err)...)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// eachStateFile calls fn for each file in the logical state listing, state,
+// fetching additional pages as needed. Names passed to fn are relative to the
+// base directory. If base is a file, fn is called once with its base name.
+func eachStateFile(ctx context.Context, idx *Indexer, storageRoot string, objectID string, vnum ocfl.VNum, base string, state *PathInfo, fn func(name, sum string) error) error {
+	if !state.IsDir {
+		return fn(path.Base(base), state.Sum)
+	}
+	for {
+		for _, child := range state.Children {
+			if child.IsDir {
+				continue
+			}
+			if err := fn(child.Name, child.Sum); err != nil {
+				return err
+			}
+		}
+		if state.NextCursor == "" {
+			return nil
+		}
+		var err error
+		state, err = idx.GetObjectState(ctx, storageRoot, objectID, vnum, base, true, 0, state.NextCursor)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// pathParam returns the unescaped value of the URL parameter. Path
+// parameters include escaped characters (e.g., '/' in object IDs) if the
+// request path includes them.
+func pathParam(r *http.Request, key string) (string, error) {
+	val := chi.URLParam(r, key)
+	if r.URL.RawPath == "" {
+		return val, nil
+	}
+	val, err := url.PathUnescape(val)
+	if err != nil {
+		return "", fmt.Errorf("path parameter '%s': %w", key, ErrInvalidArgs)
+	}
+	return val, nil
+}
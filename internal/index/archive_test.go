@@ -0,0 +1,167 @@
+package index_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
+	"github.com/srerickson/ocfl-index/internal/index"
+)
+
+func TestServiceArchive(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := httptest.NewTLSServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	cli := ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL)
+	id := "ark:/12345/bcd987"
+	state, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{
+		ObjectId:  id,
+		Version:   "v2",
+		Recursive: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// expected archive entries: logical path -> digest
+	expFiles := map[string]string{}
+	for _, child := range state.Msg.Children {
+		if !child.Isdir {
+			expFiles[child.Name] = child.Digest
+		}
+	}
+	if len(expFiles) == 0 {
+		t.Fatal("expected files in the version state")
+	}
+	get := func(p string, params url.Values) (*http.Response, []byte) {
+		t.Helper()
+		rsp, err := httpSrv.Client().Get(httpSrv.URL + p + "?" + params.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		body, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rsp, body
+	}
+	// checkEntries checks that the archive entries match the version state
+	checkEntries := func(t *testing.T, entries map[string][]byte) {
+		t.Helper()
+		for name, sum := range expFiles {
+			content, ok := entries[name]
+			if !ok {
+				t.Fatalf("archive is missing '%s'", name)
+			}
+			got := sha512.Sum512(content)
+			expEq(t, "digest for "+name, hex.EncodeToString(got[:]), sum)
+		}
+	}
+	archivePath := "/archive/" + url.PathEscape(id) + "/v2"
+	t.Run("zip", func(t *testing.T) {
+		rsp, body := get(archivePath, nil)
+		expEq(t, "status", rsp.StatusCode, http.StatusOK)
+		expEq(t, "content type", rsp.Header.Get("Content-Type"), "application/zip")
+		_, params, err := mime.ParseMediaType(rsp.Header.Get("Content-Disposition"))
+		if err != nil {
+			t.Fatal("parsing Content-Disposition:", err)
+		}
+		expEq(t, "archive file name", params["filename"], "ark%3A%2F12345%2Fbcd987-v2.zip")
+		zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := map[string][]byte{}
+		for _, f := range zr.File {
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries[f.Name], err = io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		expEq(t, "number of entries", len(entries), len(expFiles))
+		checkEntries(t, entries)
+	})
+	t.Run("tar.gz with manifest", func(t *testing.T) {
+		rsp, body := get(archivePath, url.Values{"format": {"tar.gz"}, "manifest": {"true"}})
+		expEq(t, "status", rsp.StatusCode, http.StatusOK)
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		entries := map[string][]byte{}
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries[hdr.Name], err = io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		expEq(t, "number of entries", len(entries), len(expFiles)+1)
+		checkEntries(t, entries)
+		manifest := string(entries["manifest-sha512.txt"])
+		for name, sum := range expFiles {
+			if !strings.Contains(manifest, sum+"  "+name+"\n") {
+				t.Fatalf("manifest is missing '%s': %s", name, manifest)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		rsp, _ := get(archivePath, url.Values{"format": {"rar"}})
+		expEq(t, "unsupported format status", rsp.StatusCode, http.StatusBadRequest)
+		rsp, _ = get("/archive/"+url.PathEscape(id)+"/v9", nil)
+		expEq(t, "missing version status", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = get("/archive/missing/head", nil)
+		expEq(t, "missing object status", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = get(archivePath+"/missing-dir", nil)
+		expEq(t, "missing path status", rsp.StatusCode, http.StatusNotFound)
+	})
+	t.Run("index error", func(t *testing.T) {
+		failing := *service
+		failing.Indexer = &index.Indexer{Backend: getObjectErrors{service.Indexer.Backend}}
+		rec := httptest.NewRecorder()
+		failing.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, archivePath, nil))
+		expEq(t, "status", rec.Code, http.StatusInternalServerError)
+		if strings.Contains(rec.Body.String(), "database") {
+			t.Fatalf("response body includes the index error: %q", rec.Body.String())
+		}
+	})
+}
+
+// getObjectErrors is a Backend that fails to get objects.
+type getObjectErrors struct {
+	index.Backend
+}
+
+func (getObjectErrors) GetObject(context.Context, string, string) (*index.Object, error) {
+	return nil, errors.New("database is closed")
+}
@@ -7,116 +7,405 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bufbuild/connect-go"
 	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"golang.org/x/exp/slices"
 )
 
 const readyStatus = "ready"
-const monMsgBuffLen = 64  // size for async monitor's buffered message channel
-const monMaxSessions = 64 // max number of simultaneous connections to the async monitor
+const monMsgBuffLen = 64      // size for async monitor's buffered message channel
+const monMaxSessions = 64     // max number of simultaneous connections to the async monitor
+const monSessionBuffLen = 256 // messages queued for a monitor session before it is ended
+const monHistoryLen = 256     // log messages kept for each task
+const monHistoryTasks = 8     // completed tasks with log messages kept
+const asyncMaxQueued = 16     // max number of tasks in each of Async's queues
 
 var (
 	ErrAsyncMonitorMaxSessions = errors.New("cannot accept additional monitoring sessions")
 	ErrAsyncMonitorSend        = errors.New("failed to send message to monitoring session")
+	ErrAsyncMonitorLagging     = errors.New("monitoring session fell behind: reconnect to resume from the last message received")
+	ErrAsyncClosed             = errors.New("server is shutting down")
+	ErrAsyncQueueFull          = errors.New("too many queued tasks")
+	ErrTaskDone                = errors.New("task is already complete")
+
+	// errMonitorTaskDone ends monitoring sessions that follow a task
+	errMonitorTaskDone = errors.New("task is done")
 )
 
-// Async is used to run asynchronous indexing tasks
+// taskUpdateTimeout is the time allowed for saving a task's final state, which
+// may happen after Async's context is canceled.
+const taskUpdateTimeout = 10 * time.Second
+
+// errTaskRestarted is the error recorded for tasks that were queued or
+// running when the server stopped.
+var errTaskRestarted = errors.New("server restarted before the task completed")
+
+// Async is used to run asynchronous indexing tasks. Tasks are queued and run
+// one at a time. Each task is given an ID and a record of the task is kept in
+// the TaskStore.
+//
+// Async has two queues: one for long-running tasks, like full storage root
+// scans, and one for small targeted tasks, like reindexing specific objects.
+// Targeted tasks run before other queued tasks and, if the running task
+// supports it, between its batches of work (see YieldTasks).
 type Async struct {
-	status  string
-	done    chan struct{}
-	tokenCh chan asyncToken
-	taskCh  chan asyncTask
-	monitor monitor
+	store     TaskStore
+	ctx       context.Context    // parent context for tasks
+	cancel    context.CancelFunc // cancels ctx
+	done      chan struct{}      // closed by Close
+	stopped   chan struct{}      // closed after the work loop and monitor stop
+	closeOnce sync.Once
+	wake      chan struct{} // signals the work loop that a task was queued
+	monitor   monitor
+
+	mu               sync.Mutex           // guards fields below
+	status           string               // name of the running task, or readyStatus
+	lastID           int64                // task IDs if store is nil
+	queue            []*asyncTask         // queued long-running tasks
+	targeted         []*asyncTask         // queued targeted tasks
+	reservedQueue    int                  // places in queue reserved by add
+	reservedTargeted int                  // places in targeted reserved by add
+	closed           bool                 // queued tasks were canceled: no tasks are accepted
+	running          map[int64]*asyncTask // running tasks: more than one if a task yields
 }
 
-func NewAsync(ctx context.Context) *Async {
+// NewAsync returns a new Async that runs tasks until ctx is canceled or Close
+// is called. Tasks are run with a context derived from ctx. Task records are
+// saved in store. If store is nil, task records aren't saved. Records of
+// tasks that were queued or running in store (from a previous Async) are
+// marked as failed.
+func NewAsync(ctx context.Context, store TaskStore) *Async {
+	ctx, cancel := context.WithCancel(ctx)
 	async := &Async{
 		status:  readyStatus,
+		store:   store,
+		ctx:     ctx,
+		cancel:  cancel,
 		done:    make(chan struct{}),
-		tokenCh: make(chan asyncToken, 1),
-		taskCh:  make(chan asyncTask, 1),
+		stopped: make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+		running: map[int64]*asyncTask{},
 	}
 	async.monitor.Start()
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		async.workLoop(ctx)
-	}()
+	if store != nil {
+		// this happens before any tasks can be added
+		failCtx, failCancel := context.WithTimeout(ctx, taskUpdateTimeout)
+		if err := store.FailUnfinishedTasks(failCtx, time.Now(), errTaskRestarted.Error()); err != nil {
+			fmt.Fprintf(&taskWriter{monitor: &async.monitor}, "failed to update unfinished tasks: %v", err)
+		}
+		failCancel()
+	}
 	go func() {
-		wg.Wait()
-		close(async.tokenCh)
-		close(async.taskCh)
+		async.workLoop()
+		cancel()
+		async.cancelQueued()
 		async.monitor.Close()
+		close(async.stopped)
 	}()
 	return async
 }
 
 // Async's primary work loop runs with the server's background context
-func (sch *Async) workLoop(ctx context.Context) {
+func (sch *Async) workLoop() {
 	for {
-		var task asyncTask
-		// block until task, closed, or canceled ctx
-		select {
-		case task = <-sch.taskCh:
-		case <-sch.done:
-			return
-		case <-ctx.Done():
-			close(sch.done)
-			return
+		task := sch.nextTask(false, true)
+		if task == nil {
+			// block until task, closed, or canceled ctx
+			select {
+			case <-sch.wake:
+				continue
+			case <-sch.done:
+				return
+			case <-sch.ctx.Done():
+				return
+			}
 		}
+		sch.runTask(task)
+	}
+}
+
+// nextTask removes the next queued task and adds it to the running tasks,
+// with a new context. Targeted tasks are returned first; if targetedOnly is
+// true, other tasks aren't returned. If yield is true, the task's context
+// allows it to run queued targeted tasks. It returns nil if there are no tasks
+// or Async is closed.
+func (sch *Async) nextTask(targetedOnly bool, yield bool) *asyncTask {
+	select {
+	case <-sch.done:
+		return nil
+	case <-sch.ctx.Done():
+		return nil
+	default:
+	}
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	var task *asyncTask
+	switch {
+	case len(sch.targeted) > 0:
+		task, sch.targeted = sch.targeted[0], sch.targeted[1:]
+	case len(sch.queue) > 0 && !targetedOnly:
+		task, sch.queue = sch.queue[0], sch.queue[1:]
+	default:
+		return nil
+	}
+	// the task is added to running before the lock is released so that it
+	// can always be canceled.
+	if task.Timeout > 0 {
+		task.ctx, task.cancel = context.WithTimeout(sch.ctx, task.Timeout)
+	} else {
+		task.ctx, task.cancel = context.WithCancel(sch.ctx)
+	}
+	if yield {
+		task.ctx = context.WithValue(task.ctx, asyncKey{}, sch)
+	}
+	sch.running[task.ID] = task
+	return task
+}
+
+// runTask runs a task returned by nextTask and saves its record. If the task
+// was canceled before it started, it isn't run.
+func (sch *Async) runTask(task *asyncTask) {
+	defer task.cancel()
+	sch.mu.Lock()
+	canceled := task.canceled
+	prevStatus := sch.status
+	if !canceled {
 		sch.status = task.Name
 		if sch.status == "" {
 			sch.status = "busy"
 		}
-		// TODO: configurable deadlines for task
-		task.run(ctx, &sch.monitor)
-		// ready for new task
-		sch.status = "ready"
-		<-sch.tokenCh
 	}
+	sch.mu.Unlock()
+	if canceled {
+		task.setCanceled(context.Canceled)
+	} else {
+		task.Status = TaskRunning
+		task.StartedAt = time.Now()
+		sch.saveTask(task.Task)
+		task.run(task.ctx, &taskWriter{id: task.ID, monitor: &sch.monitor})
+	}
+	sch.mu.Lock()
+	delete(sch.running, task.ID)
+	sch.status = prevStatus
+	if task.canceled && task.err != nil {
+		task.Status = TaskCanceled
+	}
+	sch.mu.Unlock()
+	sch.finishTask(task)
 }
 
-// Close frees Async resources
+// finishTask saves the completed task's record, ends monitoring sessions
+// following it, and sends its error.
+func (sch *Async) finishTask(task *asyncTask) {
+	sch.saveTask(task.Task)
+	sch.monitor.TaskDone(task.ID)
+	task.ErrCh <- task.err
+	close(task.ErrCh)
+}
+
+// cancelQueued cancels all queued tasks. It is called after the work loop
+// stops.
+func (sch *Async) cancelQueued() {
+	sch.mu.Lock()
+	queued := append(sch.targeted, sch.queue...)
+	sch.targeted, sch.queue = nil, nil
+	sch.closed = true
+	sch.mu.Unlock()
+	for _, task := range queued {
+		task.setCanceled(ErrAsyncClosed)
+		sch.finishTask(task)
+	}
+}
+
+// saveTask updates the task's record in the store
+func (sch *Async) saveTask(task *Task) {
+	if sch.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), taskUpdateTimeout)
+	defer cancel()
+	if err := sch.store.UpdateTask(ctx, task); err != nil {
+		fmt.Fprintf(&taskWriter{id: task.ID, monitor: &sch.monitor}, "failed to save task %d: %v", task.ID, err)
+	}
+}
+
+// Close stops Async: the running task's context is canceled, queued tasks are
+// canceled, no new tasks are accepted, and monitoring sessions are ended once
+// the running task returns.
 func (sch *Async) Close() {
-	close(sch.done)
+	sch.closeOnce.Do(func() {
+		close(sch.done)
+		sch.cancel()
+	})
 }
 
-// Wait blocks Async is fully shutdown
+// Wait blocks until Async is fully shutdown: the work loop has stopped after
+// Close is called or the context used with NewAsync is canceled.
 func (sch *Async) Wait() {
-	<-sch.done
+	<-sch.stopped
+}
+
+// Add queues fn to run as a new long-running task. The task's record is
+// created in the TaskStore, setting task's ID, before Add returns; task
+// shouldn't be modified until the task is complete. If task.Timeout is set,
+// the task is canceled if it runs longer. The returned channel receives the
+// task's error when it completes or is canceled. If the queue is full,
+// ErrAsyncQueueFull is returned.
+func (sch *Async) Add(ctx context.Context, task *Task, fn taskFn) (chan error, error) {
+	return sch.add(ctx, task, fn, false)
+}
+
+// AddTargeted is like Add, except the task is queued as a small targeted
+// task, which runs before other queued tasks and may run between batches of
+// a running task's work.
+func (sch *Async) AddTargeted(ctx context.Context, task *Task, fn taskFn) (chan error, error) {
+	return sch.add(ctx, task, fn, true)
 }
 
-func (sch *Async) TryNow(name string, fn taskFn) (bool, chan error) {
+func (sch *Async) add(ctx context.Context, task *Task, fn taskFn, targeted bool) (chan error, error) {
 	select {
-	case sch.tokenCh <- asyncToken{}:
-		errch := make(chan error, 1) // channel is closed during task run()
-		sch.taskCh <- asyncTask{Fn: fn, Name: name, ErrCh: errch}
-		return true, errch
+	case <-sch.stopped:
+		return nil, ErrAsyncClosed
+	case <-sch.done:
+		return nil, ErrAsyncClosed
 	default:
-		return false, nil
 	}
+	sch.mu.Lock()
+	queue, reserved := &sch.queue, &sch.reservedQueue
+	if targeted {
+		queue, reserved = &sch.targeted, &sch.reservedTargeted
+	}
+	if len(*queue)+*reserved >= asyncMaxQueued {
+		sch.mu.Unlock()
+		return nil, ErrAsyncQueueFull
+	}
+	// the task's place in the queue is reserved while its record is created,
+	// so that the lock isn't held during database I/O.
+	*reserved++
+	if sch.store == nil {
+		sch.lastID++
+		task.ID = sch.lastID
+	}
+	sch.mu.Unlock()
+	task.Status = TaskQueued
+	task.StartedAt = time.Now()
+	task.EndedAt = time.Time{}
+	task.Counts = TaskCounts{}
+	task.Error = ""
+	if sch.store != nil {
+		if err := sch.store.CreateTask(ctx, task); err != nil {
+			sch.mu.Lock()
+			*reserved--
+			sch.mu.Unlock()
+			return nil, fmt.Errorf("creating task record: %w", err)
+		}
+	}
+	errch := make(chan error, 1) // channel is closed after the task runs
+	newTask := &asyncTask{Task: task, Fn: fn, ErrCh: errch}
+	sch.mu.Lock()
+	*reserved--
+	if sch.closed {
+		// queued tasks were canceled while the record was created
+		sch.mu.Unlock()
+		newTask.setCanceled(ErrAsyncClosed)
+		sch.saveTask(task)
+		return nil, ErrAsyncClosed
+	}
+	*queue = append(*queue, newTask)
+	// the monitor must know about the task before its ID is returned, and
+	// before it can run.
+	sch.monitor.TaskAdded(task.ID)
+	sch.mu.Unlock()
+	select {
+	case sch.wake <- struct{}{}:
+	default:
+	}
+	return errch, nil
 }
 
+// Cancel cancels the queued or running task with the given ID. A running
+// task is canceled through its context. If the task isn't queued or running,
+// ErrTaskDone is returned.
+func (sch *Async) Cancel(id int64) error {
+	sch.mu.Lock()
+	if task, ok := sch.running[id]; ok {
+		task.canceled = true
+		task.cancel()
+		sch.mu.Unlock()
+		return nil
+	}
+	for _, queue := range []*[]*asyncTask{&sch.targeted, &sch.queue} {
+		for i, task := range *queue {
+			if task.ID != id {
+				continue
+			}
+			*queue = slices.Delete(*queue, i, i+1)
+			sch.mu.Unlock()
+			task.setCanceled(context.Canceled)
+			sch.finishTask(task)
+			return nil
+		}
+	}
+	sch.mu.Unlock()
+	return fmt.Errorf("task %d: %w", id, ErrTaskDone)
+}
+
+// MonitorOn streams log messages to the client. If the request includes a task
+// ID, only messages from that task are sent and the stream ends when the task
+// is complete.
 func (sch *Async) MonitorOn(ctx context.Context, rq *connect.Request[api.FollowLogsRequest], stream *connect.ServerStream[api.FollowLogsResponse], errCh chan error) error {
 	return sch.monitor.Handle(ctx, rq, stream, errCh)
 }
 
+// Status returns the name of the running task or "ready".
 func (sch *Async) Status() string {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
 	return sch.status
 }
 
-// asyncToken is used to signal a new task
-type asyncToken struct{}
+// asyncKey is the context key for the Async running a task
+type asyncKey struct{}
+
+// YieldTasks runs queued targeted tasks, if any, when ctx is the context of a
+// long-running task run by Async. Tasks call it between batches of work, with
+// no open transactions. It is a no-op for other contexts.
+func YieldTasks(ctx context.Context) {
+	sch, ok := ctx.Value(asyncKey{}).(*Async)
+	if !ok {
+		return
+	}
+	for ctx.Err() == nil {
+		task := sch.nextTask(true, false)
+		if task == nil {
+			return
+		}
+		sch.runTask(task)
+	}
+}
 
-type taskFn func(context.Context, io.Writer) error
+// taskFn is the function run by a task. It writes logs to w and may add to
+// counts, which are saved with the task's record.
+type taskFn func(ctx context.Context, w io.Writer, counts TaskCounts) error
 
 type asyncTask struct {
-	Name  string
-	Fn    taskFn
-	ErrCh chan error
-	err   error
+	*Task
+	Fn       taskFn
+	ErrCh    chan error
+	err      error
+	ctx      context.Context    // the running task's context
+	cancel   context.CancelFunc // cancels ctx
+	canceled bool               // Cancel was called for the running task
+}
+
+// setCanceled marks a queued task as canceled with the error
+func (t *asyncTask) setCanceled(err error) {
+	t.err = err
+	t.Status = TaskCanceled
+	t.EndedAt = time.Now()
+	t.Error = err.Error()
 }
 
 func (t *asyncTask) run(ctx context.Context, w io.Writer) {
@@ -128,42 +417,72 @@ func (t *asyncTask) run(ctx context.Context, w io.Writer) {
 			}
 			t.err = errors.Join(t.err, panicErr)
 		}
-		t.ErrCh <- t.err
-		close(t.ErrCh)
+		t.EndedAt = time.Now()
+		t.Status = TaskSucceeded
+		if t.err != nil {
+			t.Status = TaskFailed
+			t.Error = t.err.Error()
+		}
 	}()
 	if t.Fn == nil {
 		return
 	}
-	t.err = t.Fn(ctx, w)
+	t.err = t.Fn(ctx, w, t.Counts)
+}
+
+// taskWriter is an io.Writer for a task's log messages
+type taskWriter struct {
+	id      int64
+	monitor *monitor
+}
+
+func (w *taskWriter) Write(b []byte) (int, error) {
+	return w.monitor.write(w.id, b)
+}
+
+// progress sends a progress update for the task
+func (w *taskWriter) progress(p Progress) {
+	w.monitor.progress(w.id, p)
 }
 
-// monitor is an io.Writer that forwards messages to registered grpc sessions
+// monitor forwards task log messages to registered grpc sessions. It keeps a
+// bounded history of recent messages for each task, which is replayed to new
+// sessions. Messages are numbered so a client can resume from the last message
+// it received.
 type monitor struct {
 	sessions   sessionMap                                   // map of all connections
-	msgCh      chan string                                  // for new messages
+	msgCh      chan monitorMsg                              // for new messages and completed tasks
+	taskAddCh  chan int64                                   // for IDs of new tasks
+	active     map[int64]bool                               // IDs of queued and running tasks
+	history    map[int64]*taskHistory                       // recent messages for each task
+	doneIDs    []int64                                      // completed tasks with history, oldest first
+	seq        int64                                        // sequence number of the last message
 	sessInitCh chan monitorRequest                          // channel for new session requests
 	sessFreeCh chan *connect.Request[api.FollowLogsRequest] // channel for freeing resource on a session
 	done       chan struct{}                                // to close the monitor
 }
 
+// monitorMsg is a log message or progress update from a task, or a signal that
+// the task is done.
+type monitorMsg struct {
+	taskID   int64
+	msg      string
+	progress *Progress
+	taskDone bool
+}
+
 func (m *monitor) Start() {
 	m.sessions = make(sessionMap)
 	m.sessInitCh = make(chan monitorRequest)
 	m.sessFreeCh = make(chan *connect.Request[api.FollowLogsRequest])
-	m.msgCh = make(chan string, monMsgBuffLen)
+	m.msgCh = make(chan monitorMsg, monMsgBuffLen)
+	m.taskAddCh = make(chan int64)
+	m.active = map[int64]bool{}
+	m.history = map[int64]*taskHistory{}
 	m.done = make(chan struct{}) // should be closed explicitly
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		m.runLoop()
-	}()
-	go func() {
-		wg.Wait()
-		close(m.sessInitCh)
-		close(m.sessFreeCh)
-		close(m.msgCh)
-	}()
+	// The channels aren't closed when the run loop stops: senders select on
+	// the done channel instead.
+	go m.runLoop()
 }
 
 // Close closes the monitor, freeing resources associated with it.
@@ -171,25 +490,59 @@ func (m *monitor) Close() {
 	close(m.done)
 }
 
-func (m *monitor) Write(b []byte) (int, error) {
+// write sends a log message from the task with the given ID
+func (m *monitor) write(taskID int64, b []byte) (int, error) {
 	msg := strings.TrimRight(string(b), "\n")
 	select {
-	case m.msgCh <- msg:
-		return len([]byte(msg)), nil
+	case m.msgCh <- monitorMsg{taskID: taskID, msg: msg}:
+		return len(b), nil
 	case <-m.done:
 		return 0, io.ErrClosedPipe
 	}
 }
 
+// progress sends a progress update from the task with the given ID. Updates
+// are dropped if the monitor is closed.
+func (m *monitor) progress(taskID int64, p Progress) {
+	select {
+	case m.msgCh <- monitorMsg{taskID: taskID, progress: &p}:
+	case <-m.done:
+	}
+}
+
+// TaskAdded registers the ID of a new task. Sessions can only follow tasks
+// that have been added and aren't done.
+func (m *monitor) TaskAdded(taskID int64) {
+	select {
+	case m.taskAddCh <- taskID:
+	case <-m.done:
+	}
+}
+
+// TaskDone ends sessions following the task with the given ID, after its
+// messages have been sent. Sessions started later for the task receive the
+// task's history, if it is still kept, and end.
+func (m *monitor) TaskDone(taskID int64) {
+	select {
+	case m.msgCh <- monitorMsg{taskID: taskID, taskDone: true}:
+	case <-m.done:
+	}
+}
+
 // Handle registers a request/stream pair with the monitor causing monitor log
-// message to be streamed to the client. It blocks until one of the following
-// occurs:
+// message to be streamed to the client. The session first receives the
+// history of recent messages after the request's resume_after sequence
+// number. If the request has a task ID, only messages from that task are
+// streamed. It blocks until one of the following occurs:
 //
 // - a monitoring session cannot be established or the monitor encounters an
 // error while sending messages to the stream.
 //
+// - the session falls too far behind the messages sent to it. The client can
+// reconnect and resume from the last message it received.
+//
 // - the taskErr channel is closed (i.e., the associated task has run to
-// completion)
+// completion), or the task with the request's task ID is complete.
 //
 // - The connection context (ctx) is canceled
 //
@@ -198,59 +551,141 @@ func (m *monitor) Write(b []byte) (int, error) {
 // Note that taskErr may be nill, in which case the request will monitor any
 // existing future tasks but it will never disconnect whey those tasks complete.
 func (m *monitor) Handle(ctx context.Context, rq *connect.Request[api.FollowLogsRequest], stream *connect.ServerStream[api.FollowLogsResponse], taskErrCh chan error) error {
-	monErrCh := make(chan error, 1) // used to receive error while establishing the monitiros session
-	defer close(monErrCh)
-	m.sessInitCh <- monitorRequest{rq, stream, monErrCh}
+	sessRq := monitorRequest{
+		rq:    rq,
+		init:  make(chan monitorInit, 1), // used to receive the backlog or error establishing the session
+		out:   make(chan *api.FollowLogsResponse, monSessionBuffLen),
+		errCh: make(chan error, 1),
+	}
+	select {
+	case m.sessInitCh <- sessRq:
+	case <-m.done:
+		return connect.NewError(connect.CodeUnavailable, ErrAsyncClosed)
+	}
+	// free is used to release the session's resources, unless the monitor
+	// is already shutting down
+	free := func() {
+		select {
+		case m.sessFreeCh <- rq:
+		case <-m.done:
+		}
+	}
+	send := func(msg *api.FollowLogsResponse) error {
+		if err := stream.Send(msg); err != nil {
+			return fmt.Errorf("%w: %v", ErrAsyncMonitorSend, err)
+		}
+		return nil
+	}
+	select {
+	case init := <-sessRq.init:
+		if init.err != nil {
+			// the session wasn't created
+			return init.err
+		}
+		for _, msg := range init.backlog {
+			if err := send(msg); err != nil {
+				free()
+				return err
+			}
+		}
+	case <-m.done:
+		return connect.NewError(connect.CodeUnavailable, ErrAsyncClosed)
+	}
 	for {
 		select {
+		case msg := <-sessRq.out:
+			if err := send(msg); err != nil {
+				free()
+				return err
+			}
 		case err := <-taskErrCh:
 			// return value from task: end the session
-			m.sessFreeCh <- rq
+			free()
 			return err
-		case err := <-monErrCh:
-			if err == nil {
-				continue
+		case err := <-sessRq.errCh:
+			free()
+			if errors.Is(err, errMonitorTaskDone) {
+				// followed task is complete: messages from the task were
+				// queued before the session was ended.
+				for {
+					select {
+					case msg := <-sessRq.out:
+						if err := send(msg); err != nil {
+							return err
+						}
+					default:
+						return nil
+					}
+				}
 			}
-			if !errors.Is(err, ErrAsyncMonitorMaxSessions) {
-				// we only need to free the session resources
-				// if the session was successfully created in the
-				// first place
-				m.sessFreeCh <- rq
+			if errors.Is(err, ErrAsyncMonitorLagging) {
+				return connect.NewError(connect.CodeResourceExhausted, err)
 			}
 			return err
 		case <-ctx.Done():
 			// connection closed by client
-			m.sessFreeCh <- rq
+			free()
 			return ctx.Err()
 		case <-m.done:
 			// monitor shutting down
-			return errors.New("server closed connection")
+			return connect.NewError(connect.CodeUnavailable, ErrAsyncClosed)
 		}
 	}
 }
 
 // runLoop is the monitor's main loop. It listens for new monitor sessions,
-// frees sessions resources and send messages to all existing sessions. It runs
-// until the monitor's done channel is closed.
+// frees sessions resources and queues messages for all existing sessions. It
+// runs until the monitor's done channel is closed.
 func (m *monitor) runLoop() {
 	for {
 		select {
 		case s := <-m.sessInitCh:
 			if len(m.sessions) >= monMaxSessions {
-				s.errCh <- ErrAsyncMonitorMaxSessions
+				s.init <- monitorInit{err: ErrAsyncMonitorMaxSessions}
 				break // from select
 			}
-			m.sessions[s.rq] = monitorSession{
-				stream: s.stream,
+			sess := &monitorSession{
+				taskID: s.rq.Msg.TaskId,
+				out:    s.out,
 				errCh:  s.errCh,
 			}
+			s.init <- monitorInit{backlog: m.backlog(sess.taskID, s.rq.Msg.ResumeAfter)}
+			if sess.taskID != 0 && !m.active[sess.taskID] {
+				sess.errCh <- errMonitorTaskDone
+			}
+			m.sessions[s.rq] = sess
 		case r := <-m.sessFreeCh:
 			delete(m.sessions, r)
+		case id := <-m.taskAddCh:
+			m.active[id] = true
+			m.history[id] = &taskHistory{}
 		case msg := <-m.msgCh:
+			if msg.taskDone {
+				m.taskDone(msg.taskID)
+				break // from select
+			}
+			m.seq++
+			resp := &api.FollowLogsResponse{Message: msg.msg, TaskId: msg.taskID, Seq: m.seq}
+			if msg.progress != nil {
+				resp.Progress = asProgress(msg.progress)
+			}
+			if hist := m.history[msg.taskID]; hist != nil {
+				hist.add(resp)
+			}
 			for _, sess := range m.sessions {
-				resp := &api.FollowLogsResponse{Message: msg}
-				if err := sess.stream.Send(resp); err != nil {
-					sess.errCh <- ErrAsyncMonitorSend
+				if sess.lagging || (sess.taskID != 0 && sess.taskID != msg.taskID) {
+					continue
+				}
+				select {
+				case sess.out <- resp:
+				default:
+					// The session's stream isn't keeping up: end the session
+					// so the client can resume without missing messages.
+					sess.lagging = true
+					select {
+					case sess.errCh <- ErrAsyncMonitorLagging:
+					default:
+					}
 				}
 			}
 		case <-m.done:
@@ -263,18 +698,106 @@ func (m *monitor) runLoop() {
 	}
 }
 
+// taskDone ends sessions following the task and keeps its history for up to
+// monHistoryTasks completed tasks.
+func (m *monitor) taskDone(id int64) {
+	delete(m.active, id)
+	for _, sess := range m.sessions {
+		if sess.taskID == id {
+			// don't block if the session already has an error
+			select {
+			case sess.errCh <- errMonitorTaskDone:
+			default:
+			}
+		}
+	}
+	if _, ok := m.history[id]; !ok {
+		return
+	}
+	m.doneIDs = append(m.doneIDs, id)
+	if len(m.doneIDs) > monHistoryTasks {
+		delete(m.history, m.doneIDs[0])
+		m.doneIDs = m.doneIDs[1:]
+	}
+}
+
+// backlog returns messages from the task's history (or all histories if
+// taskID is 0) with sequence numbers greater than after, in order. If after
+// is greater than the current sequence number, the monitor has restarted
+// since the client's last message and the full history is returned.
+func (m *monitor) backlog(taskID int64, after int64) []*api.FollowLogsResponse {
+	if after > m.seq {
+		after = 0
+	}
+	var msgs []*api.FollowLogsResponse
+	for id, hist := range m.history {
+		if taskID != 0 && id != taskID {
+			continue
+		}
+		msgs = hist.after(msgs, after)
+	}
+	slices.SortFunc(msgs, func(a, b *api.FollowLogsResponse) bool {
+		return a.Seq < b.Seq
+	})
+	return msgs
+}
+
+// taskHistory is a ring buffer of a task's most recent log messages. Only the
+// latest progress update is kept.
+type taskHistory struct {
+	msgs     []*api.FollowLogsResponse
+	next     int // index in msgs for the next message, once msgs is full
+	progress *api.FollowLogsResponse
+}
+
+func (h *taskHistory) add(msg *api.FollowLogsResponse) {
+	if msg.Progress != nil {
+		h.progress = msg
+		return
+	}
+	if len(h.msgs) < monHistoryLen {
+		h.msgs = append(h.msgs, msg)
+		return
+	}
+	h.msgs[h.next] = msg
+	h.next = (h.next + 1) % monHistoryLen
+}
+
+// after appends messages with sequence numbers greater than seq to msgs.
+func (h *taskHistory) after(msgs []*api.FollowLogsResponse, seq int64) []*api.FollowLogsResponse {
+	for i := range h.msgs {
+		msg := h.msgs[(h.next+i)%len(h.msgs)]
+		if msg.Seq > seq {
+			msgs = append(msgs, msg)
+		}
+	}
+	if h.progress != nil && h.progress.Seq > seq {
+		msgs = append(msgs, h.progress)
+	}
+	return msgs
+}
+
 // session map is used by monitor to track current connections
-type sessionMap map[*connect.Request[api.FollowLogsRequest]]monitorSession
+type sessionMap map[*connect.Request[api.FollowLogsRequest]]*monitorSession
 
 // request to establish a new session
 type monitorRequest struct {
-	rq     *connect.Request[api.FollowLogsRequest]
-	stream *connect.ServerStream[api.FollowLogsResponse]
-	errCh  chan error // error establishing a session, or sending messages
+	rq    *connect.Request[api.FollowLogsRequest]
+	init  chan monitorInit             // the session's backlog, or error establishing it
+	out   chan *api.FollowLogsResponse // messages queued for the session
+	errCh chan error                   // error ending the session
+}
+
+// reply to a monitorRequest
+type monitorInit struct {
+	backlog []*api.FollowLogsResponse // history sent before new messages
+	err     error
 }
 
 // an established monitor session
 type monitorSession struct {
-	stream *connect.ServerStream[api.FollowLogsResponse]
-	errCh  chan error // error establishing a session, or sending messages
+	taskID  int64 // if non-zero, the followed task
+	out     chan *api.FollowLogsResponse
+	errCh   chan error // error ending the session
+	lagging bool       // the session's out channel was full
 }
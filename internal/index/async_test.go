@@ -2,37 +2,465 @@ package index_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/bufbuild/connect-go"
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
 	"github.com/srerickson/ocfl-index/internal/index"
 )
 
 func TestScheduler(t *testing.T) {
-	var slept bool
-	task := func(_ context.Context, w io.Writer) error {
-		time.Sleep(25 * time.Millisecond)
-		slept = true
+	var order []string
+	task := func(name string) func(context.Context, io.Writer, index.TaskCounts) error {
+		return func(_ context.Context, w io.Writer, counts index.TaskCounts) error {
+			time.Sleep(25 * time.Millisecond)
+			order = append(order, name)
+			counts["naps"]++
+			return nil
+		}
+	}
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	sleeping := &index.Task{Name: "sleeping"}
+	sleepingErr, err := sch.Add(ctx, sleeping, task("sleeping"))
+	if err != nil {
+		t.Fatal("expected task to be added:", err)
+	}
+	if sleeping.ID == 0 {
+		t.Fatal("expected task to have an ID")
+	}
+	snoozing := &index.Task{Name: "snoozing"}
+	snoozingErr, err := sch.Add(ctx, snoozing, task("snoozing"))
+	if err != nil {
+		t.Fatal("expected second task to be queued:", err)
+	}
+	// block until both tasks are complete
+	if err := <-sleepingErr; err != nil {
+		t.Fatal("expected no error")
+	}
+	if err := <-snoozingErr; err != nil {
+		t.Fatal("expected no error")
+	}
+	expEq(t, "task order", order, []string{"sleeping", "snoozing"})
+	if sleeping.Status != index.TaskSucceeded || sleeping.EndedAt.IsZero() || sleeping.Counts["naps"] != 1 {
+		t.Fatalf("unexpected task record after completion: %+v", sleeping)
+	}
+	if snoozing.ID <= sleeping.ID {
+		t.Fatalf("expected task IDs to increase: %d after %d", snoozing.ID, sleeping.ID)
+	}
+}
 
+func TestAsyncQueueFull(t *testing.T) {
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	block := make(chan struct{})
+	defer close(block)
+	task := func(ctx context.Context, _ io.Writer, _ index.TaskCounts) error {
+		<-block
 		return nil
 	}
-	sch := index.NewAsync(context.Background())
-	added, doneErr := sch.TryNow("sleeping", task)
-	if !added {
-		t.Fatal("expected task to be added")
+	var err error
+	for i := 0; err == nil && i < 100; i++ {
+		_, err = sch.Add(ctx, &index.Task{Name: "waiting"}, task)
 	}
-	if added, _ := sch.TryNow("snoozing", task); added {
-		t.Fatal("expected task to not be added")
+	if !errors.Is(err, index.ErrAsyncQueueFull) {
+		t.Fatalf("expected ErrAsyncQueueFull, got %v", err)
 	}
-	// block until running task is complete
-	err := <-doneErr
+	// the targeted task queue is separate
+	if _, err := sch.AddTargeted(ctx, &index.Task{Name: "targeted"}, task); err != nil {
+		t.Fatal("expected targeted task to be queued:", err)
+	}
+}
+
+func TestAsyncYield(t *testing.T) {
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	var order []string
+	started := make(chan struct{})
+	resume := make(chan struct{})
+	long := func(ctx context.Context, _ io.Writer, _ index.TaskCounts) error {
+		order = append(order, "batch 1")
+		close(started)
+		<-resume
+		index.YieldTasks(ctx)
+		order = append(order, "batch 2")
+		return nil
+	}
+	small := func(name string) func(context.Context, io.Writer, index.TaskCounts) error {
+		return func(ctx context.Context, _ io.Writer, _ index.TaskCounts) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	longErr, err := sch.Add(ctx, &index.Task{Name: "scan"}, long)
 	if err != nil {
-		t.Fatal("expected no error")
+		t.Fatal(err)
+	}
+	<-started
+	// queued after the scan, but run before it
+	queuedErr, err := sch.Add(ctx, &index.Task{Name: "other scan"}, small("other scan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetedErr, err := sch.AddTargeted(ctx, &index.Task{Name: "targeted"}, small("targeted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(resume)
+	for _, errCh := range []chan error{longErr, targetedErr, queuedErr} {
+		if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+	}
+	expEq(t, "task order", order, []string{"batch 1", "targeted", "batch 2", "other scan"})
+}
+
+func TestAsyncCancel(t *testing.T) {
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	started := make(chan struct{})
+	task := func(ctx context.Context, w io.Writer, _ index.TaskCounts) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	running := &index.Task{Name: "running"}
+	runningErr, err := sch.Add(ctx, running, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued := &index.Task{Name: "queued"}
+	queuedErr, err := sch.Add(ctx, queued, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := sch.Cancel(queued.ID); err != nil {
+		t.Fatal("canceling queued task:", err)
+	}
+	if err := <-queuedErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected queued task to be canceled, got %v", err)
+	}
+	expEq(t, "queued task status", queued.Status, index.TaskCanceled)
+	if err := sch.Cancel(running.ID); err != nil {
+		t.Fatal("canceling running task:", err)
+	}
+	if err := <-runningErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected running task to be canceled, got %v", err)
+	}
+	expEq(t, "running task status", running.Status, index.TaskCanceled)
+	if err := sch.Cancel(running.ID); !errors.Is(err, index.ErrTaskDone) {
+		t.Fatalf("expected ErrTaskDone for a completed task, got %v", err)
+	}
+}
+
+// Tasks can be canceled at any point before they complete, including just
+// after they are removed from the queue.
+func TestAsyncCancelStarting(t *testing.T) {
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	var runs int
+	task := func(ctx context.Context, w io.Writer, _ index.TaskCounts) error {
+		runs++
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	for i := 0; i < 100; i++ {
+		record := &index.Task{Name: "starting"}
+		errCh, err := sch.Add(ctx, record, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// vary the delay so that some tasks are canceled while they start
+		for j := 0; j < i%20; j++ {
+			runtime.Gosched()
+		}
+		if err := sch.Cancel(record.ID); err != nil {
+			t.Fatalf("canceling task %d: %v", record.ID, err)
+		}
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected task %d to be canceled, got %v", record.ID, err)
+		}
+		expEq(t, "task status", record.Status, index.TaskCanceled)
+	}
+	t.Logf("%d of 100 canceled tasks started", runs)
+}
+
+// slowTaskStore is a TaskStore with a CreateTask that blocks until release is
+// closed.
+type slowTaskStore struct {
+	index.TaskStore
+	creating chan struct{} // receives when CreateTask is called
+	release  chan struct{}
+}
+
+func (s *slowTaskStore) CreateTask(ctx context.Context, task *index.Task) error {
+	s.creating <- struct{}{}
+	<-s.release
+	return s.TaskStore.CreateTask(ctx, task)
+}
+
+// Async isn't blocked while task records are created.
+func TestAsyncSlowTaskStore(t *testing.T) {
+	ctx := context.Background()
+	idx, err := newTestIndex(ctx, "async-slow-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &slowTaskStore{
+		TaskStore: idx,
+		creating:  make(chan struct{}, 1),
+		release:   make(chan struct{}),
+	}
+	sch := index.NewAsync(ctx, store)
+	defer sch.Close()
+	type addResult struct {
+		errCh chan error
+		err   error
+	}
+	added := make(chan addResult, 1)
+	go func() {
+		errCh, err := sch.Add(ctx, &index.Task{Name: "slow record"}, nil)
+		added <- addResult{errCh, err}
+	}()
+	<-store.creating
+	unblocked := make(chan error, 1)
+	go func() {
+		sch.Status()
+		unblocked <- sch.Cancel(-1)
+	}()
+	select {
+	case err := <-unblocked:
+		if !errors.Is(err, index.ErrTaskDone) {
+			t.Fatalf("expected ErrTaskDone for unknown task, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Status and Cancel blocked while a task record was created")
 	}
-	if !slept {
-		t.Fatal("should have slept")
+	close(store.release)
+	result := <-added
+	if result.err != nil {
+		t.Fatal(result.err)
 	}
+	if err := <-result.errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Task records left queued or running by a previous server are marked as
+// failed when Async starts.
+func TestAsyncRestart(t *testing.T) {
+	ctx := context.Background()
+	idx, err := newTestIndex(ctx, "async-restart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unfinished []*index.Task
+	for _, status := range []index.TaskStatus{index.TaskQueued, index.TaskRunning} {
+		task := &index.Task{Name: "interrupted", Status: status, StartedAt: time.Now(), Counts: index.TaskCounts{}}
+		if err := idx.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		unfinished = append(unfinished, task)
+	}
+	sch := index.NewAsync(ctx, idx)
+	defer sch.Close()
+	for _, task := range unfinished {
+		got, err := idx.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "interrupted task status", got.Status, index.TaskFailed)
+		expEq(t, "interrupted task error", got.Error, "server restarted before the task completed")
+		expEq(t, "interrupted task ended", got.EndedAt.IsZero(), false)
+	}
+	// new tasks aren't affected
+	record := &index.Task{Name: "new"}
+	errCh, err := sch.Add(ctx, record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	got, err := idx.GetTask(ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "new task status", got.Status, index.TaskSucceeded)
+}
+
+func TestAsyncTimeout(t *testing.T) {
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	defer sch.Close()
+	task := &index.Task{Name: "slow", Timeout: 10 * time.Millisecond}
+	errCh, err := sch.Add(ctx, task, func(ctx context.Context, _ io.Writer, _ index.TaskCounts) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	expEq(t, "task status", task.Status, index.TaskFailed)
+}
+
+func TestAsyncClose(t *testing.T) {
+	started := make(chan struct{})
+	task := func(ctx context.Context, w io.Writer, _ index.TaskCounts) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ctx := context.Background()
+	sch := index.NewAsync(ctx, nil)
+	waiting := &index.Task{Name: "waiting"}
+	doneErr, err := sch.Add(ctx, waiting, task)
+	if err != nil {
+		t.Fatal("expected task to be added:", err)
+	}
+	queued := &index.Task{Name: "queued"}
+	queuedErr, err := sch.Add(ctx, queued, task)
+	if err != nil {
+		t.Fatal("expected task to be queued:", err)
+	}
+	<-started
 	sch.Close()
+	if err := <-doneErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected running task to be canceled, got %v", err)
+	}
+	if waiting.Status != index.TaskFailed || waiting.Error == "" {
+		t.Fatalf("expected failed task record, got %+v", waiting)
+	}
+	if err := <-queuedErr; !errors.Is(err, index.ErrAsyncClosed) {
+		t.Fatalf("expected queued task to be canceled with ErrAsyncClosed, got %v", err)
+	}
 	sch.Wait()
+	sch.Close() // no-op
+	if _, err := sch.Add(ctx, &index.Task{Name: "late"}, task); !errors.Is(err, index.ErrAsyncClosed) {
+		t.Fatalf("expected ErrAsyncClosed after Close, got %v", err)
+	}
+}
+
+func TestAsyncCloseFollowLogs(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := httptest.NewServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	cli := ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL)
+	stream, err := cli.FollowLogs(ctx, connect.NewRequest(&api.FollowLogsRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	service.Async.Close()
+	service.Async.Wait()
+	for stream.Receive() {
+	}
+	var connErr *connect.Error
+	if !errors.As(stream.Err(), &connErr) || connErr.Code() != connect.CodeUnavailable {
+		t.Fatalf("expected stream to end with code %s, got %v", connect.CodeUnavailable, stream.Err())
+	}
+}
+
+func TestAsyncFollowLogsReplay(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer service.Async.Close()
+	httpSrv := httptest.NewServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	cli := ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL)
+	// follow returns up to n messages from the task's log
+	follow := func(taskID int64, resumeAfter int64, n int) []*api.FollowLogsResponse {
+		t.Helper()
+		ctx, cancel := context.WithCancel(ctx)
+		rq := &api.FollowLogsRequest{TaskId: taskID, ResumeAfter: resumeAfter}
+		stream, err := cli.FollowLogs(ctx, connect.NewRequest(rq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Close()
+		defer cancel() // end the session before closing the stream
+		var msgs []*api.FollowLogsResponse
+		for len(msgs) < n && stream.Receive() {
+			msgs = append(msgs, stream.Msg())
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return msgs
+	}
+	messages := func(msgs []*api.FollowLogsResponse) []string {
+		strs := make([]string, len(msgs))
+		for i, m := range msgs {
+			strs[i] = m.Message
+		}
+		return strs
+	}
+	release := make(chan struct{})
+	task := &index.Task{Name: "chatty"}
+	taskErr, err := service.Async.Add(ctx, task, func(_ context.Context, w io.Writer, _ index.TaskCounts) error {
+		io.WriteString(w, "one\n")
+		io.WriteString(w, "two\n")
+		<-release
+		io.WriteString(w, "three\n")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a late subscriber gets messages written before it joined
+	msgs := follow(task.ID, 0, 2)
+	expEq(t, "replayed messages", messages(msgs), []string{"one", "two"})
+	if msgs[0].Seq == 0 || msgs[1].Seq <= msgs[0].Seq {
+		t.Fatalf("expected increasing sequence numbers, got %d, %d", msgs[0].Seq, msgs[1].Seq)
+	}
+	close(release)
+	if err := <-taskErr; err != nil {
+		t.Fatal(err)
+	}
+	// resuming after the last message received
+	expEq(t, "resumed messages", messages(follow(task.ID, msgs[1].Seq, 10)), []string{"three"})
+	// history for the completed task
+	expEq(t, "completed task messages", messages(follow(task.ID, 0, 10)), []string{"one", "two", "three"})
+	// only the most recent messages are kept
+	verbose := &index.Task{Name: "verbose"}
+	taskErr, err = service.Async.Add(ctx, verbose, func(_ context.Context, w io.Writer, _ index.TaskCounts) error {
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(w, "message %d\n", i)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-taskErr; err != nil {
+		t.Fatal(err)
+	}
+	msgs = follow(verbose.ID, 0, 1000)
+	if len(msgs) == 0 || len(msgs) >= 1000 {
+		t.Fatalf("expected history to be truncated, got %d messages", len(msgs))
+	}
+	expEq(t, "last message", msgs[len(msgs)-1].Message, "message 999")
 }
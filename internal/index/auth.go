@@ -0,0 +1,384 @@
+package index
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
+)
+
+// Permission is a class of requests that is authorized separately
+type Permission string
+
+const (
+	PermRead     Permission = "read"     // RPCs and REST endpoints that read the index
+	PermIndex    Permission = "index"    // RPCs and endpoints that start, follow, or cancel indexing
+	PermDownload Permission = "download" // content downloads and archives
+)
+
+const (
+	// Anonymous is the policy subject for unauthenticated requests.
+	Anonymous = "anonymous"
+	// Authenticated is the policy subject for any authenticated client.
+	Authenticated = "*"
+)
+
+// indexProcedures are RPCs that require PermIndex
+var indexProcedures = map[string]bool{
+	"/" + ocflv1connect.IndexServiceName + "/IndexAll":        true,
+	"/" + ocflv1connect.IndexServiceName + "/IndexIDs":        true,
+	"/" + ocflv1connect.IndexServiceName + "/FollowLogs":      true,
+	"/" + ocflv1connect.IndexServiceName + "/CancelTask":      true,
+	"/" + ocflv1connect.IndexServiceName + "/ValidateObjects": true,
+}
+
+var (
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Identity is an authenticated client
+type Identity struct {
+	// Subject identifies the client in a Policy. It is prefixed with the
+	// authentication method: 'token:<name>', 'jwt:<sub claim>', or
+	// 'cert:<common name>'.
+	Subject string
+}
+
+// Authenticator identifies the client making a request. It returns nil
+// without an error if the request doesn't include credentials it checks, and
+// an error if the credentials are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Policy lists the subjects allowed for each permission. In addition to
+// Identity subjects, it may include Anonymous and Authenticated.
+type Policy struct {
+	Read     []string `json:"read"`
+	Index    []string `json:"index"`
+	Download []string `json:"download"`
+}
+
+// Allowed returns true if the policy grants perm to id. If id is nil, the
+// request is unauthenticated.
+func (p Policy) Allowed(perm Permission, id *Identity) bool {
+	var subjects []string
+	switch perm {
+	case PermRead:
+		subjects = p.Read
+	case PermIndex:
+		subjects = p.Index
+	case PermDownload:
+		subjects = p.Download
+	}
+	for _, s := range subjects {
+		switch {
+		case s == Anonymous:
+			return true
+		case id == nil:
+			continue
+		case s == Authenticated || s == id.Subject:
+			return true
+		}
+	}
+	return false
+}
+
+// Auth is HTTP middleware for authenticating and authorizing requests to the
+// service.
+type Auth struct {
+	Authenticators []Authenticator // tried in order until one returns an Identity
+	Policy         Policy
+}
+
+type identityKey struct{}
+
+// IdentityFrom returns the Identity for an authenticated request's context, or
+// nil.
+func IdentityFrom(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// Middleware returns a handler that authorizes requests before calling next.
+// Requests with invalid credentials are rejected as unauthenticated.
+// Unauthenticated requests that aren't allowed are rejected as
+// unauthenticated; authenticated requests that aren't allowed are rejected as
+// denied.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	errWriter := connect.NewErrorWriter()
+	reject := func(w http.ResponseWriter, r *http.Request, code connect.Code, err error) {
+		if errWriter.IsSupported(r) {
+			errWriter.Write(w, r, connect.NewError(code, err))
+			return
+		}
+		status := http.StatusForbidden
+		if code == connect.CodeUnauthenticated {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, err.Error(), status)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := a.authenticate(r)
+		if err != nil {
+			reject(w, r, connect.CodeUnauthenticated, err)
+			return
+		}
+		perm := requestPermission(r)
+		if !a.Policy.Allowed(perm, id) {
+			if id == nil {
+				reject(w, r, connect.CodeUnauthenticated, fmt.Errorf("%w: %s requires authentication", ErrUnauthenticated, perm))
+				return
+			}
+			reject(w, r, connect.CodePermissionDenied, fmt.Errorf("%s not allowed for '%s': %w", perm, id.Subject, ErrPermissionDenied))
+			return
+		}
+		if id != nil {
+			r = r.WithContext(context.WithValue(r.Context(), identityKey{}, id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Auth) authenticate(r *http.Request) (*Identity, error) {
+	for _, authn := range a.Authenticators {
+		id, err := authn.Authenticate(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+		}
+		if id != nil {
+			return id, nil
+		}
+	}
+	if _, ok := bearerToken(r); ok {
+		return nil, fmt.Errorf("%w: unrecognized bearer token", ErrUnauthenticated)
+	}
+	return nil, nil
+}
+
+// requestPermission returns the permission required for the request
+func requestPermission(r *http.Request) Permission {
+	switch {
+	case strings.HasPrefix(r.URL.Path, downloadPrefix+"/"),
+		strings.HasPrefix(r.URL.Path, objectsPrefix+"/"),
+		strings.HasPrefix(r.URL.Path, archivePrefix+"/"):
+		return PermDownload
+	case r.URL.Path == s3EventsPath || indexProcedures[r.URL.Path]:
+		return PermIndex
+	}
+	return PermRead
+}
+
+// bearerToken returns the token from the request's Authorization header, if
+// present.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// BearerTokens is an Authenticator for static bearer tokens. Keys are token
+// names used in identity subjects ('token:<name>'); values are the tokens.
+// Bearer tokens that don't match are passed to later authenticators.
+type BearerTokens map[string]string
+
+var _ Authenticator = BearerTokens(nil)
+
+// Authenticate implements Authenticator
+func (tokens BearerTokens) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := bearerToken(r)
+	if !ok || token == "" {
+		return nil, nil
+	}
+	var match string
+	for name, t := range tokens {
+		// compare all tokens in constant time
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			match = name
+		}
+	}
+	if match == "" {
+		return nil, nil
+	}
+	return &Identity{Subject: "token:" + match}, nil
+}
+
+// JWTAuth is an Authenticator for bearer tokens that are JWTs signed with one
+// of Keys. Tokens must have a 'sub' claim, which is used in the identity
+// subject ('jwt:<sub>'). Bearer tokens that aren't JWTs are passed to later
+// authenticators.
+type JWTAuth struct {
+	Keys     map[string]crypto.PublicKey // verification keys by key ID (see ParseJWKS)
+	Issuer   string                      // if set, the required 'iss' claim
+	Audience string                      // if set, a required 'aud' claim
+}
+
+var _ Authenticator = (*JWTAuth)(nil)
+
+// Authenticate implements Authenticator
+func (ja *JWTAuth) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, nil
+	}
+	claims := &jwt.RegisteredClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{
+		"RS256", "RS384", "RS512", "PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512", "EdDSA",
+	}))
+	if _, err := parser.ParseWithClaims(token, claims, ja.key); err != nil {
+		return nil, fmt.Errorf("invalid JWT: %w", err)
+	}
+	if ja.Issuer != "" && !claims.VerifyIssuer(ja.Issuer, true) {
+		return nil, errors.New("invalid JWT: wrong issuer")
+	}
+	if ja.Audience != "" && !claims.VerifyAudience(ja.Audience, true) {
+		return nil, errors.New("invalid JWT: wrong audience")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid JWT: missing subject")
+	}
+	return &Identity{Subject: "jwt:" + claims.Subject}, nil
+}
+
+// key returns the verification key for the token
+func (ja *JWTAuth) key(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && len(ja.Keys) == 1 {
+		for _, k := range ja.Keys {
+			return k, nil
+		}
+	}
+	k, ok := ja.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id '%s'", kid)
+	}
+	return k, nil
+}
+
+// ParseJWKS parses public keys from a JSON Web Key Set. RSA, EC (P-256, P-384,
+// P-521), and Ed25519 keys are supported. Keys are indexed by key ID.
+func ParseJWKS(byts []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(byts, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			var n, e *big.Int
+			if n, err = b64Int(k.N); err != nil {
+				break
+			}
+			if e, err = b64Int(k.E); err != nil {
+				break
+			}
+			key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				err = fmt.Errorf("unsupported curve '%s'", k.Crv)
+			}
+			if err != nil {
+				break
+			}
+			var x, y *big.Int
+			if x, err = b64Int(k.X); err != nil {
+				break
+			}
+			if y, err = b64Int(k.Y); err != nil {
+				break
+			}
+			key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				err = fmt.Errorf("unsupported curve '%s'", k.Crv)
+				break
+			}
+			var x []byte
+			if x, err = base64.RawURLEncoding.DecodeString(k.X); err != nil {
+				break
+			}
+			if len(x) != ed25519.PublicKeySize {
+				err = errors.New("invalid Ed25519 key size")
+				break
+			}
+			key = ed25519.PublicKey(x)
+		default:
+			err = fmt.Errorf("unsupported key type '%s'", k.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWKS key '%s': %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func b64Int(s string) (*big.Int, error) {
+	byts, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(byts) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+	return new(big.Int).SetBytes(byts), nil
+}
+
+// ClientCerts is an Authenticator for TLS client certificates. The identity
+// subject is the verified certificate's common name ('cert:<common name>').
+// Certificates must be verified by the server's TLS configuration.
+type ClientCerts struct{}
+
+var _ Authenticator = ClientCerts{}
+
+// Authenticate implements Authenticator
+func (ClientCerts) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if cn == "" {
+		return nil, errors.New("client certificate has no common name")
+	}
+	return &Identity{Subject: "cert:" + cn}, nil
+}
@@ -0,0 +1,208 @@
+package index_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/golang-jwt/jwt/v4"
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
+	"github.com/srerickson/ocfl-index/internal/index"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	policy := index.Policy{
+		Read:     []string{index.Anonymous},
+		Index:    []string{"token:admin"},
+		Download: []string{index.Authenticated},
+	}
+	admin := &index.Identity{Subject: "token:admin"}
+	user := &index.Identity{Subject: "jwt:user"}
+	table := []struct {
+		perm   index.Permission
+		id     *index.Identity
+		expect bool
+	}{
+		{index.PermRead, nil, true},
+		{index.PermRead, user, true},
+		{index.PermIndex, nil, false},
+		{index.PermIndex, user, false},
+		{index.PermIndex, admin, true},
+		{index.PermDownload, nil, false},
+		{index.PermDownload, user, true},
+	}
+	for _, tcase := range table {
+		subject := index.Anonymous
+		if tcase.id != nil {
+			subject = tcase.id.Subject
+		}
+		if got := policy.Allowed(tcase.perm, tcase.id); got != tcase.expect {
+			t.Errorf("Allowed(%s, %s): expected %v, got %v", tcase.perm, subject, tcase.expect, got)
+		}
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	keys, err := index.ParseJWKS(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := &index.JWTAuth{Keys: keys, Issuer: "test-issuer"}
+	sign := func(claims jwt.RegisteredClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test-key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+	valid := sign(jwt.RegisteredClaims{
+		Subject:   "alice",
+		Issuer:    "test-issuer",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	id, err := authn.Authenticate(request(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == nil || id.Subject != "jwt:alice" {
+		t.Fatalf("expected subject 'jwt:alice', got %v", id)
+	}
+	invalid := map[string]string{
+		"expired": sign(jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "test-issuer",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		}),
+		"wrong issuer":  sign(jwt.RegisteredClaims{Subject: "alice", Issuer: "other"}),
+		"no subject":    sign(jwt.RegisteredClaims{Issuer: "test-issuer"}),
+		"bad signature": valid[:len(valid)-4] + "AAAA",
+	}
+	for name, token := range invalid {
+		if _, err := authn.Authenticate(request(token)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	// not a JWT
+	if id, err := authn.Authenticate(request("static-token")); id != nil || err != nil {
+		t.Fatalf("expected nil identity and error for non-JWT token, got %v, %v", id, err)
+	}
+}
+
+func TestClientCerts(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	id, err := index.ClientCerts{}.Authenticate(r)
+	if id != nil || err != nil {
+		t.Fatalf("expected nil identity and error without TLS, got %v, %v", id, err)
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client.example.org"}}
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	id, err = index.ClientCerts{}.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == nil || id.Subject != "cert:client.example.org" {
+		t.Fatalf("expected subject 'cert:client.example.org', got %v", id)
+	}
+}
+
+func TestServiceAuth(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Auth = &index.Auth{
+		Authenticators: []index.Authenticator{
+			index.BearerTokens{"reader": "read-token", "admin": "admin-token"},
+		},
+		Policy: index.Policy{
+			Read:     []string{index.Authenticated},
+			Index:    []string{"token:admin"},
+			Download: []string{"token:admin"},
+		},
+	}
+	httpSrv := httptest.NewServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	client := func(token string) ocflv1connect.IndexServiceClient {
+		opt := connect.WithInterceptors(connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+			return func(ctx context.Context, rq connect.AnyRequest) (connect.AnyResponse, error) {
+				if token != "" {
+					rq.Header().Set("Authorization", "Bearer "+token)
+				}
+				return next(ctx, rq)
+			}
+		}))
+		return ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL, opt)
+	}
+	expCode := func(desc string, err error, code connect.Code) {
+		t.Helper()
+		var connErr *connect.Error
+		if !errors.As(err, &connErr) || connErr.Code() != code {
+			t.Fatalf("%s: expected error code %s, got %v", desc, code, err)
+		}
+	}
+	_, err = client("").GetStatus(ctx, connect.NewRequest(&api.GetStatusRequest{}))
+	expCode("anonymous read", err, connect.CodeUnauthenticated)
+	_, err = client("wrong-token").GetStatus(ctx, connect.NewRequest(&api.GetStatusRequest{}))
+	expCode("invalid token", err, connect.CodeUnauthenticated)
+	if _, err = client("read-token").GetStatus(ctx, connect.NewRequest(&api.GetStatusRequest{})); err != nil {
+		t.Fatal("reader read:", err)
+	}
+	_, err = client("read-token").IndexAll(ctx, connect.NewRequest(&api.IndexAllRequest{}))
+	expCode("reader index", err, connect.CodePermissionDenied)
+	if _, err = client("admin-token").IndexAll(ctx, connect.NewRequest(&api.IndexAllRequest{})); err != nil {
+		t.Fatal("admin index:", err)
+	}
+	// downloads
+	download := func(token string) int {
+		rq, err := http.NewRequest(http.MethodGet, httpSrv.URL+"/download/abc", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rq.Header.Set("Authorization", "Bearer "+token)
+		rsp, err := httpSrv.Client().Do(rq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+	if code := download("read-token"); code != http.StatusForbidden {
+		t.Fatalf("reader download: expected status %d, got %d", http.StatusForbidden, code)
+	}
+	if code := download("admin-token"); code == http.StatusForbidden || code == http.StatusUnauthorized {
+		t.Fatalf("admin download: unexpected status %d", code)
+	}
+}
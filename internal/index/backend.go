@@ -14,32 +14,100 @@ var (
 	ErrMissingValue = errors.New("missing value")
 	ErrInvalidArgs  = errors.New("invalid arguments")
 	ErrIndexValue   = errors.New("unexpected value in index, possible corruption")
+	ErrSchemaOld    = errors.New("index schema is out of date")
 )
 
+// DefaultStorageRoot is the name of the storage root used when none is
+// given. Objects indexed before storage roots were named belong to it.
+const DefaultStorageRoot = "default"
+
 // Backend is an interface that can be implemented for different databases for
-// storing the indexing.
+// storing the indexing. An index may include objects from multiple storage
+// roots. Methods that take a storageRoot argument are scoped to the storage root
+// with that name: object IDs and object root paths are unique within a storage
+// root, not across the index.
 type Backend interface {
 	NewTx(context.Context) (BackendTx, error)
 
-	// GetIndexSummary returns stats on indexed objects
-	GetIndexSummary(ctx context.Context) (IndexSummary, error)
+	// GetIndexSummary returns stats on indexed objects in the storage root
+	GetIndexSummary(ctx context.Context, storageRoot string) (IndexSummary, error)
 
 	// ListObjectRoots is used to iterate over the object root directories in the index.
 	// Paths in the returned list are relative to the storage root.
-	ListObjectRoots(ctx context.Context, limit int, cursor string) (*ObjectRootList, error)
+	ListObjectRoots(ctx context.Context, storageRoot string, limit int, cursor string) (*ObjectRootList, error)
 
-	// All OCFL Object in the index
-	ListObjects(ctx context.Context, prefix string, limit int, cursor string) (*ObjectList, error)
-	GetObject(ctx context.Context, objectID string) (*Object, error)
-	GetObjectByPath(ctx context.Context, rootPath string) (*Object, error)
+	// All OCFL Object in the storage root
+	ListObjects(ctx context.Context, storageRoot string, prefix string, limit int, cursor string) (*ObjectList, error)
+	GetObject(ctx context.Context, storageRoot string, objectID string) (*Object, error)
+	GetObjectByPath(ctx context.Context, storageRoot string, rootPath string) (*Object, error)
 
 	// GetObjectState returns a path list representing files and directories in an
 	// object version state (i.e., the "logical state").
-	GetObjectState(ctx context.Context, objectID string, vnum ocfl.VNum, base string, recursive bool, limit int, cursor string) (*PathInfo, error)
+	GetObjectState(ctx context.Context, storageRoot string, objectID string, vnum ocfl.VNum, base string, recursive bool, limit int, cursor string) (*PathInfo, error)
 
-	// GetContentPath returns the path to a file with digest sum. The path is relative to
-	// the storage root.
-	GetContentPath(ctx context.Context, sum string) (string, error)
+	// EachObject calls fn for each object in the storage root with an ID
+	// that begins with prefix, in order by ID. Objects are read from a
+	// single query, so fn shouldn't use the backend. Iteration stops if fn
+	// returns an error, which is returned.
+	EachObject(ctx context.Context, storageRoot string, prefix string, fn func(*ObjectListItem) error) error
+
+	// EachObjectStateItem calls fn for each child of the directory base in
+	// the object version state, in the same order as GetObjectState. If base
+	// is a file, fn isn't called. As with EachObject, children are read from
+	// a single query, so fn shouldn't use the backend.
+	EachObjectStateItem(ctx context.Context, storageRoot string, objectID string, vnum ocfl.VNum, base string, recursive bool, fn func(*PathItem) error) error
+
+	// GetContentPath returns the path to a file with digest sum, computed
+	// with the digest algorithm alg (e.g., "sha512"). The path is relative to
+	// the storage root. The file's size is included if it is indexed.
+	GetContentPath(ctx context.Context, storageRoot string, alg string, sum string) (*ContentPath, error)
+
+	// GetObjectContentPath returns the path to a file with digest sum in the
+	// object with the given ID. The digest uses the object's digest algorithm.
+	// The path is relative to the storage root.
+	GetObjectContentPath(ctx context.Context, storageRoot string, objectID string, sum string) (*ContentPath, error)
+
+	// Search returns object versions and logical paths in the storage root
+	// that match the full-text query. Object IDs, logical paths, and version
+	// messages and users are searched. See ParseSearchQuery for the query
+	// syntax.
+	Search(ctx context.Context, storageRoot string, query string, limit int, cursor string) (*SearchResults, error)
+
+	// FindByDigest returns objects in the storage root with a file or
+	// directory with the given digest, along with the versions and logical
+	// paths where it appears. The digest is hex-encoded: a file digest uses
+	// the object's digest algorithm and a directory digest is the recursive
+	// digest reported by GetObjectState. The limit and cursor apply to
+	// objects, which are listed in order by ID.
+	FindByDigest(ctx context.Context, storageRoot string, sum string, limit int, cursor string) (*DigestMatchList, error)
+
+	// ListInvalidObjects returns objects in the storage root with validation
+	// errors or warnings, in order by object path. Issues are recorded when an
+	// object's inventory is validated during indexing. Inventories that are
+	// unchanged since they were indexed aren't validated again, so their
+	// issues are kept until the object changes or is force-reindexed. The
+	// limit and cursor apply to objects.
+	ListInvalidObjects(ctx context.Context, storageRoot string, limit int, cursor string) (*InvalidObjectList, error)
+
+	// GetFixityCheck returns the result of the most recent full validation of
+	// the object root. It returns ErrNotFound if the object hasn't been
+	// checked.
+	GetFixityCheck(ctx context.Context, storageRoot string, rootPath string) (*FixityCheck, error)
+
+	// ListFixityDue returns up to limit object roots in the storage root,
+	// least recently checked first. Object roots that haven't been checked
+	// come first, with a zero CheckedAt.
+	ListFixityDue(ctx context.Context, storageRoot string, limit int) ([]FixityCheck, error)
+
+	// TaskStore persists the history of asynchronous tasks.
+	TaskStore
+}
+
+// Migration is a step that upgrades a backend's database schema from one
+// version to the next.
+type Migration struct {
+	Name        string // migration name: the starting and ending schema versions (e.g., "0.4-0.5")
+	Description string
 }
 
 type BackendTx interface {
@@ -50,25 +118,45 @@ type BackendTx interface {
 	// declaring that an object exists at the path without fully indexing its
 	// inventory. This is a minimal indexing operation and all other Index
 	// methods include it. The object root is relative to the storage root path.
-	// If root is already present in the index, its indexed timestamp is updated
-	// to idxAt (which should typically be time.Now()) and nil is returned. The
+	// The storage root is added to the index if it isn't already present. If
+	// root is already present in the index, its indexed timestamp is updated to
+	// idxAt (which should typically be time.Now()) and nil is returned. The
 	// timestamp, idxAt, is truncated to the nearest second and converted to UTC
 	// before being stored in the index.
-	IndexObjectRoot(ctx context.Context, idxAt time.Time, roots ...ObjectRoot) error
+	IndexObjectRoot(ctx context.Context, storageRoot string, idxAt time.Time, roots ...ObjectRoot) error
 
 	// IndexObjectInventory performs the same index operations as IndexObjectRoot and,
 	// additionally, indexes the inventory, inv, which should be the root
 	// inventory of the OCFL object at the path root. If an inventory with same
-	// ID as inv exists in the index, it is replaced by inv.
-	IndexObjectInventory(ctx context.Context, idxAt time.Time, invs ...ObjectInventory) error
+	// ID as inv exists in the storage root, it is replaced by inv. If the
+	// ObjectInventory includes FileSizes, sizes for files and directories in
+	// each version state are indexed as well.
+	IndexObjectInventory(ctx context.Context, storageRoot string, idxAt time.Time, invs ...ObjectInventory) error
+
+	// SetValidationIssues replaces the validation errors and warnings recorded
+	// for the object root with issues. The object root is added to the index
+	// if it isn't already present, as with IndexObjectRoot. If issues is
+	// empty, the object's recorded issues are removed.
+	SetValidationIssues(ctx context.Context, storageRoot string, idxAt time.Time, root string, issues []ValidationIssue) error
 
-	//
-	RemoveObjectsBefore(ctx context.Context, indexedBefore time.Time) error
+	// SetFixityCheck records the result of a full validation of the object
+	// root at check.RootPath, replacing the previous result. It returns
+	// ErrNotFound if the object root isn't indexed.
+	SetFixityCheck(ctx context.Context, storageRoot string, check FixityCheck) error
+
+	// RemoveObjectsBefore removes object roots in the storage root that were
+	// last indexed before indexedBefore.
+	RemoveObjectsBefore(ctx context.Context, storageRoot string, indexedBefore time.Time) error
 
 	// GetObjectByPath returns the path to a file with digest sum. The path is relative to
 	// the storage root.
-	GetObjectByPath(ctx context.Context, p string) (*Object, error)
-	ListObjectRoots(ctx context.Context, limit int, cursor string) (*ObjectRootList, error)
+	GetObjectByPath(ctx context.Context, storageRoot string, p string) (*Object, error)
+	ListObjectRoots(ctx context.Context, storageRoot string, limit int, cursor string) (*ObjectRootList, error)
+
+	// ListObjectContentSize returns indexed file sizes for content files in
+	// the object with the given ID. Map keys are content paths relative to the
+	// object root. Content files without size information are not included.
+	ListObjectContentSize(ctx context.Context, storageRoot string, objectID string) (map[string]int64, error)
 }
 
 type IndexSummary struct {
@@ -86,6 +174,9 @@ type ObjectInventory struct {
 	// Object root's directory relative to the storage root.
 	Path      string
 	Inventory *ocflv1.Inventory
+	// FileSizes maps content paths (relative to the object root) to file
+	// sizes. It is optional: if nil, sizes are not indexed.
+	FileSizes map[string]int64
 }
 
 type ObjectRootList struct {
@@ -122,6 +213,7 @@ type Object struct {
 	Head            ocfl.VNum // object's most recent versio
 	DigestAlgorithm string    // from inventory
 	InventoryDigest string    // from inventory sidecar
+	IndexedAt       time.Time // when the inventory was last indexed
 	Versions        []*ObjectVersion
 }
 
@@ -133,6 +225,52 @@ type ObjectVersion struct {
 	User    *ocflv1.User // Version user information
 	Size    int64        // total size of files in version (calculated)
 	HasSize bool
+	// file counts for the version state (calculated). Stats may be missing
+	// for versions indexed before they were added to the schema.
+	Stats    VersionStats
+	HasStats bool
+}
+
+// SearchResults is a page of full-text search results
+type SearchResults struct {
+	Results    []SearchResult
+	NextCursor string
+}
+
+// SearchResult is an object version or logical path matching a full-text
+// search query.
+type SearchResult struct {
+	ObjectID string    // OCFL object ID
+	Version  ocfl.VNum // version with matching metadata, or in which Path was added or changed
+	Path     string    // matching logical path (empty for object ID and version metadata matches)
+}
+
+// DigestMatchList is a page of objects with matching files or directories
+// for a digest.
+type DigestMatchList struct {
+	Objects    []DigestMatchObject
+	NextCursor string
+}
+
+// DigestMatchObject is an object with one or more files or directories that
+// match a digest.
+type DigestMatchObject struct {
+	ID      string // OCFL object ID
+	Matches []DigestMatch
+}
+
+// DigestMatch is a logical path in an object version with a matching digest
+type DigestMatch struct {
+	Version ocfl.VNum
+	Path    string // logical path: "." if the digest matches the entire version state
+	IsDir   bool
+}
+
+// ContentPath is a content file in the storage root
+type ContentPath struct {
+	Path    string // path relative to the storage root
+	Size    int64  // file size, if HasSize is true
+	HasSize bool
 }
 
 // PathInfo represents information about a logical path in an objects version state
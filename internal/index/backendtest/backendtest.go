@@ -0,0 +1,1443 @@
+// Package backendtest provides a test suite for implementations of
+// index.Backend.
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/mock"
+)
+
+// testRoot is the storage root name used by most tests in the suite.
+const testRoot = "test-root"
+
+// NewBackendFunc returns an empty index.Backend with an initialized schema.
+// It is called once for each test in the suite. Implementations should use
+// t.Cleanup to close the backend.
+type NewBackendFunc func(t *testing.T) index.Backend
+
+// RunConformance runs tests that check the behavior of the index.Backend
+// returned by newBackend.
+func RunConformance(t *testing.T, newBackend NewBackendFunc) {
+	t.Run("GetIndexSummary", func(t *testing.T) { testGetIndexSummary(t, newBackend) })
+	t.Run("IndexObject", func(t *testing.T) { testIndexObject(t, newBackend) })
+	t.Run("IndexObjectUpsert", func(t *testing.T) { testIndexObjectUpsert(t, newBackend) })
+	t.Run("IndexObjectFileSizes", func(t *testing.T) { testIndexObjectFileSizes(t, newBackend) })
+	t.Run("ListObjectRoots", func(t *testing.T) { testListObjectRoots(t, newBackend) })
+	t.Run("RemoveObjectsBefore", func(t *testing.T) { testRemoveObjectsBefore(t, newBackend) })
+	t.Run("ListObjects", func(t *testing.T) { testListObjects(t, newBackend) })
+	t.Run("GetObjectState", func(t *testing.T) { testGetObjectState(t, newBackend) })
+	t.Run("GetObjectStateRecursive", func(t *testing.T) { testGetObjectStateRecursive(t, newBackend) })
+	t.Run("EachObject", func(t *testing.T) { testEachObject(t, newBackend) })
+	t.Run("EachObjectStateItem", func(t *testing.T) { testEachObjectStateItem(t, newBackend) })
+	t.Run("GetContentPath", func(t *testing.T) { testGetContentPath(t, newBackend) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newBackend) })
+	t.Run("StorageRoots", func(t *testing.T) { testStorageRoots(t, newBackend) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newBackend) })
+	t.Run("FindByDigest", func(t *testing.T) { testFindByDigest(t, newBackend) })
+	t.Run("Tasks", func(t *testing.T) { testTasks(t, newBackend) })
+	t.Run("InvalidObjects", func(t *testing.T) { testInvalidObjects(t, newBackend) })
+	t.Run("FixityChecks", func(t *testing.T) { testFixityChecks(t, newBackend) })
+}
+
+// RunConcurrencyConformance runs tests that use concurrent write
+// transactions. It should be used for backends that support them (e.g.,
+// postgres), in addition to RunConformance.
+func RunConcurrencyConformance(t *testing.T, newBackend NewBackendFunc) {
+	t.Run("IndexSameNodes", func(t *testing.T) { testIndexSameNodes(t, newBackend) })
+}
+
+// testIndexSameNodes indexes objects with the same directory in concurrent
+// transactions, so both insert the same nodes.
+func testIndexSameNodes(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	const dir, dirsize = "shared", 20
+	idx := newBackend(t)
+	m1 := mock.NewIndexingObject("object-1", mock.BigDir(dir, dirsize))
+	m2 := mock.NewIndexingObject("object-2", mock.BigDir(dir, dirsize))
+	// objects are in different storage roots so that the transactions don't
+	// wait on the same storage root row.
+	otherRoot := testRoot + "-other"
+	tx1, err := idx.NewTx(ctx)
+	expNil(t, err)
+	tx2, err := idx.NewTx(ctx)
+	expNil(t, err)
+	err = tx1.IndexObjectInventory(ctx, testRoot, m1.IndexedAt, index.ObjectInventory{
+		Path:      m1.RootDir,
+		Inventory: m1.Inventory,
+	})
+	expNil(t, err)
+	errs := make(chan error, 1)
+	go func() {
+		// inserting the shared nodes waits for tx1
+		errs <- tx2.IndexObjectInventory(ctx, otherRoot, m2.IndexedAt, index.ObjectInventory{
+			Path:      m2.RootDir,
+			Inventory: m2.Inventory,
+		})
+	}()
+	// give tx2 time to reach the shared nodes before tx1 commits
+	time.Sleep(100 * time.Millisecond)
+	expNil(t, tx1.Commit())
+	if err := <-errs; err != nil {
+		tx2.Rollback()
+		t.Fatal("indexing in concurrent transaction:", err)
+	}
+	expNil(t, tx2.Commit())
+	state1, err := idx.GetObjectState(ctx, testRoot, m1.Inventory.ID, ocfl.VNum{}, dir, false, 0, "")
+	expNil(t, err)
+	state2, err := idx.GetObjectState(ctx, otherRoot, m2.Inventory.ID, ocfl.VNum{}, dir, false, 0, "")
+	expNil(t, err)
+	expEq(t, "shared directory entries", len(state2.Children), dirsize)
+	expEq(t, "shared directory state", state2, state1)
+}
+
+func testGetIndexSummary(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	t.Run("single object", func(t *testing.T) {
+		m := mock.NewIndexingObject(t.Name())
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Path:      m.RootDir,
+				Inventory: m.Inventory,
+			})
+		})
+		summary, err := idx.GetIndexSummary(ctx, testRoot)
+		expNil(t, err)
+		expEq(t, "indexed inventories", summary.NumInventories, 1)
+		expEq(t, "indexed objects", summary.NumObjects, 1)
+		expTime(t, "updated at", summary.UpdatedAt, m.IndexedAt)
+	})
+	t.Run("empty", func(t *testing.T) {
+		idx := newBackend(t)
+		summary, err := idx.GetIndexSummary(ctx, testRoot)
+		expNil(t, err)
+		exp := index.IndexSummary{
+			NumInventories: 0,
+			NumObjects:     0,
+			UpdatedAt:      time.Time{},
+		}
+		expEq(t, "indexed summary", summary, exp)
+	})
+}
+
+func testIndexObject(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "test-object"
+	// index successive versions of the same object
+	mock1 := mock.NewIndexingObject(id)
+	mock2 := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+	mock2.IndexedAt = mock1.IndexedAt.AddDate(0, 0, 1)
+	mock3 := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(3)))
+	mock3.IndexedAt = mock1.IndexedAt.AddDate(0, 0, 2)
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		if err := tx.IndexObjectRoot(ctx, testRoot, mock1.IndexedAt, index.ObjectRoot{Path: mock1.RootDir}); err != nil {
+			return err
+		}
+		for _, m := range []*mock.IndexingObject{mock2, mock3} {
+			err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	roots, err := idx.ListObjectRoots(ctx, testRoot, 0, "")
+	expNil(t, err)
+	expEq(t, "# object roots", len(roots.ObjectRoots), 1)
+	expEq(t, "indexed object root path", roots.ObjectRoots[0].Path, mock3.RootDir)
+	expTime(t, "indexed object root indexed_at", roots.ObjectRoots[0].IndexedAt, mock3.IndexedAt)
+	obj, err := idx.GetObject(ctx, testRoot, id)
+	expNil(t, err)
+	inv := mock3.Inventory
+	expEq(t, "indexed object ID", obj.ID, inv.ID)
+	expEq(t, "indexed object root", obj.RootPath, mock3.RootDir)
+	expEq(t, "indexed object head", obj.Head, inv.Head)
+	expEq(t, "indexed digest alg", obj.DigestAlgorithm, inv.DigestAlgorithm)
+	expEq(t, "indexed inventory digest", obj.InventoryDigest, inv.Digest())
+	expEq(t, "indexed object spec", obj.Spec, inv.Type.Spec)
+	expTime(t, "indexed object indexed_at", obj.IndexedAt, mock3.IndexedAt)
+	expEq(t, "# indexed object versions", len(obj.Versions), len(inv.Versions))
+	prevFiles := 0
+	for _, idxver := range obj.Versions {
+		ver := inv.Versions[idxver.Num]
+		expNotNil(t, "version "+idxver.Num.String(), ver)
+		expTime(t, "indexed version created", idxver.Created, ver.Created)
+		expEq(t, "indexed version message", idxver.Message, ver.Message)
+		expEq(t, "indexed version has stats", idxver.HasStats, true)
+		expEq(t, "indexed version files", idxver.Stats.Files, ver.State.LenPaths())
+		expEq(t, "indexed version added and removed", prevFiles+idxver.Stats.Added-idxver.Stats.Removed, idxver.Stats.Files)
+		prevFiles = idxver.Stats.Files
+		if ver.User != nil {
+			expNotNil(t, "indexed version user", idxver.User)
+			expEq(t, "indexed version user addr", idxver.User.Address, ver.User.Address)
+			expEq(t, "indexed version user name", idxver.User.Name, ver.User.Name)
+		}
+	}
+	byPath, err := idx.GetObjectByPath(ctx, testRoot, mock3.RootDir)
+	expNil(t, err)
+	expEq(t, "object by path", byPath, obj)
+}
+
+func testIndexObjectFileSizes(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "test-object"
+	mock1 := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)), mock.BigDir("dir", 10))
+	t.Run("without sizes", func(t *testing.T) {
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+				Inventory: mock1.Inventory,
+				Path:      mock1.RootDir,
+			})
+		})
+		obj, err := idx.GetObject(ctx, testRoot, id)
+		expNil(t, err)
+		for _, v := range obj.Versions {
+			expEq(t, "version HasSize", v.HasSize, false)
+		}
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		sizes, err := tx.ListObjectContentSize(ctx, testRoot, id)
+		expNil(t, err)
+		expEq(t, "indexed content sizes", len(sizes), 0)
+	})
+	t.Run("with sizes", func(t *testing.T) {
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+				Inventory: mock1.Inventory,
+				Path:      mock1.RootDir,
+				FileSizes: mock1.FileSizes,
+			})
+		})
+		obj, err := idx.GetObject(ctx, testRoot, id)
+		expNil(t, err)
+		for _, v := range obj.Versions {
+			expEq(t, "version HasSize", v.HasSize, true)
+		}
+		state, err := idx.GetObjectState(ctx, testRoot, id, ocfl.V(2), "dir", false, 0, "")
+		expNil(t, err)
+		expEq(t, "dir HasSize", state.HasSize, true)
+		for _, ch := range state.Children {
+			expEq(t, "child HasSize", ch.HasSize, true)
+			cp, err := mock1.Inventory.ContentPath(2, "dir/"+ch.Name)
+			expNil(t, err)
+			expEq(t, "child size", ch.Size, mock1.FileSizes[cp])
+		}
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		sizes, err := tx.ListObjectContentSize(ctx, testRoot, id)
+		expNil(t, err)
+		expEq(t, "indexed content sizes", sizes, mock1.FileSizes)
+	})
+	t.Run("add sizes to existing", func(t *testing.T) {
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+				Inventory: mock1.Inventory,
+				Path:      mock1.RootDir,
+			})
+		})
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+			Inventory: mock1.Inventory,
+			Path:      mock1.RootDir,
+			FileSizes: mock1.FileSizes,
+		}))
+		expNil(t, tx.Commit())
+		state, err := idx.GetObjectState(ctx, testRoot, id, ocfl.V(2), "dir", true, 0, "")
+		expNil(t, err)
+		expEq(t, "dir HasSize", state.HasSize, true)
+		for _, ch := range state.Children {
+			expEq(t, "child HasSize", ch.HasSize, true)
+		}
+	})
+}
+
+func testListObjectRoots(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	numObjects := 721
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		for i := 0; i < numObjects; i++ {
+			id := fmt.Sprintf("test-listroots-%d", i)
+			mock := mock.NewIndexingObject(id)
+			root := index.ObjectRoot{Path: mock.RootDir}
+			if err := tx.IndexObjectRoot(ctx, testRoot, mock.IndexedAt, root); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var found []string
+	cursor := ""
+	for {
+		items, err := idx.ListObjectRoots(ctx, testRoot, 17, cursor)
+		expNil(t, err)
+		for _, r := range items.ObjectRoots {
+			found = append(found, r.Path)
+		}
+		if items.NextCursor == "" {
+			break
+		}
+		expEq(t, "full page size", len(items.ObjectRoots), 17)
+		expEq(t, "cursor is last path", items.NextCursor, found[len(found)-1])
+		cursor = items.NextCursor
+	}
+	expEq(t, "indexed object roots", len(found), numObjects)
+	expSorted(t, "object root paths", found)
+	// single page
+	items, err := idx.ListObjectRoots(ctx, testRoot, 1000, "")
+	expNil(t, err)
+	expEq(t, "single page size", len(items.ObjectRoots), numObjects)
+	expEq(t, "single page cursor", items.NextCursor, "")
+}
+
+func testRemoveObjectsBefore(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	numObjects := 721
+	indexedAt := time.Now().Add(-7 * time.Hour) // seven hours ago
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		for i := 0; i < numObjects; i++ {
+			id := fmt.Sprintf("test-delroots-%d", i)
+			mock := mock.NewIndexingObject(id)
+			root := index.ObjectRoot{Path: mock.RootDir}
+			if err := tx.IndexObjectRoot(ctx, testRoot, indexedAt, root); err != nil {
+				return err
+			}
+			indexedAt = indexedAt.Add(time.Hour)
+		}
+		return nil
+	})
+	before, err := idx.GetIndexSummary(ctx, testRoot)
+	expNil(t, err)
+	tx, err := idx.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	expNil(t, tx.RemoveObjectsBefore(ctx, testRoot, time.Now()))
+	expNil(t, tx.Commit())
+	after, err := idx.GetIndexSummary(ctx, testRoot)
+	expNil(t, err)
+	// expect 8 deleted objects
+	expEq(t, "deleted object roots", after.NumObjects, before.NumObjects-8)
+	t.Run("with inventories", func(t *testing.T) {
+		old := mock.NewIndexingObject("old-object")
+		old.IndexedAt = time.Now().Add(-time.Hour)
+		cur := mock.NewIndexingObject("current-object")
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			for _, m := range []*mock.IndexingObject{old, cur} {
+				err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+					Inventory: m.Inventory,
+					Path:      m.RootDir,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.RemoveObjectsBefore(ctx, testRoot, cur.IndexedAt.Add(-time.Minute)))
+		expNil(t, tx.Commit())
+		_, err = idx.GetObject(ctx, testRoot, old.Inventory.ID)
+		expErrIs(t, "removed object", err, index.ErrNotFound)
+		_, err = idx.GetObject(ctx, testRoot, cur.Inventory.ID)
+		expNil(t, err)
+		summary, err := idx.GetIndexSummary(ctx, testRoot)
+		expNil(t, err)
+		expEq(t, "remaining inventories", summary.NumInventories, 1)
+		expEq(t, "remaining objects", summary.NumObjects, 1)
+	})
+}
+
+func testListObjects(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	t.Run("empty", func(t *testing.T) {
+		idx := newBackend(t)
+		_, err := idx.ListObjects(ctx, testRoot, "", 10, "")
+		expNil(t, err)
+	})
+	t.Run("no prefix", func(t *testing.T) {
+		const numInvs = 27
+		mocks := make([]*mock.IndexingObject, numInvs)
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			letters := []rune("abcdefghijklmnopqrstuvqwxyz")
+			for i := 0; i < len(mocks); i++ {
+				id := fmt.Sprintf("%c-test-%d", letters[i%len(letters)], i)
+				m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+				err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+					Inventory: m.Inventory,
+					Path:      m.RootDir,
+				})
+				if err != nil {
+					return err
+				}
+				mocks[i] = m
+			}
+			return nil
+		})
+		sort.Slice(mocks, func(i, j int) bool {
+			return mocks[i].Inventory.ID < mocks[j].Inventory.ID
+		})
+		objs := []index.ObjectListItem{}
+		cursor := ""
+		for {
+			results, err := idx.ListObjects(ctx, testRoot, "", 5, cursor)
+			expNil(t, err)
+			objs = append(objs, results.Objects...)
+			cursor = results.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+		expEq(t, "number of objects in result", len(objs), len(mocks))
+		for i, m := range mocks {
+			expEq(t, "result id matches mock id", objs[i].ID, m.Inventory.ID)
+			expEq(t, "result root matches mock root", objs[i].RootPath, m.RootDir)
+			expEq(t, "result spec matches mock spec", objs[i].Spec, m.Inventory.Type.Spec)
+			expEq(t, "result head matches mock head", objs[i].Head, m.Inventory.Head)
+			expTime(t, "result v1 created match mock v1 created", objs[i].V1Created, m.Inventory.Versions[ocfl.V(1)].Created)
+			expTime(t, "result head created match mock v2 created", objs[i].HeadCreated, m.Inventory.Versions[ocfl.V(2)].Created)
+		}
+	})
+	t.Run("with prefix", func(t *testing.T) {
+		const numInvs = 54
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			letters := []rune("abcdefghijklmnopqrstuvqwxyz")
+			for i := 0; i < numInvs; i++ {
+				id := fmt.Sprintf("%c-test-%d", letters[i%len(letters)], i)
+				m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+				err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+					Inventory: m.Inventory,
+					Path:      m.RootDir,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		results, err := idx.ListObjects(ctx, testRoot, "a", 5, "")
+		expNil(t, err)
+		expEq(t, "number of results", len(results.Objects), 2)
+		expEq(t, "next page cursor", results.NextCursor, "")
+		// paginate through prefixed results
+		var ids []string
+		cursor := ""
+		for {
+			results, err := idx.ListObjects(ctx, testRoot, "b-", 1, cursor)
+			expNil(t, err)
+			for _, obj := range results.Objects {
+				ids = append(ids, obj.ID)
+			}
+			if results.NextCursor == "" {
+				break
+			}
+			expEq(t, "full page size", len(results.Objects), 1)
+			cursor = results.NextCursor
+		}
+		expEq(t, "prefixed results", ids, []string{"b-test-1", "b-test-28"})
+		// no matches
+		results, err = idx.ListObjects(ctx, testRoot, "none", 5, "")
+		expNil(t, err)
+		expEq(t, "number of results", len(results.Objects), 0)
+		expEq(t, "next page cursor", results.NextCursor, "")
+	})
+}
+
+func testGetObjectState(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	// index an object with 1231 files in a directory called "long"
+	id := "object-1"
+	head := ocfl.V(1)
+	dir := "long"
+	dirsize := 1231
+	mock1 := mock.NewIndexingObject(id,
+		mock.WithHead(head),
+		mock.BigDir(dir, dirsize))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		return tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+			Inventory: mock1.Inventory,
+			Path:      mock1.RootDir,
+		})
+	})
+	// read long directory
+	cursor := ""
+	var allFiles []string
+	for {
+		// list of all files in head state
+		pathinfo, err := idx.GetObjectState(ctx, testRoot, id, head, ".", true, 41, cursor)
+		expNil(t, err)
+		for _, ch := range pathinfo.Children {
+			allFiles = append(allFiles, ch.Name)
+		}
+		if pathinfo.NextCursor == "" {
+			break
+		}
+		cursor = pathinfo.NextCursor
+	}
+	expEq(t, "directory entries", len(allFiles), dirsize+4)
+	expSorted(t, "recursive state paths", allFiles)
+	// non-recursive listing of the long directory
+	cursor = ""
+	var dirFiles []string
+	for {
+		pathinfo, err := idx.GetObjectState(ctx, testRoot, id, head, dir, false, 41, cursor)
+		expNil(t, err)
+		expEq(t, "long is a directory", pathinfo.IsDir, true)
+		for _, ch := range pathinfo.Children {
+			expEq(t, "child IsDir", ch.IsDir, false)
+			dirFiles = append(dirFiles, ch.Name)
+		}
+		if pathinfo.NextCursor == "" {
+			break
+		}
+		expEq(t, "full page size", len(pathinfo.Children), 41)
+		expEq(t, "cursor is last name", pathinfo.NextCursor, dirFiles[len(dirFiles)-1])
+		cursor = pathinfo.NextCursor
+	}
+	expEq(t, "long directory entries", len(dirFiles), dirsize)
+	expSorted(t, "directory entry names", dirFiles)
+	for _, f := range allFiles {
+		inf, err := idx.GetObjectState(ctx, testRoot, id, head, f, false, 1, "")
+		expNil(t, err)
+		expEq(t, "IsDir", inf.IsDir, false)
+		expEq(t, "Sum len", len(inf.Sum), 128)
+		expEq(t, "Children len", len(inf.Children), 0)
+	}
+}
+
+func testEachObject(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	const numInvs = 54
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		letters := []rune("abcdefghijklmnopqrstuvqwxyz")
+		for i := 0; i < numInvs; i++ {
+			id := fmt.Sprintf("%c-test-%d", letters[i%len(letters)], i)
+			m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+			err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	// all objects are the same as those from ListObjects
+	var listed []index.ObjectListItem
+	cursor := ""
+	for {
+		results, err := idx.ListObjects(ctx, testRoot, "", 10, cursor)
+		expNil(t, err)
+		listed = append(listed, results.Objects...)
+		if results.NextCursor == "" {
+			break
+		}
+		cursor = results.NextCursor
+	}
+	var objs []index.ObjectListItem
+	err := idx.EachObject(ctx, testRoot, "", func(obj *index.ObjectListItem) error {
+		objs = append(objs, *obj)
+		return nil
+	})
+	expNil(t, err)
+	expEq(t, "number of objects", len(objs), numInvs)
+	expEq(t, "objects", objs, listed)
+	// with prefix
+	var ids []string
+	err = idx.EachObject(ctx, testRoot, "b-", func(obj *index.ObjectListItem) error {
+		ids = append(ids, obj.ID)
+		return nil
+	})
+	expNil(t, err)
+	expEq(t, "prefixed objects", ids, []string{"b-test-1", "b-test-28"})
+	// unknown storage root
+	err = idx.EachObject(ctx, "missing", "", func(obj *index.ObjectListItem) error {
+		t.Error("unexpected object in missing storage root")
+		return nil
+	})
+	expNil(t, err)
+	// errors from fn stop iteration
+	stop := errors.New("stop")
+	var calls int
+	err = idx.EachObject(ctx, testRoot, "", func(obj *index.ObjectListItem) error {
+		calls++
+		return stop
+	})
+	expErrIs(t, "error from fn", err, stop)
+	expEq(t, "calls before stopping", calls, 1)
+}
+
+func testEachObjectStateItem(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "object-1"
+	head := ocfl.V(1)
+	dir := "long"
+	dirsize := 1231
+	mock1 := mock.NewIndexingObject(id,
+		mock.WithHead(head),
+		mock.BigDir(dir, dirsize))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		return tx.IndexObjectInventory(ctx, testRoot, mock1.IndexedAt, index.ObjectInventory{
+			Inventory: mock1.Inventory,
+			Path:      mock1.RootDir,
+		})
+	})
+	each := func(base string, recursive bool) ([]index.PathItem, error) {
+		var items []index.PathItem
+		err := idx.EachObjectStateItem(ctx, testRoot, id, head, base, recursive, func(p *index.PathItem) error {
+			items = append(items, *p)
+			return nil
+		})
+		return items, err
+	}
+	// same children as GetObjectState, without pagination
+	for _, recursive := range []bool{true, false} {
+		for _, base := range []string{".", dir} {
+			items, err := each(base, recursive)
+			expNil(t, err)
+			var expect []index.PathItem
+			cursor := ""
+			for {
+				pathinfo, err := idx.GetObjectState(ctx, testRoot, id, head, base, recursive, 0, cursor)
+				expNil(t, err)
+				expect = append(expect, pathinfo.Children...)
+				if pathinfo.NextCursor == "" {
+					break
+				}
+				cursor = pathinfo.NextCursor
+			}
+			desc := fmt.Sprintf("children of '%s' (recursive=%v)", base, recursive)
+			expEq(t, desc, items, expect)
+		}
+	}
+	items, err := each(dir, false)
+	expNil(t, err)
+	expEq(t, "long directory entries", len(items), dirsize)
+	// base is a file
+	items, err = each(dir+"/"+items[0].Name, false)
+	expNil(t, err)
+	expEq(t, "file children", len(items), 0)
+	// errors
+	_, err = each("missing", false)
+	expErrIs(t, "missing path", err, index.ErrNotFound)
+	_, err = each("../missing", false)
+	expErrIs(t, "invalid path", err, index.ErrInvalidArgs)
+	err = idx.EachObjectStateItem(ctx, testRoot, "missing", head, ".", true, func(p *index.PathItem) error {
+		return nil
+	})
+	expErrIs(t, "missing object", err, index.ErrNotFound)
+}
+
+func testIndexObjectUpsert(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "test-object"
+	t.Run("repeat", func(t *testing.T) {
+		m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+		})
+		before, err := idx.GetObject(ctx, testRoot, id)
+		expNil(t, err)
+		later := m.IndexedAt.Add(time.Hour)
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.IndexObjectInventory(ctx, testRoot, later, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+		}))
+		expNil(t, tx.Commit())
+		after, err := idx.GetObject(ctx, testRoot, id)
+		expNil(t, err)
+		expTime(t, "re-indexed object indexed_at", after.IndexedAt, later)
+		after.IndexedAt = before.IndexedAt
+		expEq(t, "re-indexed object", after, before)
+		summary, err := idx.GetIndexSummary(ctx, testRoot)
+		expNil(t, err)
+		expEq(t, "indexed inventories", summary.NumInventories, 1)
+		expEq(t, "indexed objects", summary.NumObjects, 1)
+		expTime(t, "updated at", summary.UpdatedAt, later)
+	})
+	t.Run("new root path", func(t *testing.T) {
+		m := mock.NewIndexingObject(id)
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+		})
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      "moved/" + m.RootDir,
+		}))
+		expNil(t, tx.Commit())
+		obj, err := idx.GetObject(ctx, testRoot, id)
+		expNil(t, err)
+		expEq(t, "object root path", obj.RootPath, "moved/"+m.RootDir)
+		_, err = idx.GetObjectByPath(ctx, testRoot, m.RootDir)
+		expErrIs(t, "object at previous root", err, index.ErrNotFound)
+	})
+	t.Run("changed version", func(t *testing.T) {
+		m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+		idx := setup(t, newBackend, func(tx index.BackendTx) error {
+			return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+		})
+		changed := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+		changed.Inventory.Versions[ocfl.V(1)].Message = "changed"
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		err = tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: changed.Inventory,
+			Path:      changed.RootDir,
+		})
+		expErrIs(t, "indexing changed version", err, index.ErrIndexValue)
+	})
+}
+
+func testGetObjectStateRecursive(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "object-1"
+	head := ocfl.V(3)
+	m := mock.NewIndexingObject(id, mock.WithHead(head), mock.BigDir("a/b", 5))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+		})
+	})
+	for _, vnum := range head.Lineage() {
+		state := m.Inventory.Versions[vnum].State
+		expPaths := map[string]string{}
+		state.EachPath(func(name, sum string) error {
+			expPaths[name] = sum
+			return nil
+		})
+		info, err := idx.GetObjectState(ctx, testRoot, id, vnum, ".", true, 0, "")
+		expNil(t, err)
+		expEq(t, "root is a directory", info.IsDir, true)
+		gotPaths := map[string]string{}
+		for _, ch := range info.Children {
+			expEq(t, "child IsDir", ch.IsDir, false)
+			gotPaths[ch.Name] = ch.Sum
+		}
+		expEq(t, vnum.String()+" recursive state", gotPaths, expPaths)
+		// recursive listing below a subdirectory has relative paths
+		info, err = idx.GetObjectState(ctx, testRoot, id, vnum, "a", true, 0, "")
+		expNil(t, err)
+		for _, ch := range info.Children {
+			expEq(t, "subdirectory file digest", ch.Sum, expPaths["a/"+ch.Name])
+		}
+		expEq(t, "subdirectory files", len(info.Children), 5)
+		// non-recursive listing includes directories
+		info, err = idx.GetObjectState(ctx, testRoot, id, vnum, "a", false, 0, "")
+		expNil(t, err)
+		expEq(t, "subdirectory entries", len(info.Children), 1)
+		expEq(t, "subdirectory entry name", info.Children[0].Name, "b")
+		expEq(t, "subdirectory entry IsDir", info.Children[0].IsDir, true)
+		// file
+		info, err = idx.GetObjectState(ctx, testRoot, id, vnum, "change.txt", false, 0, "")
+		expNil(t, err)
+		expEq(t, "file IsDir", info.IsDir, false)
+		expEq(t, "file digest", info.Sum, expPaths["change.txt"])
+	}
+	// zero value version number is the head version
+	headInfo, err := idx.GetObjectState(ctx, testRoot, id, ocfl.VNum{}, ".", true, 0, "")
+	expNil(t, err)
+	v3Info, err := idx.GetObjectState(ctx, testRoot, id, head, ".", true, 0, "")
+	expNil(t, err)
+	expEq(t, "head state", headInfo, v3Info)
+}
+
+func testGetContentPath(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	m := mock.NewIndexingObject("object-1", mock.WithHead(ocfl.V(2)))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+			FileSizes: m.FileSizes,
+		})
+	})
+	alg := m.Inventory.DigestAlgorithm
+	err := m.Inventory.Manifest.EachPath(func(name, sum string) error {
+		p, err := idx.GetContentPath(ctx, testRoot, alg, sum)
+		expNil(t, err)
+		expEq(t, "content path", p.Path, path.Join(m.RootDir, name))
+		expEq(t, "content has size", p.HasSize, true)
+		expEq(t, "content size", p.Size, m.FileSizes[name])
+		p, err = idx.GetObjectContentPath(ctx, testRoot, m.Inventory.ID, sum)
+		expNil(t, err)
+		expEq(t, "object content path", p.Path, path.Join(m.RootDir, name))
+		// digest with another algorithm
+		_, err = idx.GetContentPath(ctx, testRoot, "md5", sum)
+		expErrIs(t, "content with other digest algorithm", err, index.ErrNotFound)
+		// content from another object
+		_, err = idx.GetObjectContentPath(ctx, testRoot, "object-2", sum)
+		expErrIs(t, "content from another object", err, index.ErrNotFound)
+		return nil
+	})
+	expNil(t, err)
+	missing := strings.Repeat("0", 128)
+	_, err = idx.GetContentPath(ctx, testRoot, alg, missing)
+	expErrIs(t, "missing content", err, index.ErrNotFound)
+	_, err = idx.GetObjectContentPath(ctx, testRoot, m.Inventory.ID, missing)
+	expErrIs(t, "missing object content", err, index.ErrNotFound)
+	_, err = idx.GetContentPath(ctx, testRoot, alg, "not-hex")
+	expErrIs(t, "invalid digest", err, index.ErrInvalidArgs)
+	_, err = idx.GetObjectContentPath(ctx, testRoot, m.Inventory.ID, "not-hex")
+	expErrIs(t, "invalid object content digest", err, index.ErrInvalidArgs)
+}
+
+func testNotFound(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "object-1"
+	m := mock.NewIndexingObject(id)
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		if err := tx.IndexObjectRoot(ctx, testRoot, m.IndexedAt, index.ObjectRoot{Path: "root-only"}); err != nil {
+			return err
+		}
+		return tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+		})
+	})
+	_, err := idx.GetObject(ctx, testRoot, "missing")
+	expErrIs(t, "GetObject", err, index.ErrNotFound)
+	_, err = idx.GetObjectByPath(ctx, testRoot, "missing")
+	expErrIs(t, "GetObjectByPath", err, index.ErrNotFound)
+	_, err = idx.GetObjectByPath(ctx, testRoot, "root-only")
+	expErrIs(t, "GetObjectByPath for root without inventory", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, testRoot, "missing", ocfl.V(1), ".", false, 0, "")
+	expErrIs(t, "GetObjectState with missing object", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, testRoot, id, ocfl.V(9), ".", false, 0, "")
+	expErrIs(t, "GetObjectState with missing version", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, testRoot, id, ocfl.V(1), "missing.txt", false, 0, "")
+	expErrIs(t, "GetObjectState with missing path", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, testRoot, id, ocfl.V(1), "change.txt/missing", false, 0, "")
+	expErrIs(t, "GetObjectState with path below a file", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, testRoot, id, ocfl.V(1), "../change.txt", false, 0, "")
+	expErrIs(t, "GetObjectState with invalid path", err, index.ErrInvalidArgs)
+	tx, err := idx.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	_, err = tx.GetObjectByPath(ctx, testRoot, "missing")
+	expErrIs(t, "BackendTx.GetObjectByPath", err, index.ErrNotFound)
+}
+
+func testStorageRoots(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "object-1"
+	// the same object id and path in two storage roots, with different heads
+	mockA := mock.NewIndexingObject(id)
+	mockB := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		err := tx.IndexObjectInventory(ctx, "root-a", mockA.IndexedAt, index.ObjectInventory{
+			Inventory: mockA.Inventory,
+			Path:      mockA.RootDir,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.IndexObjectInventory(ctx, "root-b", mockB.IndexedAt, index.ObjectInventory{
+			Inventory: mockB.Inventory,
+			Path:      mockB.RootDir,
+		})
+	})
+	for root, m := range map[string]*mock.IndexingObject{"root-a": mockA, "root-b": mockB} {
+		summary, err := idx.GetIndexSummary(ctx, root)
+		expNil(t, err)
+		expEq(t, root+" indexed inventories", summary.NumInventories, 1)
+		expEq(t, root+" indexed objects", summary.NumObjects, 1)
+		obj, err := idx.GetObject(ctx, root, id)
+		expNil(t, err)
+		expEq(t, root+" object head", obj.Head, m.Inventory.Head)
+		expEq(t, root+" object versions", len(obj.Versions), m.Inventory.Head.Num())
+		byPath, err := idx.GetObjectByPath(ctx, root, m.RootDir)
+		expNil(t, err)
+		expEq(t, root+" object by path", byPath, obj)
+		objs, err := idx.ListObjects(ctx, root, "", 0, "")
+		expNil(t, err)
+		expEq(t, root+" listed objects", len(objs.Objects), 1)
+		expEq(t, root+" listed object head", objs.Objects[0].Head, m.Inventory.Head)
+		state, err := idx.GetObjectState(ctx, root, id, ocfl.VNum{}, ".", true, 0, "")
+		expNil(t, err)
+		numFiles := 0
+		m.Inventory.Versions[m.Inventory.Head].State.EachPath(func(_, _ string) error {
+			numFiles++
+			return nil
+		})
+		expEq(t, root+" head state files", len(state.Children), numFiles)
+	}
+	// content only in root-b
+	sumsA := map[string]bool{}
+	mockA.Inventory.Manifest.EachPath(func(_, sum string) error {
+		sumsA[sum] = true
+		return nil
+	})
+	mockB.Inventory.Manifest.EachPath(func(name, sum string) error {
+		if sumsA[sum] {
+			return nil
+		}
+		alg := mockB.Inventory.DigestAlgorithm
+		p, err := idx.GetContentPath(ctx, "root-b", alg, sum)
+		expNil(t, err)
+		expEq(t, "content path", p.Path, path.Join(mockB.RootDir, name))
+		_, err = idx.GetContentPath(ctx, "root-a", alg, sum)
+		expErrIs(t, "content from another storage root", err, index.ErrNotFound)
+		return nil
+	})
+	// unknown storage root
+	summary, err := idx.GetIndexSummary(ctx, "missing")
+	expNil(t, err)
+	expEq(t, "unknown storage root summary", summary, index.IndexSummary{})
+	_, err = idx.GetObject(ctx, "missing", id)
+	expErrIs(t, "GetObject in unknown storage root", err, index.ErrNotFound)
+	_, err = idx.GetObjectState(ctx, "missing", id, ocfl.V(1), ".", false, 0, "")
+	expErrIs(t, "GetObjectState in unknown storage root", err, index.ErrNotFound)
+	// removing objects is scoped to the storage root
+	tx, err := idx.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	expNil(t, tx.RemoveObjectsBefore(ctx, "root-a", time.Now().Add(time.Hour)))
+	err = tx.IndexObjectRoot(ctx, "", time.Now(), index.ObjectRoot{Path: "unnamed"})
+	expErrIs(t, "indexing without storage root name", err, index.ErrInvalidArgs)
+	expNil(t, tx.Commit())
+	_, err = idx.GetObject(ctx, "root-a", id)
+	expErrIs(t, "removed object", err, index.ErrNotFound)
+	_, err = idx.GetObject(ctx, "root-b", id)
+	expNil(t, err)
+}
+
+func testSearch(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	id := "ark:/12345/search-object"
+	m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(3)))
+	other := mock.NewIndexingObject(id)
+	indexAll := func(tx index.BackendTx) error {
+		err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.IndexObjectInventory(ctx, "other-root", other.IndexedAt, index.ObjectInventory{
+			Inventory: other.Inventory,
+			Path:      other.RootDir,
+		})
+	}
+	idx := setup(t, newBackend, indexAll)
+	search := func(t *testing.T, query string) []string {
+		t.Helper()
+		results, err := idx.Search(ctx, testRoot, query, 0, "")
+		expNil(t, err)
+		return searchResultStrings(results.Results)
+	}
+	t.Run("logical path", func(t *testing.T) {
+		expEq(t, "results", search(t, "common.txt"), []string{id + " v1 common.txt"})
+		expEq(t, "results", search(t, "change.txt"), []string{
+			id + " v1 change.txt",
+			id + " v2 change.txt",
+			id + " v3 change.txt",
+		})
+	})
+	t.Run("prefix", func(t *testing.T) {
+		expEq(t, "results", search(t, "renam*"), []string{
+			id + " v1 v1-rename.txt",
+			id + " v2 v2-rename.txt",
+			id + " v3 v3-rename.txt",
+		})
+		expEq(t, "results", search(t, `"v2 ren"*`), []string{id + " v2 v2-rename.txt"})
+	})
+	t.Run("object id and version metadata", func(t *testing.T) {
+		expVersions := []string{id + " v1 ", id + " v2 ", id + " v3 "}
+		expEq(t, "results", search(t, "ark:/12345"), expVersions)
+		expEq(t, "results", search(t, "NOBODY"), expVersions)
+		expEq(t, "results", search(t, `"none com"`), expVersions)
+		expEq(t, "results", search(t, "search-object v2"), []string{id + " v2 "})
+	})
+	t.Run("no match", func(t *testing.T) {
+		expEq(t, "results", len(search(t, "missing")), 0)
+		expEq(t, "results", len(search(t, `"common change"`)), 0)
+		expEq(t, "results", len(search(t, "common.txt nobody")), 0)
+	})
+	t.Run("pagination", func(t *testing.T) {
+		var all []index.SearchResult
+		cursor := ""
+		for i := 0; ; i++ {
+			results, err := idx.Search(ctx, testRoot, "change", 2, cursor)
+			expNil(t, err)
+			if i == 0 {
+				expEq(t, "first page size", len(results.Results), 2)
+			}
+			all = append(all, results.Results...)
+			if results.NextCursor == "" {
+				break
+			}
+			cursor = results.NextCursor
+		}
+		expEq(t, "all results", searchResultStrings(all), search(t, "change"))
+	})
+	t.Run("invalid query", func(t *testing.T) {
+		for _, q := range []string{"", " ", "...", `"unterminated`} {
+			_, err := idx.Search(ctx, testRoot, q, 0, "")
+			expErrIs(t, fmt.Sprintf("query %q", q), err, index.ErrInvalidArgs)
+		}
+	})
+	t.Run("reindex and remove", func(t *testing.T) {
+		idx := setup(t, newBackend, indexAll)
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, indexAll(tx))
+		expNil(t, tx.Commit())
+		results, err := idx.Search(ctx, testRoot, "common", 0, "")
+		expNil(t, err)
+		expEq(t, "results after reindex", len(results.Results), 1)
+		tx, err = idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.RemoveObjectsBefore(ctx, testRoot, time.Now().Add(time.Hour)))
+		expNil(t, tx.Commit())
+		results, err = idx.Search(ctx, testRoot, "common", 0, "")
+		expNil(t, err)
+		expEq(t, "results after remove", len(results.Results), 0)
+		results, err = idx.Search(ctx, "other-root", "common", 0, "")
+		expNil(t, err)
+		expEq(t, "results in other storage root", len(results.Results), 1)
+	})
+}
+
+func testFindByDigest(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	// files in "dir" are the same in both objects
+	mock1 := mock.NewIndexingObject("object-1", mock.WithHead(ocfl.V(2)), mock.BigDir("dir", 3))
+	mock2 := mock.NewIndexingObject("object-2", mock.BigDir("dir", 3))
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		for _, m := range []*mock.IndexingObject{mock1, mock2} {
+			err := tx.IndexObjectInventory(ctx, testRoot, m.IndexedAt, index.ObjectInventory{
+				Inventory: m.Inventory,
+				Path:      m.RootDir,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	find := func(t *testing.T, sum string) []string {
+		t.Helper()
+		list, err := idx.FindByDigest(ctx, testRoot, sum, 0, "")
+		expNil(t, err)
+		expEq(t, "next cursor", list.NextCursor, "")
+		return digestMatchStrings(list)
+	}
+	t.Run("file", func(t *testing.T) {
+		sum := mock1.Inventory.Versions[ocfl.V(1)].State.GetDigest("dir/0-file.txt")
+		expEq(t, "matches", find(t, sum), []string{
+			"object-1 v1 dir/0-file.txt",
+			"object-1 v2 dir/0-file.txt",
+			"object-2 v1 dir/0-file.txt",
+		})
+		// common.txt is unchanged; rename.txt is renamed in each version
+		sum = mock1.Inventory.Versions[ocfl.V(1)].State.GetDigest("common.txt")
+		expEq(t, "matches", find(t, sum), []string{
+			"object-1 v1 common.txt",
+			"object-1 v2 common.txt",
+		})
+		sum = mock1.Inventory.Versions[ocfl.V(1)].State.GetDigest("v1-rename.txt")
+		expEq(t, "matches", find(t, sum), []string{
+			"object-1 v1 v1-rename.txt",
+			"object-1 v2 v2-rename.txt",
+		})
+	})
+	t.Run("directory", func(t *testing.T) {
+		state, err := idx.GetObjectState(ctx, testRoot, "object-1", ocfl.V(1), "dir", false, 0, "")
+		expNil(t, err)
+		expEq(t, "matches", find(t, state.Sum), []string{
+			"object-1 v1 dir/",
+			"object-1 v2 dir/",
+			"object-2 v1 dir/",
+		})
+		state, err = idx.GetObjectState(ctx, testRoot, "object-2", ocfl.V(1), ".", false, 0, "")
+		expNil(t, err)
+		expEq(t, "matches", find(t, state.Sum), []string{"object-2 v1 ./"})
+	})
+	t.Run("pagination", func(t *testing.T) {
+		sum := mock1.Inventory.Versions[ocfl.V(1)].State.GetDigest("dir/0-file.txt")
+		list, err := idx.FindByDigest(ctx, testRoot, sum, 1, "")
+		expNil(t, err)
+		expEq(t, "first page", digestMatchStrings(list), []string{
+			"object-1 v1 dir/0-file.txt",
+			"object-1 v2 dir/0-file.txt",
+		})
+		expEq(t, "next cursor", list.NextCursor, "object-1")
+		list, err = idx.FindByDigest(ctx, testRoot, sum, 1, list.NextCursor)
+		expNil(t, err)
+		expEq(t, "second page", digestMatchStrings(list), []string{"object-2 v1 dir/0-file.txt"})
+		expEq(t, "next cursor", list.NextCursor, "")
+	})
+	t.Run("no match", func(t *testing.T) {
+		expEq(t, "matches", len(find(t, "abcdef")), 0)
+		sum := mock1.Inventory.Versions[ocfl.V(1)].State.GetDigest("common.txt")
+		list, err := idx.FindByDigest(ctx, "other-root", sum, 0, "")
+		expNil(t, err)
+		expEq(t, "matches in other storage root", len(list.Objects), 0)
+	})
+	t.Run("invalid digest", func(t *testing.T) {
+		for _, sum := range []string{"", "not-hex"} {
+			_, err := idx.FindByDigest(ctx, testRoot, sum, 0, "")
+			expErrIs(t, fmt.Sprintf("digest %q", sum), err, index.ErrInvalidArgs)
+		}
+	})
+}
+
+func testTasks(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	idx := newBackend(t)
+	started := time.Now()
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		task := &index.Task{
+			Name:        "indexing",
+			StorageRoot: testRoot,
+			Options:     map[string]string{"force": "false"},
+			Status:      index.TaskRunning,
+			StartedAt:   started,
+			Counts:      index.TaskCounts{},
+		}
+		expNil(t, idx.CreateTask(ctx, task))
+		if len(ids) > 0 && task.ID <= ids[len(ids)-1] {
+			t.Fatalf("task IDs should increase: %d after %d", task.ID, ids[len(ids)-1])
+		}
+		ids = append(ids, task.ID)
+	}
+	t.Run("get", func(t *testing.T) {
+		task, err := idx.GetTask(ctx, ids[0])
+		expNil(t, err)
+		expEq(t, "task name", task.Name, "indexing")
+		expEq(t, "task storage root", task.StorageRoot, testRoot)
+		expEq(t, "task options", task.Options, map[string]string{"force": "false"})
+		expEq(t, "task status", task.Status, index.TaskRunning)
+		expTime(t, "task started at", task.StartedAt, started)
+		expEq(t, "task ended at", task.EndedAt.IsZero(), true)
+	})
+	t.Run("update", func(t *testing.T) {
+		task, err := idx.GetTask(ctx, ids[1])
+		expNil(t, err)
+		task.Status = index.TaskFailed
+		task.StartedAt = started.Add(time.Second)
+		task.EndedAt = started.Add(time.Minute)
+		task.Counts = index.TaskCounts{"indexed": 3, "errors": 1}
+		task.Error = "something went wrong"
+		expNil(t, idx.UpdateTask(ctx, task))
+		got, err := idx.GetTask(ctx, ids[1])
+		expNil(t, err)
+		expEq(t, "task status", got.Status, index.TaskFailed)
+		expTime(t, "task started at", got.StartedAt, task.StartedAt)
+		expTime(t, "task ended at", got.EndedAt, task.EndedAt)
+		expEq(t, "task counts", got.Counts, task.Counts)
+		expEq(t, "task error", got.Error, task.Error)
+	})
+	t.Run("list", func(t *testing.T) {
+		var got []int64
+		cursor := ""
+		for {
+			list, err := idx.ListTasks(ctx, 2, cursor)
+			expNil(t, err)
+			for _, task := range list.Tasks {
+				got = append(got, task.ID)
+			}
+			if list.NextCursor == "" {
+				break
+			}
+			cursor = list.NextCursor
+		}
+		expect := make([]int64, len(ids))
+		for i := range ids {
+			expect[i] = ids[len(ids)-1-i]
+		}
+		expEq(t, "listed task ids", got, expect)
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := idx.GetTask(ctx, ids[len(ids)-1]+1)
+		expErrIs(t, "missing task", err, index.ErrNotFound)
+	})
+	t.Run("fail unfinished", func(t *testing.T) {
+		queued := &index.Task{Name: "indexing", Status: index.TaskQueued, StartedAt: started, Counts: index.TaskCounts{}}
+		expNil(t, idx.CreateTask(ctx, queued))
+		ended := started.Add(time.Hour)
+		expNil(t, idx.FailUnfinishedTasks(ctx, ended, "server restarted"))
+		for _, id := range []int64{ids[0], queued.ID} {
+			got, err := idx.GetTask(ctx, id)
+			expNil(t, err)
+			expEq(t, "unfinished task status", got.Status, index.TaskFailed)
+			expTime(t, "unfinished task ended at", got.EndedAt, ended)
+			expEq(t, "unfinished task error", got.Error, "server restarted")
+		}
+		// completed tasks aren't changed
+		got, err := idx.GetTask(ctx, ids[1])
+		expNil(t, err)
+		expEq(t, "completed task error", got.Error, "something went wrong")
+		expTime(t, "completed task ended at", got.EndedAt, started.Add(time.Minute))
+	})
+}
+
+// digestMatchStrings returns strings for matches in the list with the form
+// "{object_id} {version} {path}". Directory paths end with "/".
+func digestMatchStrings(list *index.DigestMatchList) []string {
+	var strs []string
+	for _, obj := range list.Objects {
+		for _, m := range obj.Matches {
+			s := obj.ID + " " + m.Version.String() + " " + m.Path
+			if m.IsDir {
+				s += "/"
+			}
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// searchResultStrings returns sorted strings for search results with the
+// form "{object_id} {version} {path}".
+func searchResultStrings(results []index.SearchResult) []string {
+	strs := make([]string, len(results))
+	for i, r := range results {
+		strs[i] = r.ObjectID + " " + r.Version.String() + " " + r.Path
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+// setup returns a new backend with values added by fn.
+func testInvalidObjects(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	checked := time.Now().Add(-time.Hour)
+	issues := map[string][]index.ValidationIssue{
+		"object-a": {
+			{Severity: index.SeverityError, Code: "E040", Message: "E040: head version missing", InventoryPath: "object-a/inventory.json", CheckedAt: checked},
+			{Severity: index.SeverityWarning, Code: "W004", Message: "W004: weak digest algorithm", InventoryPath: "object-a/inventory.json", CheckedAt: checked},
+		},
+		"object-b": {
+			{Severity: index.SeverityError, Message: "inventory not found", InventoryPath: "object-b/inventory.json", CheckedAt: checked},
+		},
+		"object-c": nil, // valid
+	}
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		for root, objIssues := range issues {
+			if err := tx.SetValidationIssues(ctx, testRoot, checked, root, objIssues); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	summary, err := idx.GetIndexSummary(ctx, testRoot)
+	expNil(t, err)
+	expEq(t, "object roots", summary.NumObjects, 3)
+	t.Run("list", func(t *testing.T) {
+		list, err := idx.ListInvalidObjects(ctx, testRoot, 1, "")
+		expNil(t, err)
+		expEq(t, "first page length", len(list.Objects), 1)
+		expEq(t, "first object", list.Objects[0].RootPath, "object-a")
+		got := list.Objects[0].Issues
+		expEq(t, "first object issues", len(got), 2)
+		for i, exp := range issues["object-a"] {
+			expTime(t, "issue checked at", got[i].CheckedAt, exp.CheckedAt)
+			got[i].CheckedAt = exp.CheckedAt
+			expEq(t, "issue", got[i], exp)
+		}
+		list, err = idx.ListInvalidObjects(ctx, testRoot, 1, list.NextCursor)
+		expNil(t, err)
+		expEq(t, "second page length", len(list.Objects), 1)
+		expEq(t, "second object", list.Objects[0].RootPath, "object-b")
+		expEq(t, "second object issues", len(list.Objects[0].Issues), 1)
+		expEq(t, "second object code", list.Objects[0].Issues[0].Code, "")
+		expEq(t, "next cursor", list.NextCursor, "")
+		list, err = idx.ListInvalidObjects(ctx, "other-root", 0, "")
+		expNil(t, err)
+		expEq(t, "invalid objects in other storage root", len(list.Objects), 0)
+	})
+	t.Run("replace", func(t *testing.T) {
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.SetValidationIssues(ctx, testRoot, time.Now(), "object-a", nil))
+		expNil(t, tx.Commit())
+		list, err := idx.ListInvalidObjects(ctx, testRoot, 0, "")
+		expNil(t, err)
+		expEq(t, "invalid objects", len(list.Objects), 1)
+		expEq(t, "invalid object", list.Objects[0].RootPath, "object-b")
+	})
+	t.Run("removed objects", func(t *testing.T) {
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.RemoveObjectsBefore(ctx, testRoot, time.Now().Add(time.Minute)))
+		expNil(t, tx.Commit())
+		list, err := idx.ListInvalidObjects(ctx, testRoot, 0, "")
+		expNil(t, err)
+		expEq(t, "invalid objects after removing object roots", len(list.Objects), 0)
+	})
+}
+
+func testFixityChecks(t *testing.T, newBackend NewBackendFunc) {
+	ctx := context.Background()
+	now := time.Now()
+	checks := []index.FixityCheck{
+		{RootPath: "object-b", Valid: false, Codes: []string{"E092", "E093"}, Error: "E093: content digest doesn't match", CheckedAt: now.Add(-2 * time.Hour)},
+		{RootPath: "object-c", Valid: true, CheckedAt: now.Add(-time.Hour)},
+	}
+	idx := setup(t, newBackend, func(tx index.BackendTx) error {
+		for _, root := range []string{"object-a", "object-b", "object-c"} {
+			if err := tx.SetValidationIssues(ctx, testRoot, now, root, nil); err != nil {
+				return err
+			}
+		}
+		for _, check := range checks {
+			if err := tx.SetFixityCheck(ctx, testRoot, check); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	t.Run("get", func(t *testing.T) {
+		got, err := idx.GetFixityCheck(ctx, testRoot, "object-b")
+		expNil(t, err)
+		expTime(t, "checked at", got.CheckedAt, checks[0].CheckedAt)
+		got.CheckedAt = checks[0].CheckedAt
+		expEq(t, "fixity check", *got, checks[0])
+		_, err = idx.GetFixityCheck(ctx, testRoot, "object-a")
+		expEq(t, "unchecked object err", errors.Is(err, index.ErrNotFound), true)
+		_, err = idx.GetFixityCheck(ctx, "other-root", "object-b")
+		expEq(t, "other storage root err", errors.Is(err, index.ErrNotFound), true)
+	})
+	t.Run("list due", func(t *testing.T) {
+		due, err := idx.ListFixityDue(ctx, testRoot, 10)
+		expNil(t, err)
+		expEq(t, "due objects", len(due), 3)
+		expEq(t, "never checked first", due[0].RootPath, "object-a")
+		expEq(t, "never checked time", due[0].CheckedAt.IsZero(), true)
+		expEq(t, "oldest check second", due[1].RootPath, "object-b")
+		expEq(t, "oldest check codes", due[1].Codes, checks[0].Codes)
+		expEq(t, "newest check last", due[2].RootPath, "object-c")
+		due, err = idx.ListFixityDue(ctx, testRoot, 1)
+		expNil(t, err)
+		expEq(t, "due objects with limit", len(due), 1)
+		due, err = idx.ListFixityDue(ctx, "other-root", 10)
+		expNil(t, err)
+		expEq(t, "due objects in other storage root", len(due), 0)
+	})
+	t.Run("replace", func(t *testing.T) {
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.SetFixityCheck(ctx, testRoot, index.FixityCheck{RootPath: "object-b", Valid: true, CheckedAt: now}))
+		err = tx.SetFixityCheck(ctx, testRoot, index.FixityCheck{RootPath: "object-x", Valid: true, CheckedAt: now})
+		expEq(t, "unindexed object err", errors.Is(err, index.ErrNotFound), true)
+		expNil(t, tx.Commit())
+		got, err := idx.GetFixityCheck(ctx, testRoot, "object-b")
+		expNil(t, err)
+		expEq(t, "replaced valid", got.Valid, true)
+		expEq(t, "replaced codes", len(got.Codes), 0)
+		expEq(t, "replaced error", got.Error, "")
+		due, err := idx.ListFixityDue(ctx, testRoot, 10)
+		expNil(t, err)
+		expEq(t, "newest check last", due[2].RootPath, "object-b")
+	})
+	t.Run("removed objects", func(t *testing.T) {
+		tx, err := idx.NewTx(ctx)
+		expNil(t, err)
+		defer tx.Rollback()
+		expNil(t, tx.RemoveObjectsBefore(ctx, testRoot, time.Now().Add(time.Minute)))
+		expNil(t, tx.Commit())
+		_, err = idx.GetFixityCheck(ctx, testRoot, "object-c")
+		expEq(t, "removed object err", errors.Is(err, index.ErrNotFound), true)
+	})
+}
+
+func setup(t *testing.T, newBackend NewBackendFunc, fn func(tx index.BackendTx) error) index.Backend {
+	t.Helper()
+	ctx := context.Background()
+	db := newBackend(t)
+	tx, err := db.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	expNil(t, fn(tx))
+	expNil(t, tx.Commit())
+	return db
+}
+
+func expNil(t *testing.T, val any) {
+	t.Helper()
+	if val != nil {
+		t.Fatalf("%v!=nil", val)
+	}
+}
+
+func expNotNil(t *testing.T, desc string, val any) {
+	t.Helper()
+	if val == nil || reflect.ValueOf(val).IsNil() {
+		t.Fatalf("%s=nil", desc)
+	}
+}
+
+func expEq(t *testing.T, desc string, got, expect any) {
+	t.Helper()
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("%s: got='%v', expected='%v'", desc, got, expect)
+	}
+}
+
+func expErrIs(t *testing.T, desc string, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: got error '%v', expected '%v'", desc, err, target)
+	}
+}
+
+func expSorted(t *testing.T, desc string, vals []string) {
+	t.Helper()
+	for i := 1; i < len(vals); i++ {
+		if vals[i-1] >= vals[i] {
+			t.Fatalf("%s: not sorted: '%s' before '%s'", desc, vals[i-1], vals[i])
+		}
+	}
+}
+
+// expTime compares timestamps to the second: backends aren't expected to
+// store sub-second precision.
+func expTime(t *testing.T, desc string, got, expect time.Time) {
+	t.Helper()
+	if !got.Truncate(time.Second).Equal(expect.Truncate(time.Second)) {
+		t.Fatalf("%s: got='%v', expected='%v'", desc, got, expect)
+	}
+}
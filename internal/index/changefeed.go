@@ -0,0 +1,390 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	defaultChangeWait  = 5 * time.Second  // default time to collect changes before reindexing
+	defaultDirInterval = 10 * time.Second // default time between DirWatcher scans
+	maxS3EventSize     = 4 << 20          // max size of S3 event notification requests
+	s3EventsPath       = "/events/s3"
+)
+
+// ObjectChange is a notification that an object's inventory was created or
+// updated.
+type ObjectChange struct {
+	StorageRoot string // storage root name; if empty, the default storage root
+	Key         string // path of the inventory.json file in the storage root's FS
+}
+
+// ChangeSource is a source of object change notifications.
+type ChangeSource interface {
+	// Changes sends notifications to ch until ctx is canceled or the source
+	// fails.
+	Changes(ctx context.Context, ch chan<- ObjectChange) error
+}
+
+// ChangeFeed reindexes objects in response to notifications from change
+// sources. Changes are collected for a short period and reindexed together in
+// a single indexing task. Notifications may be lost, and removed objects
+// aren't reported, so the feed also scans all storage roots at the Reconcile
+// interval.
+type ChangeFeed struct {
+	Service   *Service
+	Sources   []ChangeSource
+	Wait      time.Duration // time to collect changes before reindexing (default: 5s)
+	Reconcile time.Duration // time between full storage root scans (0: never)
+}
+
+// Run consumes change notifications until ctx is canceled or a source returns
+// an error.
+func (feed *ChangeFeed) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wait := feed.Wait
+	if wait <= 0 {
+		wait = defaultChangeWait
+	}
+	changes := make(chan ObjectChange)
+	errs := make(chan error, len(feed.Sources))
+	for _, src := range feed.Sources {
+		src := src
+		go func() { errs <- src.Changes(ctx, changes) }()
+	}
+	var reconcile <-chan time.Time
+	if feed.Reconcile > 0 {
+		ticker := time.NewTicker(feed.Reconcile)
+		defer ticker.Stop()
+		reconcile = ticker.C
+	}
+	flush := time.NewTicker(wait)
+	defer flush.Stop()
+	pending := map[string][]string{} // storage root name -> object paths
+	reconciling := false             // a full scan is due
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		case change := <-changes:
+			root, err := feed.Service.storageRoot(change.StorageRoot)
+			if err != nil {
+				feed.Service.Log.Warn("ignoring change notification", "key", change.Key, "err", err)
+				continue
+			}
+			if objPath, ok := inventoryObjectPath(root.Path, change.Key); ok {
+				pending[root.Name] = append(pending[root.Name], objPath)
+			}
+		case <-reconcile:
+			reconciling = true
+		case <-flush.C:
+			// If a task can't be queued, it is tried again later.
+			if reconciling {
+				task := &Task{Name: "reconciling"}
+				if feed.addTask(ctx, task, feed.reconcileTask(), false) {
+					// the full scan includes any pending changes
+					reconciling = false
+					pending = map[string][]string{}
+				}
+				continue
+			}
+			if len(pending) > 0 {
+				task := &Task{Name: "indexing changes", Options: changesOptions(pending)}
+				if feed.addTask(ctx, task, feed.changesTask(pending), true) {
+					pending = map[string][]string{}
+				}
+			}
+		}
+	}
+}
+
+// addTask queues the task with the service's Async, returning true if it was
+// added. Changes are queued as targeted tasks.
+func (feed *ChangeFeed) addTask(ctx context.Context, task *Task, fn taskFn, targeted bool) bool {
+	add := feed.Service.Async.Add
+	if targeted {
+		add = feed.Service.Async.AddTargeted
+	}
+	_, err := add(ctx, task, fn)
+	if err != nil && !errors.Is(err, ErrAsyncQueueFull) {
+		feed.Service.Log.Warn("can't start change feed task", "task", task.Name, "err", err)
+	}
+	return err == nil
+}
+
+// changesTask returns a task that reindexes the object paths in each storage
+// root.
+func (feed *ChangeFeed) changesTask(pending map[string][]string) taskFn {
+	return func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		var errs []error
+		names := maps.Keys(pending)
+		slices.Sort(names)
+		for _, name := range names {
+			root, err := feed.Service.storageRoot(name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			opts := feed.Service.indexOptions(root, w)
+			paths := pending[name]
+			slices.Sort(paths)
+			opts.ObjectPaths = slices.Compact(paths)
+			opts.Counts = counts
+			opts.Log.Info("indexing changed objects", "storage_root", name, "objects", len(opts.ObjectPaths))
+			if err := feed.Service.Indexer.Index(ctx, opts); err != nil {
+				opts.Log.Error("indexing changed objects failed", "storage_root", name, "err", err)
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// changesOptions returns task options describing the pending changes: the
+// number of changed objects in each storage root.
+func changesOptions(pending map[string][]string) map[string]string {
+	opts := make(map[string]string, len(pending))
+	for name, paths := range pending {
+		opts["objects:"+name] = strconv.Itoa(len(paths))
+	}
+	return opts
+}
+
+// reconcileTask returns a task that scans and reindexes all storage roots.
+func (feed *ChangeFeed) reconcileTask() taskFn {
+	return func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		var errs []error
+		for i := range feed.Service.Roots {
+			opts := feed.Service.indexOptions(&feed.Service.Roots[i], w)
+			opts.Counts = counts
+			if err := feed.Service.Indexer.Index(ctx, opts); err != nil {
+				opts.Log.Error("reconciling storage root failed", "storage_root", opts.StorageRoot, "err", err)
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// inventoryObjectPath returns the object root path, relative to the storage
+// root directory rootPath, for the inventory file key. It returns false if key
+// isn't an object root inventory in the storage root.
+func inventoryObjectPath(rootPath string, key string) (string, bool) {
+	key = path.Clean(strings.TrimPrefix(key, "/"))
+	if path.Base(key) != "inventory.json" {
+		return "", false
+	}
+	dir := path.Dir(key)
+	if rootPath = path.Clean(rootPath); rootPath != "." {
+		if !strings.HasPrefix(dir, rootPath+"/") {
+			return "", false
+		}
+		dir = strings.TrimPrefix(dir, rootPath+"/")
+	}
+	if dir == "." || dir == "extensions" || strings.HasPrefix(dir, "extensions/") {
+		return "", false
+	}
+	// inventories in version directories are written before the object's
+	// root inventory.
+	var v ocfl.VNum
+	if ocfl.ParseVNum(path.Base(dir), &v) == nil {
+		return "", false
+	}
+	return dir, true
+}
+
+// DirWatcher is a ChangeSource for storage roots on the local filesystem. It
+// periodically walks Dir for inventory.json files that have been added or
+// modified since the previous walk. Inventories found in the first walk are
+// only reported if Since is set and they were modified after it.
+type DirWatcher struct {
+	Dir         string        // local directory used for the storage root's FS
+	StorageRoot string        // storage root name
+	Interval    time.Duration // time between scans (default: 10s)
+	Since       time.Time     // report inventories modified after Since in the first walk
+}
+
+var _ ChangeSource = (*DirWatcher)(nil)
+
+// Changes implements ChangeSource
+func (dw *DirWatcher) Changes(ctx context.Context, ch chan<- ObjectChange) error {
+	interval := dw.Interval
+	if interval <= 0 {
+		interval = defaultDirInterval
+	}
+	var seen map[string]fileState // nil before the first walk
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		found, err := dw.scan(ctx)
+		if err != nil {
+			return err
+		}
+		keys := maps.Keys(found)
+		slices.Sort(keys)
+		for _, key := range keys {
+			if seen == nil && (dw.Since.IsZero() || !found[key].modTime.After(dw.Since)) {
+				continue
+			}
+			if prev, ok := seen[key]; ok && prev.equal(found[key]) {
+				continue
+			}
+			select {
+			case ch <- ObjectChange{StorageRoot: dw.StorageRoot, Key: key}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		seen = found
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fileState is used to detect modified files
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (a fileState) equal(b fileState) bool {
+	return a.size == b.size && a.modTime.Equal(b.modTime)
+}
+
+// scan returns the state of all inventory.json files in the directory, with
+// keys relative to the directory.
+func (dw *DirWatcher) scan(ctx context.Context) (map[string]fileState, error) {
+	found := map[string]fileState{}
+	err := filepath.WalkDir(dw.Dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && name != dw.Dir {
+				// removed during the walk
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "inventory.json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dw.Dir, name)
+		if err != nil {
+			return err
+		}
+		found[filepath.ToSlash(rel)] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// S3Webhook is a ChangeSource for S3 event notifications sent to an HTTP
+// endpoint, as supported by MinIO and other S3-compatible services. Only
+// 'ObjectCreated' events are reported. The storage_root query parameter sets
+// the storage root for the notifications. Object keys should be relative to
+// the storage root's FS (for the s3 driver, the bucket).
+type S3Webhook struct {
+	changes chan ObjectChange
+}
+
+var _ ChangeSource = (*S3Webhook)(nil)
+
+func NewS3Webhook() *S3Webhook {
+	return &S3Webhook{changes: make(chan ObjectChange)}
+}
+
+// Changes implements ChangeSource
+func (wh *S3Webhook) Changes(ctx context.Context, ch chan<- ObjectChange) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change := <-wh.changes:
+			select {
+			case ch <- change:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// s3Event is the S3 event notification message structure
+type s3Event struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Object struct {
+				Key string `json:"key"` // url-encoded
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// ServeHTTP handles S3 event notifications. It responds after all
+// notifications in the request have been accepted by the change feed.
+func (wh *S3Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var event s3Event
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxS3EventSize)).Decode(&event); err != nil {
+		http.Error(w, "invalid event notification: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	root := r.URL.Query().Get("storage_root")
+	for _, rec := range event.Records {
+		if !strings.Contains(rec.EventName, "ObjectCreated:") {
+			continue
+		}
+		key, err := url.QueryUnescape(rec.S3.Object.Key)
+		if err != nil {
+			http.Error(w, "invalid object key: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if path.Base(key) != "inventory.json" {
+			continue
+		}
+		select {
+		case wh.changes <- ObjectChange{StorageRoot: root, Key: key}:
+		case <-r.Context().Done():
+			http.Error(w, "change feed is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,156 @@
+package index_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl/logging"
+)
+
+func TestS3Webhook(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wh := index.NewS3Webhook()
+	changes := make(chan index.ObjectChange, 10)
+	go wh.Changes(ctx, changes)
+	// MinIO-style event notification
+	body := `{"EventName": "s3:ObjectCreated:Put", "Records": [
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"object": {"key": "root%2Fark%253A123%252Fabc%2Finventory.json"}}},
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"object": {"key": "root%2Fark%253A123%252Fabc%2Finventory.json.sha512"}}},
+		{"eventName": "s3:ObjectRemoved:Delete", "s3": {"object": {"key": "root%2Fobj%2Finventory.json"}}}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/events/s3?storage_root=test", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	wh.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	expect := index.ObjectChange{StorageRoot: "test", Key: "root/ark%3A123%2Fabc/inventory.json"}
+	select {
+	case got := <-changes:
+		if got != expect {
+			t.Fatalf("expected change %v, got %v", expect, got)
+		}
+	default:
+		t.Fatal("expected a change notification")
+	}
+	if len(changes) > 0 {
+		t.Fatalf("unexpected change notification: %v", <-changes)
+	}
+	// invalid request
+	req = httptest.NewRequest(http.MethodPost, "/events/s3", strings.NewReader("{"))
+	rec = httptest.NewRecorder()
+	wh.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestChangeFeed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// storage root in a temporary directory, initially without the object
+	const objID = "ark:123/abc"
+	const objPath = "ark%3A123%2Fabc"
+	rootDir := t.TempDir()
+	if err := copyDir(filepath.Join(fixtureRoot, "simple-root"), rootDir); err != nil {
+		t.Fatal(err)
+	}
+	hidden := filepath.Join(t.TempDir(), objPath)
+	if err := os.Rename(filepath.Join(rootDir, objPath), hidden); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &index.Service{
+		Indexer: idx,
+		Roots: []index.StorageRoot{
+			{Name: index.DefaultStorageRoot, FS: ocfl.NewFS(os.DirFS(rootDir)), Path: "."},
+		},
+		Log:   logging.DisabledLogger(),
+		Async: index.NewAsync(ctx, idx),
+	}
+	if err := idx.Index(ctx, &index.IndexOptions{FS: srv.Roots[0].FS, RootPath: "."}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.GetObject(ctx, index.DefaultStorageRoot, objID); !errors.Is(err, index.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before the change, got %v", err)
+	}
+	since := time.Now()
+	feed := &index.ChangeFeed{
+		Service: srv,
+		Sources: []index.ChangeSource{
+			&index.DirWatcher{Dir: rootDir, Interval: 10 * time.Millisecond, Since: since},
+		},
+		Wait: 10 * time.Millisecond,
+	}
+	feedErr := make(chan error, 1)
+	go func() { feedErr <- feed.Run(ctx) }()
+	// add the object to the storage root: the inventory is modified after
+	// the watcher's Since so the change is reported even if the watcher's
+	// first walk finds it.
+	modTime := since.Add(time.Second)
+	if err := os.Chtimes(filepath.Join(hidden, "inventory.json"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(hidden, filepath.Join(rootDir, objPath)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		obj, err := idx.GetObject(ctx, index.DefaultStorageRoot, objID)
+		if err == nil {
+			if obj.RootPath != objPath {
+				t.Fatalf("expected object path %q, got %q", objPath, obj.RootPath)
+			}
+			break
+		}
+		if !errors.Is(err, index.ErrNotFound) {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-feedErr:
+			t.Fatalf("change feed stopped: %v", err)
+		case <-deadline:
+			t.Fatal("timeout waiting for the change feed to index the object")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-feedErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled after cancel, got %v", err)
+	}
+}
+
+// copyDir copies the contents of directory src to directory dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		byts, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), byts, 0644)
+	})
+}
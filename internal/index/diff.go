@@ -0,0 +1,285 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl/ocflv1"
+)
+
+// ChangeType describes how a logical path differs between two versions
+type ChangeType int
+
+const (
+	Added    ChangeType = iota + 1 // path only exists in the 'to' version
+	Removed                        // path only exists in the 'from' version
+	Modified                       // path exists in both versions with different content
+	Renamed                        // content moved from OldPath to Path
+)
+
+func (c ChangeType) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Renamed:
+		return "renamed"
+	}
+	return "unknown"
+}
+
+// VersionRef identifies an object version in the index. If Version is zero,
+// it refers to the object's head.
+type VersionRef struct {
+	ObjectID string
+	Version  ocfl.VNum
+}
+
+// VersionDiff is a page of changes between two version states
+type VersionDiff struct {
+	Changes    []PathChange
+	NextCursor string
+}
+
+// PathChange is a file that differs between two version states
+type PathChange struct {
+	Type    ChangeType
+	Path    string // logical path in the 'to' version (or the 'from' version, if removed)
+	OldPath string // for renamed files, the logical path in the 'from' version
+	Sum     string // digest in the 'to' version (empty if removed)
+	OldSum  string // digest in the 'from' version (empty if added)
+}
+
+// DiffVersions compares files below the logical path base in two version
+// states, which may belong to different objects. Directories with the same
+// recursive digest in both versions are skipped. A removed file and an added
+// file with the same digest are reported as a rename. Changes are sorted by
+// path; the limit and cursor are used to page through them. Each page
+// requires comparing the entire version states, less any unchanged
+// directories.
+func (idx *Indexer) DiffVersions(ctx context.Context, storageRoot string, from, to VersionRef, base string, limit int, cursor string) (*VersionDiff, error) {
+	if limit < 1 || limit > 1000 {
+		limit = 1000
+	}
+	base = path.Clean(base)
+	d := &differ{Backend: idx.Backend, storageRoot: storageRoot, from: from, to: to}
+	fromInfo, err := idx.GetObjectState(ctx, storageRoot, from.ObjectID, from.Version, base, false, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	toInfo, err := idx.GetObjectState(ctx, storageRoot, to.ObjectID, to.Version, base, false, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	err = d.diffEntry(ctx, base,
+		&PathItem{Sum: fromInfo.Sum, IsDir: fromInfo.IsDir},
+		&PathItem{Sum: toInfo.Sum, IsDir: toInfo.IsDir})
+	if err != nil {
+		return nil, err
+	}
+	changes := d.findRenames()
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	start := sort.Search(len(changes), func(i int) bool { return changes[i].Path > cursor })
+	diff := &VersionDiff{Changes: changes[start:]}
+	if len(diff.Changes) > limit {
+		diff.Changes = diff.Changes[:limit]
+		diff.NextCursor = diff.Changes[limit-1].Path
+	}
+	return diff, nil
+}
+
+// differ accumulates changes between two version states
+type differ struct {
+	Backend
+	storageRoot string
+	from, to    VersionRef
+	added       []PathChange
+	removed     []PathChange
+	modified    []PathChange
+}
+
+// diffEntry compares the path p in the two version states. If a or b is nil,
+// the path doesn't exist in that version.
+func (d *differ) diffEntry(ctx context.Context, p string, a, b *PathItem) error {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a != nil && b != nil && a.IsDir == b.IsDir && a.Sum == b.Sum:
+		// unchanged file or subtree
+		return nil
+	case a != nil && b != nil && a.IsDir && b.IsDir:
+		return d.diffDir(ctx, p)
+	case a != nil && b != nil && !a.IsDir && !b.IsDir:
+		d.modified = append(d.modified, PathChange{Type: Modified, Path: p, Sum: b.Sum, OldSum: a.Sum})
+		return nil
+	}
+	// the path only exists in one version or it is a file in one version and
+	// a directory in the other.
+	if a != nil {
+		files, err := d.files(ctx, d.from, p, a)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			d.removed = append(d.removed, PathChange{Type: Removed, Path: f.Name, OldSum: f.Sum})
+		}
+	}
+	if b != nil {
+		files, err := d.files(ctx, d.to, p, b)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			d.added = append(d.added, PathChange{Type: Added, Path: f.Name, Sum: f.Sum})
+		}
+	}
+	return nil
+}
+
+// diffDir compares the children of directory p in the two version states.
+func (d *differ) diffDir(ctx context.Context, p string) error {
+	fromChildren, err := d.children(ctx, d.from, p)
+	if err != nil {
+		return err
+	}
+	toChildren, err := d.children(ctx, d.to, p)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fromChildren)+len(toChildren))
+	for n := range fromChildren {
+		names = append(names, n)
+	}
+	for n := range toChildren {
+		if _, ok := fromChildren[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := d.diffEntry(ctx, path.Join(p, n), fromChildren[n], toChildren[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// children returns the immediate children of directory p in the version
+func (d *differ) children(ctx context.Context, ref VersionRef, p string) (map[string]*PathItem, error) {
+	children := map[string]*PathItem{}
+	cursor := ""
+	for {
+		info, err := d.GetObjectState(ctx, d.storageRoot, ref.ObjectID, ref.Version, p, false, 0, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for i := range info.Children {
+			children[info.Children[i].Name] = &info.Children[i]
+		}
+		if info.NextCursor == "" {
+			return children, nil
+		}
+		cursor = info.NextCursor
+	}
+}
+
+// files returns item, with its logical path p, if it is a file. If item is a
+// directory, all files below it are returned with their logical paths.
+func (d *differ) files(ctx context.Context, ref VersionRef, p string, item *PathItem) ([]PathItem, error) {
+	if !item.IsDir {
+		return []PathItem{{Name: p, Sum: item.Sum}}, nil
+	}
+	var files []PathItem
+	cursor := ""
+	for {
+		info, err := d.GetObjectState(ctx, d.storageRoot, ref.ObjectID, ref.Version, p, true, 0, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range info.Children {
+			f.Name = path.Join(p, f.Name)
+			files = append(files, f)
+		}
+		if info.NextCursor == "" {
+			return files, nil
+		}
+		cursor = info.NextCursor
+	}
+}
+
+// findRenames returns all changes, with removed and added files that have the
+// same digest combined as renames.
+func (d *differ) findRenames() []PathChange {
+	removedBySum := map[string][]int{}
+	for i, r := range d.removed {
+		removedBySum[r.OldSum] = append(removedBySum[r.OldSum], i)
+	}
+	renamed := make([]bool, len(d.removed))
+	changes := make([]PathChange, 0, len(d.added)+len(d.removed)+len(d.modified))
+	changes = append(changes, d.modified...)
+	for _, a := range d.added {
+		if idxs := removedBySum[a.Sum]; len(idxs) > 0 {
+			r := d.removed[idxs[0]]
+			removedBySum[a.Sum] = idxs[1:]
+			renamed[idxs[0]] = true
+			a.Type = Renamed
+			a.OldPath = r.Path
+			a.OldSum = r.OldSum
+		}
+		changes = append(changes, a)
+	}
+	for i, r := range d.removed {
+		if !renamed[i] {
+			changes = append(changes, r)
+		}
+	}
+	return changes
+}
+
+// VersionStats are file counts for a version state and its changes from the
+// previous version. Unlike DiffVersions, renamed files are counted as both
+// added and removed.
+type VersionStats struct {
+	Files    int // files in the version state
+	Added    int // paths that aren't in the previous version
+	Removed  int // paths in the previous version that aren't in this one
+	Modified int // paths in both versions with different content
+}
+
+// InventoryVersionStats returns VersionStats for each version in the
+// inventory.
+func InventoryVersionStats(inv *ocflv1.Inventory) (map[ocfl.VNum]VersionStats, error) {
+	stats := make(map[ocfl.VNum]VersionStats, len(inv.Versions))
+	var prev map[string]string
+	for _, vnum := range inv.Head.Lineage() {
+		ver := inv.Versions[vnum]
+		if ver == nil || ver.State == nil {
+			return nil, fmt.Errorf("inventory is missing version state for '%s'", vnum)
+		}
+		state := ver.State.AllPaths()
+		vs := VersionStats{Files: len(state)}
+		for p, sum := range state {
+			prevSum, exists := prev[p]
+			switch {
+			case !exists:
+				vs.Added++
+			case prevSum != sum:
+				vs.Modified++
+			}
+		}
+		for p := range prev {
+			if _, exists := state[p]; !exists {
+				vs.Removed++
+			}
+		}
+		stats[vnum] = vs
+		prev = state
+	}
+	return stats, nil
+}
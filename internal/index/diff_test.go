@@ -0,0 +1,138 @@
+package index_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/mock"
+)
+
+// stateLogger records paths requested with GetObjectState
+type stateLogger struct {
+	index.Backend
+	paths []string
+}
+
+func (b *stateLogger) GetObjectState(ctx context.Context, storageRoot string, objectID string, vnum ocfl.VNum, base string, recursive bool, limit int, cursor string) (*index.PathInfo, error) {
+	b.paths = append(b.paths, base)
+	return b.Backend.GetObjectState(ctx, storageRoot, objectID, vnum, base, recursive, limit, cursor)
+}
+
+func TestDiffVersions(t *testing.T) {
+	ctx := context.Background()
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dir is the same in both objects and all versions
+	mock1 := mock.NewIndexingObject("object-1", mock.WithHead(ocfl.V(2)), mock.BigDir("dir", 3))
+	mock2 := mock.NewIndexingObject("object-2", mock.BigDir("dir", 3))
+	tx, err := idx.NewTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, m := range []*mock.IndexingObject{mock1, mock2} {
+		err := tx.IndexObjectInventory(ctx, index.DefaultStorageRoot, m.IndexedAt, index.ObjectInventory{
+			Inventory: m.Inventory,
+			Path:      m.RootDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	logger := &stateLogger{Backend: idx.Backend}
+	idx = &index.Indexer{Backend: logger}
+	diff := func(t *testing.T, from, to index.VersionRef, base string) []string {
+		t.Helper()
+		result, err := idx.DiffVersions(ctx, index.DefaultStorageRoot, from, to, base, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "next cursor", result.NextCursor, "")
+		return changeStrings(result.Changes)
+	}
+	v1 := index.VersionRef{ObjectID: "object-1", Version: ocfl.V(1)}
+	v2 := index.VersionRef{ObjectID: "object-1", Version: ocfl.V(2)}
+	t.Run("versions", func(t *testing.T) {
+		logger.paths = nil
+		expEq(t, "changes", diff(t, v1, v2, ""), []string{
+			"modified change.txt",
+			"removed v1-new.txt",
+			"added v2-new.txt",
+			"renamed v1-rename.txt v2-rename.txt",
+		})
+		for _, p := range logger.paths {
+			if p == "dir" {
+				t.Fatal("unchanged directory was listed")
+			}
+		}
+		expEq(t, "reverse changes", diff(t, v2, v1, "."), []string{
+			"modified change.txt",
+			"added v1-new.txt",
+			"renamed v2-rename.txt v1-rename.txt",
+			"removed v2-new.txt",
+		})
+		expEq(t, "unchanged", len(diff(t, v2, v2, "")), 0)
+		expEq(t, "head version", len(diff(t, v2, index.VersionRef{ObjectID: "object-1"}, "")), 0)
+	})
+	t.Run("file", func(t *testing.T) {
+		expEq(t, "changes", diff(t, v1, v2, "change.txt"), []string{"modified change.txt"})
+		expEq(t, "changes", len(diff(t, v1, v2, "common.txt")), 0)
+	})
+	t.Run("objects", func(t *testing.T) {
+		other := index.VersionRef{ObjectID: "object-2", Version: ocfl.V(1)}
+		expEq(t, "changes", len(diff(t, v1, other, "dir")), 0)
+		// file content is unique to each object
+		expEq(t, "changes", diff(t, v1, other, ""), []string{
+			"modified change.txt",
+			"modified common.txt",
+			"modified v1-new.txt",
+			"modified v1-rename.txt",
+		})
+	})
+	t.Run("pagination", func(t *testing.T) {
+		var all []index.PathChange
+		cursor := ""
+		for {
+			result, err := idx.DiffVersions(ctx, index.DefaultStorageRoot, v1, v2, "", 3, cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, result.Changes...)
+			if result.NextCursor == "" {
+				break
+			}
+			cursor = result.NextCursor
+		}
+		expEq(t, "changes", changeStrings(all), diff(t, v1, v2, ""))
+	})
+	t.Run("missing", func(t *testing.T) {
+		_, err := idx.DiffVersions(ctx, index.DefaultStorageRoot, v1, index.VersionRef{ObjectID: "missing"}, "", 0, "")
+		if err == nil {
+			t.Fatal("expected an error for missing object")
+		}
+		_, err = idx.DiffVersions(ctx, index.DefaultStorageRoot, v1, v2, "missing", 0, "")
+		if err == nil {
+			t.Fatal("expected an error for missing path")
+		}
+	})
+}
+
+// changeStrings returns strings for changes with the form "{type} {path}" or,
+// for renames, "{type} {old_path} {path}".
+func changeStrings(changes []index.PathChange) []string {
+	strs := make([]string, len(changes))
+	for i, c := range changes {
+		strs[i] = c.Type.String() + " " + c.Path
+		if c.Type == index.Renamed {
+			strs[i] = c.Type.String() + " " + c.OldPath + " " + c.Path
+		}
+	}
+	return strs
+}
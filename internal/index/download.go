@@ -0,0 +1,277 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl/backend/cloud"
+)
+
+const (
+	downloadPrefix = "/download" // content files by digest
+	objectsPrefix  = "/objects"  // files by object version and logical path
+)
+
+// downloadHandler serves content files by digest. The digest algorithm
+// (e.g., "sha512") is part of the request path so that digests from different
+// algorithms can't collide. The digest is used as the file's ETag, so
+// conditional requests with If-None-Match are supported. Range requests are
+// supported if the storage root's files are seekable or if it is a cloud
+// storage bucket. Query parameters:
+//
+//   - inline: if "true", the file is served for display in the browser
+//     rather than as an attachment.
+//   - root: storage root name
+func (srv Service) downloadHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		alg := chi.URLParam(r, "alg")
+		sum := chi.URLParam(r, "sum")
+		if alg == "" || sum == "" {
+			http.NotFound(w, r)
+			return
+		}
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			name = sum
+		}
+		// storage root name is an optional query parameter
+		root, err := srv.storageRoot(r.URL.Query().Get("root"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		content, err := srv.Indexer.GetContentPath(ctx, root.Name, alg, sum)
+		if err != nil {
+			srv.httpError(w, r, err, "download", "sum", sum)
+			return
+		}
+		srv.serveContent(w, r, root, content, sum, name)
+	}
+}
+
+// filesHandler serves a file by its logical path in an object version. The
+// path is resolved through the version state, and content is only read from
+// the object's own content files. Object IDs in the request path must be
+// path-escaped and the version may be "head". Query parameters are the same
+// as for downloadHandler.
+func (srv Service) filesHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		objectID, err := pathParam(r, "object_id")
+		if err != nil || objectID == "" {
+			http.NotFound(w, r)
+			return
+		}
+		version, err := pathParam(r, "version")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		logical, err := pathParam(r, "*")
+		if err != nil || logical == "" {
+			http.NotFound(w, r)
+			return
+		}
+		var vnum ocfl.VNum
+		if version != "head" {
+			if err := ocfl.ParseVNum(version, &vnum); err != nil {
+				http.Error(w, fmt.Sprintf("invalid version: '%s'", version), http.StatusBadRequest)
+				return
+			}
+		}
+		root, err := srv.storageRoot(r.URL.Query().Get("root"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		state, err := srv.Indexer.GetObjectState(ctx, root.Name, objectID, vnum, logical, false, 1, "")
+		if err != nil {
+			srv.httpError(w, r, err, "download", "object_id", objectID, "path", logical)
+			return
+		}
+		if state.IsDir {
+			http.Error(w, fmt.Sprintf("'%s' is a directory", logical), http.StatusBadRequest)
+			return
+		}
+		content, err := srv.Indexer.GetObjectContentPath(ctx, root.Name, objectID, state.Sum)
+		if err != nil {
+			srv.httpError(w, r, err, "download", "object_id", objectID, "sum", state.Sum)
+			return
+		}
+		srv.serveContent(w, r, root, content, state.Sum, path.Base(logical))
+	}
+}
+
+// serveContent writes the content file to w. The file's digest, sum, is used
+// as its ETag and name is used for the Content-Disposition header and to
+// determine the content type.
+func (srv Service) serveContent(w http.ResponseWriter, r *http.Request, root *StorageRoot, content *ContentPath, sum string, name string) {
+	f, err := openContent(r.Context(), root, content)
+	if err != nil {
+		// storage errors may include paths and other details that
+		// shouldn't be sent to the client.
+		srv.Log.Error("download", "sum", sum, "path", content.Path, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "true" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	// content is addressed by its digest, so the digest is a strong ETag
+	w.Header().Set("ETag", `"`+sum+`"`)
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	if seeker, ok := f.(io.ReadSeeker); ok {
+		// ServeContent handles range requests, conditional requests, and
+		// content type sniffing.
+		http.ServeContent(w, r, name, time.Time{}, seeker)
+		return
+	}
+	// the file isn't seekable: range requests aren't supported.
+	w.Header().Set("Accept-Ranges", "none")
+	if etagMatch(r.Header.Get("If-None-Match"), w.Header().Get("ETag")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	reader := bufio.NewReaderSize(f, 512)
+	if w.Header().Get("Content-Type") == "" {
+		head, _ := reader.Peek(512)
+		w.Header().Set("Content-Type", http.DetectContentType(head))
+	}
+	if content.HasSize {
+		w.Header().Set("Content-Length", strconv.FormatInt(content.Size, 10))
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err = io.Copy(w, reader); err != nil {
+		// headers have been sent: abort the response so the client
+		// doesn't receive a truncated file as if it were complete.
+		srv.Log.Error("download", "sum", sum, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// openContent opens the content file for reading. The returned file is an
+// io.ReadSeeker if seeking is supported: either the file from the storage
+// root's FS can seek or, for cloud storage roots, reads after seeking use
+// ranged reads from the bucket.
+func openContent(ctx context.Context, root *StorageRoot, content *ContentPath) (io.ReadCloser, error) {
+	name := path.Join(root.Path, content.Path)
+	if fsys, ok := root.FS.(*cloud.FS); ok {
+		size := content.Size
+		if !content.HasSize {
+			attrs, err := fsys.Bucket.Attributes(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			size = attrs.Size
+		}
+		return &rangeReader{ctx: ctx, fsys: fsys, key: name, size: size}, nil
+	}
+	f, err := root.FS.OpenFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if seeker, ok := f.(io.ReadSeeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err == nil {
+			return struct {
+				io.ReadSeeker
+				io.Closer
+			}{seeker, f}, nil
+		}
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{f, f}, nil
+}
+
+// rangeReader is an io.ReadSeekCloser for a file in a cloud storage bucket.
+// Reads after a seek use a new ranged reader starting at the offset.
+type rangeReader struct {
+	ctx    context.Context
+	fsys   *cloud.FS
+	key    string
+	size   int64
+	offset int64
+	r      io.ReadCloser // reader at offset (nil after a seek)
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.offset >= rr.size {
+		return 0, io.EOF
+	}
+	if rr.r == nil {
+		r, err := rr.fsys.Bucket.NewRangeReader(rr.ctx, rr.key, rr.offset, -1, nil)
+		if err != nil {
+			return 0, err
+		}
+		rr.r = r
+	}
+	n, err := rr.r.Read(p)
+	rr.offset += int64(n)
+	return n, err
+}
+
+func (rr *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += rr.offset
+	case io.SeekEnd:
+		offset += rr.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+	if offset != rr.offset && rr.r != nil {
+		rr.r.Close()
+		rr.r = nil
+	}
+	rr.offset = offset
+	return offset, nil
+}
+
+func (rr *rangeReader) Close() error {
+	if rr.r == nil {
+		return nil
+	}
+	return rr.r.Close()
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag.
+func etagMatch(ifNoneMatch string, etag string) bool {
+	for _, val := range splitHeader(ifNoneMatch) {
+		if val == "*" || val == etag || val == "W/"+etag {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHeader splits a comma-separated header value
+func splitHeader(val string) []string {
+	var vals []string
+	for _, v := range strings.Split(val, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
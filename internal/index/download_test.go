@@ -0,0 +1,217 @@
+package index_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/srerickson/ocfl"
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
+	"github.com/srerickson/ocfl-index/internal/index"
+)
+
+func TestServiceDownload(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := httptest.NewTLSServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	cli := ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL)
+	state, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{
+		ObjectId:  "ark:/12345/bcd987",
+		Recursive: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum string
+	for _, child := range state.Msg.Children {
+		if child.Name == "foo/bar.xml" {
+			sum = child.Digest
+		}
+	}
+	if sum == "" {
+		t.Fatal("expected foo/bar.xml in the version state")
+	}
+	do := func(method string, p string, header http.Header) (*http.Response, []byte) {
+		t.Helper()
+		rq, err := http.NewRequest(method, httpSrv.URL+p, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			rq.Header[k] = v
+		}
+		rsp, err := httpSrv.Client().Do(rq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		body, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rsp, body
+	}
+	var content []byte
+	t.Run("full", func(t *testing.T) {
+		var rsp *http.Response
+		rsp, content = do(http.MethodGet, "/download/sha512/"+sum+"/file.txt", nil)
+		expEq(t, "status", rsp.StatusCode, http.StatusOK)
+		got := sha512.Sum512(content)
+		expEq(t, "content digest", hex.EncodeToString(got[:]), sum)
+		expEq(t, "etag", rsp.Header.Get("ETag"), `"`+sum+`"`)
+		expEq(t, "content length", rsp.Header.Get("Content-Length"), strconv.Itoa(len(content)))
+		expEq(t, "content type", rsp.Header.Get("Content-Type"), "text/plain; charset=utf-8")
+		expEq(t, "content disposition", rsp.Header.Get("Content-Disposition"), `attachment; filename=file.txt`)
+		expEq(t, "accept ranges", rsp.Header.Get("Accept-Ranges"), "bytes")
+	})
+	if len(content) < 4 {
+		t.Fatal("expected longer content")
+	}
+	t.Run("range", func(t *testing.T) {
+		rsp, body := do(http.MethodGet, "/download/sha512/"+sum, http.Header{"Range": {"bytes=1-3"}})
+		expEq(t, "status", rsp.StatusCode, http.StatusPartialContent)
+		expEq(t, "partial content", body, content[1:4])
+		expEq(t, "content range", rsp.Header.Get("Content-Range"), "bytes 1-3/"+strconv.Itoa(len(content)))
+	})
+	t.Run("if-none-match", func(t *testing.T) {
+		rsp, body := do(http.MethodGet, "/download/sha512/"+sum, http.Header{"If-None-Match": {`"` + sum + `"`}})
+		expEq(t, "status", rsp.StatusCode, http.StatusNotModified)
+		expEq(t, "body length", len(body), 0)
+		rsp, _ = do(http.MethodGet, "/download/sha512/"+sum, http.Header{"If-None-Match": {`"other"`}})
+		expEq(t, "status for other etag", rsp.StatusCode, http.StatusOK)
+	})
+	t.Run("inline", func(t *testing.T) {
+		rsp, _ := do(http.MethodGet, "/download/sha512/"+sum+"/file.txt?inline=true", nil)
+		expEq(t, "content disposition", rsp.Header.Get("Content-Disposition"), `inline; filename=file.txt`)
+	})
+	t.Run("head", func(t *testing.T) {
+		rsp, body := do(http.MethodHead, "/download/sha512/"+sum, nil)
+		expEq(t, "status", rsp.StatusCode, http.StatusOK)
+		expEq(t, "content length", rsp.Header.Get("Content-Length"), strconv.Itoa(len(content)))
+		expEq(t, "body length", len(body), 0)
+	})
+	t.Run("missing", func(t *testing.T) {
+		rsp, _ := do(http.MethodGet, "/download/sha512/abcd", nil)
+		expEq(t, "status", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = do(http.MethodGet, "/download/sha256/"+sum, nil)
+		expEq(t, "status for other algorithm", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = do(http.MethodGet, "/download/sha512/not-hex", nil)
+		expEq(t, "status for invalid digest", rsp.StatusCode, http.StatusBadRequest)
+	})
+	t.Run("files", func(t *testing.T) {
+		objPath := "/objects/" + url.PathEscape("ark:/12345/bcd987")
+		rsp, body := do(http.MethodGet, objPath+"/versions/head/files/foo/bar.xml", nil)
+		expEq(t, "status", rsp.StatusCode, http.StatusOK)
+		expEq(t, "content", body, content)
+		expEq(t, "etag", rsp.Header.Get("ETag"), `"`+sum+`"`)
+		expEq(t, "content type", rsp.Header.Get("Content-Type"), "text/xml; charset=utf-8")
+		expEq(t, "content disposition", rsp.Header.Get("Content-Disposition"), `attachment; filename=bar.xml`)
+		rsp, body = do(http.MethodGet, objPath+"/versions/v2/files/foo/bar.xml", http.Header{"Range": {"bytes=1-3"}})
+		expEq(t, "range status", rsp.StatusCode, http.StatusPartialContent)
+		expEq(t, "partial content", body, content[1:4])
+		rsp, _ = do(http.MethodGet, objPath+"/versions/head/files/foo", nil)
+		expEq(t, "directory status", rsp.StatusCode, http.StatusBadRequest)
+		rsp, _ = do(http.MethodGet, objPath+"/versions/head/files/missing.txt", nil)
+		expEq(t, "missing file status", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = do(http.MethodGet, objPath+"/versions/v9/files/foo/bar.xml", nil)
+		expEq(t, "missing version status", rsp.StatusCode, http.StatusNotFound)
+		rsp, _ = do(http.MethodGet, "/objects/missing/versions/head/files/foo/bar.xml", nil)
+		expEq(t, "missing object status", rsp.StatusCode, http.StatusNotFound)
+	})
+}
+
+// contentPathErrors is a Backend that fails to look up content paths.
+type contentPathErrors struct {
+	index.Backend
+}
+
+func (contentPathErrors) GetContentPath(context.Context, string, string, string) (*index.ContentPath, error) {
+	return nil, errors.New("database is closed")
+}
+
+func (contentPathErrors) GetObjectContentPath(context.Context, string, string, string) (*index.ContentPath, error) {
+	return nil, errors.New("database is closed")
+}
+
+// objectStateErrors is a Backend that fails to get object version states.
+type objectStateErrors struct {
+	index.Backend
+}
+
+func (objectStateErrors) GetObjectState(context.Context, string, string, ocfl.VNum, string, bool, int, string) (*index.PathInfo, error) {
+	return nil, errors.New("database is closed")
+}
+
+func TestServiceDownloadErrors(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const objPath = "ark%3A%2F12345%2Fbcd987"
+	state, err := service.Indexer.GetObjectState(ctx, index.DefaultStorageRoot, "ark:/12345/bcd987", ocfl.VNum{}, "foo/bar.xml", false, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloadPath := "/download/sha512/" + state.Sum
+	filesPath := "/objects/" + url.PathEscape("ark:/12345/bcd987") + "/versions/head/files/foo/bar.xml"
+	get := func(handler http.Handler, p string) (*http.Response, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		return rec.Result(), rec.Body.String()
+	}
+	t.Run("index error", func(t *testing.T) {
+		failing := *service
+		failing.Indexer = &index.Indexer{Backend: contentPathErrors{service.Indexer.Backend}}
+		handler := failing.HTTPHandler()
+		for _, p := range []string{downloadPath, filesPath} {
+			rsp, body := get(handler, p)
+			expEq(t, "status for "+p, rsp.StatusCode, http.StatusInternalServerError)
+			if strings.Contains(body, "database") {
+				t.Fatalf("response body includes the index error: %q", body)
+			}
+		}
+	})
+	t.Run("object state error", func(t *testing.T) {
+		failing := *service
+		failing.Indexer = &index.Indexer{Backend: objectStateErrors{service.Indexer.Backend}}
+		rsp, body := get(failing.HTTPHandler(), filesPath)
+		expEq(t, "status", rsp.StatusCode, http.StatusInternalServerError)
+		if strings.Contains(body, "database") {
+			t.Fatalf("response body includes the index error: %q", body)
+		}
+	})
+	t.Run("storage error", func(t *testing.T) {
+		failing := *service
+		failing.Roots = []index.StorageRoot{service.Roots[0]}
+		failing.Roots[0].FS = &brokenContent{
+			FS:     service.Roots[0].FS,
+			broken: map[string]bool{path.Join("simple-root", objPath): true},
+		}
+		handler := failing.HTTPHandler()
+		for _, p := range []string{downloadPath, filesPath} {
+			rsp, body := get(handler, p)
+			expEq(t, "status for "+p, rsp.StatusCode, http.StatusInternalServerError)
+			if strings.Contains(body, "permission") || strings.Contains(body, "content") {
+				t.Fatalf("response body includes the storage error: %q", body)
+			}
+			expEq(t, "content disposition for "+p, rsp.Header.Get("Content-Disposition"), "")
+		}
+	})
+}
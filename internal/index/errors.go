@@ -0,0 +1,95 @@
+package index
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bufbuild/connect-go"
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+// errorCodes maps index errors to connect error codes
+var errorCodes = []struct {
+	err  error
+	code connect.Code
+}{
+	{ErrNotFound, connect.CodeNotFound},
+	{ErrInvalidArgs, connect.CodeInvalidArgument},
+	{ErrIndexValue, connect.CodeDataLoss},
+	{ErrTaskDone, connect.CodeFailedPrecondition},
+}
+
+// connectError returns err as a connect error with a code corresponding to
+// the index error it wraps and an api.ErrorDetail with values from the
+// request message, req (which may be nil). If err is already a connect error
+// or doesn't wrap a known index error, it is returned unchanged.
+func connectError(err error, req any) error {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return err
+	}
+	for _, e := range errorCodes {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		connectErr = connect.NewError(e.code, err)
+		if detail, err := connect.NewErrorDetail(errorDetail(e.err, req)); err == nil {
+			connectErr.AddDetail(detail)
+		}
+		return connectErr
+	}
+	return err
+}
+
+// errorDetail returns an api.ErrorDetail for the index error, reason, using
+// values from fields in the request message, if they exist.
+func errorDetail(reason error, req any) *api.ErrorDetail {
+	detail := &api.ErrorDetail{Reason: reason.Error()}
+	if r, ok := req.(interface{ GetStorageRoot() string }); ok {
+		detail.StorageRoot = r.GetStorageRoot()
+	}
+	if r, ok := req.(interface{ GetObjectId() string }); ok {
+		detail.ObjectId = r.GetObjectId()
+	}
+	if r, ok := req.(interface{ GetVersion() string }); ok {
+		detail.Version = r.GetVersion()
+	}
+	if r, ok := req.(interface{ GetBasePath() string }); ok {
+		detail.Path = r.GetBasePath()
+	}
+	if r, ok := req.(interface{ GetDigest() string }); ok {
+		detail.Digest = r.GetDigest()
+	}
+	return detail
+}
+
+// errorInterceptor converts errors returned by the service's handlers with
+// connectError. Request messages for streaming handlers aren't available to
+// the interceptor, so streaming handlers that want request values in error
+// details call connectError themselves.
+type errorInterceptor struct{}
+
+var _ connect.Interceptor = errorInterceptor{}
+
+func (errorInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil {
+			return nil, connectError(err, req.Any())
+		}
+		return resp, nil
+	}
+}
+
+func (errorInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (errorInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := next(ctx, conn); err != nil {
+			return connectError(err, nil)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,260 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/pipeline"
+	"github.com/srerickson/ocfl/logging"
+	"github.com/srerickson/ocfl/ocflv1"
+	"github.com/srerickson/ocfl/validation"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+const defaultValidationBatch = 1000 // objects validated per storage root in scheduled tasks
+
+// FixityCheck is the result of a full validation of an object, including its
+// content digests.
+type FixityCheck struct {
+	RootPath  string    // object path relative to the storage root
+	Valid     bool      // the object passed validation
+	Codes     []string  // OCFL validation codes for errors found, if known
+	Error     string    // the first validation error, if the object isn't valid
+	CheckedAt time.Time // when the object was validated (zero if it hasn't been)
+}
+
+// newFixityCheck returns a FixityCheck for the object at root with the
+// validation result.
+func newFixityCheck(root string, result *validation.Result, checkedAt time.Time) FixityCheck {
+	check := FixityCheck{RootPath: root, Valid: true, CheckedAt: checkedAt}
+	if err := result.Err(); err != nil {
+		check.Valid = false
+		check.Error = err.Error()
+	}
+	for _, err := range result.Errors() {
+		if code := validationCodeRE.FindString(err.Error()); code != "" && !slices.Contains(check.Codes, code) {
+			check.Codes = append(check.Codes, code)
+		}
+	}
+	return check
+}
+
+type ValidateOptions struct {
+	FS          ocfl.FS // storage root fs
+	RootPath    string  // storage root directory
+	StorageRoot string  // storage root name in the index (default: DefaultStorageRoot)
+	Conc        int     // number of objects validated concurrently
+	Log         *slog.Logger
+	ObjectPaths []string       // validate specific object root paths only
+	Oldest      int            // if ObjectPaths isn't set, validate this many object roots, least recently validated first (0: all)
+	Counts      TaskCounts     // if set, counts of validated and invalid objects are added to it
+	Progress    func(Progress) // if set, called with progress updates
+}
+
+// ValidateObjects runs full validation, including content digests, for
+// objects in the storage root and records the results in the index (see
+// FixityCheck). Objects are validated concurrently in a pipeline. Without
+// ObjectPaths or Oldest, all indexed object roots are validated.
+func (idx *Indexer) ValidateObjects(ctx context.Context, opts *ValidateOptions) (err error) {
+	if opts.Log == nil {
+		opts.Log = logging.DisabledLogger()
+	}
+	if opts.StorageRoot == "" {
+		opts.StorageRoot = DefaultStorageRoot
+	}
+	progress := newProgress(opts.Progress, opts.StorageRoot, PhaseValidateObjects)
+	var paths []string // objects to validate, if not validating all
+	switch {
+	case len(opts.ObjectPaths) > 0:
+		paths = opts.ObjectPaths
+	case opts.Oldest > 0:
+		due, err := idx.ListFixityDue(ctx, opts.StorageRoot, opts.Oldest)
+		if err != nil {
+			return err
+		}
+		paths = make([]string, len(due))
+		for i := range due {
+			paths[i] = due[i].RootPath
+		}
+	default:
+		summ, err := idx.GetIndexSummary(ctx, opts.StorageRoot)
+		if err != nil {
+			return err
+		}
+		progress.Found = int64(summ.NumObjects)
+	}
+	if paths != nil {
+		progress.Found = int64(len(paths))
+	}
+	progress.report(true)
+	opts.Log.Info("validating objects ...", "storage_root", opts.StorageRoot, "objects", progress.Found, "workers", opts.Conc)
+	addPaths := func(add func(string) bool) error {
+		if paths != nil {
+			for _, p := range paths {
+				if !add(p) {
+					break
+				}
+			}
+			return nil
+		}
+		cursor := ""
+		for {
+			list, err := idx.ListObjectRoots(ctx, opts.StorageRoot, 0, cursor)
+			if err != nil {
+				return err
+			}
+			for _, r := range list.ObjectRoots {
+				if !add(r.Path) {
+					return nil
+				}
+			}
+			if cursor = list.NextCursor; cursor == "" {
+				return nil
+			}
+		}
+	}
+	// validate function (run in multiple go routines)
+	validate := func(objPath string) (FixityCheck, error) {
+		result := ocflv1.ValidateObject(ctx, opts.FS, path.Join(opts.RootPath, objPath))
+		return newFixityCheck(objPath, result, time.Now()), nil
+	}
+	// record function (single go routine): results are saved in batches.
+	var tx BackendTx
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+	numValidated := 0
+	numInvalid := 0
+	defer func() {
+		opts.Counts.add("validated", numValidated)
+		opts.Counts.add("invalid", numInvalid)
+	}()
+	record := func(objPath string, check FixityCheck, err error) error {
+		if err != nil {
+			return fmt.Errorf("in object '%s': %w", objPath, err)
+		}
+		if err := ctx.Err(); err != nil {
+			// validation may have failed because it was canceled
+			return err
+		}
+		if tx == nil {
+			if tx, err = idx.NewTx(ctx); err != nil {
+				return err
+			}
+		}
+		if err := tx.SetFixityCheck(ctx, opts.StorageRoot, check); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				opts.Log.Warn("object root isn't indexed", "object_path", objPath)
+				return nil
+			}
+			return err
+		}
+		numValidated++
+		progress.Processed++
+		if !check.Valid {
+			numInvalid++
+			progress.Errors++
+			opts.Log.Error("object is invalid", "object_path", objPath, "codes", strings.Join(check.Codes, " "), "err", check.Error)
+		}
+		progress.report(false)
+		if numValidated%txCapInv == 0 {
+			err := tx.Commit()
+			tx = nil
+			if err != nil {
+				return err
+			}
+			// queued targeted tasks can run between batches
+			YieldTasks(ctx)
+		}
+		return nil
+	}
+	if err := pipeline.Run(addPaths, validate, record, opts.Conc); err != nil {
+		return fmt.Errorf("validation halted prematurely: %w", err)
+	}
+	if tx != nil {
+		err := tx.Commit()
+		tx = nil
+		if err != nil {
+			return err
+		}
+	}
+	progress.report(true)
+	opts.Log.Info("validation complete", "storage_root", opts.StorageRoot, "validated", numValidated, "invalid", numInvalid)
+	return nil
+}
+
+// ValidationSchedule periodically queues a task that validates the least
+// recently validated objects in each storage root.
+type ValidationSchedule struct {
+	Service *Service
+	Every   time.Duration // time between validation tasks
+	Batch   int           // objects validated in each storage root per task (default: 1000)
+}
+
+// Run queues validation tasks until ctx is canceled. A new task isn't queued
+// until the previous one has finished.
+func (sched *ValidationSchedule) Run(ctx context.Context) error {
+	if sched.Every <= 0 {
+		return fmt.Errorf("validation schedule interval must be positive: %w", ErrInvalidArgs)
+	}
+	batch := sched.Batch
+	if batch <= 0 {
+		batch = defaultValidationBatch
+	}
+	ticker := time.NewTicker(sched.Every)
+	defer ticker.Stop()
+	var pending chan error // the last scheduled task's error channel
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if pending != nil {
+				select {
+				case <-pending:
+					pending = nil
+				default:
+					sched.Service.Log.Info("skipping scheduled validation: the previous task hasn't finished")
+					continue
+				}
+			}
+			task := &Task{
+				Name:    "validating",
+				Options: map[string]string{"oldest": fmt.Sprint(batch)},
+			}
+			errCh, err := sched.Service.Async.Add(ctx, task, sched.task(batch))
+			if err != nil {
+				sched.Service.Log.Warn("can't start scheduled validation task", "err", err)
+				continue
+			}
+			pending = errCh
+		}
+	}
+}
+
+// task returns a task that validates the least recently validated objects in
+// each storage root.
+func (sched *ValidationSchedule) task(batch int) taskFn {
+	return func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		var errs []error
+		for i := range sched.Service.Roots {
+			opts := sched.Service.validateOptions(&sched.Service.Roots[i], w)
+			opts.Oldest = batch
+			opts.Counts = counts
+			if err := sched.Service.Indexer.ValidateObjects(ctx, opts); err != nil {
+				opts.Log.Error("scheduled validation failed", "storage_root", opts.StorageRoot, "err", err)
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
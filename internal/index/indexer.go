@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"path"
 	"strings"
 	"time"
@@ -26,18 +27,32 @@ type Indexer struct {
 type IndexOptions struct {
 	FS          ocfl.FS // storage root fs
 	RootPath    string  // storage root directory
-	ScanConc    int     // concurrency for readdir-based object scanning
+	StorageRoot string  // storage root name in the index (default: DefaultStorageRoot)
+	ScanConc    int     // concurrency for readdir-based object scanning and file stats
 	ParseConc   int     // concurrency for inventory parsers
 	Log         *slog.Logger
-	ObjectIDs   []string // index specific object ids only
-	ObjectPaths []string // index specific object root paths only
+	ObjectIDs   []string       // index specific object ids only
+	ObjectPaths []string       // index specific object root paths only
+	FileSizes   bool           // index content file sizes (requires stat for each content file)
+	Force       bool           // reindex (and revalidate) objects even if their inventories are unchanged
+	Counts      TaskCounts     // if set, counts of object roots and indexed, skipped, and invalid objects are added to it
+	Progress    func(Progress) // if set, called with progress updates for each indexing phase
 }
 
-// Index updates the index database
-func (idx *Indexer) Index(ctx context.Context, opts *IndexOptions) error {
+// Index updates the index database. Changes are committed in batches. If ctx
+// is canceled, indexing stops and the current batch is rolled back.
+func (idx *Indexer) Index(ctx context.Context, opts *IndexOptions) (err error) {
 	if opts.Log == nil {
 		opts.Log = logging.DisabledLogger()
 	}
+	if opts.StorageRoot == "" {
+		opts.StorageRoot = DefaultStorageRoot
+	}
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			opts.Log.Warn("indexing canceled", "storage_root", opts.StorageRoot, "err", ctx.Err())
+		}
+	}()
 	if len(opts.ObjectPaths)+len(opts.ObjectIDs) == 0 {
 		// reindex everything
 		if err := idx.syncObjectRoots(ctx, opts); err != nil {
@@ -56,12 +71,14 @@ func (idx *Indexer) Index(ctx context.Context, opts *IndexOptions) error {
 func (idx *Indexer) syncObjectRoots(ctx context.Context, opts *IndexOptions) error {
 	count := 0
 	var err error
-	opts.Log.Info("updating object paths from storage root. This may take a while ...", "root", opts.RootPath)
+	opts.Log.Info("updating object paths from storage root. This may take a while ...", "root", opts.RootPath, "storage_root", opts.StorageRoot)
 	defer func() {
 		opts.Log.Info("object path update complete", "object_roots", count, "root", opts.RootPath)
 	}()
 	startSync := time.Now()
-	count, err = syncObjecRootsTX(ctx, idx.Backend, opts.FS, opts.RootPath, opts.Log)
+	scanProgress := newProgress(opts.Progress, opts.StorageRoot, PhaseScanRoots)
+	count, err = syncObjecRootsTX(ctx, idx.Backend, opts.StorageRoot, opts.FS, opts.RootPath, opts.Log, scanProgress)
+	opts.Counts.add("object_roots", count)
 	if err != nil {
 		return err
 	}
@@ -71,33 +88,38 @@ func (idx *Indexer) syncObjectRoots(ctx context.Context, opts *IndexOptions) err
 	}
 	defer tx.Rollback()
 	opts.Log.Info("removing stale object roots from index")
-	if err := tx.RemoveObjectsBefore(ctx, startSync); err != nil {
+	newProgress(opts.Progress, opts.StorageRoot, PhaseRemoveStale).report(true)
+	if err := tx.RemoveObjectsBefore(ctx, opts.StorageRoot, startSync); err != nil {
 		return err
 	}
 	return tx.Commit()
 }
 
-func syncObjecRootsTX(ctx context.Context, db Backend, fsys ocfl.FS, root string, logger *slog.Logger) (int, error) {
+func syncObjecRootsTX(ctx context.Context, db Backend, storageRoot string, fsys ocfl.FS, root string, logger *slog.Logger, progress *progressReporter) (int, error) {
 	tx, err := db.NewTx(ctx)
 	if err != nil {
 		return 0, err
 	}
-	defer tx.Rollback()
+	// tx is replaced after each commit
+	defer func() { tx.Rollback() }()
 	found := 0
 	eachObj := func(obj *ocfl.ObjectRoot) error {
 		// The indexed object root path should be relatvive to the storage root
 		r := ObjectRoot{Path: strings.TrimPrefix(obj.Path, root+"/")}
 		logger.Debug("object_root", "path", r)
-		if err := tx.IndexObjectRoot(ctx, time.Now(), r); err != nil {
+		if err := tx.IndexObjectRoot(ctx, storageRoot, time.Now(), r); err != nil {
 			return err
 		}
 		found++
+		progress.Found, progress.Processed = int64(found), int64(found)
+		progress.report(false)
 		if found%txCapObjRoot == 0 {
 			// commit and start a new transaction
 			logger.Info("search object roots...", "count", found)
 			if err := tx.Commit(); err != nil {
 				return err
 			}
+			YieldTasks(ctx)
 			tx, err = db.NewTx(ctx)
 			if err != nil {
 				return err
@@ -115,6 +137,7 @@ func syncObjecRootsTX(ctx context.Context, db Backend, fsys ocfl.FS, root string
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
+	progress.report(true)
 	return found, nil
 }
 
@@ -125,6 +148,16 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 		return err
 	}
 	indexingAll := len(opts.ObjectIDs)+len(opts.ObjectPaths) == 0
+	progress := newProgress(opts.Progress, opts.StorageRoot, PhaseParseInventories)
+	if indexingAll {
+		summ, err := idx.GetIndexSummary(ctx, opts.StorageRoot)
+		if err != nil {
+			return err
+		}
+		progress.Found = int64(summ.NumObjects)
+	} else {
+		progress.Found = int64(len(opts.ObjectIDs) + len(opts.ObjectPaths))
+	}
 	// new transaction in NewTx
 	tx, err := idx.NewTx(ctx)
 	if err != nil {
@@ -140,14 +173,22 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 		close(txCh)
 	}()
 
-	opts.Log.Info("indexing inventories ...", "path", opts.RootPath, "inventory_workers", opts.ParseConc)
+	opts.Log.Info("indexing inventories ...", "path", opts.RootPath, "storage_root", opts.StorageRoot, "inventory_workers", opts.ParseConc)
+	progress.report(true)
 	numObjs := 0
+	numSkipped := 0 // unchanged objects
+	numErrs := 0    // objects with errors
+	defer func() {
+		opts.Counts.add("indexed", numObjs)
+		opts.Counts.add("skipped", numSkipped)
+		opts.Counts.add("errors", numErrs)
+	}()
 	// three-phase pipeline for indexing: add object paths; parse
 	// inventories; do indexing.
 	addPaths := func(addPath func(string) bool) error {
 		if indexingAll {
 			// reindex everyting
-			return addAllObjectsPaths(ctx, addPath, txCh)
+			return addAllObjectsPaths(ctx, opts.StorageRoot, addPath, txCh)
 		}
 		// add just paths for specified objects
 		paths := make([]string, 0, len(opts.ObjectIDs)+len(opts.ObjectPaths))
@@ -168,38 +209,83 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 	}
 	// parse inventories function (run in multiple go routines)
 	parse := func(objPath string) (*indexJob, error) {
-		var prev *Object // previously indexed object
+		var prev *Object               // previously indexed object
+		var prevSizes map[string]int64 // previously indexed content file sizes
 		{
 			tx := <-txCh
 			var err error
-			prev, err = tx.GetObjectByPath(ctx, objPath)
+			prev, err = tx.GetObjectByPath(ctx, opts.StorageRoot, objPath)
 			if err != nil && !errors.Is(err, ErrNotFound) {
 				txCh <- tx
 				return nil, err
 			}
+			if prev != nil && opts.FileSizes {
+				prevSizes, err = tx.ListObjectContentSize(ctx, opts.StorageRoot, prev.ID)
+				if err != nil {
+					txCh <- tx
+					return nil, err
+				}
+			}
 			txCh <- tx
 		}
-		// TODO: read sidecar here and compare to prev's sidecar value (if
-		// available). Can skip reading full inventory if sidecars match
+		if prev != nil && !opts.Force {
+			// If the inventory sidecar matches the indexed inventory digest,
+			// the inventory doesn't need to be read or validated. Validation
+			// issues recorded when it was indexed are kept.
+			objRoot := path.Join(opts.RootPath, objPath)
+			sidecar, err := readSidecar(ctx, opts.FS, objRoot, prev.DigestAlgorithm)
+			if err != nil {
+				opts.Log.Debug("can't read inventory sidecar", "object_path", objPath, "err", err)
+			}
+			job := &indexJob{prev: prev, sidecar: sidecar}
+			if err == nil && job.unchanged(opts.FileSizes) {
+				return job, nil
+			}
+		}
 		// validate inventory
-		invPath := path.Join(opts.RootPath, objPath, "inventory.json")
-		inv, vErrs := ocflv1.ValidateInventory(ctx, opts.FS, invPath, nil)
+		invPath := path.Join(objPath, "inventory.json") // relative to storage root
+		inv, vErrs := ocflv1.ValidateInventory(ctx, opts.FS, path.Join(opts.RootPath, invPath), nil)
+		issues := validationIssues(invPath, vErrs, time.Now())
 		if err := vErrs.Err(); err != nil {
 			// don't quit if the inventory has errors
-			return &indexJob{err: err}, nil
+			return &indexJob{err: err, validated: true, issues: issues}, nil
 		}
-		job := &indexJob{prev: prev, inv: inv}
+		job := &indexJob{prev: prev, inv: inv, validated: true, issues: issues}
 		if job.inv != nil {
 			job.sidecar = job.inv.Digest()
 		}
+		if opts.FileSizes && (opts.Force || !job.unchanged(true)) {
+			objRoot := path.Join(opts.RootPath, objPath)
+			sizes, err := contentSizes(ctx, opts.FS, objRoot, inv, prevSizes, opts.ScanConc)
+			if err != nil {
+				return &indexJob{err: err, validated: true, issues: issues}, nil
+			}
+			job.sizes = sizes
+		}
 		return job, nil
 	}
 	// index update function (single go routine)
 	index := func(root string, job *indexJob, err error) error {
+		progress.Processed++
+		progress.Skipped, progress.Errors = int64(numSkipped), int64(numErrs)
+		defer func() {
+			progress.Skipped, progress.Errors = int64(numSkipped), int64(numErrs)
+			progress.report(false)
+		}()
 		if err != nil {
 			return fmt.Errorf("in object '%s': %w", root, err)
 		}
+		if job.validated {
+			// replace the object's validation issues from the previous run
+			tx := <-txCh
+			err := tx.SetValidationIssues(ctx, opts.StorageRoot, time.Now(), root, job.issues)
+			txCh <- tx
+			if err != nil {
+				return err
+			}
+		}
 		if job.err != nil {
+			numErrs++
 			// different behavior here depending on whether we are indexing
 			// everything or select IDs. For select ids, we quit without
 			// indexing additionl objects. For indexing all, we log and
@@ -209,23 +295,26 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 			}
 			opts.Log.Error("object has errors", "err", job.err, "object_path", root)
 		}
-		if job.inv == nil {
-			// nothing to do
+		if !opts.Force && job.unchanged(opts.FileSizes) {
+			// the inventory wasn't validated again, so any issues recorded
+			// for the object are still current
+			numSkipped++
+			opts.Log.Debug("object is unchanged", "object_path", root)
 			return nil
 		}
-		if job.prev != nil && job.sidecar != "" && job.prev.InventoryDigest == job.sidecar {
-			opts.Log.Debug("object is unchanged", "object_path", root)
+		if job.inv == nil {
+			// nothing to do
 			return nil
 		}
 		numObjs++
-		objInvs := ObjectInventory{Path: root, Inventory: job.inv}
+		objInvs := ObjectInventory{Path: root, Inventory: job.inv, FileSizes: job.sizes}
 		// index inventories
 		tx := <-txCh
 		defer func() {
 			txCh <- tx
 		}()
 		opts.Log.Debug("index_inventory", "id", objInvs.Inventory.ID)
-		if err := tx.IndexObjectInventory(ctx, time.Now(), objInvs); err != nil {
+		if err := tx.IndexObjectInventory(ctx, opts.StorageRoot, time.Now(), objInvs); err != nil {
 			return err
 		}
 		if numObjs%txCapInv == 0 {
@@ -234,6 +323,8 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 			if err = tx.Commit(); err != nil {
 				return err
 			}
+			// queued targeted tasks can run between batches
+			YieldTasks(ctx)
 			// set tx as a new transaction
 			if tx, err = idx.NewTx(ctx); err != nil {
 				return err
@@ -253,22 +344,42 @@ func (idx *Indexer) indexInventories(ctx context.Context, opts *IndexOptions) er
 		}
 		txCh <- tx
 	}
-	opts.Log.Info("indexing complete", "path", opts.RootPath, "new_updated", numObjs)
+	progress.report(true)
+	opts.Log.Info("indexing complete", "path", opts.RootPath, "new_updated", numObjs, "skipped", numSkipped)
 	return nil
 }
 
 type indexJob struct {
-	sidecar string
-	inv     *ocflv1.Inventory
-	prev    *Object // existing index entry
-	err     error   // error during inventory parse
+	sidecar string            // inventory digest
+	inv     *ocflv1.Inventory // nil if the inventory wasn't read
+	prev    *Object           // existing index entry
+	sizes   map[string]int64  // content file sizes (if indexing sizes)
+	err     error             // error during inventory parse
+
+	validated bool              // the inventory was validated
+	issues    []ValidationIssue // validation errors and warnings
+}
+
+// unchanged returns true if the job's inventory is already indexed, including
+// version stats. If sizes is true, the existing index entry must also include
+// file size information.
+func (job *indexJob) unchanged(sizes bool) bool {
+	if job.prev == nil || job.sidecar == "" || !strings.EqualFold(job.prev.InventoryDigest, job.sidecar) {
+		return false
+	}
+	for _, v := range job.prev.Versions {
+		if !v.HasStats || (sizes && !v.HasSize) {
+			return false
+		}
+	}
+	return true
 }
 
-func addAllObjectsPaths(ctx context.Context, add func(string) bool, txCh chan BackendTx) error {
+func addAllObjectsPaths(ctx context.Context, storageRoot string, add func(string) bool, txCh chan BackendTx) error {
 	cursor := ""
 	for {
 		tx := <-txCh
-		roots, err := tx.ListObjectRoots(ctx, 0, cursor)
+		roots, err := tx.ListObjectRoots(ctx, storageRoot, 0, cursor)
 		if err != nil {
 			txCh <- tx
 			return err
@@ -287,5 +398,79 @@ func addAllObjectsPaths(ctx context.Context, add func(string) bool, txCh chan Ba
 	return nil
 }
 
-// TODO: ocfl api should expose api for this
-//func getSide
+// contentSizes returns a map of content paths (relative to the object root) to
+// file sizes for all files in the inventory manifest. Sizes already present in
+// known are reused; other content files are stat'ed concurrently using conc
+// workers.
+func contentSizes(ctx context.Context, fsys ocfl.FS, objRoot string, inv *ocflv1.Inventory, known map[string]int64, conc int) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	var todo []string
+	inv.Manifest.EachPath(func(name, _ string) error {
+		if size, ok := known[name]; ok {
+			sizes[name] = size
+			return nil
+		}
+		todo = append(todo, name)
+		return nil
+	})
+	addPaths := func(add func(string) bool) error {
+		for _, name := range todo {
+			if !add(name) {
+				break
+			}
+		}
+		return nil
+	}
+	stat := func(name string) (int64, error) {
+		return statFile(ctx, fsys, path.Join(objRoot, name))
+	}
+	setSize := func(name string, size int64, err error) error {
+		if err != nil {
+			return fmt.Errorf("getting size of content file '%s': %w", name, err)
+		}
+		sizes[name] = size
+		return nil
+	}
+	if err := pipeline.Run(addPaths, stat, setSize, conc); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// statFile returns the size of the file name in fsys.
+func statFile(ctx context.Context, fsys ocfl.FS, name string) (int64, error) {
+	f, err := fsys.OpenFile(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// readSidecar returns the inventory digest from the inventory sidecar file,
+// 'inventory.json.<alg>', in the object root directory objRoot.
+func readSidecar(ctx context.Context, fsys ocfl.FS, objRoot string, alg string) (string, error) {
+	// TODO: ocfl api should expose api for this
+	if alg == "" {
+		return "", errors.New("missing digest algorithm")
+	}
+	f, err := fsys.OpenFile(ctx, path.Join(objRoot, "inventory.json."+alg))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	// sidecar content is the digest, whitespace, and 'inventory.json'
+	byts, err := io.ReadAll(io.LimitReader(f, 1024))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(byts))
+	if len(fields) == 0 {
+		return "", errors.New("empty inventory sidecar")
+	}
+	return fields[0], nil
+}
@@ -2,9 +2,17 @@ package index_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 
+	"github.com/srerickson/ocfl"
 	"github.com/srerickson/ocfl-index/internal/index"
 	"github.com/srerickson/ocfl-index/internal/sqlite"
 	"github.com/srerickson/ocfl/backend/cloud"
@@ -42,11 +50,12 @@ func newTestService(ctx context.Context, fixture string) (*index.Service, error)
 		return nil, fmt.Errorf("initializing fixture index: %w", err)
 	}
 	srv := &index.Service{
-		Indexer:  idx,
-		FS:       fsys,
-		RootPath: fixture,
-		Log:      logging.DisabledLogger(),
-		Async:    index.NewAsync(ctx),
+		Indexer: idx,
+		Roots: []index.StorageRoot{
+			{Name: index.DefaultStorageRoot, FS: fsys, Path: fixture},
+		},
+		Log:   logging.DisabledLogger(),
+		Async: index.NewAsync(ctx, idx),
 	}
 	opts := &index.IndexOptions{
 		FS:       fsys,
@@ -57,3 +66,480 @@ func newTestService(ctx context.Context, fixture string) (*index.Service, error)
 	}
 	return srv, nil
 }
+
+func TestIndexFileSizes(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := cloud.NewFS(buck)
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &index.IndexOptions{
+		FS:        fsys,
+		RootPath:  "simple-root",
+		FileSizes: true,
+	}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	state, err := idx.GetObjectState(ctx, index.DefaultStorageRoot, "ark:/12345/bcd987", ocfl.V(1), ".", true, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.HasSize {
+		t.Fatal("expected version state to have size")
+	}
+	var total int64
+	for _, ch := range state.Children {
+		if !ch.HasSize {
+			t.Fatalf("expected '%s' to have size", ch.Name)
+		}
+		total += ch.Size
+	}
+	if total != state.Size {
+		t.Fatalf("expected version state size to be %d, got %d", total, state.Size)
+	}
+}
+
+// openCounter is an ocfl.FS that counts opened inventory.json files
+type openCounter struct {
+	ocfl.FS
+	mx          sync.Mutex
+	inventories int
+}
+
+func (fsys *openCounter) OpenFile(ctx context.Context, name string) (fs.File, error) {
+	if path.Base(name) == "inventory.json" {
+		fsys.mx.Lock()
+		fsys.inventories++
+		fsys.mx.Unlock()
+	}
+	return fsys.FS.OpenFile(ctx, name)
+}
+
+func TestIndexSkipUnchanged(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := &openCounter{FS: cloud.NewFS(buck)}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &index.IndexOptions{
+		FS:       fsys,
+		RootPath: "simple-root",
+	}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	if fsys.inventories == 0 {
+		t.Fatal("expected inventories to be read during initial indexing")
+	}
+	// reindexing: sidecars match, inventories aren't read
+	fsys.inventories = 0
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	if fsys.inventories != 0 {
+		t.Fatalf("expected unchanged inventories to be skipped, %d were read", fsys.inventories)
+	}
+	// forced reindexing: all inventories are read
+	opts.Force = true
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	if fsys.inventories == 0 {
+		t.Fatal("expected inventories to be read with Force")
+	}
+	// indexing file sizes requires reading inventories
+	fsys.inventories = 0
+	opts.Force = false
+	opts.FileSizes = true
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	if fsys.inventories == 0 {
+		t.Fatal("expected inventories to be read when indexing file sizes")
+	}
+}
+
+func TestIndexProgress(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var updates []index.Progress
+	opts := &index.IndexOptions{
+		FS:       cloud.NewFS(buck),
+		RootPath: "simple-root",
+		Progress: func(p index.Progress) { updates = append(updates, p) },
+	}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	// last update for each phase
+	last := map[index.ProgressPhase]index.Progress{}
+	for _, p := range updates {
+		if p.StorageRoot != index.DefaultStorageRoot {
+			t.Fatalf("expected progress for storage root %q, got %q", index.DefaultStorageRoot, p.StorageRoot)
+		}
+		last[p.Phase] = p
+	}
+	for _, phase := range []index.ProgressPhase{index.PhaseScanRoots, index.PhaseRemoveStale, index.PhaseParseInventories} {
+		if _, ok := last[phase]; !ok {
+			t.Fatalf("no progress updates for phase '%s'", phase)
+		}
+	}
+	scan := last[index.PhaseScanRoots]
+	if scan.Found == 0 {
+		t.Fatal("expected object roots to be found in scan")
+	}
+	parse := last[index.PhaseParseInventories]
+	if parse.Found != scan.Found || parse.Processed != scan.Found {
+		t.Fatalf("expected %d inventories processed, got %d of %d", scan.Found, parse.Processed, parse.Found)
+	}
+	// reindexing: unchanged objects are skipped
+	updates = nil
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	parse = updates[len(updates)-1]
+	if parse.Phase != index.PhaseParseInventories || parse.Skipped != scan.Found {
+		t.Fatalf("expected %d skipped objects in final update, got %+v", scan.Found, parse)
+	}
+}
+
+// brokenInventories is an ocfl.FS that can't open the inventory.json files in
+// the broken object roots
+type brokenInventories struct {
+	ocfl.FS
+	broken map[string]bool
+}
+
+func (fsys *brokenInventories) OpenFile(ctx context.Context, name string) (fs.File, error) {
+	if path.Base(name) == "inventory.json" && fsys.broken[path.Dir(name)] {
+		return nil, fs.ErrPermission
+	}
+	return fsys.FS.OpenFile(ctx, name)
+}
+
+func TestIndexInvalidObjects(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const broken = "ark%3A%2F12345%2Fbcd987"
+	fsys := &brokenInventories{
+		FS:     cloud.NewFS(buck),
+		broken: map[string]bool{path.Join("simple-root", broken): true},
+	}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &index.IndexOptions{
+		FS:       fsys,
+		RootPath: "simple-root",
+		Counts:   index.TaskCounts{},
+	}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "error count", opts.Counts["errors"], int64(1))
+	list, err := idx.ListInvalidObjects(ctx, index.DefaultStorageRoot, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Objects) != 1 || list.Objects[0].RootPath != broken {
+		t.Fatalf("expected %q to be the only invalid object, got %+v", broken, list.Objects)
+	}
+	issue := list.Objects[0].Issues[0]
+	expEq(t, "issue severity", issue.Severity, index.SeverityError)
+	expEq(t, "issue code", issue.Code, "E034")
+	expEq(t, "issue inventory path", issue.InventoryPath, broken+"/inventory.json")
+	// issues are removed when the object is valid
+	fsys.broken = nil
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	list, err = idx.ListInvalidObjects(ctx, index.DefaultStorageRoot, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "invalid objects after fix", len(list.Objects), 0)
+}
+
+// Unchanged objects aren't revalidated, so validation issues recorded by an
+// earlier run are kept until the object is reindexed with force.
+func TestIndexUnchangedKeepsIssues(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &index.IndexOptions{
+		FS:       cloud.NewFS(buck),
+		RootPath: "simple-root",
+		Counts:   index.TaskCounts{},
+	}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	// issue recorded by an earlier index run
+	const objPath = "ark%3A%2F12345%2Fbcd987"
+	earlier := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	tx, err := idx.NewTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.SetValidationIssues(ctx, index.DefaultStorageRoot, earlier, objPath, []index.ValidationIssue{{
+		Severity:      index.SeverityWarning,
+		Code:          "W004",
+		Message:       "W004: earlier warning",
+		InventoryPath: objPath + "/inventory.json",
+		CheckedAt:     earlier,
+	}})
+	if err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	opts.Counts = index.TaskCounts{}
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "indexed objects", opts.Counts["indexed"], int64(0))
+	list, err := idx.ListInvalidObjects(ctx, index.DefaultStorageRoot, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Objects) != 1 || list.Objects[0].RootPath != objPath {
+		t.Fatalf("expected issues for %q to be kept, got %+v", objPath, list.Objects)
+	}
+	expEq(t, "kept issue code", list.Objects[0].Issues[0].Code, "W004")
+	expEq(t, "kept issue checked at", list.Objects[0].Issues[0].CheckedAt.Equal(earlier), true)
+	// the object is revalidated with force
+	opts.Force = true
+	if err := idx.Index(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	list, err = idx.ListInvalidObjects(ctx, index.DefaultStorageRoot, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "invalid objects after force", len(list.Objects), 0)
+}
+
+// brokenContent is an ocfl.FS that can't open content files in the broken
+// object roots
+type brokenContent struct {
+	ocfl.FS
+	broken map[string]bool
+}
+
+func (fsys *brokenContent) OpenFile(ctx context.Context, name string) (fs.File, error) {
+	for root := range fsys.broken {
+		if strings.HasPrefix(name, root+"/") && strings.Contains(name, "/content/") {
+			return nil, fs.ErrPermission
+		}
+	}
+	return fsys.FS.OpenFile(ctx, name)
+}
+
+func TestValidateObjects(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const broken = "ark%3A%2F12345%2Fbcd987"
+	fsys := &brokenContent{
+		FS:     cloud.NewFS(buck),
+		broken: map[string]bool{path.Join("simple-root", broken): true},
+	}
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Index(ctx, &index.IndexOptions{FS: fsys, RootPath: "simple-root"}); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := idx.GetIndexSummary(ctx, index.DefaultStorageRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("all objects", func(t *testing.T) {
+		var updates []index.Progress
+		opts := &index.ValidateOptions{
+			FS:       fsys,
+			RootPath: "simple-root",
+			Conc:     2,
+			Counts:   index.TaskCounts{},
+			Progress: func(p index.Progress) { updates = append(updates, p) },
+		}
+		if err := idx.ValidateObjects(ctx, opts); err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "validated count", opts.Counts["validated"], int64(summary.NumObjects))
+		expEq(t, "invalid count", opts.Counts["invalid"], int64(1))
+		last := updates[len(updates)-1]
+		expEq(t, "progress phase", last.Phase, index.PhaseValidateObjects)
+		expEq(t, "progress processed", last.Processed, int64(summary.NumObjects))
+		check, err := idx.GetFixityCheck(ctx, index.DefaultStorageRoot, broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check.Valid || len(check.Codes) == 0 || check.Error == "" {
+			t.Fatalf("expected %q to fail validation with codes, got %+v", broken, check)
+		}
+		due, err := idx.ListFixityDue(ctx, index.DefaultStorageRoot, summary.NumObjects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range due {
+			if d.CheckedAt.IsZero() {
+				t.Fatalf("expected %q to have been validated", d.RootPath)
+			}
+			if d.RootPath != broken && !d.Valid {
+				t.Fatalf("expected %q to be valid, got %+v", d.RootPath, d)
+			}
+		}
+	})
+	t.Run("oldest", func(t *testing.T) {
+		fsys.broken = nil
+		before, err := idx.ListFixityDue(ctx, index.DefaultStorageRoot, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &index.ValidateOptions{
+			FS:       fsys,
+			RootPath: "simple-root",
+			Oldest:   1,
+			Counts:   index.TaskCounts{},
+		}
+		if err := idx.ValidateObjects(ctx, opts); err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "validated count", opts.Counts["validated"], int64(1))
+		check, err := idx.GetFixityCheck(ctx, index.DefaultStorageRoot, before[0].RootPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !check.CheckedAt.After(before[0].CheckedAt) {
+			t.Fatalf("expected %q to be revalidated", before[0].RootPath)
+		}
+	})
+	t.Run("object paths", func(t *testing.T) {
+		opts := &index.ValidateOptions{
+			FS:          fsys,
+			RootPath:    "simple-root",
+			ObjectPaths: []string{broken},
+			Counts:      index.TaskCounts{},
+		}
+		if err := idx.ValidateObjects(ctx, opts); err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "validated count", opts.Counts["validated"], int64(1))
+		expEq(t, "invalid count", opts.Counts["invalid"], int64(0))
+		check, err := idx.GetFixityCheck(ctx, index.DefaultStorageRoot, broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "fixed object is valid", check.Valid, true)
+	})
+}
+
+// blockingFS is an ocfl.FS that blocks OpenFile until release is closed
+type blockingFS struct {
+	ocfl.FS
+	release chan struct{}
+}
+
+func (fsys *blockingFS) OpenFile(ctx context.Context, name string) (fs.File, error) {
+	select {
+	case <-fsys.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return fsys.FS.OpenFile(ctx, name)
+}
+
+// The validation schedule doesn't queue a task while the previous one is
+// queued or running.
+func TestValidationSchedule(t *testing.T) {
+	ctx := context.Background()
+	buck, err := fileblob.OpenBucket(fixtureRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the index isn't shared with other tests: validation results are saved
+	idx, err := newTestIndex(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Index(ctx, &index.IndexOptions{FS: cloud.NewFS(buck), RootPath: "simple-root"}); err != nil {
+		t.Fatal(err)
+	}
+	fsys := &blockingFS{FS: cloud.NewFS(buck), release: make(chan struct{})}
+	service := &index.Service{
+		Indexer: idx,
+		Roots:   []index.StorageRoot{{Name: index.DefaultStorageRoot, FS: fsys, Path: "simple-root"}},
+		Log:     logging.DisabledLogger(),
+		Async:   index.NewAsync(ctx, idx),
+	}
+	defer service.Async.Close()
+	sched := &index.ValidationSchedule{Service: service, Every: 5 * time.Millisecond, Batch: 1}
+	// countTasks returns the number of scheduled validation task records
+	countTasks := func() int {
+		t.Helper()
+		list, err := service.Indexer.ListTasks(ctx, 100, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for _, task := range list.Tasks {
+			if task.Name == "validating" {
+				n++
+			}
+		}
+		return n
+	}
+	schedCtx, cancel := context.WithCancel(ctx)
+	schedDone := make(chan error, 1)
+	go func() { schedDone <- sched.Run(schedCtx) }()
+	// many intervals pass while the first task is blocked
+	time.Sleep(200 * time.Millisecond)
+	expEq(t, "scheduled tasks while the first is running", countTasks(), 1)
+	// the schedule resumes once the task is finished
+	close(fsys.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for countTasks() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("no task was scheduled after the first one finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-schedDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled from Run, got %v", err)
+	}
+}
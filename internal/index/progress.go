@@ -0,0 +1,81 @@
+package index
+
+import (
+	"time"
+)
+
+// progressInterval is the minimum time between progress updates in a phase
+const progressInterval = time.Second
+
+// ProgressPhase is a step in an indexing task
+type ProgressPhase int
+
+const (
+	PhaseScanRoots        ProgressPhase = iota + 1 // scanning the storage root for object roots
+	PhaseParseInventories                          // parsing and indexing object inventories
+	PhaseRemoveStale                               // removing objects that weren't found in the scan
+	PhaseValidateObjects                           // validating objects, including content digests
+)
+
+func (p ProgressPhase) String() string {
+	switch p {
+	case PhaseScanRoots:
+		return "scan roots"
+	case PhaseParseInventories:
+		return "parse inventories"
+	case PhaseRemoveStale:
+		return "remove stale"
+	case PhaseValidateObjects:
+		return "validate objects"
+	}
+	return "unknown"
+}
+
+// Progress is a progress update for an indexing phase
+type Progress struct {
+	StorageRoot string
+	Phase       ProgressPhase
+	Found       int64         // objects found in the scan, or objects to process in other phases
+	Processed   int64         // objects processed in the phase
+	Skipped     int64         // unchanged objects that were skipped
+	Errors      int64         // objects with errors
+	ETA         time.Duration // estimated time remaining in the phase (0 if unknown)
+}
+
+// progressReporter sends progress updates for a phase to fn, at most once per
+// progressInterval.
+type progressReporter struct {
+	fn    func(Progress)
+	start time.Time
+	last  time.Time
+	Progress
+}
+
+// newProgress returns a progressReporter for the phase. If fn is nil, updates
+// are discarded.
+func newProgress(fn func(Progress), storageRoot string, phase ProgressPhase) *progressReporter {
+	return &progressReporter{
+		fn:       fn,
+		start:    time.Now(),
+		Progress: Progress{StorageRoot: storageRoot, Phase: phase},
+	}
+}
+
+// report sends the current progress if the interval has passed since the
+// last update, or if force is true.
+func (r *progressReporter) report(force bool) {
+	if r.fn == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(r.last) < progressInterval {
+		return
+	}
+	r.last = now
+	p := r.Progress
+	if p.Found > p.Processed && p.Processed > 0 {
+		perObj := now.Sub(r.start) / time.Duration(p.Processed)
+		p.ETA = (perObj * time.Duration(p.Found-p.Processed)).Round(time.Second)
+	}
+	r.fn(p)
+}
@@ -0,0 +1,238 @@
+package index
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-chi/chi"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+const restPrefix = "/api/v1" // read-only REST/JSON API
+
+// openAPIDoc describes the REST API. Its schemas must match the messages in
+// index.proto (see TestOpenAPISchemas).
+//
+//go:embed openapi.json
+var openAPIDoc []byte
+
+// restJSON is used to encode responses from the REST API. Fields use the names
+// from index.proto and are included even if they have zero values.
+var restJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// restError is the body of error responses from the REST API
+type restError struct {
+	Code    string           `json:"code"` // connect error code (e.g., "not_found")
+	Message string           `json:"message"`
+	Details *json.RawMessage `json:"details,omitempty"` // ErrorDetail, if available
+}
+
+// restHandler returns a handler for the read-only REST/JSON API. Responses are
+// the same messages returned by the corresponding RPCs, encoded as JSON.
+// Paginated endpoints include a Link header with the URL for the next page.
+// All endpoints accept a 'root' query parameter with the storage root name.
+//
+//   - GET /status: GetStatus
+//   - GET /objects: ListObjects (query parameters: prefix, page_size, page_token)
+//   - GET /objects/{object_id}: GetObject
+//   - GET /objects/{object_id}/versions/{version}/state/{path}: GetObjectState
+//     (query parameters: recursive, page_size, page_token)
+//   - GET /openapi.json: OpenAPI document for the API
+//
+// Object IDs in the request path must be path-escaped and the version may be
+// "head".
+func (srv Service) restHandler() http.Handler {
+	mux := chi.NewRouter()
+	mux.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDoc)
+	})
+	mux.Method(http.MethodGet, "/status", restFunc(srv.restGetStatus))
+	mux.Method(http.MethodGet, "/objects", restFunc(srv.restListObjects))
+	mux.Method(http.MethodGet, "/objects/{object_id}", restFunc(srv.restGetObject))
+	mux.Method(http.MethodGet, "/objects/{object_id}/versions/{version}/state", restFunc(srv.restGetObjectState))
+	mux.Method(http.MethodGet, "/objects/{object_id}/versions/{version}/state/*", restFunc(srv.restGetObjectState))
+	return mux
+}
+
+func (srv Service) restGetStatus(w http.ResponseWriter, r *http.Request) (proto.Message, proto.Message, error) {
+	req := &api.GetStatusRequest{StorageRoot: r.URL.Query().Get("root")}
+	resp, err := srv.GetStatus(r.Context(), connect.NewRequest(req))
+	if err != nil {
+		return req, nil, err
+	}
+	return req, resp.Msg, nil
+}
+
+func (srv Service) restListObjects(w http.ResponseWriter, r *http.Request) (proto.Message, proto.Message, error) {
+	query := r.URL.Query()
+	req := &api.ListObjectsRequest{
+		StorageRoot: query.Get("root"),
+		IdPrefix:    query.Get("prefix"),
+		PageToken:   query.Get("page_token"),
+	}
+	var err error
+	if req.PageSize, err = pageSizeParam(r); err != nil {
+		return req, nil, err
+	}
+	resp, err := srv.ListObjects(r.Context(), connect.NewRequest(req))
+	if err != nil {
+		return req, nil, err
+	}
+	setNextLink(w, r, resp.Msg.NextPageToken)
+	return req, resp.Msg, nil
+}
+
+func (srv Service) restGetObject(w http.ResponseWriter, r *http.Request) (proto.Message, proto.Message, error) {
+	req := &api.GetObjectRequest{StorageRoot: r.URL.Query().Get("root")}
+	var err error
+	if req.ObjectId, err = pathParam(r, "object_id"); err != nil {
+		return req, nil, err
+	}
+	resp, err := srv.GetObject(r.Context(), connect.NewRequest(req))
+	if err != nil {
+		return req, nil, err
+	}
+	return req, resp.Msg, nil
+}
+
+func (srv Service) restGetObjectState(w http.ResponseWriter, r *http.Request) (proto.Message, proto.Message, error) {
+	query := r.URL.Query()
+	req := &api.GetObjectStateRequest{
+		StorageRoot: query.Get("root"),
+		Recursive:   query.Get("recursive") == "true",
+		PageToken:   query.Get("page_token"),
+	}
+	var err error
+	if req.ObjectId, err = pathParam(r, "object_id"); err != nil {
+		return req, nil, err
+	}
+	if req.Version, err = pathParam(r, "version"); err != nil {
+		return req, nil, err
+	}
+	if req.Version == "head" {
+		req.Version = ""
+	}
+	if req.BasePath, err = pathParam(r, "*"); err != nil {
+		return req, nil, err
+	}
+	if req.PageSize, err = pageSizeParam(r); err != nil {
+		return req, nil, err
+	}
+	resp, err := srv.GetObjectState(r.Context(), connect.NewRequest(req))
+	if err != nil {
+		return req, nil, err
+	}
+	setNextLink(w, r, resp.Msg.NextPageToken)
+	return req, resp.Msg, nil
+}
+
+// restFunc handles a REST API request. It returns the request message, which
+// is used for error details, and the response message.
+type restFunc func(w http.ResponseWriter, r *http.Request) (req proto.Message, resp proto.Message, err error)
+
+// ServeHTTP writes the response message as JSON, or the error as a restError.
+func (fn restFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, resp, err := fn(w, r)
+	if err != nil {
+		writeRESTError(w, r.Context(), req, err)
+		return
+	}
+	body, err := restJSON.Marshal(resp)
+	if err != nil {
+		writeRESTError(w, r.Context(), req, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// writeRESTError writes err as a restError, with the HTTP status corresponding
+// to its connect error code.
+func writeRESTError(w http.ResponseWriter, ctx context.Context, req proto.Message, err error) {
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// the client is gone
+		return
+	}
+	err = connectError(err, req)
+	body := restError{Code: connect.CodeUnknown.String(), Message: err.Error()}
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		body.Code = connectErr.Code().String()
+		body.Message = connectErr.Message()
+		for _, d := range connectErr.Details() {
+			val, err := d.Value()
+			if err != nil {
+				continue
+			}
+			if detail, ok := val.(*api.ErrorDetail); ok {
+				if b, err := restJSON.Marshal(detail); err == nil {
+					raw := json.RawMessage(b)
+					body.Details = &raw
+				}
+				break
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(connect.CodeOf(err)))
+	json.NewEncoder(w).Encode(body)
+}
+
+// httpStatus returns the HTTP status for the connect error code.
+func httpStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeInvalidArgument:
+		return http.StatusBadRequest
+	case connect.CodeFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// pageSizeParam returns the value of the page_size query parameter (0 if it
+// isn't set).
+func pageSizeParam(r *http.Request) (int32, error) {
+	val := r.URL.Query().Get("page_size")
+	if val == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseInt(val, 10, 32)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid page_size '%s': %w", val, ErrInvalidArgs)
+	}
+	return int32(size), nil
+}
+
+// setNextLink sets a Link header with the URL of the next page of results, if
+// there is one. The URL is the request URL with the page_token parameter
+// replaced.
+func setNextLink(w http.ResponseWriter, r *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	next := *r.URL
+	query := next.Query()
+	query.Set("page_token", token)
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
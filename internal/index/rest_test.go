@@ -0,0 +1,203 @@
+package index_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	_ "github.com/srerickson/ocfl-index/gen/ocfl/v1"
+)
+
+func TestServiceREST(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := httptest.NewTLSServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	get := func(t *testing.T, p string, status int, val any) *http.Response {
+		t.Helper()
+		rsp, err := httpSrv.Client().Get(httpSrv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		body, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "status for "+p, rsp.StatusCode, status)
+		expEq(t, "content type", rsp.Header.Get("Content-Type"), "application/json")
+		if err := json.Unmarshal(body, val); err != nil {
+			t.Fatalf("decoding response for %s: %v", p, err)
+		}
+		return rsp
+	}
+	id := url.PathEscape("ark:/12345/bcd987")
+	t.Run("status", func(t *testing.T) {
+		var status struct {
+			Status         string `json:"status"`
+			StorageRoot    string `json:"storage_root"`
+			NumInventories int    `json:"num_inventories"`
+		}
+		get(t, "/api/v1/status", http.StatusOK, &status)
+		expEq(t, "status", status.Status, "ready")
+		expEq(t, "inventories", status.NumInventories, 3)
+	})
+	t.Run("objects", func(t *testing.T) {
+		type objectList struct {
+			Objects []struct {
+				ObjectID string `json:"object_id"`
+			} `json:"objects"`
+			NextPageToken string `json:"next_page_token"`
+		}
+		var ids []string
+		next := "/api/v1/objects?page_size=2"
+		for next != "" {
+			var list objectList
+			rsp := get(t, next, http.StatusOK, &list)
+			for _, obj := range list.Objects {
+				ids = append(ids, obj.ObjectID)
+			}
+			next = ""
+			if link := rsp.Header.Get("Link"); link != "" {
+				expEq(t, "Link header has next page", list.NextPageToken != "", true)
+				target, rel, _ := strings.Cut(link, ";")
+				expEq(t, "Link rel", strings.TrimSpace(rel), `rel="next"`)
+				next = strings.Trim(target, "<>")
+			}
+		}
+		expEq(t, "number of objects", len(ids), 3)
+		expEq(t, "sorted objects", sort.StringsAreSorted(ids), true)
+		var prefixed objectList
+		get(t, "/api/v1/objects?prefix="+url.QueryEscape("ark:/12345/bcd"), http.StatusOK, &prefixed)
+		expEq(t, "objects with prefix", len(prefixed.Objects), 1)
+	})
+	t.Run("object", func(t *testing.T) {
+		var obj struct {
+			ObjectID string `json:"object_id"`
+			Versions []struct {
+				Num      string `json:"num"`
+				NumFiles string `json:"num_files"` // int64 values are strings
+			} `json:"versions"`
+			Fixity any `json:"fixity"`
+		}
+		get(t, "/api/v1/objects/"+id, http.StatusOK, &obj)
+		expEq(t, "object id", obj.ObjectID, "ark:/12345/bcd987")
+		expEq(t, "versions", len(obj.Versions), 3)
+		expEq(t, "version files", obj.Versions[0].NumFiles, "3")
+		expEq(t, "unset fixity", obj.Fixity, nil)
+	})
+	t.Run("state", func(t *testing.T) {
+		type state struct {
+			IsDir    bool `json:"isdir"`
+			Children []struct {
+				Name  string `json:"name"`
+				IsDir bool   `json:"isdir"`
+			} `json:"children"`
+		}
+		var root state
+		get(t, "/api/v1/objects/"+id+"/versions/head/state", http.StatusOK, &root)
+		expEq(t, "root is dir", root.IsDir, true)
+		expEq(t, "root children", len(root.Children), 3)
+		var dir state
+		get(t, "/api/v1/objects/"+id+"/versions/v1/state/foo", http.StatusOK, &dir)
+		expEq(t, "dir children", len(dir.Children), 1)
+		expEq(t, "dir child", dir.Children[0].Name, "bar.xml")
+		var recursive state
+		get(t, "/api/v1/objects/"+id+"/versions/v1/state?recursive=true", http.StatusOK, &recursive)
+		expEq(t, "recursive children", len(recursive.Children), 3)
+	})
+	t.Run("errors", func(t *testing.T) {
+		type restError struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Details struct {
+				ObjectID string `json:"object_id"`
+				Version  string `json:"version"`
+				Path     string `json:"path"`
+			} `json:"details"`
+		}
+		var notFound restError
+		get(t, "/api/v1/objects/missing", http.StatusNotFound, &notFound)
+		expEq(t, "error code", notFound.Code, "not_found")
+		expEq(t, "error object id", notFound.Details.ObjectID, "missing")
+		var missingPath restError
+		get(t, "/api/v1/objects/"+id+"/versions/v2/state/missing.txt", http.StatusNotFound, &missingPath)
+		expEq(t, "error version", missingPath.Details.Version, "v2")
+		expEq(t, "error path", missingPath.Details.Path, "missing.txt")
+		var badVersion restError
+		get(t, "/api/v1/objects/"+id+"/versions/one/state", http.StatusBadRequest, &badVersion)
+		expEq(t, "error code", badVersion.Code, "invalid_argument")
+		var badPageSize restError
+		get(t, "/api/v1/objects?page_size=x", http.StatusBadRequest, &badPageSize)
+		expEq(t, "error code", badPageSize.Code, "invalid_argument")
+	})
+}
+
+// TestOpenAPISchemas checks that the schemas in the OpenAPI document served by
+// the REST API have the same fields as the corresponding messages in
+// index.proto.
+func TestOpenAPISchemas(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := httptest.NewTLSServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	rsp, err := httpSrv.Client().Get(httpSrv.URL + "/api/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Components.Schemas) == 0 {
+		t.Fatal("OpenAPI document has no schemas")
+	}
+	for name, schema := range doc.Components.Schemas {
+		if name == "Error" {
+			// not a proto message
+			continue
+		}
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName("ocfl.v1." + name))
+		if err != nil {
+			t.Errorf("schema %s: no matching proto message: %v", name, err)
+			continue
+		}
+		msg, ok := desc.(protoreflect.MessageDescriptor)
+		if !ok {
+			t.Errorf("schema %s: not a proto message", name)
+			continue
+		}
+		var fields, props []string
+		for i := 0; i < msg.Fields().Len(); i++ {
+			fields = append(fields, string(msg.Fields().Get(i).Name()))
+		}
+		for p := range schema.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(fields)
+		sort.Strings(props)
+		expEq(t, "properties for schema "+name, props, fields)
+	}
+}
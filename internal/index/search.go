@@ -0,0 +1,119 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl/ocflv1"
+)
+
+// SearchTerm is a term in a full-text search query: a sequence of tokens that
+// must appear together, in order.
+type SearchTerm struct {
+	Tokens []string // lowercase letters and numbers
+	Prefix bool     // the last token may match as a prefix
+}
+
+// ParseSearchQuery splits a full-text search query into terms, all of which
+// must match. Terms are separated by spaces. Text in double quotes is a single
+// term (a phrase). A term ending with '*' matches as a prefix. Search text is
+// split into tokens at any character that isn't a letter or number, so the
+// term 'report.pdf' matches 'report' followed by 'pdf'. Backends are expected
+// to tokenize indexed text the same way.
+func ParseSearchQuery(query string) ([]SearchTerm, error) {
+	var terms []SearchTerm
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var text string
+		if rest[0] == '"' {
+			var found bool
+			text, rest, found = strings.Cut(rest[1:], `"`)
+			if !found {
+				return nil, fmt.Errorf("unterminated quote in search query: %w", ErrInvalidArgs)
+			}
+			if strings.HasPrefix(rest, "*") {
+				text += "*"
+				rest = rest[1:]
+			}
+		} else {
+			i := strings.IndexFunc(rest, unicode.IsSpace)
+			if i < 0 {
+				i = len(rest)
+			}
+			text, rest = rest[:i], rest[i:]
+		}
+		rest = strings.TrimSpace(rest)
+		term := SearchTerm{
+			Tokens: SearchTokens(text),
+			Prefix: strings.HasSuffix(text, "*"),
+		}
+		if len(term.Tokens) > 0 {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query: %w", ErrInvalidArgs)
+	}
+	return terms, nil
+}
+
+// SearchTokens splits text into lowercase tokens at characters that aren't
+// letters or numbers.
+func SearchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// SearchEntry is text from an inventory that is indexed for full-text search.
+// Each version has one entry for its metadata and one entry for each logical
+// path that was added or changed in the version.
+type SearchEntry struct {
+	Version     ocfl.VNum
+	ObjectID    string // set for version metadata entries
+	Path        string // set for logical path entries
+	Message     string
+	UserName    string
+	UserAddress string
+}
+
+// SearchEntries returns full-text search entries for all versions in the
+// inventory.
+func SearchEntries(inv *ocflv1.Inventory) ([]SearchEntry, error) {
+	var entries []SearchEntry
+	var prev *ocflv1.Version
+	for _, vnum := range inv.Head.Lineage() {
+		ver := inv.Versions[vnum]
+		if ver == nil {
+			return nil, fmt.Errorf("missing version '%s' in inventory", vnum)
+		}
+		meta := SearchEntry{
+			Version:  vnum,
+			ObjectID: inv.ID,
+			Message:  ver.Message,
+		}
+		if ver.User != nil {
+			meta.UserName = ver.User.Name
+			meta.UserAddress = ver.User.Address
+		}
+		entries = append(entries, meta)
+		var changed []string
+		if err := ver.State.EachPath(func(logical string, digest string) error {
+			if prev == nil || prev.State.GetDigest(logical) != digest {
+				changed = append(changed, logical)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		sort.Strings(changed)
+		for _, p := range changed {
+			entries = append(entries, SearchEntry{Version: vnum, Path: p})
+		}
+		prev = ver
+	}
+	return entries, nil
+}
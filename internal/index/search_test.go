@@ -0,0 +1,71 @@
+package index_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/mock"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	table := map[string][]index.SearchTerm{
+		"report":                 {{Tokens: []string{"report"}}},
+		"  Report.PDF  data/v1 ": {{Tokens: []string{"report", "pdf"}}, {Tokens: []string{"data", "v1"}}},
+		"rep*":                   {{Tokens: []string{"rep"}, Prefix: true}},
+		`"first version" mail*`:  {{Tokens: []string{"first", "version"}}, {Tokens: []string{"mail"}, Prefix: true}},
+		`"a b"* c`:               {{Tokens: []string{"a", "b"}, Prefix: true}, {Tokens: []string{"c"}}},
+		"ark:/12345 -- ?":        {{Tokens: []string{"ark", "12345"}}},
+		"café":                   {{Tokens: []string{"café"}}},
+	}
+	for query, exp := range table {
+		terms, err := index.ParseSearchQuery(query)
+		if err != nil {
+			t.Fatalf("query %q: %v", query, err)
+		}
+		expEq(t, "terms for "+query, terms, exp)
+	}
+	for _, query := range []string{"", "  ", "*", "-- .", `"unterminated`} {
+		if _, err := index.ParseSearchQuery(query); !errors.Is(err, index.ErrInvalidArgs) {
+			t.Fatalf("query %q: expected ErrInvalidArgs, got %v", query, err)
+		}
+	}
+}
+
+func TestSearchEntries(t *testing.T) {
+	id := "object-1"
+	m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
+	entries, err := index.SearchEntries(m.Inventory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.Path == "" {
+			expEq(t, "version entry object id", e.ObjectID, id)
+			expEq(t, "version entry message", e.Message, e.Version.String())
+			expEq(t, "version entry user", e.UserName, "nobody")
+			continue
+		}
+		if e.ObjectID != "" || e.Message != "" {
+			t.Fatalf("path entry with version metadata: %v", e)
+		}
+		paths = append(paths, e.Version.String()+" "+e.Path)
+	}
+	expEq(t, "number of version entries", len(entries)-len(paths), 2)
+	// common.txt is unchanged in v2
+	exp := []string{
+		"v1 change.txt",
+		"v1 common.txt",
+		"v1 v1-new.txt",
+		"v1 v1-rename.txt",
+		"v2 change.txt",
+		"v2 v2-new.txt",
+		"v2 v2-rename.txt",
+	}
+	if !reflect.DeepEqual(paths, exp) {
+		t.Fatalf("got paths %v, expected %v", paths, exp)
+	}
+}
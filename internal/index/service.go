@@ -7,13 +7,15 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bufbuild/connect-go"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"golang.org/x/exp/slog"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/srerickson/ocfl"
@@ -22,85 +24,231 @@ import (
 	"github.com/srerickson/ocfl/ocflv1"
 )
 
-const downloadPrefix = "/download"
-
 // Service implements the gRPC services
 type Service struct {
-	Log       *slog.Logger
-	FS        ocfl.FS
-	RootPath  string
-	Indexer   *Indexer
-	Async     *Async
-	ParseConc int
-	ScanConc  int
+	Log         *slog.Logger
+	Roots       []StorageRoot // the first storage root is the default
+	Indexer     *Indexer
+	Async       *Async
+	ParseConc   int
+	ScanConc    int
+	FileSizes   bool          // index content file sizes
+	TaskTimeout time.Duration // default timeout for indexing tasks (0: none)
+	Webhook     *S3Webhook    // optional handler for S3 event notifications
+	Auth        *Auth         // optional authentication and authorization
+}
+
+// StorageRoot is a named OCFL storage root served by the Service.
+type StorageRoot struct {
+	Name string  // storage root name in the index
+	FS   ocfl.FS // storage root fs
+	Path string  // storage root directory
 }
 
 // Service implements the service generated with connect-go
 var _ (ocflv1connect.IndexServiceHandler) = (*Service)(nil)
 
-func (srv Service) IndexAll(ctx context.Context, rq *connect.Request[api.IndexAllRequest]) (*connect.Response[api.IndexAllResponse], error) {
-	added, _ := srv.Async.TryNow("indexing", func(ctx context.Context, w io.Writer) error {
-		opts := &IndexOptions{
-			FS:        srv.FS,
-			RootPath:  srv.RootPath,
-			ParseConc: srv.ParseConc,
-			ScanConc:  srv.ScanConc,
-			Log:       slog.New(slog.NewJSONHandler(io.MultiWriter(os.Stderr, w), &slog.HandlerOptions{})),
+// storageRoot returns the storage root with the given name. If name is empty,
+// the default storage root is returned.
+func (srv Service) storageRoot(name string) (*StorageRoot, error) {
+	if name == "" && len(srv.Roots) > 0 {
+		return &srv.Roots[0], nil
+	}
+	for i := range srv.Roots {
+		if srv.Roots[i].Name == name {
+			return &srv.Roots[i], nil
 		}
+	}
+	return nil, fmt.Errorf("storage root '%s': %w", name, ErrNotFound)
+}
+
+// indexOptions returns options for indexing the storage root, with logs
+// written to stderr and w.
+func (srv Service) indexOptions(root *StorageRoot, w io.Writer) *IndexOptions {
+	opts := &IndexOptions{
+		FS:          root.FS,
+		RootPath:    root.Path,
+		StorageRoot: root.Name,
+		ParseConc:   srv.ParseConc,
+		ScanConc:    srv.ScanConc,
+		FileSizes:   srv.FileSizes,
+		Log:         slog.New(slog.NewJSONHandler(io.MultiWriter(os.Stderr, w), &slog.HandlerOptions{})),
+	}
+	if tw, ok := w.(*taskWriter); ok {
+		// progress updates are sent to sessions following the task
+		opts.Progress = tw.progress
+	}
+	return opts
+}
+
+// validateOptions returns options for validating objects in the storage
+// root, with logs written to stderr and w.
+func (srv Service) validateOptions(root *StorageRoot, w io.Writer) *ValidateOptions {
+	opts := &ValidateOptions{
+		FS:          root.FS,
+		RootPath:    root.Path,
+		StorageRoot: root.Name,
+		Conc:        srv.ParseConc,
+		Log:         slog.New(slog.NewJSONHandler(io.MultiWriter(os.Stderr, w), &slog.HandlerOptions{})),
+	}
+	if tw, ok := w.(*taskWriter); ok {
+		opts.Progress = tw.progress
+	}
+	return opts
+}
+
+func (srv Service) IndexAll(ctx context.Context, rq *connect.Request[api.IndexAllRequest]) (*connect.Response[api.IndexAllResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	task := &Task{
+		Name:        "indexing",
+		StorageRoot: root.Name,
+		Options:     map[string]string{"force": strconv.FormatBool(rq.Msg.Force)},
+	}
+	srv.setTaskTimeout(task, rq.Msg.Timeout)
+	_, err = srv.Async.Add(ctx, task, func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		opts := srv.indexOptions(root, w)
+		opts.Force = rq.Msg.Force
+		opts.Counts = counts
 		return srv.Indexer.Index(ctx, opts)
 	})
-	if !added {
-		return nil, errors.New("an indexing task is already running")
+	if err != nil {
+		return nil, err
 	}
-	return connect.NewResponse(&api.IndexAllResponse{}), nil
+	return connect.NewResponse(&api.IndexAllResponse{TaskId: task.ID}), nil
 }
 
 func (srv Service) IndexIDs(ctx context.Context, rq *connect.Request[api.IndexIDsRequest]) (*connect.Response[api.IndexIDsResponse], error) {
 	// todo check max number of ids
-	added, taskErr := srv.Async.TryNow("indexing", func(ctx context.Context, w io.Writer) error {
-		opts := &IndexOptions{
-			FS:        srv.FS,
-			RootPath:  srv.RootPath,
-			ParseConc: srv.ParseConc,
-			ScanConc:  srv.ScanConc,
-			ObjectIDs: rq.Msg.ObjectIds,
-			Log:       slog.New(slog.NewJSONHandler(io.MultiWriter(os.Stderr, w), &slog.HandlerOptions{})),
-		}
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	task := &Task{
+		Name:        "indexing",
+		StorageRoot: root.Name,
+		Options: map[string]string{
+			"force":      strconv.FormatBool(rq.Msg.Force),
+			"object_ids": strings.Join(rq.Msg.ObjectIds, " "),
+		},
+	}
+	srv.setTaskTimeout(task, rq.Msg.Timeout)
+	taskErr, err := srv.Async.AddTargeted(ctx, task, func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		opts := srv.indexOptions(root, w)
+		opts.ObjectIDs = rq.Msg.ObjectIds
+		opts.Force = rq.Msg.Force
+		opts.Counts = counts
 		return srv.Indexer.Index(ctx, opts)
 	})
-	if !added {
-		return nil, errors.New("an indexing task is already running")
+	if err != nil {
+		return nil, err
 	}
 	if err := <-taskErr; err != nil {
 		return nil, err
 	}
-	return connect.NewResponse(&api.IndexIDsResponse{}), nil
+	return connect.NewResponse(&api.IndexIDsResponse{TaskId: task.ID}), nil
 	// return srv.Async.MonitorOn(ctx, rq, stream, taskErr)
 }
 
-func (srv Service) GetStatus(ctx context.Context, _ *connect.Request[api.GetStatusRequest]) (*connect.Response[api.GetStatusResponse], error) {
-	// FIXME: shouldn't need to load storage root with each call
-	store, err := ocflv1.GetStore(ctx, srv.FS, srv.RootPath)
+func (srv Service) ValidateObjects(ctx context.Context, rq *connect.Request[api.ValidateObjectsRequest]) (*connect.Response[api.ValidateObjectsResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if rq.Msg.Oldest < 0 {
+		return nil, fmt.Errorf("oldest must not be negative: %w", ErrInvalidArgs)
+	}
+	paths := make([]string, len(rq.Msg.ObjectIds))
+	for i, id := range rq.Msg.ObjectIds {
+		obj, err := srv.Indexer.GetObject(ctx, root.Name, id)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = obj.RootPath
+	}
+	task := &Task{
+		Name:        "validating",
+		StorageRoot: root.Name,
+		Options:     map[string]string{},
+	}
+	if len(rq.Msg.ObjectIds) > 0 {
+		task.Options["object_ids"] = strings.Join(rq.Msg.ObjectIds, " ")
+	} else if rq.Msg.Oldest > 0 {
+		task.Options["oldest"] = strconv.Itoa(int(rq.Msg.Oldest))
+	}
+	srv.setTaskTimeout(task, rq.Msg.Timeout)
+	_, err = srv.Async.Add(ctx, task, func(ctx context.Context, w io.Writer, counts TaskCounts) error {
+		opts := srv.validateOptions(root, w)
+		opts.ObjectPaths = paths
+		opts.Oldest = int(rq.Msg.Oldest)
+		opts.Counts = counts
+		return srv.Indexer.ValidateObjects(ctx, opts)
+	})
 	if err != nil {
 		return nil, err
 	}
-	summ, err := srv.Indexer.GetIndexSummary(ctx)
+	return connect.NewResponse(&api.ValidateObjectsResponse{TaskId: task.ID}), nil
+}
+
+// setTaskTimeout sets the task's timeout from the request, or the service's
+// default, and records it in the task's options.
+func (srv Service) setTaskTimeout(task *Task, timeout *durationpb.Duration) {
+	task.Timeout = srv.TaskTimeout
+	if timeout != nil {
+		task.Timeout = timeout.AsDuration()
+	}
+	if task.Timeout > 0 {
+		task.Options["timeout"] = task.Timeout.String()
+	}
+}
+
+func (srv Service) GetStatus(ctx context.Context, rq *connect.Request[api.GetStatusRequest]) (*connect.Response[api.GetStatusResponse], error) {
+	selected, err := srv.storageRoot(rq.Msg.StorageRoot)
 	if err != nil {
 		return nil, err
 	}
 	msg := &api.GetStatusResponse{
-		StoreRootPath:    srv.RootPath,
-		StoreDescription: store.Description(),
-		StoreSpec:        store.Spec().String(),
-		NumInventories:   int32(summ.NumInventories),
-		NumObjectPaths:   int32(summ.NumObjects),
-		Status:           srv.Async.status,
+		Status:       srv.Async.Status(),
+		StorageRoot:  selected.Name,
+		StorageRoots: make([]*api.GetStatusResponse_StorageRoot, len(srv.Roots)),
+	}
+	for i, root := range srv.Roots {
+		// FIXME: shouldn't need to load storage root with each call
+		store, err := ocflv1.GetStore(ctx, root.FS, root.Path)
+		if err != nil {
+			return nil, fmt.Errorf("storage root '%s': %w", root.Name, err)
+		}
+		summ, err := srv.Indexer.GetIndexSummary(ctx, root.Name)
+		if err != nil {
+			return nil, err
+		}
+		msg.StorageRoots[i] = &api.GetStatusResponse_StorageRoot{
+			Name:           root.Name,
+			RootPath:       root.Path,
+			Description:    store.Description(),
+			Spec:           store.Spec().String(),
+			NumInventories: int32(summ.NumInventories),
+			NumObjectPaths: int32(summ.NumObjects),
+		}
+		if root.Name == selected.Name {
+			msg.StoreRootPath = root.Path
+			msg.StoreDescription = store.Description()
+			msg.StoreSpec = store.Spec().String()
+			msg.NumInventories = int32(summ.NumInventories)
+			msg.NumObjectPaths = int32(summ.NumObjects)
+		}
 	}
 	return connect.NewResponse(msg), nil
 }
 
 func (srv Service) ListObjects(ctx context.Context, rq *connect.Request[api.ListObjectsRequest]) (*connect.Response[api.ListObjectsResponse], error) {
-	objects, err := srv.Indexer.ListObjects(ctx, rq.Msg.IdPrefix, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := srv.Indexer.ListObjects(ctx, root.Name, rq.Msg.IdPrefix, int(rq.Msg.PageSize), rq.Msg.PageToken)
 	if err != nil {
 		return nil, err
 	}
@@ -108,24 +256,145 @@ func (srv Service) ListObjects(ctx context.Context, rq *connect.Request[api.List
 }
 
 func (srv Service) GetObject(ctx context.Context, rq *connect.Request[api.GetObjectRequest]) (*connect.Response[api.GetObjectResponse], error) {
-	obj, err := srv.Indexer.GetObject(ctx, rq.Msg.ObjectId)
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := srv.Indexer.GetObject(ctx, root.Name, rq.Msg.ObjectId)
 	if err != nil {
 		return nil, err
 	}
-	return asGetObjectResponse(obj), nil
+	resp := asGetObjectResponse(obj)
+	check, err := srv.Indexer.GetFixityCheck(ctx, root.Name, obj.RootPath)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if check != nil {
+		resp.Msg.Fixity = &api.FixityCheck{
+			Valid:     check.Valid,
+			Codes:     check.Codes,
+			Error:     check.Error,
+			CheckedAt: timestamppb.New(check.CheckedAt),
+		}
+	}
+	return resp, nil
+}
+
+func (srv Service) Search(ctx context.Context, rq *connect.Request[api.SearchRequest]) (*connect.Response[api.SearchResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	results, err := srv.Indexer.Search(ctx, root.Name, rq.Msg.Query, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return asSearchResponse(results), nil
+}
+
+func (srv Service) FindByDigest(ctx context.Context, rq *connect.Request[api.FindByDigestRequest]) (*connect.Response[api.FindByDigestResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	list, err := srv.Indexer.FindByDigest(ctx, root.Name, rq.Msg.Digest, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return asFindByDigestResponse(list), nil
+}
+
+func (srv Service) DiffVersions(ctx context.Context, rq *connect.Request[api.DiffVersionsRequest]) (*connect.Response[api.DiffVersionsResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	from := VersionRef{ObjectID: rq.Msg.ObjectId}
+	to := VersionRef{ObjectID: rq.Msg.ToObjectId}
+	if to.ObjectID == "" {
+		to.ObjectID = from.ObjectID
+	}
+	if v := rq.Msg.FromVersion; v != "" {
+		if err := ocfl.ParseVNum(v, &from.Version); err != nil {
+			return nil, fmt.Errorf("invalid version '%s': %w", v, ErrInvalidArgs)
+		}
+	}
+	if v := rq.Msg.ToVersion; v != "" {
+		if err := ocfl.ParseVNum(v, &to.Version); err != nil {
+			return nil, fmt.Errorf("invalid version '%s': %w", v, ErrInvalidArgs)
+		}
+	}
+	diff, err := srv.Indexer.DiffVersions(ctx, root.Name, from, to, rq.Msg.BasePath, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return asDiffVersionsResponse(diff), nil
+}
+
+func (srv Service) ListInvalidObjects(ctx context.Context, rq *connect.Request[api.ListInvalidObjectsRequest]) (*connect.Response[api.ListInvalidObjectsResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	list, err := srv.Indexer.ListInvalidObjects(ctx, root.Name, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return asListInvalidObjectsResponse(list), nil
 }
 
 func (srv Service) FollowLogs(ctx context.Context, rq *connect.Request[api.FollowLogsRequest], stream *connect.ServerStream[api.FollowLogsResponse]) error {
+	if id := rq.Msg.TaskId; id != 0 {
+		if _, err := srv.Indexer.GetTask(ctx, id); err != nil {
+			return err
+		}
+	}
 	return srv.Async.MonitorOn(ctx, rq, stream, nil)
 }
 
+func (srv Service) ListTasks(ctx context.Context, rq *connect.Request[api.ListTasksRequest]) (*connect.Response[api.ListTasksResponse], error) {
+	list, err := srv.Indexer.ListTasks(ctx, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	msg := &api.ListTasksResponse{
+		Tasks:         make([]*api.Task, len(list.Tasks)),
+		NextPageToken: list.NextCursor,
+	}
+	for i := range list.Tasks {
+		msg.Tasks[i] = asTask(&list.Tasks[i])
+	}
+	return connect.NewResponse(msg), nil
+}
+
+func (srv Service) GetTask(ctx context.Context, rq *connect.Request[api.GetTaskRequest]) (*connect.Response[api.GetTaskResponse], error) {
+	task, err := srv.Indexer.GetTask(ctx, rq.Msg.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&api.GetTaskResponse{Task: asTask(task)}), nil
+}
+
 // HTTPHandler returns new http.Handler for the index service
 func (srv Service) HTTPHandler() http.Handler {
 	mux := chi.NewRouter()
 	mux.Use(RequestLogger(srv.Log))
-	mux.Mount(ocflv1connect.NewIndexServiceHandler(srv))
-	mux.Get(downloadPrefix+"/{sum}", srv.downloadHandler())
-	mux.Get(downloadPrefix+"/{sum}/{name}", srv.downloadHandler())
+	if srv.Auth != nil {
+		mux.Use(srv.Auth.Middleware)
+	}
+	mux.Mount(ocflv1connect.NewIndexServiceHandler(srv, connect.WithInterceptors(errorInterceptor{})))
+	mux.Mount(restPrefix, srv.restHandler())
+	mux.Get(downloadPrefix+"/{alg}/{sum}", srv.downloadHandler())
+	mux.Get(downloadPrefix+"/{alg}/{sum}/{name}", srv.downloadHandler())
+	mux.Head(downloadPrefix+"/{alg}/{sum}", srv.downloadHandler())
+	mux.Head(downloadPrefix+"/{alg}/{sum}/{name}", srv.downloadHandler())
+	mux.Get(objectsPrefix+"/{object_id}/versions/{version}/files/*", srv.filesHandler())
+	mux.Head(objectsPrefix+"/{object_id}/versions/{version}/files/*", srv.filesHandler())
+	mux.Get(archivePrefix+"/{object_id}/{version}", srv.archiveHandler())
+	mux.Get(archivePrefix+"/{object_id}/{version}/*", srv.archiveHandler())
+	if srv.Webhook != nil {
+		mux.Post(s3EventsPath, srv.Webhook.ServeHTTP)
+	}
 	return mux
 }
 
@@ -147,50 +416,117 @@ func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-func (srv Service) downloadHandler() func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		sum := chi.URLParam(r, "sum")
-		if sum == "" {
-			http.NotFound(w, r)
-			return
-		}
-		name := chi.URLParam(r, "name")
-		if name == "" {
-			name = sum
-		}
-		p, err := srv.Indexer.GetContentPath(ctx, sum)
-		if err != nil {
-			http.NotFound(w, r)
-			return
+func (srv Service) GetObjectState(ctx context.Context, rq *connect.Request[api.GetObjectStateRequest]) (*connect.Response[api.GetObjectStateResponse], error) {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return nil, err
+	}
+	var vnum ocfl.VNum
+	if v := rq.Msg.Version; v != "" {
+		if err := ocfl.ParseVNum(v, &vnum); err != nil {
+			return nil, fmt.Errorf("invalid version '%s': %w", v, ErrInvalidArgs)
 		}
-		f, err := srv.FS.OpenFile(ctx, path.Join(srv.RootPath, p))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	}
+	list, err := srv.Indexer.GetObjectState(ctx, root.Name, rq.Msg.ObjectId, vnum, rq.Msg.BasePath, rq.Msg.Recursive, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return asGetObjectStateResponse(list), nil
+
+}
+
+// streamBatchSize is the maximum number of items in each message sent by
+// StreamObjects and StreamObjectState.
+const streamBatchSize = 1000
+
+// StreamObjects sends objects in batches as they are read from the index.
+// Send blocks until the client is ready for more, so the number of objects
+// held in memory is limited to one batch.
+func (srv Service) StreamObjects(ctx context.Context, rq *connect.Request[api.StreamObjectsRequest], stream *connect.ServerStream[api.StreamObjectsResponse]) error {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return connectError(err, rq.Msg)
+	}
+	msg := &api.StreamObjectsResponse{}
+	err = srv.Indexer.EachObject(ctx, root.Name, rq.Msg.IdPrefix, func(obj *ObjectListItem) error {
+		msg.Objects = append(msg.Objects, &api.ListObjectsResponse_Object{
+			ObjectId:    obj.ID,
+			Head:        obj.Head.String(),
+			V1Created:   timestamppb.New(obj.V1Created),
+			HeadCreated: timestamppb.New(obj.HeadCreated),
+		})
+		if len(msg.Objects) < streamBatchSize {
+			return nil
 		}
-		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
-		defer f.Close()
-		if _, err = io.Copy(w, f); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		if err := stream.Send(msg); err != nil {
+			return err
 		}
+		msg.Objects = msg.Objects[:0]
+		return nil
+	})
+	if err != nil {
+		return connectError(err, rq.Msg)
 	}
+	if len(msg.Objects) > 0 {
+		return stream.Send(msg)
+	}
+	return nil
 }
 
-func (srv Service) GetObjectState(ctx context.Context, rq *connect.Request[api.GetObjectStateRequest]) (*connect.Response[api.GetObjectStateResponse], error) {
+// StreamObjectState sends the children of the base path in batches as they are
+// read from the index. The first message describes the base path and is sent
+// even if it doesn't have children.
+func (srv Service) StreamObjectState(ctx context.Context, rq *connect.Request[api.StreamObjectStateRequest], stream *connect.ServerStream[api.StreamObjectStateResponse]) error {
+	root, err := srv.storageRoot(rq.Msg.StorageRoot)
+	if err != nil {
+		return connectError(err, rq.Msg)
+	}
 	var vnum ocfl.VNum
 	if v := rq.Msg.Version; v != "" {
-		if err := ocfl.ParseVNum(rq.Msg.Version, &vnum); err != nil {
-			return nil, err
+		if err := ocfl.ParseVNum(v, &vnum); err != nil {
+			return connectError(fmt.Errorf("invalid version '%s': %w", v, ErrInvalidArgs), rq.Msg)
 		}
 	}
-	list, err := srv.Indexer.GetObjectState(ctx, rq.Msg.ObjectId, vnum, rq.Msg.BasePath, rq.Msg.Recursive, int(rq.Msg.PageSize), rq.Msg.PageToken)
+	// the base path's digest and size; the first child is a cheap lookup
+	base, err := srv.Indexer.GetObjectState(ctx, root.Name, rq.Msg.ObjectId, vnum, rq.Msg.BasePath, false, 1, "")
 	if err != nil {
-		return nil, err
+		return connectError(err, rq.Msg)
 	}
-	return asGetObjectStateResponse(list), nil
-
+	msg := &api.StreamObjectStateResponse{
+		Digest:  base.Sum,
+		Isdir:   base.IsDir,
+		Size:    base.Size,
+		HasSize: base.HasSize,
+	}
+	if !base.IsDir || len(base.Children) == 0 {
+		return stream.Send(msg)
+	}
+	sent := false
+	err = srv.Indexer.EachObjectStateItem(ctx, root.Name, rq.Msg.ObjectId, vnum, rq.Msg.BasePath, rq.Msg.Recursive, func(p *PathItem) error {
+		msg.Children = append(msg.Children, &api.GetObjectStateResponse_Item{
+			Name:    p.Name,
+			Size:    p.Size,
+			HasSize: p.HasSize,
+			Isdir:   p.IsDir,
+			Digest:  p.Sum,
+		})
+		if len(msg.Children) < streamBatchSize {
+			return nil
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+		sent = true
+		msg = &api.StreamObjectStateResponse{Children: msg.Children[:0]}
+		return nil
+	})
+	if err != nil {
+		return connectError(err, rq.Msg)
+	}
+	if !sent || len(msg.Children) > 0 {
+		return stream.Send(msg)
+	}
+	return nil
 }
 
 func asGetObjectStateResponse(inf *PathInfo) *connect.Response[api.GetObjectStateResponse] {
@@ -231,19 +567,160 @@ func asObjectListResponse(objects *ObjectList) *connect.Response[api.ListObjects
 	return connect.NewResponse(msg)
 }
 
+func asSearchResponse(results *SearchResults) *connect.Response[api.SearchResponse] {
+	msg := &api.SearchResponse{
+		Results:       make([]*api.SearchResponse_Result, len(results.Results)),
+		NextPageToken: results.NextCursor,
+	}
+	for i, r := range results.Results {
+		msg.Results[i] = &api.SearchResponse_Result{
+			ObjectId: r.ObjectID,
+			Version:  r.Version.String(),
+			Path:     r.Path,
+		}
+	}
+	return connect.NewResponse(msg)
+}
+
+func asFindByDigestResponse(list *DigestMatchList) *connect.Response[api.FindByDigestResponse] {
+	msg := &api.FindByDigestResponse{
+		Objects:       make([]*api.FindByDigestResponse_Object, len(list.Objects)),
+		NextPageToken: list.NextCursor,
+	}
+	for i, obj := range list.Objects {
+		msg.Objects[i] = &api.FindByDigestResponse_Object{
+			ObjectId: obj.ID,
+			Paths:    make([]*api.FindByDigestResponse_Object_Path, len(obj.Matches)),
+		}
+		for j, m := range obj.Matches {
+			msg.Objects[i].Paths[j] = &api.FindByDigestResponse_Object_Path{
+				Version: m.Version.String(),
+				Path:    m.Path,
+				Isdir:   m.IsDir,
+			}
+		}
+	}
+	return connect.NewResponse(msg)
+}
+
+func (srv Service) CancelTask(ctx context.Context, rq *connect.Request[api.CancelTaskRequest]) (*connect.Response[api.CancelTaskResponse], error) {
+	if _, err := srv.Indexer.GetTask(ctx, rq.Msg.TaskId); err != nil {
+		return nil, err
+	}
+	if err := srv.Async.Cancel(rq.Msg.TaskId); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&api.CancelTaskResponse{}), nil
+}
+
+func asTask(task *Task) *api.Task {
+	msg := &api.Task{
+		Id:          task.ID,
+		Name:        task.Name,
+		StorageRoot: task.StorageRoot,
+		Options:     task.Options,
+		Status:      string(task.Status),
+		StartedAt:   timestamppb.New(task.StartedAt),
+		Counts:      task.Counts,
+		Error:       task.Error,
+	}
+	if !task.EndedAt.IsZero() {
+		msg.EndedAt = timestamppb.New(task.EndedAt)
+	}
+	return msg
+}
+
+func asProgress(p *Progress) *api.Progress {
+	msg := &api.Progress{
+		StorageRoot: p.StorageRoot,
+		Phase:       api.Progress_Phase(p.Phase),
+		Found:       p.Found,
+		Processed:   p.Processed,
+		Skipped:     p.Skipped,
+		Errors:      p.Errors,
+	}
+	if p.ETA > 0 {
+		msg.Eta = durationpb.New(p.ETA)
+	}
+	return msg
+}
+
+func asListInvalidObjectsResponse(list *InvalidObjectList) *connect.Response[api.ListInvalidObjectsResponse] {
+	msg := &api.ListInvalidObjectsResponse{
+		Objects:       make([]*api.ListInvalidObjectsResponse_Object, len(list.Objects)),
+		NextPageToken: list.NextCursor,
+	}
+	for i, obj := range list.Objects {
+		issues := make([]*api.ListInvalidObjectsResponse_Issue, len(obj.Issues))
+		for j, issue := range obj.Issues {
+			issues[j] = &api.ListInvalidObjectsResponse_Issue{
+				Severity:      string(issue.Severity),
+				Code:          issue.Code,
+				Message:       issue.Message,
+				InventoryPath: issue.InventoryPath,
+				CheckedAt:     timestamppb.New(issue.CheckedAt),
+			}
+		}
+		msg.Objects[i] = &api.ListInvalidObjectsResponse_Object{
+			ObjectPath: obj.RootPath,
+			Issues:     issues,
+		}
+	}
+	return connect.NewResponse(msg)
+}
+
+func asDiffVersionsResponse(diff *VersionDiff) *connect.Response[api.DiffVersionsResponse] {
+	msg := &api.DiffVersionsResponse{
+		Changes:       make([]*api.DiffVersionsResponse_Change, len(diff.Changes)),
+		NextPageToken: diff.NextCursor,
+	}
+	for i, c := range diff.Changes {
+		msg.Changes[i] = &api.DiffVersionsResponse_Change{
+			Path:      c.Path,
+			OldPath:   c.OldPath,
+			Digest:    c.Sum,
+			OldDigest: c.OldSum,
+		}
+		switch c.Type {
+		case Added:
+			msg.Changes[i].Type = api.DiffVersionsResponse_CHANGE_TYPE_ADDED
+		case Removed:
+			msg.Changes[i].Type = api.DiffVersionsResponse_CHANGE_TYPE_REMOVED
+		case Modified:
+			msg.Changes[i].Type = api.DiffVersionsResponse_CHANGE_TYPE_MODIFIED
+		case Renamed:
+			msg.Changes[i].Type = api.DiffVersionsResponse_CHANGE_TYPE_RENAMED
+		}
+	}
+	return connect.NewResponse(msg)
+}
+
 func asGetObjectResponse(obj *Object) *connect.Response[api.GetObjectResponse] {
 	msg := &api.GetObjectResponse{
 		ObjectId:        obj.ID,
 		RootPath:        obj.RootPath,
 		DigestAlgorithm: obj.DigestAlgorithm,
 		Spec:            obj.Spec.String(),
+		InventoryDigest: obj.InventoryDigest,
+		// the sidecar uses the inventory's digest algorithm
+		SidecarAlgorithm: obj.DigestAlgorithm,
+	}
+	if !obj.IndexedAt.IsZero() {
+		msg.IndexedAt = timestamppb.New(obj.IndexedAt)
 	}
 	msg.Versions = make([]*api.GetObjectResponse_Version, len(obj.Versions))
 	for i, v := range obj.Versions {
 		msg.Versions[i] = &api.GetObjectResponse_Version{
-			Num:     v.Num.String(),
-			Message: v.Message,
-			Created: timestamppb.New(v.Created),
+			Num:         v.Num.String(),
+			Message:     v.Message,
+			Created:     timestamppb.New(v.Created),
+			Size:        v.Size,
+			HasSize:     v.HasSize,
+			NumFiles:    int64(v.Stats.Files),
+			NumAdded:    int64(v.Stats.Added),
+			NumRemoved:  int64(v.Stats.Removed),
+			NumModified: int64(v.Stats.Modified),
+			HasStats:    v.HasStats,
 		}
 		if v.User != nil {
 			msg.Versions[i].User = &api.GetObjectResponse_Version_User{
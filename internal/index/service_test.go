@@ -10,6 +10,7 @@ import (
 	"github.com/bufbuild/connect-go"
 	api "github.com/srerickson/ocfl-index/gen/ocfl/v1"
 	"github.com/srerickson/ocfl-index/gen/ocfl/v1/ocflv1connect"
+	"github.com/srerickson/ocfl-index/internal/index"
 )
 
 func TestServiceGetStatus(t *testing.T) {
@@ -24,6 +25,81 @@ func TestServiceGetObejct(t *testing.T) {
 	runServiceTest(t, testGetObjectSimpleRequest)
 }
 
+func TestServiceStream(t *testing.T) {
+	runServiceTest(t, testStreamRequests)
+}
+
+func TestServiceErrors(t *testing.T) {
+	runServiceTest(t, testErrors)
+}
+
+func TestServiceSearch(t *testing.T) {
+	runServiceTest(t, testSearchRequest)
+}
+
+func TestServiceFindByDigest(t *testing.T) {
+	runServiceTest(t, testFindByDigestRequest)
+}
+
+func TestServiceDiffVersions(t *testing.T) {
+	runServiceTest(t, testDiffVersionsRequest)
+}
+
+func TestServiceTasks(t *testing.T) {
+	runServiceTest(t, testTasksRequest)
+}
+
+func TestServiceValidateObjects(t *testing.T) {
+	runServiceTest(t, testValidateObjectsRequest)
+}
+
+func TestServiceStorageRoots(t *testing.T) {
+	ctx := context.Background()
+	service, err := newTestService(ctx, "simple-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// second storage root that hasn't been indexed
+	service.Roots = append(service.Roots, index.StorageRoot{
+		Name: "other",
+		FS:   service.Roots[0].FS,
+		Path: service.Roots[0].Path,
+	})
+	httpSrv := httptest.NewTLSServer(service.HTTPHandler())
+	defer httpSrv.Close()
+	cli := ocflv1connect.NewIndexServiceClient(httpSrv.Client(), httpSrv.URL)
+	rsp, err := cli.GetStatus(ctx, connect.NewRequest(&api.GetStatusRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "default storage root", rsp.Msg.StorageRoot, index.DefaultStorageRoot)
+	expEq(t, "number of storage roots", len(rsp.Msg.StorageRoots), 2)
+	expEq(t, "first storage root", rsp.Msg.StorageRoots[0].Name, index.DefaultStorageRoot)
+	expEq(t, "first storage root inventories", rsp.Msg.StorageRoots[0].NumInventories, int32(3))
+	expEq(t, "second storage root", rsp.Msg.StorageRoots[1].Name, "other")
+	expEq(t, "second storage root inventories", rsp.Msg.StorageRoots[1].NumInventories, int32(0))
+	rsp, err = cli.GetStatus(ctx, connect.NewRequest(&api.GetStatusRequest{StorageRoot: "other"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "selected storage root", rsp.Msg.StorageRoot, "other")
+	expEq(t, "selected storage root inventories", rsp.Msg.NumInventories, int32(0))
+	// object only indexed in the default storage root
+	id := "ark:/12345/bcd987"
+	_, err = cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id, StorageRoot: "other"}))
+	if err == nil {
+		t.Fatal("expected an error for object in un-indexed storage root")
+	}
+	_, err = cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id, StorageRoot: "missing"}))
+	if err == nil {
+		t.Fatal("expected an error for unknown storage root")
+	}
+}
+
 // Helpers below
 
 type serviceTestFunc func(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient)
@@ -86,7 +162,292 @@ func testGetObjectSimpleRequest(t *testing.T, ctx context.Context, cli ocflv1con
 	expEq(t, "algorith", rsp.Msg.DigestAlgorithm, "sha512")
 	expEq(t, "object root", rsp.Msg.RootPath, "ark%3A%2F12345%2Fbcd987")
 	expEq(t, "spec", rsp.Msg.Spec, "1.0")
+	expEq(t, "inventory digest", rsp.Msg.InventoryDigest, "9c79b29388b177feead576b156376e9180fcab81710fcdd3cac95b79d822c3b09b30d115619a861eb3d74c2933ef3689ba4660a6bc31001da1e87db281d09f66")
+	expEq(t, "sidecar algorithm", rsp.Msg.SidecarAlgorithm, "sha512")
+	expEq(t, "has indexed_at", rsp.Msg.IndexedAt != nil, true)
 	expEq(t, "number version", len(rsp.Msg.Versions), 3)
+	// [files, added, removed, modified] for each version
+	expStats := [][4]int64{{3, 3, 0, 0}, {3, 1, 1, 1}, {3, 1, 1, 0}}
+	for i, v := range rsp.Msg.Versions {
+		expEq(t, v.Num+" has stats", v.HasStats, true)
+		expEq(t, v.Num+" stats", [4]int64{v.NumFiles, v.NumAdded, v.NumRemoved, v.NumModified}, expStats[i])
+	}
+}
+
+// errors from index are returned with connect codes and details
+func testErrors(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	id := "ark:/12345/bcd987"
+	expCode := func(t *testing.T, err error, code connect.Code) *api.ErrorDetail {
+		t.Helper()
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		expEq(t, "error code", connect.CodeOf(err), code)
+		var connectErr *connect.Error
+		if !errors.As(err, &connectErr) || len(connectErr.Details()) == 0 {
+			t.Fatal("expected error details")
+		}
+		val, err := connectErr.Details()[0].Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		detail, ok := val.(*api.ErrorDetail)
+		if !ok {
+			t.Fatalf("expected ErrorDetail, got %T", val)
+		}
+		return detail
+	}
+	t.Run("unknown object", func(t *testing.T) {
+		_, err := cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: "missing"}))
+		detail := expCode(t, err, connect.CodeNotFound)
+		expEq(t, "detail object id", detail.ObjectId, "missing")
+		expEq(t, "detail reason", detail.Reason, index.ErrNotFound.Error())
+	})
+	t.Run("unknown storage root", func(t *testing.T) {
+		_, err := cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id, StorageRoot: "missing"}))
+		detail := expCode(t, err, connect.CodeNotFound)
+		expEq(t, "detail storage root", detail.StorageRoot, "missing")
+	})
+	t.Run("missing path", func(t *testing.T) {
+		_, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{ObjectId: id, Version: "v1", BasePath: "missing"}))
+		detail := expCode(t, err, connect.CodeNotFound)
+		expEq(t, "detail object id", detail.ObjectId, id)
+		expEq(t, "detail version", detail.Version, "v1")
+		expEq(t, "detail path", detail.Path, "missing")
+	})
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{ObjectId: id, BasePath: "../a"}))
+		expCode(t, err, connect.CodeInvalidArgument)
+	})
+	t.Run("invalid version", func(t *testing.T) {
+		_, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{ObjectId: id, Version: "one"}))
+		detail := expCode(t, err, connect.CodeInvalidArgument)
+		expEq(t, "detail version", detail.Version, "one")
+	})
+	t.Run("invalid search", func(t *testing.T) {
+		_, err := cli.Search(ctx, connect.NewRequest(&api.SearchRequest{}))
+		expCode(t, err, connect.CodeInvalidArgument)
+	})
+}
+
+// StreamObjectsRequest and StreamObjectStateRequest
+func testStreamRequests(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	id := "ark:/12345/bcd987"
+	t.Run("objects", func(t *testing.T) {
+		list, err := cli.ListObjects(ctx, connect.NewRequest(&api.ListObjectsRequest{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, err := cli.StreamObjects(ctx, connect.NewRequest(&api.StreamObjectsRequest{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Close()
+		var ids []string
+		for stream.Receive() {
+			for _, obj := range stream.Msg().Objects {
+				ids = append(ids, obj.ObjectId)
+			}
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "number of objects", len(ids), len(list.Msg.Objects))
+		for i, obj := range list.Msg.Objects {
+			expEq(t, "object id", ids[i], obj.ObjectId)
+		}
+	})
+	streamState := func(t *testing.T, req *api.StreamObjectStateRequest) ([]*api.StreamObjectStateResponse, error) {
+		t.Helper()
+		stream, err := cli.StreamObjectState(ctx, connect.NewRequest(req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Close()
+		var msgs []*api.StreamObjectStateResponse
+		for stream.Receive() {
+			msgs = append(msgs, stream.Msg())
+		}
+		return msgs, stream.Err()
+	}
+	t.Run("state", func(t *testing.T) {
+		state, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{
+			ObjectId:  id,
+			Version:   "v1",
+			Recursive: true,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs, err := streamState(t, &api.StreamObjectStateRequest{ObjectId: id, Version: "v1", Recursive: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "number of messages", len(msgs), 1)
+		expEq(t, "digest", msgs[0].Digest, state.Msg.Digest)
+		expEq(t, "isdir", msgs[0].Isdir, true)
+		expEq(t, "number of children", len(msgs[0].Children), len(state.Msg.Children))
+		for i, child := range state.Msg.Children {
+			expEq(t, "child name", msgs[0].Children[i].Name, child.Name)
+			expEq(t, "child digest", msgs[0].Children[i].Digest, child.Digest)
+		}
+	})
+	t.Run("file", func(t *testing.T) {
+		msgs, err := streamState(t, &api.StreamObjectStateRequest{ObjectId: id, Version: "v1", BasePath: "foo/bar.xml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expEq(t, "number of messages", len(msgs), 1)
+		expEq(t, "isdir", msgs[0].Isdir, false)
+		expEq(t, "digest length", len(msgs[0].Digest), 128)
+		expEq(t, "number of children", len(msgs[0].Children), 0)
+	})
+	t.Run("missing path", func(t *testing.T) {
+		_, err := streamState(t, &api.StreamObjectStateRequest{ObjectId: id, Version: "v1", BasePath: "missing"})
+		expEq(t, "error code", connect.CodeOf(err), connect.CodeNotFound)
+		var connectErr *connect.Error
+		if !errors.As(err, &connectErr) || len(connectErr.Details()) == 0 {
+			t.Fatal("expected error details")
+		}
+		val, err := connectErr.Details()[0].Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		detail, ok := val.(*api.ErrorDetail)
+		if !ok {
+			t.Fatalf("expected ErrorDetail, got %T", val)
+		}
+		expEq(t, "detail path", detail.Path, "missing")
+	})
+}
+
+// SearchRequest
+func testSearchRequest(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	req := connect.NewRequest(&api.SearchRequest{Query: "bcd987"})
+	rsp, err := cli.Search(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "number of results", len(rsp.Msg.Results), 3)
+	for _, r := range rsp.Msg.Results {
+		expEq(t, "result object_id", r.ObjectId, "ark:/12345/bcd987")
+		expEq(t, "result path", r.Path, "")
+	}
+	_, err = cli.Search(ctx, connect.NewRequest(&api.SearchRequest{}))
+	if err == nil {
+		t.Fatal("expected an error for empty query")
+	}
+}
+
+// FindByDigestRequest
+func testFindByDigestRequest(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	id := "ark:/12345/bcd987"
+	state, err := cli.GetObjectState(ctx, connect.NewRequest(&api.GetObjectStateRequest{
+		ObjectId: id,
+		Version:  "v1",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := cli.FindByDigest(ctx, connect.NewRequest(&api.FindByDigestRequest{Digest: state.Msg.Digest}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.Msg.Objects) == 0 {
+		t.Fatal("expected some objects")
+	}
+	var found bool
+	for _, obj := range rsp.Msg.Objects {
+		for _, p := range obj.Paths {
+			if obj.ObjectId == id && p.Version == "v1" && p.Path == "." && p.Isdir {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("FindByDigest response doesn't include the version state")
+	}
+	_, err = cli.FindByDigest(ctx, connect.NewRequest(&api.FindByDigestRequest{Digest: "not-hex"}))
+	if err == nil {
+		t.Fatal("expected an error for invalid digest")
+	}
+}
+
+// DiffVersionsRequest
+func testDiffVersionsRequest(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	req := connect.NewRequest(&api.DiffVersionsRequest{
+		ObjectId:    "ark:/12345/bcd987",
+		FromVersion: "v1",
+		ToVersion:   "v3",
+	})
+	rsp, err := cli.DiffVersions(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.Msg.Changes) == 0 {
+		t.Fatal("expected some changes")
+	}
+	for _, c := range rsp.Msg.Changes {
+		if c.Type == api.DiffVersionsResponse_CHANGE_TYPE_UNSPECIFIED {
+			t.Fatalf("change type not set for '%s'", c.Path)
+		}
+	}
+	req.Msg.ToVersion = "v1"
+	rsp, err = cli.DiffVersions(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "changes for same version", len(rsp.Msg.Changes), 0)
+}
+
+func testTasksRequest(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	idxRsp, err := cli.IndexAll(ctx, connect.NewRequest(&api.IndexAllRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskID := idxRsp.Msg.TaskId
+	if taskID == 0 {
+		t.Fatal("expected IndexAll to return a task ID")
+	}
+	// the stream ends when the task is complete
+	stream, err := cli.FollowLogs(ctx, connect.NewRequest(&api.FollowLogsRequest{TaskId: taskID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+		expEq(t, "log message task id", stream.Msg().TaskId, taskID)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	getRsp, err := cli.GetTask(ctx, connect.NewRequest(&api.GetTaskRequest{TaskId: taskID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := getRsp.Msg.Task
+	expEq(t, "task status", task.Status, string(index.TaskSucceeded))
+	expEq(t, "task storage root", task.StorageRoot, index.DefaultStorageRoot)
+	if task.EndedAt == nil {
+		t.Fatal("expected task end time")
+	}
+	if task.Counts["object_roots"] == 0 {
+		t.Fatalf("expected object_roots count, got %v", task.Counts)
+	}
+	listRsp, err := cli.ListTasks(ctx, connect.NewRequest(&api.ListTasksRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listRsp.Msg.Tasks) == 0 {
+		t.Fatal("expected some tasks")
+	}
+	expEq(t, "most recent task id", listRsp.Msg.Tasks[0].Id, taskID)
+	if _, err := cli.CancelTask(ctx, connect.NewRequest(&api.CancelTaskRequest{TaskId: taskID})); err == nil {
+		t.Fatal("expected an error canceling a completed task")
+	}
+	if _, err := cli.GetTask(ctx, connect.NewRequest(&api.GetTaskRequest{TaskId: taskID + 1})); err == nil {
+		t.Fatal("expected an error for a task that doesn't exist")
+	}
 }
 
 func expEq(t *testing.T, desc string, got, expect any) {
@@ -95,3 +456,48 @@ func expEq(t *testing.T, desc string, got, expect any) {
 		t.Fatalf("%s: got='%v', expected='%v'", desc, got, expect)
 	}
 }
+
+// ValidateObjectsRequest
+func testValidateObjectsRequest(t *testing.T, ctx context.Context, cli ocflv1connect.IndexServiceClient) {
+	id := "ark:/12345/bcd987"
+	objRsp, err := cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objRsp.Msg.Fixity != nil {
+		t.Fatal("expected no fixity check before validation")
+	}
+	valRsp, err := cli.ValidateObjects(ctx, connect.NewRequest(&api.ValidateObjectsRequest{ObjectIds: []string{id}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskID := valRsp.Msg.TaskId
+	// the stream ends when the task is complete
+	stream, err := cli.FollowLogs(ctx, connect.NewRequest(&api.FollowLogsRequest{TaskId: taskID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	getRsp, err := cli.GetTask(ctx, connect.NewRequest(&api.GetTaskRequest{TaskId: taskID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expEq(t, "task status", getRsp.Msg.Task.Status, string(index.TaskSucceeded))
+	expEq(t, "validated count", getRsp.Msg.Task.Counts["validated"], int64(1))
+	objRsp, err = cli.GetObject(ctx, connect.NewRequest(&api.GetObjectRequest{ObjectId: id}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objRsp.Msg.Fixity == nil || !objRsp.Msg.Fixity.Valid || objRsp.Msg.Fixity.CheckedAt == nil {
+		t.Fatalf("expected a valid fixity check, got %v", objRsp.Msg.Fixity)
+	}
+	_, err = cli.ValidateObjects(ctx, connect.NewRequest(&api.ValidateObjectsRequest{ObjectIds: []string{"missing"}}))
+	if err == nil {
+		t.Fatal("expected an error validating a missing object")
+	}
+}
@@ -0,0 +1,66 @@
+package index
+
+import (
+	"context"
+	"time"
+)
+
+// TaskStatus is the state of an asynchronous task
+type TaskStatus string
+
+const (
+	TaskQueued    TaskStatus = "queued"
+	TaskRunning   TaskStatus = "running"
+	TaskSucceeded TaskStatus = "succeeded"
+	TaskFailed    TaskStatus = "failed"
+	TaskCanceled  TaskStatus = "canceled"
+)
+
+// Task is the record of an asynchronous task run by Async.
+type Task struct {
+	ID          int64
+	Name        string            // task name (e.g., "indexing")
+	StorageRoot string            // storage root name, if the task is for a single storage root
+	Options     map[string]string // options the task was submitted with
+	Status      TaskStatus
+	StartedAt   time.Time     // when the task started or, if it is queued, when it was queued
+	EndedAt     time.Time     // zero until the task is complete
+	Counts      TaskCounts    // counts reported by the task (e.g., indexed objects)
+	Error       string        // the task's error, if it failed or was canceled
+	Timeout     time.Duration // if set, the task is canceled if it runs longer (not saved)
+}
+
+// TaskCounts are named counts reported by a task.
+type TaskCounts map[string]int64
+
+// add increments the count for name. It is a no-op if counts is nil.
+func (counts TaskCounts) add(name string, n int) {
+	if counts != nil {
+		counts[name] += int64(n)
+	}
+}
+
+// TaskList is a page of task records
+type TaskList struct {
+	Tasks      []Task
+	NextCursor string
+}
+
+// TaskStore is used by Async to persist task records. Backends implement it.
+type TaskStore interface {
+	// CreateTask adds a record for a new task and sets its ID. Task IDs
+	// increase with each new task.
+	CreateTask(ctx context.Context, task *Task) error
+	// UpdateTask updates the status, start and end times, counts, and error
+	// of the task record with the task's ID.
+	UpdateTask(ctx context.Context, task *Task) error
+	// GetTask returns the task record with the given ID.
+	GetTask(ctx context.Context, id int64) (*Task, error)
+	// ListTasks lists task records, most recent first.
+	ListTasks(ctx context.Context, limit int, cursor string) (*TaskList, error)
+	// FailUnfinishedTasks marks the records of queued and running tasks as
+	// failed, with the end time endedAt and the error message msg. Async
+	// calls it when it starts: tasks left unfinished by a previous server
+	// process won't complete.
+	FailUnfinishedTasks(ctx context.Context, endedAt time.Time, msg string) error
+}
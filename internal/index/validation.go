@@ -0,0 +1,67 @@
+package index
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/srerickson/ocfl/validation"
+)
+
+// validationCodeRE matches the OCFL validation code (e.g., 'E034') at the
+// start of a validation error message.
+var validationCodeRE = regexp.MustCompile(`^[EW]\d{3}\b`)
+
+// ValidationSeverity distinguishes validation errors from warnings
+type ValidationSeverity string
+
+const (
+	SeverityError   ValidationSeverity = "error"
+	SeverityWarning ValidationSeverity = "warning"
+)
+
+// ValidationIssue is a validation error or warning for an object, found when
+// its inventory was last validated during indexing.
+type ValidationIssue struct {
+	Severity      ValidationSeverity
+	Code          string    // OCFL validation code (e.g., 'E034'), if known
+	Message       string    // validation error message
+	InventoryPath string    // path of the validated inventory, relative to the storage root
+	CheckedAt     time.Time // when the inventory was validated
+}
+
+// InvalidObject is an object root with validation errors or warnings
+type InvalidObject struct {
+	RootPath string // object path relative to the storage root
+	Issues   []ValidationIssue
+}
+
+// InvalidObjectList is a page of objects with validation issues
+type InvalidObjectList struct {
+	Objects    []InvalidObject
+	NextCursor string
+}
+
+// validationIssues returns the errors and warnings in result as
+// ValidationIssues for the inventory at invPath (relative to the storage
+// root).
+func validationIssues(invPath string, result *validation.Result, checkedAt time.Time) []ValidationIssue {
+	if result == nil {
+		return nil
+	}
+	var issues []ValidationIssue
+	add := func(sev ValidationSeverity, errs []error) {
+		for _, err := range errs {
+			msg := err.Error()
+			issues = append(issues, ValidationIssue{
+				Severity:      sev,
+				Code:          validationCodeRE.FindString(msg),
+				Message:       msg,
+				InventoryPath: invPath,
+				CheckedAt:     checkedAt,
+			})
+		}
+	}
+	add(SeverityError, result.Errors())
+	add(SeverityWarning, result.WarnErrors())
+	return issues
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/postgres/sqlc"
+)
+
+// SetFixityCheck implements index.BackendTx
+func (tx *Tx) SetFixityCheck(ctx context.Context, storageRoot string, check index.FixityCheck) error {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	rootRow, err := qry.GetObjectRoot(ctx, sqlc.GetObjectRootParams{
+		Name: storageRoot,
+		Path: check.RootPath,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("object root '%s': %w", check.RootPath, index.ErrNotFound)
+		}
+		return err
+	}
+	err = qry.UpsertFixityCheck(ctx, sqlc.UpsertFixityCheckParams{
+		RootID:    rootRow.ID,
+		Valid:     check.Valid,
+		Codes:     strings.Join(check.Codes, " "),
+		Error:     check.Error,
+		CheckedAt: check.CheckedAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("indexing fixity check: %w", err)
+	}
+	return nil
+}
+
+// GetFixityCheck implements index.Backend
+func (db *Backend) GetFixityCheck(ctx context.Context, storageRoot string, rootPath string) (*index.FixityCheck, error) {
+	row, err := sqlc.New(db).GetFixityCheck(ctx, sqlc.GetFixityCheckParams{
+		Name: storageRoot,
+		Path: rootPath,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("fixity check for object root '%s': %w", rootPath, index.ErrNotFound)
+		}
+		return nil, err
+	}
+	return &index.FixityCheck{
+		RootPath:  row.Path,
+		Valid:     row.Valid,
+		Codes:     strings.Fields(row.Codes),
+		Error:     row.Error,
+		CheckedAt: row.CheckedAt.UTC(),
+	}, nil
+}
+
+// ListFixityDue implements index.Backend
+func (db *Backend) ListFixityDue(ctx context.Context, storageRoot string, limit int) ([]index.FixityCheck, error) {
+	rows, err := sqlc.New(db).ListFixityDue(ctx, sqlc.ListFixityDueParams{
+		Name:  storageRoot,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]index.FixityCheck, len(rows))
+	for i, row := range rows {
+		checks[i] = index.FixityCheck{
+			RootPath: row.Path,
+			Valid:    row.Valid.Bool,
+			Codes:    strings.Fields(row.Codes.String),
+			Error:    row.Error.String,
+		}
+		if row.CheckedAt.Valid {
+			checks[i].CheckedAt = row.CheckedAt.Time.UTC()
+		}
+	}
+	return checks, nil
+}
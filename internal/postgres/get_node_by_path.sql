@@ -0,0 +1,30 @@
+-- returns single node using object/version/path:
+-- $1: objectid
+-- $2: version
+-- $3: path (which may be '.')
+-- $4: storage root name
+WITH RECURSIVE
+    paths(id, path) AS (
+        SELECT versions.node_id, CAST('.' AS TEXT) COLLATE "C"
+        FROM ocfl_index_versions versions
+        INNER JOIN ocfl_index_inventories invs ON versions.inventory_id = invs.id
+        INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+        WHERE store.name = $4 AND invs.ocfl_id = $1
+        -- if version is '', use objects.head
+        AND versions.name = COALESCE(NULLIF($2::text,''), invs.head)
+    UNION
+        SELECT
+            names.node_id,
+            -- if paths.path is '.', no joining slash for next path
+            CASE WHEN paths.path = '.' THEN names.name
+                ELSE paths.path || '/' || names.name END
+        FROM ocfl_index_names names
+        INNER JOIN paths ON names.parent_id = paths.id
+        WHERE $3::text = CASE WHEN paths.path = '.' THEN names.name
+                ELSE paths.path || '/' || names.name END
+            OR starts_with($3::text, CASE WHEN paths.path = '.' THEN names.name
+                ELSE paths.path || '/' || names.name END || '/')
+    )
+SELECT paths.id, nodes.sum, nodes.dir, nodes.size FROM paths
+    INNER JOIN ocfl_index_nodes nodes ON paths.id = nodes.id
+WHERE paths.path = $3::text LIMIT 1;
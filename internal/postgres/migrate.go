@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/postgres/sqlc"
+)
+
+var (
+	//go:embed migrations
+	migrationFS embed.FS
+
+	// the migrations table is part of schema.sql, but it isn't included in
+	// databases created with older versions of ocfl-index.
+	queryCreateMigrations = `CREATE TABLE IF NOT EXISTS ocfl_index_migrations (
+    name TEXT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL
+);`
+)
+
+// migration is a step that upgrades the schema from one version to the next
+type migration struct {
+	index.Migration
+	from  sqlc.OcflIndexSchema
+	to    sqlc.OcflIndexSchema
+	query string
+}
+
+// PendingMigrations returns the migrations that would be applied by Migrate,
+// in order.
+func (db *Backend) PendingMigrations(ctx context.Context) ([]index.Migration, error) {
+	schema, err := sqlc.New(db).GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema version: %w", err)
+	}
+	plan, err := planMigrations(schema)
+	if err != nil {
+		return nil, err
+	}
+	return migrationInfo(plan), nil
+}
+
+// Migrate upgrades the database schema to the version required by the
+// Backend. Migrations are run in a single transaction: if any step fails, the
+// database is unchanged. The applied migrations are returned.
+func (db *Backend) Migrate(ctx context.Context) ([]index.Migration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("starting new transaction: %w", err)
+	}
+	defer tx.Rollback()
+	// block concurrent migrations by other servers using the database
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE ocfl_index_schema IN EXCLUSIVE MODE;"); err != nil {
+		return nil, fmt.Errorf("locking schema table: %w", err)
+	}
+	qry := sqlc.New(db).WithTx(tx)
+	schema, err := qry.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema version: %w", err)
+	}
+	plan, err := planMigrations(schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	if _, err := tx.ExecContext(ctx, queryCreateMigrations); err != nil {
+		return nil, fmt.Errorf("creating migrations table: %w", err)
+	}
+	for _, m := range plan {
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+		err := qry.SetSchemaVersion(ctx, sqlc.SetSchemaVersionParams{
+			Major: m.to.Major,
+			Minor: m.to.Minor,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: setting schema version: %w", m.Name, err)
+		}
+		err = qry.InsertMigration(ctx, sqlc.InsertMigrationParams{
+			Name:      m.Name,
+			AppliedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: recording migration: %w", m.Name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return migrationInfo(plan), nil
+}
+
+// planMigrations returns the sequence of migrations needed to upgrade the
+// schema from version from to schemaVer.
+func planMigrations(from sqlc.OcflIndexSchema) ([]migration, error) {
+	all, err := readMigrations(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrationPath(all, from, schemaVer)
+}
+
+// migrationPath returns migrations from all that upgrade the schema from
+// version from to version to.
+func migrationPath(all []migration, from, to sqlc.OcflIndexSchema) ([]migration, error) {
+	if schemaLess(to, from) {
+		return nil, fmt.Errorf("database uses schema v%d.%d, which is newer than v%d.%d", from.Major, from.Minor, to.Major, to.Minor)
+	}
+	var plan []migration
+	cur := from
+	for cur != to {
+		i := sort.Search(len(all), func(i int) bool { return !schemaLess(all[i].from, cur) })
+		if i == len(all) || all[i].from != cur {
+			return nil, fmt.Errorf("no migration from schema v%d.%d to v%d.%d", cur.Major, cur.Minor, to.Major, to.Minor)
+		}
+		plan = append(plan, all[i])
+		cur = all[i].to
+	}
+	return plan, nil
+}
+
+// readMigrations returns the migrations in dir, sorted by starting schema
+// version.
+func readMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var all []migration
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m := migration{}
+		m.Name = strings.TrimSuffix(e.Name(), ".sql")
+		_, err := fmt.Sscanf(m.Name, "%d.%d-%d.%d", &m.from.Major, &m.from.Minor, &m.to.Major, &m.to.Minor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %q: %w", e.Name(), err)
+		}
+		if !schemaLess(m.from, m.to) {
+			return nil, fmt.Errorf("invalid migration name %q: versions out of order", e.Name())
+		}
+		byts, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m.query = string(byts)
+		// description is the first line comment
+		line, _, _ := strings.Cut(m.query, "\n")
+		if strings.HasPrefix(line, "--") {
+			m.Description = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		}
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return schemaLess(all[i].from, all[j].from) })
+	return all, nil
+}
+
+func migrationInfo(plan []migration) []index.Migration {
+	info := make([]index.Migration, len(plan))
+	for i := range plan {
+		info[i] = plan[i].Migration
+	}
+	return info
+}
+
+func schemaLess(a, b sqlc.OcflIndexSchema) bool {
+	return a.Major < b.Major || (a.Major == b.Major && a.Minor < b.Minor)
+}
@@ -0,0 +1,6 @@
+-- add file counts for version states
+-- (counts for existing versions are set when their objects are re-indexed)
+ALTER TABLE ocfl_index_versions ADD COLUMN num_files BIGINT;
+ALTER TABLE ocfl_index_versions ADD COLUMN num_added BIGINT;
+ALTER TABLE ocfl_index_versions ADD COLUMN num_removed BIGINT;
+ALTER TABLE ocfl_index_versions ADD COLUMN num_modified BIGINT;
@@ -0,0 +1,25 @@
+-- scope object roots and inventories to named storage roots
+--
+-- Existing object roots and inventories are assigned to the storage root named
+-- 'default'. New columns are added last, matching the column order in
+-- schema.sql.
+INSERT INTO ocfl_index_storage_roots (name) VALUES ('default')
+    ON CONFLICT(name) DO NOTHING;
+
+ALTER TABLE ocfl_index_object_roots
+    ADD COLUMN storage_root_id BIGINT REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE;
+UPDATE ocfl_index_object_roots SET storage_root_id = (
+    SELECT id FROM ocfl_index_storage_roots WHERE name = 'default');
+ALTER TABLE ocfl_index_object_roots
+    ALTER COLUMN storage_root_id SET NOT NULL,
+    DROP CONSTRAINT ocfl_index_object_roots_path_key,
+    ADD UNIQUE(storage_root_id, path);
+
+ALTER TABLE ocfl_index_inventories
+    ADD COLUMN storage_root_id BIGINT REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE;
+UPDATE ocfl_index_inventories invs SET storage_root_id = roots.storage_root_id
+    FROM ocfl_index_object_roots roots WHERE invs.root_id = roots.id;
+ALTER TABLE ocfl_index_inventories
+    ALTER COLUMN storage_root_id SET NOT NULL,
+    DROP CONSTRAINT ocfl_index_inventories_ocfl_id_key,
+    ADD UNIQUE(storage_root_id, ocfl_id);
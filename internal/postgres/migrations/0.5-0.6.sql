@@ -0,0 +1,54 @@
+-- add full-text search for object IDs, logical paths, and version metadata
+--
+-- Search entries are created for all indexed inventories, as they would be by
+-- index.SearchEntries: one for each version's metadata and one for each logical
+-- path added or changed in a version.
+CREATE TABLE ocfl_index_search (
+  id BIGSERIAL PRIMARY KEY,
+  inventory_id BIGINT NOT NULL REFERENCES ocfl_index_inventories(id) ON DELETE CASCADE,
+  version TEXT NOT NULL,
+  ocfl_id TEXT NOT NULL,
+  path TEXT NOT NULL,
+  message TEXT NOT NULL,
+  user_name TEXT NOT NULL,
+  user_address TEXT NOT NULL,
+  terms TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', regexp_replace(
+    ocfl_id || ' ' || path || ' ' || message || ' ' || user_name || ' ' || user_address,
+    '[^[:alnum:]]+', ' ', 'g'
+  ))) STORED
+);
+CREATE INDEX ocfl_index_search_inventory ON ocfl_index_search (inventory_id);
+CREATE INDEX ocfl_index_search_terms ON ocfl_index_search USING GIN (terms);
+
+INSERT INTO ocfl_index_search (inventory_id, version, ocfl_id, path, message, user_name, user_address)
+    SELECT vers.inventory_id, vers.name, invs.ocfl_id, '', vers.message, vers.user_name, vers.user_address
+    FROM ocfl_index_versions vers
+    INNER JOIN ocfl_index_inventories invs ON vers.inventory_id = invs.id
+    ORDER BY vers.inventory_id, vers.num;
+
+WITH RECURSIVE
+    paths(inventory_id, num, node_id, path) AS (
+        SELECT vers.inventory_id, vers.num, vers.node_id, CAST('' AS TEXT) COLLATE "C"
+        FROM ocfl_index_versions vers
+    UNION ALL
+        SELECT paths.inventory_id, paths.num, names.node_id,
+            CASE paths.path WHEN '' THEN names.name ELSE paths.path || '/' || names.name END
+        FROM paths
+        INNER JOIN ocfl_index_names names ON names.parent_id = paths.node_id
+    ),
+    files AS (
+        SELECT paths.inventory_id, paths.num, paths.node_id, paths.path
+        FROM paths
+        INNER JOIN ocfl_index_nodes nodes ON paths.node_id = nodes.id
+        WHERE nodes.dir = FALSE
+    )
+INSERT INTO ocfl_index_search (inventory_id, version, ocfl_id, path, message, user_name, user_address)
+    SELECT files.inventory_id, vers.name, '', files.path, '', '', ''
+    FROM files
+    INNER JOIN ocfl_index_versions vers ON vers.inventory_id = files.inventory_id AND vers.num = files.num
+    WHERE NOT EXISTS (
+        SELECT 1 FROM files prev
+        WHERE prev.inventory_id = files.inventory_id AND prev.path = files.path
+            AND prev.num = files.num - 1 AND prev.node_id = files.node_id
+    )
+    ORDER BY files.inventory_id, files.num, files.path;
@@ -0,0 +1,12 @@
+-- add table for the history of asynchronous tasks
+create table ocfl_index_tasks (
+    id BIGSERIAL PRIMARY KEY, -- task ID
+    name TEXT NOT NULL, -- e.g., 'indexing'
+    storage_root TEXT NOT NULL, -- storage root name (may be empty)
+    options TEXT NOT NULL, -- JSON object with task options
+    status TEXT NOT NULL, -- 'running', 'succeeded', or 'failed'
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ, -- null while running
+    counts TEXT NOT NULL, -- JSON object with counts reported by the task
+    error TEXT NOT NULL
+);
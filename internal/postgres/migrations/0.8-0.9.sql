@@ -0,0 +1,11 @@
+-- add table for object validation errors and warnings found during indexing
+create table ocfl_index_validation_issues (
+    id BIGSERIAL PRIMARY KEY,
+    root_id BIGINT NOT NULL REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    severity TEXT NOT NULL, -- 'error' or 'warning'
+    code TEXT NOT NULL, -- OCFL validation code (e.g., 'E034'), may be empty
+    message TEXT NOT NULL,
+    inventory_path TEXT NOT NULL, -- relative to the storage root
+    checked_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX ocfl_index_validation_issues_root_id ON ocfl_index_validation_issues (root_id);
@@ -0,0 +1,157 @@
+create table ocfl_index_schema (
+    major INTEGER NOT NULL,
+    minor INTEGER NOT NULL,
+    PRIMARY KEY (major, minor)
+);
+-- only one row
+INSERT INTO ocfl_index_schema (major, minor) values (0,11);
+
+-- schema migrations applied to the database
+create table ocfl_index_migrations (
+    name TEXT PRIMARY KEY, -- e.g., '0.4-0.5'
+    applied_at TIMESTAMPTZ NOT NULL
+);
+
+-- Named OCFL Storage Roots
+create table ocfl_index_storage_roots (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  UNIQUE(name)
+);
+
+-- OCFL Object Root Directories, scoped to a storage root. Text columns used for cursor-based pagination
+-- use the "C" collation so results are ordered by byte value, as in sqlite.
+create table ocfl_index_object_roots (
+  id BIGSERIAL PRIMARY KEY,
+  path TEXT COLLATE "C" NOT NULL,
+  indexed_at TIMESTAMPTZ NOT NULL,
+  storage_root_id BIGINT NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE,
+  UNIQUE(storage_root_id, path)
+);
+
+-- OCFL Object Inventories
+create table ocfl_index_inventories (
+    id BIGSERIAL PRIMARY KEY, -- internal identifier
+    root_id BIGINT NOT NULL references ocfl_index_object_roots(id) ON DELETE CASCADE,
+    ocfl_id TEXT COLLATE "C" NOT NULL, -- OCFL Object ID
+    spec TEXT NOT NULL, -- OCFL specification version
+    digest_algorithm TEXT NOT NULL, -- Inventory digest algorithm
+    inventory_digest TEXT NOT NULL, -- Inventory checksum
+    head TEXT NOT NULL, -- version number (e.g., 'v4')
+    indexed_at TIMESTAMPTZ NOT NULL,
+    storage_root_id BIGINT NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE, -- same as the object root's
+    UNIQUE(storage_root_id, ocfl_id),
+    UNIQUE(root_id)
+);
+
+-- A node represents some unique content, identified by a checksum and a
+-- file/directory status. If the node is a file, the checksum corresponds to the
+-- digest from an inventory. If the node is a directory, the checksum correspond
+-- to a recursive digest of the of the directory's contents. Multiple
+-- 'ocfl_index_names' can refer to the same node.
+create table ocfl_index_nodes (
+  id BIGSERIAL PRIMARY KEY, -- internal id
+  dir BOOLEAN NOT NULL, -- node is a directory, not a file
+  sum BYTEA NOT NULL, -- digest (raw bytes)
+  size BIGINT, -- can be null!
+  UNIQUE(sum, dir)
+);
+
+-- OCFL Object Versions
+create table ocfl_index_versions (
+    inventory_id BIGINT NOT NULL REFERENCES ocfl_index_inventories(id) ON DELETE CASCADE,
+    num BIGINT NOT NULL, -- version num (1,2,3)
+    name TEXT NOT NULL, -- version string (e.g. 'v4')
+    message TEXT NOT NULL, -- 'message' field from inventory
+    created TIMESTAMPTZ NOT NULL, -- 'created' field from inventory
+    user_name TEXT NOT NULL, -- user 'name' field from inventory
+    user_address TEXT NOT NULL, -- user 'address' field from inventory
+    node_id BIGINT NOT NULL REFERENCES ocfl_index_nodes(id), -- root node for the version
+    num_files BIGINT, -- number of files in the version state
+    num_added BIGINT, -- files added since the previous version
+    num_removed BIGINT, -- files removed since the previous version
+    num_modified BIGINT, -- files modified since the previous version
+    PRIMARY KEY(inventory_id, num),
+    UNIQUE(inventory_id, name)
+);
+-- for finding the versions with a node as their state
+CREATE INDEX ocfl_index_versions_node_id ON ocfl_index_versions (node_id);
+
+-- A name is represents a logical path element (file or directory). They are
+-- also 'edges' between parent nodes and child nodes.
+create table ocfl_index_names (
+  name TEXT COLLATE "C" NOT NULL, -- the path element name sould not include "/"
+  node_id BIGINT NOT NULL REFERENCES ocfl_index_nodes(id), -- the named node (child)
+  parent_id BIGINT NOT NULL REFERENCES ocfl_index_nodes(id), -- the parent node
+  PRIMARY KEY(parent_id, name)
+);
+-- for finding the parents of a node
+CREATE INDEX ocfl_index_names_node_id ON ocfl_index_names (node_id);
+
+-- A content path represents a manifest entry from an inventory. Node entries
+-- that are files should have corresponding content_paths. Content paths are
+-- scoped to an ocfl object.
+CREATE TABLE ocfl_index_content_paths (
+  inventory_id BIGINT NOT NULL REFERENCES ocfl_index_inventories(id) ON DELETE CASCADE,
+  node_id BIGINT NOT NULL REFERENCES ocfl_index_nodes(id),
+  file_path TEXT NOT NULL, -- path relative to the object path
+  PRIMARY KEY(inventory_id, node_id)
+);
+
+-- Full-text search entries (see index.SearchEntries). Each version has an entry
+-- with the object ID and version metadata, and an entry for each logical path
+-- added or changed in the version. Characters other than letters and numbers
+-- separate search tokens, as in index.SearchTokens.
+CREATE TABLE ocfl_index_search (
+  id BIGSERIAL PRIMARY KEY,
+  inventory_id BIGINT NOT NULL REFERENCES ocfl_index_inventories(id) ON DELETE CASCADE,
+  version TEXT NOT NULL, -- version string (e.g. 'v4')
+  ocfl_id TEXT NOT NULL,
+  path TEXT NOT NULL,
+  message TEXT NOT NULL,
+  user_name TEXT NOT NULL,
+  user_address TEXT NOT NULL,
+  terms TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', regexp_replace(
+    ocfl_id || ' ' || path || ' ' || message || ' ' || user_name || ' ' || user_address,
+    '[^[:alnum:]]+', ' ', 'g'
+  ))) STORED
+);
+CREATE INDEX ocfl_index_search_inventory ON ocfl_index_search (inventory_id);
+CREATE INDEX ocfl_index_search_terms ON ocfl_index_search USING GIN (terms);
+
+-- History of asynchronous tasks (e.g., indexing)
+create table ocfl_index_tasks (
+    id BIGSERIAL PRIMARY KEY, -- task ID
+    name TEXT NOT NULL, -- e.g., 'indexing'
+    storage_root TEXT NOT NULL, -- storage root name (may be empty)
+    options TEXT NOT NULL, -- JSON object with task options
+    status TEXT NOT NULL, -- 'queued', 'running', 'succeeded', 'failed', or 'canceled'
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ, -- null while running
+    counts TEXT NOT NULL, -- JSON object with counts reported by the task
+    error TEXT NOT NULL
+);
+
+-- Validation errors and warnings for objects, from the most recent validation
+-- of the object's inventory during indexing
+create table ocfl_index_validation_issues (
+    id BIGSERIAL PRIMARY KEY,
+    root_id BIGINT NOT NULL REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    severity TEXT NOT NULL, -- 'error' or 'warning'
+    code TEXT NOT NULL, -- OCFL validation code (e.g., 'E034'), may be empty
+    message TEXT NOT NULL,
+    inventory_path TEXT NOT NULL, -- relative to the storage root
+    checked_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX ocfl_index_validation_issues_root_id ON ocfl_index_validation_issues (root_id);
+
+-- Results of full object validation, including content digests (fixity
+-- checks). Each object root has the result of its most recent check.
+create table ocfl_index_fixity_checks (
+    root_id BIGINT PRIMARY KEY REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    valid BOOLEAN NOT NULL,
+    codes TEXT NOT NULL, -- space-separated OCFL validation codes for errors found
+    error TEXT NOT NULL, -- the first validation error (empty if valid)
+    checked_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX ocfl_index_fixity_checks_checked_at ON ocfl_index_fixity_checks (checked_at);
@@ -0,0 +1,120 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+
+package sqlc
+
+import (
+	"database/sql"
+	"time"
+)
+
+type OcflIndexContentPath struct {
+	InventoryID int64
+	NodeID      int64
+	FilePath    string
+}
+
+type OcflIndexFixityCheck struct {
+	RootID    int64
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
+type OcflIndexInventory struct {
+	ID              int64
+	RootID          int64
+	OcflID          string
+	Spec            string
+	DigestAlgorithm string
+	InventoryDigest string
+	Head            string
+	IndexedAt       time.Time
+	StorageRootID   int64
+}
+
+type OcflIndexMigration struct {
+	Name      string
+	AppliedAt time.Time
+}
+
+type OcflIndexName struct {
+	Name     string
+	NodeID   int64
+	ParentID int64
+}
+
+type OcflIndexNode struct {
+	ID   int64
+	Dir  bool
+	Sum  []byte
+	Size sql.NullInt64
+}
+
+type OcflIndexObjectRoot struct {
+	ID            int64
+	Path          string
+	IndexedAt     time.Time
+	StorageRootID int64
+}
+
+type OcflIndexSchema struct {
+	Major int32
+	Minor int32
+}
+
+type OcflIndexSearch struct {
+	ID          int64
+	InventoryID int64
+	Version     string
+	OcflID      string
+	Path        string
+	Message     string
+	UserName    string
+	UserAddress string
+	Terms       interface{}
+}
+
+type OcflIndexStorageRoot struct {
+	ID   int64
+	Name string
+}
+
+type OcflIndexTask struct {
+	ID          int64
+	Name        string
+	StorageRoot string
+	Options     string
+	Status      string
+	StartedAt   time.Time
+	EndedAt     sql.NullTime
+	Counts      string
+	Error       string
+}
+
+type OcflIndexValidationIssue struct {
+	ID            int64
+	RootID        int64
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
+type OcflIndexVersion struct {
+	InventoryID int64
+	Num         int64
+	Name        string
+	Message     string
+	Created     time.Time
+	UserName    string
+	UserAddress string
+	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
+}
@@ -0,0 +1,1559 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: sqlc_queries.sql
+
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countInventories = `-- name: CountInventories :one
+SELECT COUNT(invs.id) from ocfl_index_inventories invs
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1
+`
+
+func (q *Queries) CountInventories(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countInventories, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countObjectRoots = `-- name: CountObjectRoots :one
+SELECT COUNT(roots.id) from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1
+`
+
+func (q *Queries) CountObjectRoots(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countObjectRoots, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const debugAllInventories = `-- name: DebugAllInventories :many
+SELECT id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at, storage_root_id from ocfl_index_inventories
+`
+
+func (q *Queries) DebugAllInventories(ctx context.Context) ([]OcflIndexInventory, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllInventories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexInventory
+	for rows.Next() {
+		var i OcflIndexInventory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RootID,
+			&i.OcflID,
+			&i.Spec,
+			&i.DigestAlgorithm,
+			&i.InventoryDigest,
+			&i.Head,
+			&i.IndexedAt,
+			&i.StorageRootID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllNames = `-- name: DebugAllNames :many
+SELECT name, node_id, parent_id FROM ocfl_index_names
+`
+
+func (q *Queries) DebugAllNames(ctx context.Context) ([]OcflIndexName, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexName
+	for rows.Next() {
+		var i OcflIndexName
+		if err := rows.Scan(&i.Name, &i.NodeID, &i.ParentID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllNodes = `-- name: DebugAllNodes :many
+SELECT id, dir, sum, size FROM ocfl_index_nodes
+`
+
+func (q *Queries) DebugAllNodes(ctx context.Context) ([]OcflIndexNode, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllNodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexNode
+	for rows.Next() {
+		var i OcflIndexNode
+		if err := rows.Scan(
+			&i.ID,
+			&i.Dir,
+			&i.Sum,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllObjectRoots = `-- name: DebugAllObjectRoots :many
+SELECT id, path, indexed_at, storage_root_id from ocfl_index_object_roots
+`
+
+func (q *Queries) DebugAllObjectRoots(ctx context.Context) ([]OcflIndexObjectRoot, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllObjectRoots)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexObjectRoot
+	for rows.Next() {
+		var i OcflIndexObjectRoot
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.IndexedAt,
+			&i.StorageRootID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllStorageRoots = `-- name: DebugAllStorageRoots :many
+SELECT id, name from ocfl_index_storage_roots
+`
+
+func (q *Queries) DebugAllStorageRoots(ctx context.Context) ([]OcflIndexStorageRoot, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllStorageRoots)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexStorageRoot
+	for rows.Next() {
+		var i OcflIndexStorageRoot
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllVersions = `-- name: DebugAllVersions :many
+SELECT inventory_id, num, name, message, created, user_name, user_address, node_id, num_files, num_added, num_removed, num_modified from ocfl_index_versions
+`
+
+func (q *Queries) DebugAllVersions(ctx context.Context) ([]OcflIndexVersion, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexVersion
+	for rows.Next() {
+		var i OcflIndexVersion
+		if err := rows.Scan(
+			&i.InventoryID,
+			&i.Num,
+			&i.Name,
+			&i.Message,
+			&i.Created,
+			&i.UserName,
+			&i.UserAddress,
+			&i.NodeID,
+			&i.NumFiles,
+			&i.NumAdded,
+			&i.NumRemoved,
+			&i.NumModified,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteInventory = `-- name: DeleteInventory :exec
+DELETE from ocfl_index_inventories WHERE id = $1
+`
+
+func (q *Queries) DeleteInventory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteInventory, id)
+	return err
+}
+
+const deleteObjectRootsBefore = `-- name: DeleteObjectRootsBefore :exec
+DELETE FROM ocfl_index_object_roots WHERE storage_root_id = (
+    SELECT id FROM ocfl_index_storage_roots WHERE name = $1) AND indexed_at < $2
+`
+
+type DeleteObjectRootsBeforeParams struct {
+	Name      string
+	IndexedAt time.Time
+}
+
+func (q *Queries) DeleteObjectRootsBefore(ctx context.Context, arg DeleteObjectRootsBeforeParams) error {
+	_, err := q.db.ExecContext(ctx, deleteObjectRootsBefore, arg.Name, arg.IndexedAt)
+	return err
+}
+
+const deleteSearchEntries = `-- name: DeleteSearchEntries :exec
+DELETE FROM ocfl_index_search WHERE inventory_id = $1
+`
+
+func (q *Queries) DeleteSearchEntries(ctx context.Context, inventoryID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSearchEntries, inventoryID)
+	return err
+}
+
+const deleteValidationIssues = `-- name: DeleteValidationIssues :exec
+DELETE FROM ocfl_index_validation_issues WHERE root_id = $1
+`
+
+// Validation Issues
+func (q *Queries) DeleteValidationIssues(ctx context.Context, rootID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteValidationIssues, rootID)
+	return err
+}
+
+const deleteVersions = `-- name: DeleteVersions :exec
+DELETE from ocfl_index_versions WHERE inventory_id = $1
+`
+
+func (q *Queries) DeleteVersions(ctx context.Context, inventoryID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteVersions, inventoryID)
+	return err
+}
+
+const failUnfinishedTasks = `-- name: FailUnfinishedTasks :exec
+UPDATE ocfl_index_tasks SET status = 'failed', ended_at = $1, error = $2
+WHERE status IN ('queued', 'running')
+`
+
+type FailUnfinishedTasksParams struct {
+	EndedAt sql.NullTime
+	Error   string
+}
+
+func (q *Queries) FailUnfinishedTasks(ctx context.Context, arg FailUnfinishedTasksParams) error {
+	_, err := q.db.ExecContext(ctx, failUnfinishedTasks, arg.EndedAt, arg.Error)
+	return err
+}
+
+const getContentPath = `-- name: GetContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.digest_algorithm = $2 AND nodes.sum = $3 LIMIT 1
+`
+
+type GetContentPathParams struct {
+	Name            string
+	DigestAlgorithm string
+	Sum             []byte
+}
+
+type GetContentPathRow struct {
+	FilePath string
+	Path     string
+	Size     sql.NullInt64
+}
+
+func (q *Queries) GetContentPath(ctx context.Context, arg GetContentPathParams) (GetContentPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getContentPath, arg.Name, arg.DigestAlgorithm, arg.Sum)
+	var i GetContentPathRow
+	err := row.Scan(&i.FilePath, &i.Path, &i.Size)
+	return i, err
+}
+
+const getFixityCheck = `-- name: GetFixityCheck :one
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_fixity_checks checks
+INNER JOIN ocfl_index_object_roots roots ON checks.root_id = roots.id
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path = $2
+`
+
+type GetFixityCheckParams struct {
+	Name string
+	Path string
+}
+
+type GetFixityCheckRow struct {
+	Path      string
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
+func (q *Queries) GetFixityCheck(ctx context.Context, arg GetFixityCheckParams) (GetFixityCheckRow, error) {
+	row := q.db.QueryRowContext(ctx, getFixityCheck, arg.Name, arg.Path)
+	var i GetFixityCheckRow
+	err := row.Scan(
+		&i.Path,
+		&i.Valid,
+		&i.Codes,
+		&i.Error,
+		&i.CheckedAt,
+	)
+	return i, err
+}
+
+const getInventoryID = `-- name: GetInventoryID :one
+SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, invs.storage_root_id, objs.path FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2
+`
+
+type GetInventoryIDParams struct {
+	Name   string
+	OcflID string
+}
+
+type GetInventoryIDRow struct {
+	ID              int64
+	RootID          int64
+	OcflID          string
+	Spec            string
+	DigestAlgorithm string
+	InventoryDigest string
+	Head            string
+	IndexedAt       time.Time
+	StorageRootID   int64
+	Path            string
+}
+
+func (q *Queries) GetInventoryID(ctx context.Context, arg GetInventoryIDParams) (GetInventoryIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getInventoryID, arg.Name, arg.OcflID)
+	var i GetInventoryIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.RootID,
+		&i.OcflID,
+		&i.Spec,
+		&i.DigestAlgorithm,
+		&i.InventoryDigest,
+		&i.Head,
+		&i.IndexedAt,
+		&i.StorageRootID,
+		&i.Path,
+	)
+	return i, err
+}
+
+const getInventoryPath = `-- name: GetInventoryPath :one
+SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, invs.storage_root_id, objs.path FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND objs.path = $2
+`
+
+type GetInventoryPathParams struct {
+	Name string
+	Path string
+}
+
+type GetInventoryPathRow struct {
+	ID              int64
+	RootID          int64
+	OcflID          string
+	Spec            string
+	DigestAlgorithm string
+	InventoryDigest string
+	Head            string
+	IndexedAt       time.Time
+	StorageRootID   int64
+	Path            string
+}
+
+func (q *Queries) GetInventoryPath(ctx context.Context, arg GetInventoryPathParams) (GetInventoryPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getInventoryPath, arg.Name, arg.Path)
+	var i GetInventoryPathRow
+	err := row.Scan(
+		&i.ID,
+		&i.RootID,
+		&i.OcflID,
+		&i.Spec,
+		&i.DigestAlgorithm,
+		&i.InventoryDigest,
+		&i.Head,
+		&i.IndexedAt,
+		&i.StorageRootID,
+		&i.Path,
+	)
+	return i, err
+}
+
+const getNodeSum = `-- name: GetNodeSum :one
+SELECT id, dir, sum, size from ocfl_index_nodes WHERE sum = $1 AND dir = $2
+`
+
+type GetNodeSumParams struct {
+	Sum []byte
+	Dir bool
+}
+
+func (q *Queries) GetNodeSum(ctx context.Context, arg GetNodeSumParams) (OcflIndexNode, error) {
+	row := q.db.QueryRowContext(ctx, getNodeSum, arg.Sum, arg.Dir)
+	var i OcflIndexNode
+	err := row.Scan(
+		&i.ID,
+		&i.Dir,
+		&i.Sum,
+		&i.Size,
+	)
+	return i, err
+}
+
+const getObjectContentPath = `-- name: GetObjectContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2 AND nodes.sum = $3 LIMIT 1
+`
+
+type GetObjectContentPathParams struct {
+	Name   string
+	OcflID string
+	Sum    []byte
+}
+
+type GetObjectContentPathRow struct {
+	FilePath string
+	Path     string
+	Size     sql.NullInt64
+}
+
+func (q *Queries) GetObjectContentPath(ctx context.Context, arg GetObjectContentPathParams) (GetObjectContentPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getObjectContentPath, arg.Name, arg.OcflID, arg.Sum)
+	var i GetObjectContentPathRow
+	err := row.Scan(&i.FilePath, &i.Path, &i.Size)
+	return i, err
+}
+
+const getObjectRoot = `-- name: GetObjectRoot :one
+
+SELECT roots.id, roots.path, roots.indexed_at, roots.storage_root_id from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path = $2
+`
+
+type GetObjectRootParams struct {
+	Name string
+	Path string
+}
+
+// OCFL Object Roots
+func (q *Queries) GetObjectRoot(ctx context.Context, arg GetObjectRootParams) (OcflIndexObjectRoot, error) {
+	row := q.db.QueryRowContext(ctx, getObjectRoot, arg.Name, arg.Path)
+	var i OcflIndexObjectRoot
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.IndexedAt,
+		&i.StorageRootID,
+	)
+	return i, err
+}
+
+const getObjectRootLastIndexedAt = `-- name: GetObjectRootLastIndexedAt :one
+SELECT roots.indexed_at from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 ORDER BY roots.indexed_at DESC LIMIT 1
+`
+
+func (q *Queries) GetObjectRootLastIndexedAt(ctx context.Context, name string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getObjectRootLastIndexedAt, name)
+	var indexed_at time.Time
+	err := row.Scan(&indexed_at)
+	return indexed_at, err
+}
+
+const getSchemaVersion = `-- name: GetSchemaVersion :one
+
+SELECT major, minor FROM ocfl_index_schema LIMIT 1
+`
+
+// sqlc definitions
+//
+// Index Schema Version
+func (q *Queries) GetSchemaVersion(ctx context.Context) (OcflIndexSchema, error) {
+	row := q.db.QueryRowContext(ctx, getSchemaVersion)
+	var i OcflIndexSchema
+	err := row.Scan(&i.Major, &i.Minor)
+	return i, err
+}
+
+const getTask = `-- name: GetTask :one
+SELECT id, name, storage_root, options, status, started_at, ended_at, counts, error FROM ocfl_index_tasks WHERE id = $1
+`
+
+func (q *Queries) GetTask(ctx context.Context, id int64) (OcflIndexTask, error) {
+	row := q.db.QueryRowContext(ctx, getTask, id)
+	var i OcflIndexTask
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StorageRoot,
+		&i.Options,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Counts,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getVersion = `-- name: GetVersion :one
+SELECT inventory_id, num, name, message, created, user_name, user_address, node_id, num_files, num_added, num_removed, num_modified from ocfl_index_versions WHERE inventory_id = $1 and num = $2
+`
+
+type GetVersionParams struct {
+	InventoryID int64
+	Num         int64
+}
+
+func (q *Queries) GetVersion(ctx context.Context, arg GetVersionParams) (OcflIndexVersion, error) {
+	row := q.db.QueryRowContext(ctx, getVersion, arg.InventoryID, arg.Num)
+	var i OcflIndexVersion
+	err := row.Scan(
+		&i.InventoryID,
+		&i.Num,
+		&i.Name,
+		&i.Message,
+		&i.Created,
+		&i.UserName,
+		&i.UserAddress,
+		&i.NodeID,
+		&i.NumFiles,
+		&i.NumAdded,
+		&i.NumRemoved,
+		&i.NumModified,
+	)
+	return i, err
+}
+
+const insertIgnoreContentPath = `-- name: InsertIgnoreContentPath :exec
+INSERT INTO ocfl_index_content_paths (inventory_id, node_id, file_path) VALUES (
+    $1,
+    (SELECT id FROM ocfl_index_nodes WHERE sum = $2 AND dir IS FALSE LIMIT 1),
+    $3)
+    ON CONFLICT DO NOTHING
+`
+
+type InsertIgnoreContentPathParams struct {
+	InventoryID int64
+	Sum         []byte
+	FilePath    string
+}
+
+// Content Paths
+func (q *Queries) InsertIgnoreContentPath(ctx context.Context, arg InsertIgnoreContentPathParams) error {
+	_, err := q.db.ExecContext(ctx, insertIgnoreContentPath, arg.InventoryID, arg.Sum, arg.FilePath)
+	return err
+}
+
+const insertIgnoreName = `-- name: InsertIgnoreName :exec
+INSERT INTO ocfl_index_names (name, node_id, parent_id) values ($1, $2, $3)
+    ON CONFLICT DO NOTHING
+`
+
+type InsertIgnoreNameParams struct {
+	Name     string
+	NodeID   int64
+	ParentID int64
+}
+
+// Names
+func (q *Queries) InsertIgnoreName(ctx context.Context, arg InsertIgnoreNameParams) error {
+	_, err := q.db.ExecContext(ctx, insertIgnoreName, arg.Name, arg.NodeID, arg.ParentID)
+	return err
+}
+
+const insertMigration = `-- name: InsertMigration :exec
+INSERT INTO ocfl_index_migrations (name, applied_at) VALUES ($1, $2)
+`
+
+type InsertMigrationParams struct {
+	Name      string
+	AppliedAt time.Time
+}
+
+func (q *Queries) InsertMigration(ctx context.Context, arg InsertMigrationParams) error {
+	_, err := q.db.ExecContext(ctx, insertMigration, arg.Name, arg.AppliedAt)
+	return err
+}
+
+const insertNode = `-- name: InsertNode :one
+INSERT INTO ocfl_index_nodes (sum, dir, size) values ($1, $2, $3)
+    ON CONFLICT (sum, dir) DO UPDATE SET sum = EXCLUDED.sum
+RETURNING id, (xmax = 0)::boolean AS inserted
+`
+
+type InsertNodeParams struct {
+	Sum  []byte
+	Dir  bool
+	Size sql.NullInt64
+}
+
+type InsertNodeRow struct {
+	ID       int64
+	Inserted bool
+}
+
+// Nodes
+//
+// If the node was inserted by a concurrent transaction, its id is returned and
+// inserted is false. (The no-op update locks the existing row so that it can
+// be returned.)
+func (q *Queries) InsertNode(ctx context.Context, arg InsertNodeParams) (InsertNodeRow, error) {
+	row := q.db.QueryRowContext(ctx, insertNode, arg.Sum, arg.Dir, arg.Size)
+	var i InsertNodeRow
+	err := row.Scan(&i.ID, &i.Inserted)
+	return i, err
+}
+
+const insertSearchEntry = `-- name: InsertSearchEntry :exec
+INSERT INTO ocfl_index_search (inventory_id, version, ocfl_id, path, message, user_name, user_address)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type InsertSearchEntryParams struct {
+	InventoryID int64
+	Version     string
+	OcflID      string
+	Path        string
+	Message     string
+	UserName    string
+	UserAddress string
+}
+
+// Full-text Search
+func (q *Queries) InsertSearchEntry(ctx context.Context, arg InsertSearchEntryParams) error {
+	_, err := q.db.ExecContext(ctx, insertSearchEntry,
+		arg.InventoryID,
+		arg.Version,
+		arg.OcflID,
+		arg.Path,
+		arg.Message,
+		arg.UserName,
+		arg.UserAddress,
+	)
+	return err
+}
+
+const insertTask = `-- name: InsertTask :one
+INSERT INTO ocfl_index_tasks (name, storage_root, options, status, started_at, counts, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
+`
+
+type InsertTaskParams struct {
+	Name        string
+	StorageRoot string
+	Options     string
+	Status      string
+	StartedAt   time.Time
+	Counts      string
+	Error       string
+}
+
+// Tasks
+func (q *Queries) InsertTask(ctx context.Context, arg InsertTaskParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertTask,
+		arg.Name,
+		arg.StorageRoot,
+		arg.Options,
+		arg.Status,
+		arg.StartedAt,
+		arg.Counts,
+		arg.Error,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertValidationIssue = `-- name: InsertValidationIssue :exec
+INSERT INTO ocfl_index_validation_issues (root_id, severity, code, message, inventory_path, checked_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertValidationIssueParams struct {
+	RootID        int64
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
+func (q *Queries) InsertValidationIssue(ctx context.Context, arg InsertValidationIssueParams) error {
+	_, err := q.db.ExecContext(ctx, insertValidationIssue,
+		arg.RootID,
+		arg.Severity,
+		arg.Code,
+		arg.Message,
+		arg.InventoryPath,
+		arg.CheckedAt,
+	)
+	return err
+}
+
+const insertVersion = `-- name: InsertVersion :exec
+INSERT INTO ocfl_index_versions
+    (inventory_id, num, name, message, created, user_name, user_address, node_id,
+    num_files, num_added, num_removed, num_modified)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+`
+
+type InsertVersionParams struct {
+	InventoryID int64
+	Num         int64
+	Name        string
+	Message     string
+	Created     time.Time
+	UserName    string
+	UserAddress string
+	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
+}
+
+// OCFL Object Versions
+func (q *Queries) InsertVersion(ctx context.Context, arg InsertVersionParams) error {
+	_, err := q.db.ExecContext(ctx, insertVersion,
+		arg.InventoryID,
+		arg.Num,
+		arg.Name,
+		arg.Message,
+		arg.Created,
+		arg.UserName,
+		arg.UserAddress,
+		arg.NodeID,
+		arg.NumFiles,
+		arg.NumAdded,
+		arg.NumRemoved,
+		arg.NumModified,
+	)
+	return err
+}
+
+const listFixityDue = `-- name: ListFixityDue :many
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+LEFT JOIN ocfl_index_fixity_checks checks ON checks.root_id = roots.id
+WHERE store.name = $1
+ORDER BY checks.checked_at ASC NULLS FIRST, roots.path ASC LIMIT $2
+`
+
+type ListFixityDueParams struct {
+	Name  string
+	Limit int32
+}
+
+type ListFixityDueRow struct {
+	Path      string
+	Valid     sql.NullBool
+	Codes     sql.NullString
+	Error     sql.NullString
+	CheckedAt sql.NullTime
+}
+
+func (q *Queries) ListFixityDue(ctx context.Context, arg ListFixityDueParams) ([]ListFixityDueRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFixityDue, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFixityDueRow
+	for rows.Next() {
+		var i ListFixityDueRow
+		if err := rows.Scan(
+			&i.Path,
+			&i.Valid,
+			&i.Codes,
+			&i.Error,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvalidObjects = `-- name: ListInvalidObjects :many
+SELECT roots.path, issues.severity, issues.code, issues.message, issues.inventory_path, issues.checked_at
+FROM ocfl_index_validation_issues issues
+INNER JOIN ocfl_index_object_roots roots ON issues.root_id = roots.id
+WHERE issues.root_id IN (
+    SELECT invalid.id FROM ocfl_index_object_roots invalid
+    INNER JOIN ocfl_index_storage_roots store ON invalid.storage_root_id = store.id
+    WHERE store.name = $1 AND invalid.path > $2 AND EXISTS (
+        SELECT 1 FROM ocfl_index_validation_issues i WHERE i.root_id = invalid.id
+    )
+    ORDER BY invalid.path ASC LIMIT $3
+)
+ORDER BY roots.path ASC, issues.id ASC
+`
+
+type ListInvalidObjectsParams struct {
+	Name  string
+	Path  string
+	Limit int32
+}
+
+type ListInvalidObjectsRow struct {
+	Path          string
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
+func (q *Queries) ListInvalidObjects(ctx context.Context, arg ListInvalidObjectsParams) ([]ListInvalidObjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInvalidObjects, arg.Name, arg.Path, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInvalidObjectsRow
+	for rows.Next() {
+		var i ListInvalidObjectsRow
+		if err := rows.Scan(
+			&i.Path,
+			&i.Severity,
+			&i.Code,
+			&i.Message,
+			&i.InventoryPath,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInventories = `-- name: ListInventories :many
+SELECT
+    invs.id,
+    invs.ocfl_id,
+    root.path,
+    invs.spec,
+    invs.head,
+    v1.created v1_created,
+    head.created head_created
+FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots root
+    ON invs.root_id = root.id
+INNER JOIN ocfl_index_versions head
+    ON invs.id = head.inventory_id AND invs.head = head.name
+INNER JOIN ocfl_index_versions v1
+    ON invs.id = v1.inventory_id AND v1.num = 1
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id > $2
+ORDER BY invs.ocfl_id ASC LIMIT $3
+`
+
+type ListInventoriesParams struct {
+	Name   string
+	OcflID string
+	Limit  int32
+}
+
+type ListInventoriesRow struct {
+	ID          int64
+	OcflID      string
+	Path        string
+	Spec        string
+	Head        string
+	V1Created   time.Time
+	HeadCreated time.Time
+}
+
+func (q *Queries) ListInventories(ctx context.Context, arg ListInventoriesParams) ([]ListInventoriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInventories, arg.Name, arg.OcflID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInventoriesRow
+	for rows.Next() {
+		var i ListInventoriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OcflID,
+			&i.Path,
+			&i.Spec,
+			&i.Head,
+			&i.V1Created,
+			&i.HeadCreated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInventoriesPrefix = `-- name: ListInventoriesPrefix :many
+SELECT
+    invs.id,
+    invs.ocfl_id,
+    root.path,
+    invs.spec,
+    invs.head,
+    v1.created v1_created,
+    head.created head_created
+FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots root
+    ON invs.root_id = root.id
+INNER JOIN ocfl_index_versions head
+    ON invs.id = head.inventory_id AND invs.head = head.name
+INNER JOIN ocfl_index_versions v1
+    ON invs.id = v1.inventory_id AND v1.num = 1
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id > $2 AND starts_with(invs.ocfl_id, $3)
+ORDER BY invs.ocfl_id ASC LIMIT $4
+`
+
+type ListInventoriesPrefixParams struct {
+	Name       string
+	OcflID     string
+	StartsWith string
+	Limit      int32
+}
+
+type ListInventoriesPrefixRow struct {
+	ID          int64
+	OcflID      string
+	Path        string
+	Spec        string
+	Head        string
+	V1Created   time.Time
+	HeadCreated time.Time
+}
+
+func (q *Queries) ListInventoriesPrefix(ctx context.Context, arg ListInventoriesPrefixParams) ([]ListInventoriesPrefixRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInventoriesPrefix,
+		arg.Name,
+		arg.OcflID,
+		arg.StartsWith,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInventoriesPrefixRow
+	for rows.Next() {
+		var i ListInventoriesPrefixRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OcflID,
+			&i.Path,
+			&i.Spec,
+			&i.Head,
+			&i.V1Created,
+			&i.HeadCreated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listObjectContentSize = `-- name: ListObjectContentSize :many
+SELECT cont.file_path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2 AND nodes.size IS NOT NULL
+`
+
+type ListObjectContentSizeParams struct {
+	Name   string
+	OcflID string
+}
+
+type ListObjectContentSizeRow struct {
+	FilePath string
+	Size     sql.NullInt64
+}
+
+func (q *Queries) ListObjectContentSize(ctx context.Context, arg ListObjectContentSizeParams) ([]ListObjectContentSizeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listObjectContentSize, arg.Name, arg.OcflID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListObjectContentSizeRow
+	for rows.Next() {
+		var i ListObjectContentSizeRow
+		if err := rows.Scan(&i.FilePath, &i.Size); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listObjectRoots = `-- name: ListObjectRoots :many
+SELECT roots.id, roots.path, roots.indexed_at, roots.storage_root_id FROM ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path > $2 ORDER BY roots.path ASC LIMIT $3
+`
+
+type ListObjectRootsParams struct {
+	Name  string
+	Path  string
+	Limit int32
+}
+
+func (q *Queries) ListObjectRoots(ctx context.Context, arg ListObjectRootsParams) ([]OcflIndexObjectRoot, error) {
+	rows, err := q.db.QueryContext(ctx, listObjectRoots, arg.Name, arg.Path, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexObjectRoot
+	for rows.Next() {
+		var i OcflIndexObjectRoot
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.IndexedAt,
+			&i.StorageRootID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTasks = `-- name: ListTasks :many
+SELECT id, name, storage_root, options, status, started_at, ended_at, counts, error FROM ocfl_index_tasks WHERE id < $1 ORDER BY id DESC LIMIT $2
+`
+
+type ListTasksParams struct {
+	ID    int64
+	Limit int32
+}
+
+func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]OcflIndexTask, error) {
+	rows, err := q.db.QueryContext(ctx, listTasks, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexTask
+	for rows.Next() {
+		var i OcflIndexTask
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StorageRoot,
+			&i.Options,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Counts,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersions = `-- name: ListVersions :many
+SELECT versions.inventory_id, versions.num, versions.name, versions.message, versions.created, versions.user_name, versions.user_address, versions.node_id, versions.num_files, versions.num_added, versions.num_removed, versions.num_modified, nodes.size size FROM ocfl_index_versions versions
+INNER JOIN ocfl_index_nodes nodes ON nodes.id = versions.node_id
+WHERE versions.inventory_id = $1 ORDER BY versions.num ASC
+`
+
+type ListVersionsRow struct {
+	InventoryID int64
+	Num         int64
+	Name        string
+	Message     string
+	Created     time.Time
+	UserName    string
+	UserAddress string
+	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
+	Size        sql.NullInt64
+}
+
+func (q *Queries) ListVersions(ctx context.Context, inventoryID int64) ([]ListVersionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVersions, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVersionsRow
+	for rows.Next() {
+		var i ListVersionsRow
+		if err := rows.Scan(
+			&i.InventoryID,
+			&i.Num,
+			&i.Name,
+			&i.Message,
+			&i.Created,
+			&i.UserName,
+			&i.UserAddress,
+			&i.NodeID,
+			&i.NumFiles,
+			&i.NumAdded,
+			&i.NumRemoved,
+			&i.NumModified,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nodeDirChildren = `-- name: NodeDirChildren :many
+SELECT child.id, names.name, child.dir, child.sum, child.size FROM ocfl_index_nodes child
+INNER JOIN ocfl_index_names names ON child.id = names.node_id
+WHERE names.parent_id = $1 AND names.name > $2 ORDER BY names.name ASC LIMIT $3
+`
+
+type NodeDirChildrenParams struct {
+	ParentID int64
+	Name     string
+	Limit    int32
+}
+
+type NodeDirChildrenRow struct {
+	ID   int64
+	Name string
+	Dir  bool
+	Sum  []byte
+	Size sql.NullInt64
+}
+
+func (q *Queries) NodeDirChildren(ctx context.Context, arg NodeDirChildrenParams) ([]NodeDirChildrenRow, error) {
+	rows, err := q.db.QueryContext(ctx, nodeDirChildren, arg.ParentID, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodeDirChildrenRow
+	for rows.Next() {
+		var i NodeDirChildrenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dir,
+			&i.Sum,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const search = `-- name: Search :many
+SELECT search.id, invs.ocfl_id, search.version, search.path FROM ocfl_index_search search
+INNER JOIN ocfl_index_inventories invs ON search.inventory_id = invs.id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND search.terms @@ to_tsquery('simple', $2) AND search.id > $3
+ORDER BY search.id ASC LIMIT $4
+`
+
+type SearchParams struct {
+	Name      string
+	ToTsquery string
+	ID        int64
+	Limit     int32
+}
+
+type SearchRow struct {
+	ID      int64
+	OcflID  string
+	Version string
+	Path    string
+}
+
+func (q *Queries) Search(ctx context.Context, arg SearchParams) ([]SearchRow, error) {
+	rows, err := q.db.QueryContext(ctx, search,
+		arg.Name,
+		arg.ToTsquery,
+		arg.ID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchRow
+	for rows.Next() {
+		var i SearchRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OcflID,
+			&i.Version,
+			&i.Path,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setNodeSize = `-- name: SetNodeSize :exec
+UPDATE ocfl_index_nodes SET size = $1 WHERE sum = $2 AND dir = $3
+`
+
+type SetNodeSizeParams struct {
+	Size sql.NullInt64
+	Sum  []byte
+	Dir  bool
+}
+
+func (q *Queries) SetNodeSize(ctx context.Context, arg SetNodeSizeParams) error {
+	_, err := q.db.ExecContext(ctx, setNodeSize, arg.Size, arg.Sum, arg.Dir)
+	return err
+}
+
+const setSchemaVersion = `-- name: SetSchemaVersion :exec
+UPDATE ocfl_index_schema SET major = $1, minor = $2
+`
+
+type SetSchemaVersionParams struct {
+	Major int32
+	Minor int32
+}
+
+func (q *Queries) SetSchemaVersion(ctx context.Context, arg SetSchemaVersionParams) error {
+	_, err := q.db.ExecContext(ctx, setSchemaVersion, arg.Major, arg.Minor)
+	return err
+}
+
+const setVersionStats = `-- name: SetVersionStats :exec
+UPDATE ocfl_index_versions
+    SET num_files = $1, num_added = $2, num_removed = $3, num_modified = $4
+    WHERE inventory_id = $5 AND num = $6
+`
+
+type SetVersionStatsParams struct {
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
+	InventoryID int64
+	Num         int64
+}
+
+func (q *Queries) SetVersionStats(ctx context.Context, arg SetVersionStatsParams) error {
+	_, err := q.db.ExecContext(ctx, setVersionStats,
+		arg.NumFiles,
+		arg.NumAdded,
+		arg.NumRemoved,
+		arg.NumModified,
+		arg.InventoryID,
+		arg.Num,
+	)
+	return err
+}
+
+const updateTask = `-- name: UpdateTask :exec
+UPDATE ocfl_index_tasks SET status = $1, started_at = $2, ended_at = $3, counts = $4, error = $5
+WHERE id = $6
+`
+
+type UpdateTaskParams struct {
+	Status    string
+	StartedAt time.Time
+	EndedAt   sql.NullTime
+	Counts    string
+	Error     string
+	ID        int64
+}
+
+func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) error {
+	_, err := q.db.ExecContext(ctx, updateTask,
+		arg.Status,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Counts,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertFixityCheck = `-- name: UpsertFixityCheck :exec
+INSERT INTO ocfl_index_fixity_checks (root_id, valid, codes, error, checked_at)
+VALUES ($1, $2, $3, $4, $5)
+    ON CONFLICT(root_id) DO UPDATE SET
+    valid=$2,
+    codes=$3,
+    error=$4,
+    checked_at=$5
+`
+
+type UpsertFixityCheckParams struct {
+	RootID    int64
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
+// Fixity Checks
+func (q *Queries) UpsertFixityCheck(ctx context.Context, arg UpsertFixityCheckParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFixityCheck,
+		arg.RootID,
+		arg.Valid,
+		arg.Codes,
+		arg.Error,
+		arg.CheckedAt,
+	)
+	return err
+}
+
+const upsertInventory = `-- name: UpsertInventory :one
+INSERT INTO ocfl_index_inventories (
+    ocfl_id,
+    root_id,
+    spec,
+    digest_algorithm,
+    inventory_digest,
+    head,
+    indexed_at,
+    storage_root_id
+) values ($1, $2, $3, $4, $5, $6, $7, $8)
+    ON CONFLICT(storage_root_id, ocfl_id) DO UPDATE SET
+    root_id=$2,
+    spec=$3,
+    digest_algorithm=$4,
+    inventory_digest=$5,
+    head=$6,
+    indexed_at=$7
+RETURNING id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at, storage_root_id
+`
+
+type UpsertInventoryParams struct {
+	OcflID          string
+	RootID          int64
+	Spec            string
+	DigestAlgorithm string
+	InventoryDigest string
+	Head            string
+	IndexedAt       time.Time
+	StorageRootID   int64
+}
+
+// OCFL Object Inventory
+func (q *Queries) UpsertInventory(ctx context.Context, arg UpsertInventoryParams) (OcflIndexInventory, error) {
+	row := q.db.QueryRowContext(ctx, upsertInventory,
+		arg.OcflID,
+		arg.RootID,
+		arg.Spec,
+		arg.DigestAlgorithm,
+		arg.InventoryDigest,
+		arg.Head,
+		arg.IndexedAt,
+		arg.StorageRootID,
+	)
+	var i OcflIndexInventory
+	err := row.Scan(
+		&i.ID,
+		&i.RootID,
+		&i.OcflID,
+		&i.Spec,
+		&i.DigestAlgorithm,
+		&i.InventoryDigest,
+		&i.Head,
+		&i.IndexedAt,
+		&i.StorageRootID,
+	)
+	return i, err
+}
+
+const upsertObjectRoot = `-- name: UpsertObjectRoot :one
+INSERT INTO ocfl_index_object_roots (storage_root_id, path, indexed_at) VALUES ($1, $2, $3)
+    ON CONFLICT(storage_root_id, path) DO UPDATE SET indexed_at=$3
+RETURNING id, path, indexed_at, storage_root_id
+`
+
+type UpsertObjectRootParams struct {
+	StorageRootID int64
+	Path          string
+	IndexedAt     time.Time
+}
+
+func (q *Queries) UpsertObjectRoot(ctx context.Context, arg UpsertObjectRootParams) (OcflIndexObjectRoot, error) {
+	row := q.db.QueryRowContext(ctx, upsertObjectRoot, arg.StorageRootID, arg.Path, arg.IndexedAt)
+	var i OcflIndexObjectRoot
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.IndexedAt,
+		&i.StorageRootID,
+	)
+	return i, err
+}
+
+const upsertStorageRoot = `-- name: UpsertStorageRoot :one
+
+INSERT INTO ocfl_index_storage_roots (name) VALUES ($1)
+    ON CONFLICT(name) DO UPDATE SET name=$1
+RETURNING id, name
+`
+
+// Storage Roots
+func (q *Queries) UpsertStorageRoot(ctx context.Context, name string) (OcflIndexStorageRoot, error) {
+	row := q.db.QueryRowContext(ctx, upsertStorageRoot, name)
+	var i OcflIndexStorageRoot
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
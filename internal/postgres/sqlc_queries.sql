@@ -0,0 +1,341 @@
+-- sqlc definitions
+
+--
+-- Index Schema Version
+--
+-- name: GetSchemaVersion :one
+SELECT * FROM ocfl_index_schema LIMIT 1;
+
+-- name: SetSchemaVersion :exec
+UPDATE ocfl_index_schema SET major = $1, minor = $2;
+
+-- name: InsertMigration :exec
+INSERT INTO ocfl_index_migrations (name, applied_at) VALUES ($1, $2);
+
+
+--
+-- Storage Roots
+--
+
+-- name: UpsertStorageRoot :one
+INSERT INTO ocfl_index_storage_roots (name) VALUES ($1)
+    ON CONFLICT(name) DO UPDATE SET name=$1
+RETURNING *;
+
+-- name: DebugAllStorageRoots :many
+SELECT * from ocfl_index_storage_roots;
+
+--
+-- OCFL Object Roots
+--
+
+-- name: GetObjectRoot :one
+SELECT roots.* from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path = $2;
+
+-- name: UpsertObjectRoot :one
+INSERT INTO ocfl_index_object_roots (storage_root_id, path, indexed_at) VALUES ($1, $2, $3)
+    ON CONFLICT(storage_root_id, path) DO UPDATE SET indexed_at=$3
+RETURNING *;
+
+-- name: ListObjectRoots :many
+SELECT roots.* FROM ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path > $2 ORDER BY roots.path ASC LIMIT $3;
+
+-- name: DeleteObjectRootsBefore :exec
+DELETE FROM ocfl_index_object_roots WHERE storage_root_id = (
+    SELECT id FROM ocfl_index_storage_roots WHERE name = $1) AND indexed_at < $2;
+
+-- name: CountObjectRoots :one
+SELECT COUNT(roots.id) from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1;
+
+-- name: GetObjectRootLastIndexedAt :one
+SELECT roots.indexed_at from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 ORDER BY roots.indexed_at DESC LIMIT 1;
+
+-- name: DebugAllObjectRoots :many
+SELECT * from ocfl_index_object_roots;
+
+--
+-- OCFL Object Inventory
+--
+-- name: UpsertInventory :one
+INSERT INTO ocfl_index_inventories (
+    ocfl_id,
+    root_id,
+    spec,
+    digest_algorithm,
+    inventory_digest,
+    head,
+    indexed_at,
+    storage_root_id
+) values ($1, $2, $3, $4, $5, $6, $7, $8)
+    ON CONFLICT(storage_root_id, ocfl_id) DO UPDATE SET
+    root_id=$2,
+    spec=$3,
+    digest_algorithm=$4,
+    inventory_digest=$5,
+    head=$6,
+    indexed_at=$7
+RETURNING *;
+
+-- name: GetInventoryID :one
+SELECT invs.*, objs.path FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2;
+
+-- name: GetInventoryPath :one
+SELECT invs.*, objs.path FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND objs.path = $2;
+
+-- name: ListInventoriesPrefix :many
+SELECT
+    invs.id,
+    invs.ocfl_id,
+    root.path,
+    invs.spec,
+    invs.head,
+    v1.created v1_created,
+    head.created head_created
+FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots root
+    ON invs.root_id = root.id
+INNER JOIN ocfl_index_versions head
+    ON invs.id = head.inventory_id AND invs.head = head.name
+INNER JOIN ocfl_index_versions v1
+    ON invs.id = v1.inventory_id AND v1.num = 1
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id > $2 AND starts_with(invs.ocfl_id, $3)
+ORDER BY invs.ocfl_id ASC LIMIT $4;
+
+-- name: ListInventories :many
+SELECT
+    invs.id,
+    invs.ocfl_id,
+    root.path,
+    invs.spec,
+    invs.head,
+    v1.created v1_created,
+    head.created head_created
+FROM ocfl_index_inventories invs
+INNER JOIN ocfl_index_object_roots root
+    ON invs.root_id = root.id
+INNER JOIN ocfl_index_versions head
+    ON invs.id = head.inventory_id AND invs.head = head.name
+INNER JOIN ocfl_index_versions v1
+    ON invs.id = v1.inventory_id AND v1.num = 1
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id > $2
+ORDER BY invs.ocfl_id ASC LIMIT $3;
+
+-- name: CountInventories :one
+SELECT COUNT(invs.id) from ocfl_index_inventories invs
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1;
+
+-- name: DebugAllInventories :many
+SELECT * from ocfl_index_inventories;
+
+-- name: DeleteInventory :exec
+DELETE from ocfl_index_inventories WHERE id = $1;
+
+
+--
+-- OCFL Object Versions
+--
+-- name: InsertVersion :exec
+INSERT INTO ocfl_index_versions
+    (inventory_id, num, name, message, created, user_name, user_address, node_id,
+    num_files, num_added, num_removed, num_modified)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12);
+
+-- name: SetVersionStats :exec
+UPDATE ocfl_index_versions
+    SET num_files = $1, num_added = $2, num_removed = $3, num_modified = $4
+    WHERE inventory_id = $5 AND num = $6;
+
+-- name: ListVersions :many
+SELECT versions.*, nodes.size size FROM ocfl_index_versions versions
+INNER JOIN ocfl_index_nodes nodes ON nodes.id = versions.node_id
+WHERE versions.inventory_id = $1 ORDER BY versions.num ASC;
+
+-- name: GetVersion :one
+SELECT * from ocfl_index_versions WHERE inventory_id = $1 and num = $2;
+
+-- name: DebugAllVersions :many
+SELECT * from ocfl_index_versions;
+
+-- name: DeleteVersions :exec
+DELETE from ocfl_index_versions WHERE inventory_id = $1;
+
+
+--
+-- Nodes
+--
+-- name: InsertNode :one
+-- If the node was inserted by a concurrent transaction, its id is returned and
+-- inserted is false. (The no-op update locks the existing row so that it can
+-- be returned.)
+INSERT INTO ocfl_index_nodes (sum, dir, size) values ($1, $2, $3)
+    ON CONFLICT (sum, dir) DO UPDATE SET sum = EXCLUDED.sum
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: GetNodeSum :one
+SELECT * from ocfl_index_nodes WHERE sum = $1 AND dir = $2;
+
+-- name: SetNodeSize :exec
+UPDATE ocfl_index_nodes SET size = $1 WHERE sum = $2 AND dir = $3;
+
+
+-- name: NodeDirChildren :many
+SELECT child.id, names.name, child.dir, child.sum, child.size FROM ocfl_index_nodes child
+INNER JOIN ocfl_index_names names ON child.id = names.node_id
+WHERE names.parent_id = $1 AND names.name > $2 ORDER BY names.name ASC LIMIT $3;
+
+-- name: DebugAllNodes :many
+SELECT * FROM ocfl_index_nodes;
+
+
+--
+-- Names
+--
+-- name: InsertIgnoreName :exec
+INSERT INTO ocfl_index_names (name, node_id, parent_id) values ($1, $2, $3)
+    ON CONFLICT DO NOTHING;
+
+-- name: DebugAllNames :many
+SELECT * FROM ocfl_index_names;
+
+--
+-- Content Paths
+--
+-- name: InsertIgnoreContentPath :exec
+INSERT INTO ocfl_index_content_paths (inventory_id, node_id, file_path) VALUES (
+    $1,
+    (SELECT id FROM ocfl_index_nodes WHERE sum = $2 AND dir IS FALSE LIMIT 1),
+    $3)
+    ON CONFLICT DO NOTHING;
+
+-- name: GetContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.digest_algorithm = $2 AND nodes.sum = $3 LIMIT 1;
+
+-- name: GetObjectContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2 AND nodes.sum = $3 LIMIT 1;
+
+-- name: ListObjectContentSize :many
+SELECT cont.file_path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND invs.ocfl_id = $2 AND nodes.size IS NOT NULL;
+
+--
+-- Full-text Search
+--
+-- name: InsertSearchEntry :exec
+INSERT INTO ocfl_index_search (inventory_id, version, ocfl_id, path, message, user_name, user_address)
+    VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteSearchEntries :exec
+DELETE FROM ocfl_index_search WHERE inventory_id = $1;
+
+-- name: Search :many
+SELECT search.id, invs.ocfl_id, search.version, search.path FROM ocfl_index_search search
+INNER JOIN ocfl_index_inventories invs ON search.inventory_id = invs.id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = $1 AND search.terms @@ to_tsquery('simple', $2) AND search.id > $3
+ORDER BY search.id ASC LIMIT $4;
+
+
+--
+-- Tasks
+--
+-- name: InsertTask :one
+INSERT INTO ocfl_index_tasks (name, storage_root, options, status, started_at, counts, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
+
+-- name: UpdateTask :exec
+UPDATE ocfl_index_tasks SET status = $1, started_at = $2, ended_at = $3, counts = $4, error = $5
+WHERE id = $6;
+
+-- name: GetTask :one
+SELECT * FROM ocfl_index_tasks WHERE id = $1;
+
+-- name: ListTasks :many
+SELECT * FROM ocfl_index_tasks WHERE id < $1 ORDER BY id DESC LIMIT $2;
+
+-- name: FailUnfinishedTasks :exec
+UPDATE ocfl_index_tasks SET status = 'failed', ended_at = $1, error = $2
+WHERE status IN ('queued', 'running');
+
+--
+-- Validation Issues
+--
+-- name: DeleteValidationIssues :exec
+DELETE FROM ocfl_index_validation_issues WHERE root_id = $1;
+
+-- name: InsertValidationIssue :exec
+INSERT INTO ocfl_index_validation_issues (root_id, severity, code, message, inventory_path, checked_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListInvalidObjects :many
+SELECT roots.path, issues.severity, issues.code, issues.message, issues.inventory_path, issues.checked_at
+FROM ocfl_index_validation_issues issues
+INNER JOIN ocfl_index_object_roots roots ON issues.root_id = roots.id
+WHERE issues.root_id IN (
+    SELECT invalid.id FROM ocfl_index_object_roots invalid
+    INNER JOIN ocfl_index_storage_roots store ON invalid.storage_root_id = store.id
+    WHERE store.name = $1 AND invalid.path > $2 AND EXISTS (
+        SELECT 1 FROM ocfl_index_validation_issues i WHERE i.root_id = invalid.id
+    )
+    ORDER BY invalid.path ASC LIMIT $3
+)
+ORDER BY roots.path ASC, issues.id ASC;
+
+--
+-- Fixity Checks
+--
+-- name: UpsertFixityCheck :exec
+INSERT INTO ocfl_index_fixity_checks (root_id, valid, codes, error, checked_at)
+VALUES ($1, $2, $3, $4, $5)
+    ON CONFLICT(root_id) DO UPDATE SET
+    valid=$2,
+    codes=$3,
+    error=$4,
+    checked_at=$5;
+
+-- name: GetFixityCheck :one
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_fixity_checks checks
+INNER JOIN ocfl_index_object_roots roots ON checks.root_id = roots.id
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = $1 AND roots.path = $2;
+
+-- name: ListFixityDue :many
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+LEFT JOIN ocfl_index_fixity_checks checks ON checks.root_id = roots.id
+WHERE store.name = $1
+ORDER BY checks.checked_at ASC NULLS FIRST, roots.path ASC LIMIT $2;
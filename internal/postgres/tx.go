@@ -0,0 +1,389 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/pathtree"
+	"github.com/srerickson/ocfl-index/internal/postgres/sqlc"
+	"github.com/srerickson/ocfl/digest"
+	"github.com/srerickson/ocfl/ocflv1"
+)
+
+type Tx struct {
+	db *Backend
+	tx *sql.Tx
+}
+
+var _ index.BackendTx = (*Tx)(nil)
+
+// NewTX creates a new transaction for the backend
+func (db *Backend) NewTx(ctx context.Context) (index.BackendTx, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{db: db, tx: tx}, nil
+}
+
+// Rollback aborts the transaction
+func (tx *Tx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx *Tx) GetObjectByPath(ctx context.Context, storageRoot string, p string) (*index.Object, error) {
+	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
+	return getObjectByPathTx(ctx, qryTx, storageRoot, p)
+}
+
+func (tx *Tx) ListObjectRoots(ctx context.Context, storageRoot string, limit int, cursor string) (*index.ObjectRootList, error) {
+	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
+	return listObjectRootsTx(ctx, qryTx, storageRoot, limit, cursor)
+}
+
+func (tx *Tx) IndexObjectRoot(ctx context.Context, storageRoot string, indexedAt time.Time, roots ...index.ObjectRoot) error {
+	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
+	storeRow, err := indexStorageRootTx(ctx, qryTx, storageRoot)
+	if err != nil {
+		return err
+	}
+	for _, r := range roots {
+		if _, err := indexObjectRootTx(ctx, qryTx, storeRow, r.Path, indexedAt); err != nil {
+			return fmt.Errorf("indexing object root: %w", err)
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) IndexObjectInventory(ctx context.Context, storageRoot string, idxAt time.Time, inv ...index.ObjectInventory) error {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	storeRow, err := indexStorageRootTx(ctx, qry, storageRoot)
+	if err != nil {
+		return err
+	}
+	for i := range inv {
+		rootrow, err := indexObjectRootTx(ctx, qry, storeRow, inv[i].Path, idxAt)
+		if err != nil {
+			return fmt.Errorf("indexing object root: %w", err)
+		}
+		if err := indexInventoryTx(ctx, qry, storeRow, rootrow, idxAt, inv[i].Inventory, inv[i].FileSizes); err != nil {
+			return fmt.Errorf("indexing inventory: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListObjectContentSize returns indexed sizes for content files in the object
+// with the given ID.
+func (tx *Tx) ListObjectContentSize(ctx context.Context, storageRoot string, objID string) (map[string]int64, error) {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	rows, err := qry.ListObjectContentSize(ctx, sqlc.ListObjectContentSizeParams{
+		Name:   storageRoot,
+		OcflID: objID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		sizes[r.FilePath] = r.Size.Int64
+	}
+	return sizes, nil
+}
+
+// Remove all objects in the storage root with indexed_at values older than
+// before.
+func (tx *Tx) RemoveObjectsBefore(ctx context.Context, storageRoot string, indexedBefore time.Time) error {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	return qry.DeleteObjectRootsBefore(ctx, sqlc.DeleteObjectRootsBeforeParams{
+		Name:      storageRoot,
+		IndexedAt: indexedBefore.UTC(), // indexed_at is always UTC
+	})
+}
+
+// indexStorageRootTx adds the storage root name to the index, if necessary, and
+// returns its row id.
+func indexStorageRootTx(ctx context.Context, qry *sqlc.Queries, name string) (int64, error) {
+	if name == "" {
+		return 0, fmt.Errorf("storage root name is required: %w", index.ErrInvalidArgs)
+	}
+	store, err := qry.UpsertStorageRoot(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("upsert storage root: %w", err)
+	}
+	return store.ID, nil
+}
+
+func indexObjectRootTx(ctx context.Context, qry *sqlc.Queries, storeRow int64, root string, idxAt time.Time) (int64, error) {
+	if root == "" {
+		return 0, fmt.Errorf("object root is required: %w", index.ErrInvalidArgs)
+	}
+	if idxAt.IsZero() {
+		idxAt = time.Now()
+	}
+	idxobj, err := qry.UpsertObjectRoot(ctx, sqlc.UpsertObjectRootParams{
+		StorageRootID: storeRow,
+		Path:          root,
+		IndexedAt:     idxAt.Truncate(time.Second).UTC(), // always use UTC
+	})
+	if err != nil {
+		return 0, fmt.Errorf("upsert object root: %w", err)
+	}
+	return idxobj.ID, nil
+}
+
+// index the inventory. To index without filesize checks, sizes must be nil.
+func indexInventoryTx(ctx context.Context, qry *sqlc.Queries, storeRow int64, rootRow int64, idxAt time.Time, inv *ocflv1.Inventory, sizes map[string]int64) error {
+	if idxAt.IsZero() {
+		idxAt = time.Now()
+	}
+	idxInv, err := qry.UpsertInventory(ctx, sqlc.UpsertInventoryParams{
+		OcflID:          inv.ID,
+		RootID:          rootRow,
+		StorageRootID:   storeRow,
+		Head:            inv.Head.String(),
+		Spec:            inv.Type.Spec.String(),
+		DigestAlgorithm: inv.DigestAlgorithm,
+		InventoryDigest: inv.Digest(),
+		IndexedAt:       idxAt.Truncate(time.Second).UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	invrow := idxInv.ID
+	// versions table
+	stats, err := index.InventoryVersionStats(inv)
+	if err != nil {
+		return err
+	}
+	for vnum, ver := range inv.Versions {
+		// sizes may be nil
+		tree, err := index.PathTree(inv, vnum, sizes)
+		if err != nil {
+			return fmt.Errorf("building tree from version state: %w", err)
+		}
+		if sizes != nil && !tree.Val.HasSize {
+			// the tree's root node should have size for entire version state
+			return fmt.Errorf("incomplete content file size information for '%s'", vnum)
+		}
+		nodeRow, err := addPathtreeNodes(ctx, qry, tree)
+		if err != nil {
+			return fmt.Errorf("indexing version state: %w", err)
+		}
+		if err := insertVersion(ctx, qry, vnum, ver, stats[vnum], invrow, nodeRow); err != nil {
+			return fmt.Errorf("indexing inventory versions: %w", err)
+		}
+	}
+	// content paths
+	if err := insertContent(ctx, qry, inv.Manifest, invrow); err != nil {
+		return fmt.Errorf("indexing content files: %w", err)
+	}
+	// full-text search
+	if err := insertSearchEntries(ctx, qry, inv, invrow); err != nil {
+		return fmt.Errorf("indexing search entries: %w", err)
+	}
+	return nil
+}
+
+// insertSearchEntries replaces the inventory's full-text search entries.
+func insertSearchEntries(ctx context.Context, qry *sqlc.Queries, inv *ocflv1.Inventory, invRow int64) error {
+	entries, err := index.SearchEntries(inv)
+	if err != nil {
+		return err
+	}
+	if err := qry.DeleteSearchEntries(ctx, invRow); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := qry.InsertSearchEntry(ctx, sqlc.InsertSearchEntryParams{
+			InventoryID: invRow,
+			Version:     e.Version.String(),
+			OcflID:      e.ObjectID,
+			Path:        e.Path,
+			Message:     e.Message,
+			UserName:    e.UserName,
+			UserAddress: e.UserAddress,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertVersion adds a new row to the versions table as part of the object
+// indexing. invRow is the row id for the parent inventory, nodeID is the root
+// node for the version state. If a row exists for the version, an error is
+// returned if indexed values don't match those in the given version. Existing
+// rows are only updated to add missing stats.
+func insertVersion(ctx context.Context, qry *sqlc.Queries, vnum ocfl.VNum, ver *ocflv1.Version, stats index.VersionStats, invRow int64, nodeID int64) error {
+	idxV, err := qry.GetVersion(ctx, sqlc.GetVersionParams{
+		InventoryID: invRow,
+		Num:         int64(vnum.Num()),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		params := sqlc.InsertVersionParams{
+			InventoryID: invRow,
+			Num:         int64(vnum.Num()),
+			Name:        vnum.String(),
+			Message:     ver.Message,
+			Created:     ver.Created.UTC(), // location prevents errors reading from db
+			NodeID:      nodeID,
+			NumFiles:    sql.NullInt64{Int64: int64(stats.Files), Valid: true},
+			NumAdded:    sql.NullInt64{Int64: int64(stats.Added), Valid: true},
+			NumRemoved:  sql.NullInt64{Int64: int64(stats.Removed), Valid: true},
+			NumModified: sql.NullInt64{Int64: int64(stats.Modified), Valid: true},
+		}
+		if ver.User != nil {
+			params.UserAddress = ver.User.Address
+			params.UserName = ver.User.Name
+		}
+		if err := qry.InsertVersion(ctx, params); err != nil {
+			return err
+		}
+		return nil
+	}
+	if idxV.NodeID != nodeID {
+		return fmt.Errorf("state for version '%s' has changed: %w", vnum, index.ErrIndexValue)
+	}
+	if idxV.Message != ver.Message {
+		return fmt.Errorf("message for version '%s' has changed: %w", vnum, index.ErrIndexValue)
+	}
+	if idxV.Created.Unix() != ver.Created.Unix() {
+		return fmt.Errorf("created timestamp for version '%s' has changed: %w", vnum, index.ErrIndexValue)
+	}
+	if ver.User != nil {
+		if idxV.UserAddress != ver.User.Address {
+			return fmt.Errorf("user address for version '%s' has changed: %w", vnum, index.ErrIndexValue)
+		}
+		if idxV.UserName != ver.User.Name {
+			return fmt.Errorf("user name for version '%s' has changed: %w", vnum, index.ErrIndexValue)
+		}
+	}
+	if !idxV.NumFiles.Valid {
+		// version was indexed before stats were added to the schema
+		err := qry.SetVersionStats(ctx, sqlc.SetVersionStatsParams{
+			InventoryID: invRow,
+			Num:         int64(vnum.Num()),
+			NumFiles:    sql.NullInt64{Int64: int64(stats.Files), Valid: true},
+			NumAdded:    sql.NullInt64{Int64: int64(stats.Added), Valid: true},
+			NumRemoved:  sql.NullInt64{Int64: int64(stats.Removed), Valid: true},
+			NumModified: sql.NullInt64{Int64: int64(stats.Modified), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("setting stats for version '%s': %w", vnum, err)
+		}
+	}
+	return nil
+}
+
+func insertContent(ctx context.Context, tx *sqlc.Queries, man *digest.Map, invID int64) error {
+	return man.EachPath(func(name, digest string) error {
+		sum, err := hex.DecodeString(digest)
+		if err != nil {
+			return err
+		}
+		params := sqlc.InsertIgnoreContentPathParams{
+			Sum:         sum,
+			FilePath:    name,
+			InventoryID: invID,
+		}
+		if err := tx.InsertIgnoreContentPath(ctx, params); err != nil {
+			return fmt.Errorf("adding content path: '%s'", name)
+		}
+		return nil
+	})
+}
+
+// addPathtreeNodes adds all values in the pathtree to the index, both names and nodes. It returns the
+// rows id for the node representing the tree's root
+func addPathtreeNodes(ctx context.Context, tx *sqlc.Queries, tree *pathtree.Node[index.IndexingVal]) (int64, error) {
+	return addPathtreeChild(ctx, tx, tree, 0, "")
+}
+
+// recursive implementation for addPathtree
+func addPathtreeChild(ctx context.Context, tx *sqlc.Queries, tree *pathtree.Node[index.IndexingVal], parentID int64, name string) (int64, error) {
+	nodeID, isNew, err := getSetNode(ctx, tx, tree.Val, tree.IsDir())
+	if err != nil {
+		return 0, err
+	}
+	if parentID != 0 {
+		// for non-root nodes, make sure name exists connecting parentID and nodeID
+		err = tx.InsertIgnoreName(ctx, sqlc.InsertIgnoreNameParams{
+			NodeID:   nodeID,
+			ParentID: parentID,
+			Name:     name,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if !isNew {
+		// if getSetNode didn't create or update the node, the children have
+		// already been created.
+		return nodeID, nil
+	}
+	for _, e := range tree.DirEntries() {
+		n := e.Name()
+		child := tree.Child(n)
+		if _, err = addPathtreeChild(ctx, tx, child, nodeID, n); err != nil {
+			return 0, err
+		}
+	}
+	return nodeID, nil
+}
+
+// getSetNode gets, inserts, and possibly updates the node for sum/dir,
+// returning the rowid and a boolean indicating if the node was created or
+// updated. An update only occurs in the case that val includes size information
+// but the indexed node does not.
+func getSetNode(ctx context.Context, qry *sqlc.Queries, val index.IndexingVal, isdir bool) (int64, bool, error) {
+	node, err := qry.GetNodeSum(ctx, sqlc.GetNodeSumParams{
+		Sum: val.Sum,
+		Dir: isdir,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, false, err
+		}
+		row, err := qry.InsertNode(ctx, sqlc.InsertNodeParams{
+			Size: sql.NullInt64{Int64: val.Size, Valid: val.HasSize},
+			Sum:  val.Sum,
+			Dir:  isdir,
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		// if the node was inserted by a concurrent transaction, its children
+		// have been inserted too.
+		return row.ID, row.Inserted, nil
+	}
+	if val.HasSize && !node.Size.Valid {
+		// update node size when possible
+		err := qry.SetNodeSize(ctx, sqlc.SetNodeSizeParams{
+			Size: sql.NullInt64{Int64: val.Size, Valid: val.HasSize},
+			Sum:  val.Sum,
+			Dir:  isdir,
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		// children may also need sizes
+		return node.ID, true, nil
+	}
+	return node.ID, false, nil
+}
@@ -0,0 +1,34 @@
+-- returns objects, versions, and logical paths for nodes with a digest:
+-- ?1: node digest (raw bytes)
+-- ?2: storage root name
+-- ?3: cursor (object id)
+-- ?4: page limit (number of objects)
+WITH RECURSIVE
+    paths(node_id, path, dir) AS (
+        SELECT nodes.id, '', nodes.dir
+        FROM ocfl_index_nodes nodes
+        WHERE nodes.sum = ?1
+    UNION
+        SELECT
+            names.parent_id,
+            -- if paths.path is '', no joining slash for next path
+            names.name || COALESCE(NULLIF('/' || paths.path, '/'), ''),
+            paths.dir
+        FROM ocfl_index_names names
+        INNER JOIN paths ON names.node_id = paths.node_id
+    ),
+    found(ocfl_id, num, version, path, dir) AS (
+        -- if the path is '', the node is the version state
+        SELECT invs.ocfl_id, versions.num, versions.name, COALESCE(NULLIF(paths.path, ''), '.'), paths.dir
+        FROM paths
+        INNER JOIN ocfl_index_versions versions ON versions.node_id = paths.node_id
+        INNER JOIN ocfl_index_inventories invs ON versions.inventory_id = invs.id
+        INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+        WHERE store.name = ?2 AND invs.ocfl_id > ?3
+    ),
+    objects(ocfl_id) AS (
+        SELECT DISTINCT ocfl_id FROM found ORDER BY ocfl_id ASC LIMIT ?4
+    )
+SELECT found.ocfl_id, found.version, found.path, found.dir FROM found
+    INNER JOIN objects ON found.ocfl_id = objects.ocfl_id
+ORDER BY found.ocfl_id ASC, found.num ASC, found.path ASC;
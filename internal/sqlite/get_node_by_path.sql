@@ -2,12 +2,14 @@
 -- ?1: objectid
 -- ?2: version
 -- ?3: path (which may be '.')
+-- ?4: storage root name
 WITH RECURSIVE
     paths(id, path) AS (
         SELECT versions.node_id, '.'
         FROM ocfl_index_versions versions
         INNER JOIN ocfl_index_inventories invs ON versions.inventory_id = invs.id
-        WHERE invs.ocfl_id = ?1
+        INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+        WHERE store.name = ?4 AND invs.ocfl_id = ?1
         -- if version is '', use objects.head
         AND versions.name = COALESCE(NULLIF(?2,''), invs.head)
     UNION
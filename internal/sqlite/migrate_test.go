@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/srerickson/ocfl-index/internal/sqlite/sqlc"
+)
+
+func TestReadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0.5-1.0.sql": &fstest.MapFile{Data: []byte("-- second\nSELECT 2;")},
+		"migrations/0.4-0.5.sql": &fstest.MapFile{Data: []byte("-- first\nSELECT 1;")},
+		"migrations/README.md":   &fstest.MapFile{Data: []byte("not a migration")},
+	}
+	all, err := readMigrations(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(all))
+	}
+	if all[0].Name != "0.4-0.5" || all[0].Description != "first" {
+		t.Errorf("unexpected first migration: %+v", all[0].Migration)
+	}
+	if all[1].to != (sqlc.OcflIndexSchema{Major: 1, Minor: 0}) {
+		t.Errorf("unexpected 'to' version for second migration: %v", all[1].to)
+	}
+	v := func(maj, min int64) sqlc.OcflIndexSchema {
+		return sqlc.OcflIndexSchema{Major: maj, Minor: min}
+	}
+	plan, err := migrationPath(all, v(0, 4), v(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(plan))
+	}
+	plan, err = migrationPath(all, v(1, 0), v(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 0 {
+		t.Fatalf("expected no steps, got %d", len(plan))
+	}
+	if _, err := migrationPath(all, v(0, 3), v(1, 0)); err == nil {
+		t.Error("expected an error for missing migration")
+	}
+	if _, err := migrationPath(all, v(1, 0), v(0, 5)); err == nil {
+		t.Error("expected an error for downgrade")
+	}
+	// invalid name
+	fsys["migrations/latest.sql"] = &fstest.MapFile{Data: []byte("SELECT 3;")}
+	if _, err := readMigrations(fsys, "migrations"); err == nil {
+		t.Error("expected an error for invalid migration name")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := readMigrations(migrationFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every embedded migration must be reachable from the oldest schema
+	if len(all) > 0 {
+		if _, err := migrationPath(all, all[0].from, schemaVer); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
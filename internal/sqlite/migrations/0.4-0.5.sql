@@ -0,0 +1,43 @@
+-- scope object roots and inventories to named storage roots
+--
+-- Existing object roots and inventories are assigned to the storage root named
+-- 'default'. sqlite can't change table constraints, so both tables are
+-- rebuilt; this requires foreign key enforcement to be off (see Migrate).
+INSERT INTO ocfl_index_storage_roots (name) VALUES ('default')
+    ON CONFLICT(name) DO NOTHING;
+
+CREATE TABLE ocfl_index_object_roots_new (
+  id INTEGER PRIMARY KEY,
+  path TEXT NOT NULL,
+  indexed_at DATETIME NOT NULL,
+  storage_root_id INTEGER NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE,
+  UNIQUE(storage_root_id, path)
+);
+INSERT INTO ocfl_index_object_roots_new (id, path, indexed_at, storage_root_id)
+    SELECT roots.id, roots.path, roots.indexed_at, store.id
+    FROM ocfl_index_object_roots roots, ocfl_index_storage_roots store
+    WHERE store.name = 'default';
+DROP TABLE ocfl_index_object_roots;
+ALTER TABLE ocfl_index_object_roots_new RENAME TO ocfl_index_object_roots;
+
+CREATE TABLE ocfl_index_inventories_new (
+    id INTEGER PRIMARY KEY,
+    root_id INTEGER NOT NULL references ocfl_index_object_roots(id) ON DELETE CASCADE,
+    ocfl_id TEXT NOT NULL,
+    spec TEXT NOT NULL,
+    digest_algorithm TEXT NOT NULL,
+    inventory_digest TEXT NOT NULL,
+    head TEXT NOT NULL,
+    indexed_at DATETIME NOT NULL,
+    storage_root_id INTEGER NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE,
+    UNIQUE(storage_root_id, ocfl_id),
+    UNIQUE(root_id)
+);
+INSERT INTO ocfl_index_inventories_new
+    (id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at, storage_root_id)
+    SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm,
+        invs.inventory_digest, invs.head, invs.indexed_at, roots.storage_root_id
+    FROM ocfl_index_inventories invs
+    INNER JOIN ocfl_index_object_roots roots ON invs.root_id = roots.id;
+DROP TABLE ocfl_index_inventories;
+ALTER TABLE ocfl_index_inventories_new RENAME TO ocfl_index_inventories;
@@ -0,0 +1,58 @@
+-- add full-text search for object IDs, logical paths, and version metadata
+--
+-- Search entries are created for all indexed inventories, as they would be by
+-- index.SearchEntries: one for each version's metadata and one for each logical
+-- path added or changed in a version.
+CREATE VIRTUAL TABLE ocfl_index_search USING fts5(
+  ocfl_id,
+  path,
+  message,
+  user_name,
+  user_address,
+  inventory_id UNINDEXED,
+  version UNINDEXED,
+  tokenize = 'unicode61 remove_diacritics 0'
+);
+
+CREATE TRIGGER ocfl_index_search_delete AFTER DELETE ON ocfl_index_inventories
+BEGIN
+  DELETE FROM ocfl_index_search WHERE inventory_id = old.id;
+END;
+
+INSERT INTO ocfl_index_search (ocfl_id, path, message, user_name, user_address, inventory_id, version)
+    SELECT invs.ocfl_id, '', vers.message, vers.user_name, vers.user_address, vers.inventory_id, vers.name
+    FROM ocfl_index_versions vers
+    INNER JOIN ocfl_index_inventories invs ON vers.inventory_id = invs.id
+    ORDER BY vers.inventory_id, vers.num;
+
+-- logical paths for files in every version state
+CREATE TEMP TABLE ocfl_index_version_files AS
+    WITH RECURSIVE
+        paths(inventory_id, num, node_id, path) AS (
+            SELECT vers.inventory_id, vers.num, vers.node_id, ''
+            FROM ocfl_index_versions vers
+        UNION ALL
+            SELECT paths.inventory_id, paths.num, names.node_id,
+                CASE paths.path WHEN '' THEN names.name ELSE paths.path || '/' || names.name END
+            FROM paths
+            INNER JOIN ocfl_index_names names ON names.parent_id = paths.node_id
+        )
+    SELECT paths.inventory_id, paths.num, paths.node_id, paths.path
+    FROM paths
+    INNER JOIN ocfl_index_nodes nodes ON paths.node_id = nodes.id
+    WHERE nodes.dir = FALSE;
+
+CREATE INDEX ocfl_index_version_files_path ON ocfl_index_version_files (inventory_id, path, num);
+
+INSERT INTO ocfl_index_search (ocfl_id, path, message, user_name, user_address, inventory_id, version)
+    SELECT '', files.path, '', '', '', files.inventory_id, vers.name
+    FROM ocfl_index_version_files files
+    INNER JOIN ocfl_index_versions vers ON vers.inventory_id = files.inventory_id AND vers.num = files.num
+    WHERE NOT EXISTS (
+        SELECT 1 FROM ocfl_index_version_files prev
+        WHERE prev.inventory_id = files.inventory_id AND prev.path = files.path
+            AND prev.num = files.num - 1 AND prev.node_id = files.node_id
+    )
+    ORDER BY files.inventory_id, files.num, files.path;
+
+DROP TABLE ocfl_index_version_files;
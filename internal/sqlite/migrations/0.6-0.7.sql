@@ -0,0 +1,3 @@
+-- add indexes for finding objects by node digest
+CREATE INDEX ocfl_index_names_node_id ON ocfl_index_names (node_id);
+CREATE INDEX ocfl_index_versions_node_id ON ocfl_index_versions (node_id);
@@ -0,0 +1,9 @@
+-- add table for the results of full object validation (fixity checks)
+create table ocfl_index_fixity_checks (
+    root_id INTEGER PRIMARY KEY REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    valid BOOLEAN NOT NULL,
+    codes TEXT NOT NULL, -- space-separated OCFL validation codes for errors found
+    error TEXT NOT NULL, -- the first validation error (empty if valid)
+    checked_at DATETIME NOT NULL
+);
+CREATE INDEX ocfl_index_fixity_checks_checked_at ON ocfl_index_fixity_checks (checked_at);
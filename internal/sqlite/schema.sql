@@ -6,21 +6,28 @@ create table ocfl_index_schema (
     PRIMARY KEY (major, minor)
 );
 -- only one row
-INSERT INTO ocfl_index_schema (major, minor) values (0,4);
+INSERT INTO ocfl_index_schema (major, minor) values (0,11);
 
--- not currently used.
+-- schema migrations applied to the database
+create table ocfl_index_migrations (
+    name TEXT PRIMARY KEY, -- e.g., '0.4-0.5'
+    applied_at DATETIME NOT NULL
+);
+
+-- Named OCFL Storage Roots
 create table ocfl_index_storage_roots (
   id INTEGER PRIMARY KEY,
   name TEXT NOT NULL,   
   UNIQUE(name)
 );
 
--- OCFL Object Root Directories
+-- OCFL Object Root Directories, scoped to a storage root
 create table ocfl_index_object_roots (
   id INTEGER PRIMARY KEY,
   path TEXT NOT NULL,
   indexed_at DATETIME NOT NULL,
-  UNIQUE(path)
+  storage_root_id INTEGER NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE,
+  UNIQUE(storage_root_id, path)
 );
 
 -- OCFL Object Inventories
@@ -33,7 +40,8 @@ create table ocfl_index_inventories (
     inventory_digest TEXT NOT NULL, -- Inventory checksum
     head TEXT NOT NULL, -- version number (e.g., 'v4')
     indexed_at DATETIME NOT NULL, 
-    UNIQUE(ocfl_id),
+    storage_root_id INTEGER NOT NULL REFERENCES ocfl_index_storage_roots(id) ON DELETE CASCADE, -- same as the object root's
+    UNIQUE(storage_root_id, ocfl_id),
     UNIQUE(root_id)
 );
 
@@ -48,9 +56,15 @@ create table ocfl_index_versions (
     user_name TEXT NOT NULL, -- user 'name' field from inventory
     user_address TEXT NOT NULL, -- user 'address' field from inventory
     node_id INTEGER NOT NULL REFERENCES ocfl_index_nodes(id), -- root node for the version
+    num_files INTEGER, -- number of files in the version state
+    num_added INTEGER, -- files added since the previous version
+    num_removed INTEGER, -- files removed since the previous version
+    num_modified INTEGER, -- files modified since the previous version
     PRIMARY KEY(inventory_id, num),
     UNIQUE(inventory_id, name)
 );
+-- for finding the versions with a node as their state
+CREATE INDEX ocfl_index_versions_node_id ON ocfl_index_versions (node_id);
 
 -- A node represents some unique content, identified by a checksum and a
 -- file/directory status. If the node is a file, the checksum corresponds to the
@@ -73,6 +87,8 @@ create table ocfl_index_names (
   parent_id INTEGER NOT NULL REFERENCES ocfl_index_nodes(id), -- the parent node
   PRIMARY KEY(parent_id, name)
 );
+-- for finding the parents of a node
+CREATE INDEX ocfl_index_names_node_id ON ocfl_index_names (node_id);
 
 -- A content path represents a manifest entry from an inventory. Node entries
 -- that are files should have corresponding content_paths. Content paths are
@@ -82,4 +98,60 @@ CREATE TABLE ocfl_index_content_paths (
   node_id INTEGER NOT NULL REFERENCES ocfl_index_nodes(id),
   file_path TEXT NOT NULL, -- path relative to the object path
   PRIMARY KEY(inventory_id, node_id)
-);
\ No newline at end of file
+);
+-- Full-text search entries (see index.SearchEntries). Each version has an entry
+-- with the object ID and version metadata, and an entry for each logical path
+-- added or changed in the version. FTS5 tables don't support foreign keys:
+-- entries are removed by the trigger below.
+CREATE VIRTUAL TABLE ocfl_index_search USING fts5(
+  ocfl_id,
+  path,
+  message,
+  user_name,
+  user_address,
+  inventory_id UNINDEXED,
+  version UNINDEXED, -- version string (e.g. 'v4')
+  tokenize = 'unicode61 remove_diacritics 0'
+);
+
+CREATE TRIGGER ocfl_index_search_delete AFTER DELETE ON ocfl_index_inventories
+BEGIN
+  DELETE FROM ocfl_index_search WHERE inventory_id = old.id;
+END;
+
+-- History of asynchronous tasks (e.g., indexing)
+create table ocfl_index_tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT, -- task ID
+    name TEXT NOT NULL, -- e.g., 'indexing'
+    storage_root TEXT NOT NULL, -- storage root name (may be empty)
+    options TEXT NOT NULL, -- JSON object with task options
+    status TEXT NOT NULL, -- 'queued', 'running', 'succeeded', 'failed', or 'canceled'
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME, -- null while running
+    counts TEXT NOT NULL, -- JSON object with counts reported by the task
+    error TEXT NOT NULL
+);
+
+-- Validation errors and warnings for objects, from the most recent validation
+-- of the object's inventory during indexing
+create table ocfl_index_validation_issues (
+    id INTEGER PRIMARY KEY,
+    root_id INTEGER NOT NULL REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    severity TEXT NOT NULL, -- 'error' or 'warning'
+    code TEXT NOT NULL, -- OCFL validation code (e.g., 'E034'), may be empty
+    message TEXT NOT NULL,
+    inventory_path TEXT NOT NULL, -- relative to the storage root
+    checked_at DATETIME NOT NULL
+);
+CREATE INDEX ocfl_index_validation_issues_root_id ON ocfl_index_validation_issues (root_id);
+
+-- Results of full object validation, including content digests (fixity
+-- checks). Each object root has the result of its most recent check.
+create table ocfl_index_fixity_checks (
+    root_id INTEGER PRIMARY KEY REFERENCES ocfl_index_object_roots(id) ON DELETE CASCADE,
+    valid BOOLEAN NOT NULL,
+    codes TEXT NOT NULL, -- space-separated OCFL validation codes for errors found
+    error TEXT NOT NULL, -- the first validation error (empty if valid)
+    checked_at DATETIME NOT NULL
+);
+CREATE INDEX ocfl_index_fixity_checks_checked_at ON ocfl_index_fixity_checks (checked_at);
@@ -0,0 +1,11 @@
+-- full-text search for object versions and logical paths:
+-- ?1: FTS5 query
+-- ?2: storage root name
+-- ?3: cursor (search entry rowid)
+-- ?4: page limit
+SELECT search.rowid, invs.ocfl_id, search.version, search.path
+FROM ocfl_index_search search
+INNER JOIN ocfl_index_inventories invs ON search.inventory_id = invs.id
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE search.ocfl_index_search MATCH ?1 AND store.name = ?2 AND search.rowid > ?3
+ORDER BY search.rowid ASC LIMIT ?4;
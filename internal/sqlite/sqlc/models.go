@@ -15,6 +15,14 @@ type OcflIndexContentPath struct {
 	FilePath    string
 }
 
+type OcflIndexFixityCheck struct {
+	RootID    int64
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
 type OcflIndexInventory struct {
 	ID              int64
 	RootID          int64
@@ -24,6 +32,12 @@ type OcflIndexInventory struct {
 	InventoryDigest string
 	Head            string
 	IndexedAt       time.Time
+	StorageRootID   int64
+}
+
+type OcflIndexMigration struct {
+	Name      string
+	AppliedAt time.Time
 }
 
 type OcflIndexName struct {
@@ -40,9 +54,10 @@ type OcflIndexNode struct {
 }
 
 type OcflIndexObjectRoot struct {
-	ID        int64
-	Path      string
-	IndexedAt time.Time
+	ID            int64
+	Path          string
+	IndexedAt     time.Time
+	StorageRootID int64
 }
 
 type OcflIndexSchema struct {
@@ -55,6 +70,28 @@ type OcflIndexStorageRoot struct {
 	Name string
 }
 
+type OcflIndexTask struct {
+	ID          int64
+	Name        string
+	StorageRoot string
+	Options     string
+	Status      string
+	StartedAt   time.Time
+	EndedAt     sql.NullTime
+	Counts      string
+	Error       string
+}
+
+type OcflIndexValidationIssue struct {
+	ID            int64
+	RootID        int64
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
 type OcflIndexVersion struct {
 	InventoryID int64
 	Num         int64
@@ -64,4 +101,8 @@ type OcflIndexVersion struct {
 	UserName    string
 	UserAddress string
 	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
 }
@@ -12,29 +12,33 @@ import (
 )
 
 const countInventories = `-- name: CountInventories :one
-SELECT COUNT(id) from ocfl_index_inventories
+SELECT COUNT(invs.id) from ocfl_index_inventories invs
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1
 `
 
-func (q *Queries) CountInventories(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countInventories)
+func (q *Queries) CountInventories(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countInventories, name)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const countObjectRoots = `-- name: CountObjectRoots :one
-SELECT COUNT(id) from ocfl_index_object_roots
+SELECT COUNT(roots.id) from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1
 `
 
-func (q *Queries) CountObjectRoots(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countObjectRoots)
+func (q *Queries) CountObjectRoots(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countObjectRoots, name)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const debugAllInventories = `-- name: DebugAllInventories :many
-SELECT id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at from ocfl_index_inventories
+SELECT id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at, storage_root_id from ocfl_index_inventories
 `
 
 func (q *Queries) DebugAllInventories(ctx context.Context) ([]OcflIndexInventory, error) {
@@ -55,6 +59,7 @@ func (q *Queries) DebugAllInventories(ctx context.Context) ([]OcflIndexInventory
 			&i.InventoryDigest,
 			&i.Head,
 			&i.IndexedAt,
+			&i.StorageRootID,
 		); err != nil {
 			return nil, err
 		}
@@ -129,7 +134,7 @@ func (q *Queries) DebugAllNodes(ctx context.Context) ([]OcflIndexNode, error) {
 }
 
 const debugAllObjectRoots = `-- name: DebugAllObjectRoots :many
-SELECT id, path, indexed_at from ocfl_index_object_roots
+SELECT id, path, indexed_at, storage_root_id from ocfl_index_object_roots
 `
 
 func (q *Queries) DebugAllObjectRoots(ctx context.Context) ([]OcflIndexObjectRoot, error) {
@@ -141,7 +146,39 @@ func (q *Queries) DebugAllObjectRoots(ctx context.Context) ([]OcflIndexObjectRoo
 	var items []OcflIndexObjectRoot
 	for rows.Next() {
 		var i OcflIndexObjectRoot
-		if err := rows.Scan(&i.ID, &i.Path, &i.IndexedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.IndexedAt,
+			&i.StorageRootID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const debugAllStorageRoots = `-- name: DebugAllStorageRoots :many
+SELECT id, name from ocfl_index_storage_roots
+`
+
+func (q *Queries) DebugAllStorageRoots(ctx context.Context) ([]OcflIndexStorageRoot, error) {
+	rows, err := q.db.QueryContext(ctx, debugAllStorageRoots)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexStorageRoot
+	for rows.Next() {
+		var i OcflIndexStorageRoot
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -156,7 +193,7 @@ func (q *Queries) DebugAllObjectRoots(ctx context.Context) ([]OcflIndexObjectRoo
 }
 
 const debugAllVersions = `-- name: DebugAllVersions :many
-SELECT inventory_id, num, name, message, created, user_name, user_address, node_id from ocfl_index_versions
+SELECT inventory_id, num, name, message, created, user_name, user_address, node_id, num_files, num_added, num_removed, num_modified from ocfl_index_versions
 `
 
 func (q *Queries) DebugAllVersions(ctx context.Context) ([]OcflIndexVersion, error) {
@@ -177,6 +214,10 @@ func (q *Queries) DebugAllVersions(ctx context.Context) ([]OcflIndexVersion, err
 			&i.UserName,
 			&i.UserAddress,
 			&i.NodeID,
+			&i.NumFiles,
+			&i.NumAdded,
+			&i.NumRemoved,
+			&i.NumModified,
 		); err != nil {
 			return nil, err
 		}
@@ -201,11 +242,27 @@ func (q *Queries) DeleteInventory(ctx context.Context, id int64) error {
 }
 
 const deleteObjectRootsBefore = `-- name: DeleteObjectRootsBefore :exec
-DELETE FROM ocfl_index_object_roots WHERE indexed_at < ?1
+DELETE FROM ocfl_index_object_roots WHERE storage_root_id = (
+    SELECT id FROM ocfl_index_storage_roots WHERE name = ?1) AND indexed_at < ?2
+`
+
+type DeleteObjectRootsBeforeParams struct {
+	Name      string
+	IndexedAt time.Time
+}
+
+func (q *Queries) DeleteObjectRootsBefore(ctx context.Context, arg DeleteObjectRootsBeforeParams) error {
+	_, err := q.db.ExecContext(ctx, deleteObjectRootsBefore, arg.Name, arg.IndexedAt)
+	return err
+}
+
+const deleteValidationIssues = `-- name: DeleteValidationIssues :exec
+DELETE FROM ocfl_index_validation_issues WHERE root_id = ?1
 `
 
-func (q *Queries) DeleteObjectRootsBefore(ctx context.Context, indexedAt time.Time) error {
-	_, err := q.db.ExecContext(ctx, deleteObjectRootsBefore, indexedAt)
+// Validation Issues
+func (q *Queries) DeleteValidationIssues(ctx context.Context, rootID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteValidationIssues, rootID)
 	return err
 }
 
@@ -218,32 +275,95 @@ func (q *Queries) DeleteVersions(ctx context.Context, inventoryID int64) error {
 	return err
 }
 
+const failUnfinishedTasks = `-- name: FailUnfinishedTasks :exec
+UPDATE ocfl_index_tasks SET status = 'failed', ended_at = ?1, error = ?2
+WHERE status IN ('queued', 'running')
+`
+
+type FailUnfinishedTasksParams struct {
+	EndedAt sql.NullTime
+	Error   string
+}
+
+func (q *Queries) FailUnfinishedTasks(ctx context.Context, arg FailUnfinishedTasksParams) error {
+	_, err := q.db.ExecContext(ctx, failUnfinishedTasks, arg.EndedAt, arg.Error)
+	return err
+}
+
 const getContentPath = `-- name: GetContentPath :one
-SELECT cont.file_path, objs.path from ocfl_index_content_paths cont
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
 INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
 INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
 INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
-WHERE nodes.sum = ? LIMIT 1
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.digest_algorithm = ?2 AND nodes.sum = ?3 LIMIT 1
 `
 
+type GetContentPathParams struct {
+	Name            string
+	DigestAlgorithm string
+	Sum             []byte
+}
+
 type GetContentPathRow struct {
 	FilePath string
 	Path     string
+	Size     sql.NullInt64
 }
 
-func (q *Queries) GetContentPath(ctx context.Context, sum []byte) (GetContentPathRow, error) {
-	row := q.db.QueryRowContext(ctx, getContentPath, sum)
+func (q *Queries) GetContentPath(ctx context.Context, arg GetContentPathParams) (GetContentPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getContentPath, arg.Name, arg.DigestAlgorithm, arg.Sum)
 	var i GetContentPathRow
-	err := row.Scan(&i.FilePath, &i.Path)
+	err := row.Scan(&i.FilePath, &i.Path, &i.Size)
+	return i, err
+}
+
+const getFixityCheck = `-- name: GetFixityCheck :one
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_fixity_checks checks
+INNER JOIN ocfl_index_object_roots roots ON checks.root_id = roots.id
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path = ?2
+`
+
+type GetFixityCheckParams struct {
+	Name string
+	Path string
+}
+
+type GetFixityCheckRow struct {
+	Path      string
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
+func (q *Queries) GetFixityCheck(ctx context.Context, arg GetFixityCheckParams) (GetFixityCheckRow, error) {
+	row := q.db.QueryRowContext(ctx, getFixityCheck, arg.Name, arg.Path)
+	var i GetFixityCheckRow
+	err := row.Scan(
+		&i.Path,
+		&i.Valid,
+		&i.Codes,
+		&i.Error,
+		&i.CheckedAt,
+	)
 	return i, err
 }
 
 const getInventoryID = `-- name: GetInventoryID :one
-SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, objs.path FROM ocfl_index_inventories invs
+SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, invs.storage_root_id, objs.path FROM ocfl_index_inventories invs
 INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
-WHERE ocfl_id = ?
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2
 `
 
+type GetInventoryIDParams struct {
+	Name   string
+	OcflID string
+}
+
 type GetInventoryIDRow struct {
 	ID              int64
 	RootID          int64
@@ -253,11 +373,12 @@ type GetInventoryIDRow struct {
 	InventoryDigest string
 	Head            string
 	IndexedAt       time.Time
+	StorageRootID   int64
 	Path            string
 }
 
-func (q *Queries) GetInventoryID(ctx context.Context, ocflID string) (GetInventoryIDRow, error) {
-	row := q.db.QueryRowContext(ctx, getInventoryID, ocflID)
+func (q *Queries) GetInventoryID(ctx context.Context, arg GetInventoryIDParams) (GetInventoryIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getInventoryID, arg.Name, arg.OcflID)
 	var i GetInventoryIDRow
 	err := row.Scan(
 		&i.ID,
@@ -268,17 +389,24 @@ func (q *Queries) GetInventoryID(ctx context.Context, ocflID string) (GetInvento
 		&i.InventoryDigest,
 		&i.Head,
 		&i.IndexedAt,
+		&i.StorageRootID,
 		&i.Path,
 	)
 	return i, err
 }
 
 const getInventoryPath = `-- name: GetInventoryPath :one
-SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, objs.path FROM ocfl_index_inventories invs
+SELECT invs.id, invs.root_id, invs.ocfl_id, invs.spec, invs.digest_algorithm, invs.inventory_digest, invs.head, invs.indexed_at, invs.storage_root_id, objs.path FROM ocfl_index_inventories invs
 INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
-WHERE objs.path = ?
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND objs.path = ?2
 `
 
+type GetInventoryPathParams struct {
+	Name string
+	Path string
+}
+
 type GetInventoryPathRow struct {
 	ID              int64
 	RootID          int64
@@ -288,11 +416,12 @@ type GetInventoryPathRow struct {
 	InventoryDigest string
 	Head            string
 	IndexedAt       time.Time
+	StorageRootID   int64
 	Path            string
 }
 
-func (q *Queries) GetInventoryPath(ctx context.Context, path string) (GetInventoryPathRow, error) {
-	row := q.db.QueryRowContext(ctx, getInventoryPath, path)
+func (q *Queries) GetInventoryPath(ctx context.Context, arg GetInventoryPathParams) (GetInventoryPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getInventoryPath, arg.Name, arg.Path)
 	var i GetInventoryPathRow
 	err := row.Scan(
 		&i.ID,
@@ -303,22 +432,12 @@ func (q *Queries) GetInventoryPath(ctx context.Context, path string) (GetInvento
 		&i.InventoryDigest,
 		&i.Head,
 		&i.IndexedAt,
+		&i.StorageRootID,
 		&i.Path,
 	)
 	return i, err
 }
 
-const getInventoryRowID = `-- name: GetInventoryRowID :one
-SELECT id from ocfl_index_inventories where ocfl_id = ?
-`
-
-func (q *Queries) GetInventoryRowID(ctx context.Context, ocflID string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getInventoryRowID, ocflID)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
-}
-
 const getNodeSum = `-- name: GetNodeSum :one
 SELECT id, dir, sum, size from ocfl_index_nodes WHERE sum = ? AND dir = ?
 `
@@ -340,25 +459,67 @@ func (q *Queries) GetNodeSum(ctx context.Context, arg GetNodeSumParams) (OcflInd
 	return i, err
 }
 
+const getObjectContentPath = `-- name: GetObjectContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2 AND nodes.sum = ?3 LIMIT 1
+`
+
+type GetObjectContentPathParams struct {
+	Name   string
+	OcflID string
+	Sum    []byte
+}
+
+type GetObjectContentPathRow struct {
+	FilePath string
+	Path     string
+	Size     sql.NullInt64
+}
+
+func (q *Queries) GetObjectContentPath(ctx context.Context, arg GetObjectContentPathParams) (GetObjectContentPathRow, error) {
+	row := q.db.QueryRowContext(ctx, getObjectContentPath, arg.Name, arg.OcflID, arg.Sum)
+	var i GetObjectContentPathRow
+	err := row.Scan(&i.FilePath, &i.Path, &i.Size)
+	return i, err
+}
+
 const getObjectRoot = `-- name: GetObjectRoot :one
 
-SELECT id, path, indexed_at from ocfl_index_object_roots WHERE path = ?
+SELECT roots.id, roots.path, roots.indexed_at, roots.storage_root_id from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path = ?2
 `
 
+type GetObjectRootParams struct {
+	Name string
+	Path string
+}
+
 // OCFL Object Roots
-func (q *Queries) GetObjectRoot(ctx context.Context, path string) (OcflIndexObjectRoot, error) {
-	row := q.db.QueryRowContext(ctx, getObjectRoot, path)
+func (q *Queries) GetObjectRoot(ctx context.Context, arg GetObjectRootParams) (OcflIndexObjectRoot, error) {
+	row := q.db.QueryRowContext(ctx, getObjectRoot, arg.Name, arg.Path)
 	var i OcflIndexObjectRoot
-	err := row.Scan(&i.ID, &i.Path, &i.IndexedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.IndexedAt,
+		&i.StorageRootID,
+	)
 	return i, err
 }
 
 const getObjectRootLastIndexedAt = `-- name: GetObjectRootLastIndexedAt :one
-SELECT indexed_at from ocfl_index_object_roots ORDER BY indexed_at DESC LIMIT 1
+SELECT roots.indexed_at from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 ORDER BY roots.indexed_at DESC LIMIT 1
 `
 
-func (q *Queries) GetObjectRootLastIndexedAt(ctx context.Context) (time.Time, error) {
-	row := q.db.QueryRowContext(ctx, getObjectRootLastIndexedAt)
+func (q *Queries) GetObjectRootLastIndexedAt(ctx context.Context, name string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getObjectRootLastIndexedAt, name)
 	var indexed_at time.Time
 	err := row.Scan(&indexed_at)
 	return indexed_at, err
@@ -379,8 +540,29 @@ func (q *Queries) GetSchemaVersion(ctx context.Context) (OcflIndexSchema, error)
 	return i, err
 }
 
+const getTask = `-- name: GetTask :one
+SELECT id, name, storage_root, options, status, started_at, ended_at, counts, error FROM ocfl_index_tasks WHERE id = ?1
+`
+
+func (q *Queries) GetTask(ctx context.Context, id int64) (OcflIndexTask, error) {
+	row := q.db.QueryRowContext(ctx, getTask, id)
+	var i OcflIndexTask
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StorageRoot,
+		&i.Options,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Counts,
+		&i.Error,
+	)
+	return i, err
+}
+
 const getVersion = `-- name: GetVersion :one
-SELECT inventory_id, num, name, message, created, user_name, user_address, node_id from ocfl_index_versions WHERE inventory_id = ?1 and num = ?2
+SELECT inventory_id, num, name, message, created, user_name, user_address, node_id, num_files, num_added, num_removed, num_modified from ocfl_index_versions WHERE inventory_id = ?1 and num = ?2
 `
 
 type GetVersionParams struct {
@@ -400,6 +582,10 @@ func (q *Queries) GetVersion(ctx context.Context, arg GetVersionParams) (OcflInd
 		&i.UserName,
 		&i.UserAddress,
 		&i.NodeID,
+		&i.NumFiles,
+		&i.NumAdded,
+		&i.NumRemoved,
+		&i.NumModified,
 	)
 	return i, err
 }
@@ -439,6 +625,20 @@ func (q *Queries) InsertIgnoreName(ctx context.Context, arg InsertIgnoreNamePara
 	return err
 }
 
+const insertMigration = `-- name: InsertMigration :exec
+INSERT INTO ocfl_index_migrations (name, applied_at) VALUES (?, ?)
+`
+
+type InsertMigrationParams struct {
+	Name      string
+	AppliedAt time.Time
+}
+
+func (q *Queries) InsertMigration(ctx context.Context, arg InsertMigrationParams) error {
+	_, err := q.db.ExecContext(ctx, insertMigration, arg.Name, arg.AppliedAt)
+	return err
+}
+
 const insertNode = `-- name: InsertNode :execlastid
 INSERT INTO ocfl_index_nodes (sum, dir, size) values (?, ?, ?)
 `
@@ -458,10 +658,69 @@ func (q *Queries) InsertNode(ctx context.Context, arg InsertNodeParams) (int64,
 	return result.LastInsertId()
 }
 
+const insertTask = `-- name: InsertTask :one
+INSERT INTO ocfl_index_tasks (name, storage_root, options, status, started_at, counts, error)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+RETURNING id
+`
+
+type InsertTaskParams struct {
+	Name        string
+	StorageRoot string
+	Options     string
+	Status      string
+	StartedAt   time.Time
+	Counts      string
+	Error       string
+}
+
+// Tasks
+func (q *Queries) InsertTask(ctx context.Context, arg InsertTaskParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertTask,
+		arg.Name,
+		arg.StorageRoot,
+		arg.Options,
+		arg.Status,
+		arg.StartedAt,
+		arg.Counts,
+		arg.Error,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertValidationIssue = `-- name: InsertValidationIssue :exec
+INSERT INTO ocfl_index_validation_issues (root_id, severity, code, message, inventory_path, checked_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+type InsertValidationIssueParams struct {
+	RootID        int64
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
+func (q *Queries) InsertValidationIssue(ctx context.Context, arg InsertValidationIssueParams) error {
+	_, err := q.db.ExecContext(ctx, insertValidationIssue,
+		arg.RootID,
+		arg.Severity,
+		arg.Code,
+		arg.Message,
+		arg.InventoryPath,
+		arg.CheckedAt,
+	)
+	return err
+}
+
 const insertVersion = `-- name: InsertVersion :execlastid
 INSERT INTO ocfl_index_versions 
-    (inventory_id, name, message, created, user_name, user_address, node_id, num)
-    VALUES (?1,?2,?3,?4,?5,?6,?7, CAST(LTRIM(?2,'v') AS INT))
+    (inventory_id, name, message, created, user_name, user_address, node_id,
+    num_files, num_added, num_removed, num_modified, num)
+    VALUES (?1,?2,?3,?4,?5,?6,?7,?8,?9,?10,?11, CAST(LTRIM(?2,'v') AS INT))
 `
 
 type InsertVersionParams struct {
@@ -472,6 +731,10 @@ type InsertVersionParams struct {
 	UserName    string
 	UserAddress string
 	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
 }
 
 // OCFL Object Versions
@@ -484,6 +747,10 @@ func (q *Queries) InsertVersion(ctx context.Context, arg InsertVersionParams) (i
 		arg.UserName,
 		arg.UserAddress,
 		arg.NodeID,
+		arg.NumFiles,
+		arg.NumAdded,
+		arg.NumRemoved,
+		arg.NumModified,
 	)
 	if err != nil {
 		return 0, err
@@ -491,6 +758,66 @@ func (q *Queries) InsertVersion(ctx context.Context, arg InsertVersionParams) (i
 	return result.LastInsertId()
 }
 
+const listInvalidObjects = `-- name: ListInvalidObjects :many
+SELECT roots.path, issues.severity, issues.code, issues.message, issues.inventory_path, issues.checked_at
+FROM ocfl_index_validation_issues issues
+INNER JOIN ocfl_index_object_roots roots ON issues.root_id = roots.id
+WHERE issues.root_id IN (
+    SELECT invalid.id FROM ocfl_index_object_roots invalid
+    INNER JOIN ocfl_index_storage_roots store ON invalid.storage_root_id = store.id
+    WHERE store.name = ?1 AND invalid.path > ?2 AND EXISTS (
+        SELECT 1 FROM ocfl_index_validation_issues i WHERE i.root_id = invalid.id
+    )
+    ORDER BY invalid.path ASC LIMIT ?3
+)
+ORDER BY roots.path ASC, issues.id ASC
+`
+
+type ListInvalidObjectsParams struct {
+	Name  string
+	Path  string
+	Limit int64
+}
+
+type ListInvalidObjectsRow struct {
+	Path          string
+	Severity      string
+	Code          string
+	Message       string
+	InventoryPath string
+	CheckedAt     time.Time
+}
+
+func (q *Queries) ListInvalidObjects(ctx context.Context, arg ListInvalidObjectsParams) ([]ListInvalidObjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInvalidObjects, arg.Name, arg.Path, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInvalidObjectsRow
+	for rows.Next() {
+		var i ListInvalidObjectsRow
+		if err := rows.Scan(
+			&i.Path,
+			&i.Severity,
+			&i.Code,
+			&i.Message,
+			&i.InventoryPath,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listInventories = `-- name: ListInventories :many
 SELECT 
     invs.id,
@@ -507,11 +834,14 @@ INNER JOIN ocfl_index_versions head
     ON invs.id = head.inventory_id AND invs.head = head.name
 INNER JOIN ocfl_index_versions v1
     ON invs.id = v1.inventory_id AND v1.num = 1
-WHERE invs.ocfl_id > ?1
-ORDER BY invs.ocfl_id ASC LIMIT ?2
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id > ?2
+ORDER BY invs.ocfl_id ASC LIMIT ?3
 `
 
 type ListInventoriesParams struct {
+	Name   string
 	OcflID string
 	Limit  int64
 }
@@ -527,7 +857,7 @@ type ListInventoriesRow struct {
 }
 
 func (q *Queries) ListInventories(ctx context.Context, arg ListInventoriesParams) ([]ListInventoriesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listInventories, arg.OcflID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, listInventories, arg.Name, arg.OcflID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -573,14 +903,17 @@ INNER JOIN ocfl_index_versions head
     ON invs.id = head.inventory_id AND invs.head = head.name
 INNER JOIN ocfl_index_versions v1
     ON invs.id = v1.inventory_id AND v1.num = 1
-WHERE invs.ocfl_id > ?1 AND invs.ocfl_id LIKE ?2 || '%' ESCAPE '\'
-ORDER BY invs.ocfl_id ASC LIMIT ?3
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id > ?2 AND invs.ocfl_id LIKE ?3 || '%' ESCAPE '\'
+ORDER BY invs.ocfl_id ASC LIMIT ?4
 `
 
 type ListInventoriesPrefixParams struct {
-	OcflID   string
-	OcflID_2 string
-	Limit    int64
+	Name   string
+	OcflID string
+	Name_2 string
+	Limit  int64
 }
 
 type ListInventoriesPrefixRow struct {
@@ -594,7 +927,12 @@ type ListInventoriesPrefixRow struct {
 }
 
 func (q *Queries) ListInventoriesPrefix(ctx context.Context, arg ListInventoriesPrefixParams) ([]ListInventoriesPrefixRow, error) {
-	rows, err := q.db.QueryContext(ctx, listInventoriesPrefix, arg.OcflID, arg.OcflID_2, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, listInventoriesPrefix,
+		arg.Name,
+		arg.OcflID,
+		arg.Name_2,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -628,16 +966,22 @@ const listObjectContentSize = `-- name: ListObjectContentSize :many
 SELECT cont.file_path, nodes.size from ocfl_index_content_paths cont
 INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id
 INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
-WHERE invs.ocfl_id = ? AND nodes.size IS NOT NULL
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2 AND nodes.size IS NOT NULL
 `
 
+type ListObjectContentSizeParams struct {
+	Name   string
+	OcflID string
+}
+
 type ListObjectContentSizeRow struct {
 	FilePath string
 	Size     sql.NullInt64
 }
 
-func (q *Queries) ListObjectContentSize(ctx context.Context, ocflID string) ([]ListObjectContentSizeRow, error) {
-	rows, err := q.db.QueryContext(ctx, listObjectContentSize, ocflID)
+func (q *Queries) ListObjectContentSize(ctx context.Context, arg ListObjectContentSizeParams) ([]ListObjectContentSizeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listObjectContentSize, arg.Name, arg.OcflID)
 	if err != nil {
 		return nil, err
 	}
@@ -660,17 +1004,19 @@ func (q *Queries) ListObjectContentSize(ctx context.Context, ocflID string) ([]L
 }
 
 const listObjectRoots = `-- name: ListObjectRoots :many
-SELECT id, path, indexed_at FROM ocfl_index_object_roots roots 
-WHERE roots.path > ?1 ORDER BY roots.path ASC LIMIT ?2
+SELECT roots.id, roots.path, roots.indexed_at, roots.storage_root_id FROM ocfl_index_object_roots roots 
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path > ?2 ORDER BY roots.path ASC LIMIT ?3
 `
 
 type ListObjectRootsParams struct {
+	Name  string
 	Path  string
 	Limit int64
 }
 
 func (q *Queries) ListObjectRoots(ctx context.Context, arg ListObjectRootsParams) ([]OcflIndexObjectRoot, error) {
-	rows, err := q.db.QueryContext(ctx, listObjectRoots, arg.Path, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, listObjectRoots, arg.Name, arg.Path, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -678,7 +1024,54 @@ func (q *Queries) ListObjectRoots(ctx context.Context, arg ListObjectRootsParams
 	var items []OcflIndexObjectRoot
 	for rows.Next() {
 		var i OcflIndexObjectRoot
-		if err := rows.Scan(&i.ID, &i.Path, &i.IndexedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.IndexedAt,
+			&i.StorageRootID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTasks = `-- name: ListTasks :many
+SELECT id, name, storage_root, options, status, started_at, ended_at, counts, error FROM ocfl_index_tasks WHERE id < ?1 ORDER BY id DESC LIMIT ?2
+`
+
+type ListTasksParams struct {
+	ID    int64
+	Limit int64
+}
+
+func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]OcflIndexTask, error) {
+	rows, err := q.db.QueryContext(ctx, listTasks, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OcflIndexTask
+	for rows.Next() {
+		var i OcflIndexTask
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StorageRoot,
+			&i.Options,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Counts,
+			&i.Error,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -693,10 +1086,9 @@ func (q *Queries) ListObjectRoots(ctx context.Context, arg ListObjectRootsParams
 }
 
 const listVersions = `-- name: ListVersions :many
-SELECT versions.inventory_id, versions.num, versions.name, versions.message, versions.created, versions.user_name, versions.user_address, versions.node_id, nodes.size size FROM ocfl_index_versions versions
+SELECT versions.inventory_id, versions.num, versions.name, versions.message, versions.created, versions.user_name, versions.user_address, versions.node_id, versions.num_files, versions.num_added, versions.num_removed, versions.num_modified, nodes.size size FROM ocfl_index_versions versions
 INNER JOIN ocfl_index_nodes nodes ON nodes.id = versions.node_id
-INNER JOIN ocfl_index_inventories objects ON objects.id = versions.inventory_id
-WHERE objects.ocfl_id = ? ORDER BY versions.num ASC
+WHERE versions.inventory_id = ? ORDER BY versions.num ASC
 `
 
 type ListVersionsRow struct {
@@ -708,11 +1100,15 @@ type ListVersionsRow struct {
 	UserName    string
 	UserAddress string
 	NodeID      int64
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
 	Size        sql.NullInt64
 }
 
-func (q *Queries) ListVersions(ctx context.Context, ocflID string) ([]ListVersionsRow, error) {
-	rows, err := q.db.QueryContext(ctx, listVersions, ocflID)
+func (q *Queries) ListVersions(ctx context.Context, inventoryID int64) ([]ListVersionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVersions, inventoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -729,6 +1125,10 @@ func (q *Queries) ListVersions(ctx context.Context, ocflID string) ([]ListVersio
 			&i.UserName,
 			&i.UserAddress,
 			&i.NodeID,
+			&i.NumFiles,
+			&i.NumAdded,
+			&i.NumRemoved,
+			&i.NumModified,
 			&i.Size,
 		); err != nil {
 			return nil, err
@@ -808,6 +1208,47 @@ func (q *Queries) SetNodeSize(ctx context.Context, arg SetNodeSizeParams) error
 	return err
 }
 
+const setSchemaVersion = `-- name: SetSchemaVersion :exec
+UPDATE ocfl_index_schema SET major = ?, minor = ?
+`
+
+type SetSchemaVersionParams struct {
+	Major int64
+	Minor int64
+}
+
+func (q *Queries) SetSchemaVersion(ctx context.Context, arg SetSchemaVersionParams) error {
+	_, err := q.db.ExecContext(ctx, setSchemaVersion, arg.Major, arg.Minor)
+	return err
+}
+
+const setVersionStats = `-- name: SetVersionStats :exec
+UPDATE ocfl_index_versions
+    SET num_files = ?1, num_added = ?2, num_removed = ?3, num_modified = ?4
+    WHERE inventory_id = ?5 AND num = ?6
+`
+
+type SetVersionStatsParams struct {
+	NumFiles    sql.NullInt64
+	NumAdded    sql.NullInt64
+	NumRemoved  sql.NullInt64
+	NumModified sql.NullInt64
+	InventoryID int64
+	Num         int64
+}
+
+func (q *Queries) SetVersionStats(ctx context.Context, arg SetVersionStatsParams) error {
+	_, err := q.db.ExecContext(ctx, setVersionStats,
+		arg.NumFiles,
+		arg.NumAdded,
+		arg.NumRemoved,
+		arg.NumModified,
+		arg.InventoryID,
+		arg.Num,
+	)
+	return err
+}
+
 const updateInventory = `-- name: UpdateInventory :exec
 UPDATE ocfl_index_inventories SET 
     spec = ?, 
@@ -842,6 +1283,62 @@ func (q *Queries) UpdateInventory(ctx context.Context, arg UpdateInventoryParams
 	return err
 }
 
+const updateTask = `-- name: UpdateTask :exec
+UPDATE ocfl_index_tasks SET status = ?1, started_at = ?2, ended_at = ?3, counts = ?4, error = ?5
+WHERE id = ?6
+`
+
+type UpdateTaskParams struct {
+	Status    string
+	StartedAt time.Time
+	EndedAt   sql.NullTime
+	Counts    string
+	Error     string
+	ID        int64
+}
+
+func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) error {
+	_, err := q.db.ExecContext(ctx, updateTask,
+		arg.Status,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Counts,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertFixityCheck = `-- name: UpsertFixityCheck :exec
+INSERT INTO ocfl_index_fixity_checks (root_id, valid, codes, error, checked_at)
+VALUES (?1, ?2, ?3, ?4, ?5)
+    ON CONFLICT(root_id) DO UPDATE SET
+    valid=?2,
+    codes=?3,
+    error=?4,
+    checked_at=?5
+`
+
+type UpsertFixityCheckParams struct {
+	RootID    int64
+	Valid     bool
+	Codes     string
+	Error     string
+	CheckedAt time.Time
+}
+
+// Fixity Checks
+func (q *Queries) UpsertFixityCheck(ctx context.Context, arg UpsertFixityCheckParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFixityCheck,
+		arg.RootID,
+		arg.Valid,
+		arg.Codes,
+		arg.Error,
+		arg.CheckedAt,
+	)
+	return err
+}
+
 const upsertInventory = `-- name: UpsertInventory :one
 INSERT INTO ocfl_index_inventories (
     ocfl_id, 
@@ -850,16 +1347,17 @@ INSERT INTO ocfl_index_inventories (
     digest_algorithm, 
     inventory_digest, 
     head,
-    indexed_at
-) values (?1, ?2, ?3, ?4, ?5, ?6, ?7)
-    ON CONFLICT(ocfl_id) DO UPDATE SET 
+    indexed_at,
+    storage_root_id
+) values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+    ON CONFLICT(storage_root_id, ocfl_id) DO UPDATE SET 
     root_id=?2,
     spec=?3, 
     digest_algorithm=?4, 
     inventory_digest=?5, 
     head=?6,
     indexed_at=?7
-RETURNING id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at
+RETURNING id, root_id, ocfl_id, spec, digest_algorithm, inventory_digest, head, indexed_at, storage_root_id
 `
 
 type UpsertInventoryParams struct {
@@ -870,6 +1368,7 @@ type UpsertInventoryParams struct {
 	InventoryDigest string
 	Head            string
 	IndexedAt       time.Time
+	StorageRootID   int64
 }
 
 // OCFL Object Inventory
@@ -882,6 +1381,7 @@ func (q *Queries) UpsertInventory(ctx context.Context, arg UpsertInventoryParams
 		arg.InventoryDigest,
 		arg.Head,
 		arg.IndexedAt,
+		arg.StorageRootID,
 	)
 	var i OcflIndexInventory
 	err := row.Scan(
@@ -893,24 +1393,46 @@ func (q *Queries) UpsertInventory(ctx context.Context, arg UpsertInventoryParams
 		&i.InventoryDigest,
 		&i.Head,
 		&i.IndexedAt,
+		&i.StorageRootID,
 	)
 	return i, err
 }
 
 const upsertObjectRoot = `-- name: UpsertObjectRoot :one
-INSERT INTO ocfl_index_object_roots (path, indexed_at) VALUES (?1, ?2) 
-    ON CONFLICT(path) DO UPDATE SET indexed_at=?2
-RETURNING id, path, indexed_at
+INSERT INTO ocfl_index_object_roots (storage_root_id, path, indexed_at) VALUES (?1, ?2, ?3) 
+    ON CONFLICT(storage_root_id, path) DO UPDATE SET indexed_at=?3
+RETURNING id, path, indexed_at, storage_root_id
 `
 
 type UpsertObjectRootParams struct {
-	Path      string
-	IndexedAt time.Time
+	StorageRootID int64
+	Path          string
+	IndexedAt     time.Time
 }
 
 func (q *Queries) UpsertObjectRoot(ctx context.Context, arg UpsertObjectRootParams) (OcflIndexObjectRoot, error) {
-	row := q.db.QueryRowContext(ctx, upsertObjectRoot, arg.Path, arg.IndexedAt)
+	row := q.db.QueryRowContext(ctx, upsertObjectRoot, arg.StorageRootID, arg.Path, arg.IndexedAt)
 	var i OcflIndexObjectRoot
-	err := row.Scan(&i.ID, &i.Path, &i.IndexedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Path,
+		&i.IndexedAt,
+		&i.StorageRootID,
+	)
+	return i, err
+}
+
+const upsertStorageRoot = `-- name: UpsertStorageRoot :one
+
+INSERT INTO ocfl_index_storage_roots (name) VALUES (?1)
+    ON CONFLICT(name) DO UPDATE SET name=?1
+RETURNING id, name
+`
+
+// Storage Roots
+func (q *Queries) UpsertStorageRoot(ctx context.Context, name string) (OcflIndexStorageRoot, error) {
+	row := q.db.QueryRowContext(ctx, upsertStorageRoot, name)
+	var i OcflIndexStorageRoot
+	err := row.Scan(&i.ID, &i.Name)
 	return i, err
 }
@@ -6,31 +6,57 @@
 -- name: GetSchemaVersion :one
 SELECT * FROM ocfl_index_schema LIMIT 1;
 
+-- name: SetSchemaVersion :exec
+UPDATE ocfl_index_schema SET major = ?, minor = ?;
+
+-- name: InsertMigration :exec
+INSERT INTO ocfl_index_migrations (name, applied_at) VALUES (?, ?);
+
+
+--
+-- Storage Roots
+--
+
+-- name: UpsertStorageRoot :one
+INSERT INTO ocfl_index_storage_roots (name) VALUES (?1)
+    ON CONFLICT(name) DO UPDATE SET name=?1
+RETURNING *;
+
+-- name: DebugAllStorageRoots :many
+SELECT * from ocfl_index_storage_roots;
 
 --
 -- OCFL Object Roots
 --
 
 -- name: GetObjectRoot :one
-SELECT * from ocfl_index_object_roots WHERE path = ?;
+SELECT roots.* from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path = ?2;
 
 -- name: UpsertObjectRoot :one
-INSERT INTO ocfl_index_object_roots (path, indexed_at) VALUES (?1, ?2) 
-    ON CONFLICT(path) DO UPDATE SET indexed_at=?2
+INSERT INTO ocfl_index_object_roots (storage_root_id, path, indexed_at) VALUES (?1, ?2, ?3) 
+    ON CONFLICT(storage_root_id, path) DO UPDATE SET indexed_at=?3
 RETURNING *;
 
 -- name: ListObjectRoots :many 
-SELECT * FROM ocfl_index_object_roots roots 
-WHERE roots.path > ?1 ORDER BY roots.path ASC LIMIT ?2;
+SELECT roots.* FROM ocfl_index_object_roots roots 
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path > ?2 ORDER BY roots.path ASC LIMIT ?3;
 
 -- name: DeleteObjectRootsBefore :exec
-DELETE FROM ocfl_index_object_roots WHERE indexed_at < ?1;
+DELETE FROM ocfl_index_object_roots WHERE storage_root_id = (
+    SELECT id FROM ocfl_index_storage_roots WHERE name = ?1) AND indexed_at < ?2;
 
 -- name: CountObjectRoots :one
-SELECT COUNT(id) from ocfl_index_object_roots;
+SELECT COUNT(roots.id) from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1;
 
 -- name: GetObjectRootLastIndexedAt :one
-SELECT indexed_at from ocfl_index_object_roots ORDER BY indexed_at DESC LIMIT 1;
+SELECT roots.indexed_at from ocfl_index_object_roots roots
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 ORDER BY roots.indexed_at DESC LIMIT 1;
 
 -- name: DebugAllObjectRoots :many
 SELECT * from ocfl_index_object_roots;
@@ -46,9 +72,10 @@ INSERT INTO ocfl_index_inventories (
     digest_algorithm, 
     inventory_digest, 
     head,
-    indexed_at
-) values (?1, ?2, ?3, ?4, ?5, ?6, ?7)
-    ON CONFLICT(ocfl_id) DO UPDATE SET 
+    indexed_at,
+    storage_root_id
+) values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+    ON CONFLICT(storage_root_id, ocfl_id) DO UPDATE SET 
     root_id=?2,
     spec=?3, 
     digest_algorithm=?4, 
@@ -57,18 +84,17 @@ INSERT INTO ocfl_index_inventories (
     indexed_at=?7
 RETURNING *;
 
--- name: GetInventoryRowID :one
-SELECT id from ocfl_index_inventories where ocfl_id = ?;
-
 -- name: GetInventoryID :one
 SELECT invs.*, objs.path FROM ocfl_index_inventories invs
 INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
-WHERE ocfl_id = ?;
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2;
 
 -- name: GetInventoryPath :one
 SELECT invs.*, objs.path FROM ocfl_index_inventories invs
 INNER JOIN ocfl_index_object_roots objs ON objs.id = invs.root_id
-WHERE objs.path = ?;
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND objs.path = ?2;
 
 -- name: ListInventoriesPrefix :many
 SELECT 
@@ -86,8 +112,10 @@ INNER JOIN ocfl_index_versions head
     ON invs.id = head.inventory_id AND invs.head = head.name
 INNER JOIN ocfl_index_versions v1
     ON invs.id = v1.inventory_id AND v1.num = 1
-WHERE invs.ocfl_id > ?1 AND invs.ocfl_id LIKE ?2 || '%' ESCAPE '\'
-ORDER BY invs.ocfl_id ASC LIMIT ?3;
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id > ?2 AND invs.ocfl_id LIKE ?3 || '%' ESCAPE '\'
+ORDER BY invs.ocfl_id ASC LIMIT ?4;
 
 -- name: ListInventories :many
 SELECT 
@@ -105,11 +133,15 @@ INNER JOIN ocfl_index_versions head
     ON invs.id = head.inventory_id AND invs.head = head.name
 INNER JOIN ocfl_index_versions v1
     ON invs.id = v1.inventory_id AND v1.num = 1
-WHERE invs.ocfl_id > ?1
-ORDER BY invs.ocfl_id ASC LIMIT ?2;
+INNER JOIN ocfl_index_storage_roots store
+    ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id > ?2
+ORDER BY invs.ocfl_id ASC LIMIT ?3;
 
 -- name: CountInventories :one
-SELECT COUNT(id) from ocfl_index_inventories;
+SELECT COUNT(invs.id) from ocfl_index_inventories invs
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1;
 
 -- name: DebugAllInventories :many
 SELECT * from ocfl_index_inventories;
@@ -133,14 +165,19 @@ DELETE from ocfl_index_inventories WHERE id = ?;
 --
 -- name: InsertVersion :execlastid
 INSERT INTO ocfl_index_versions 
-    (inventory_id, name, message, created, user_name, user_address, node_id, num)
-    VALUES (?1,?2,?3,?4,?5,?6,?7, CAST(LTRIM(?2,'v') AS INT));
+    (inventory_id, name, message, created, user_name, user_address, node_id,
+    num_files, num_added, num_removed, num_modified, num)
+    VALUES (?1,?2,?3,?4,?5,?6,?7,?8,?9,?10,?11, CAST(LTRIM(?2,'v') AS INT));
+
+-- name: SetVersionStats :exec
+UPDATE ocfl_index_versions
+    SET num_files = ?1, num_added = ?2, num_removed = ?3, num_modified = ?4
+    WHERE inventory_id = ?5 AND num = ?6;
 
 -- name: ListVersions :many
 SELECT versions.*, nodes.size size FROM ocfl_index_versions versions
 INNER JOIN ocfl_index_nodes nodes ON nodes.id = versions.node_id
-INNER JOIN ocfl_index_inventories objects ON objects.id = versions.inventory_id
-WHERE objects.ocfl_id = ? ORDER BY versions.num ASC;
+WHERE versions.inventory_id = ? ORDER BY versions.num ASC;
 
 -- name: GetVersion :one
 SELECT * from ocfl_index_versions WHERE inventory_id = ?1 and num = ?2;
@@ -193,14 +230,89 @@ INSERT OR IGNORE INTO ocfl_index_content_paths (inventory_id, node_id, file_path
     ?);
 
 -- name: GetContentPath :one
-SELECT cont.file_path, objs.path from ocfl_index_content_paths cont
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
 INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
 INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
 INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
-WHERE nodes.sum = ? LIMIT 1; 
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.digest_algorithm = ?2 AND nodes.sum = ?3 LIMIT 1;
+
+-- name: GetObjectContentPath :one
+SELECT cont.file_path, objs.path, nodes.size from ocfl_index_content_paths cont
+INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
+INNER JOIN ocfl_index_object_roots objs ON invs.root_id = objs.id
+INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id  AND nodes.dir IS FALSE
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2 AND nodes.sum = ?3 LIMIT 1; 
 
 -- name: ListObjectContentSize :many
 SELECT cont.file_path, nodes.size from ocfl_index_content_paths cont
 INNER JOIN ocfl_index_nodes nodes on nodes.id = cont.node_id
 INNER JOIN ocfl_index_inventories invs ON cont.inventory_id = invs.id
-WHERE invs.ocfl_id = ? AND nodes.size IS NOT NULL;
\ No newline at end of file
+INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+WHERE store.name = ?1 AND invs.ocfl_id = ?2 AND nodes.size IS NOT NULL;
+
+--
+-- Tasks
+--
+-- name: InsertTask :one
+INSERT INTO ocfl_index_tasks (name, storage_root, options, status, started_at, counts, error)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+RETURNING id;
+
+-- name: UpdateTask :exec
+UPDATE ocfl_index_tasks SET status = ?1, started_at = ?2, ended_at = ?3, counts = ?4, error = ?5
+WHERE id = ?6;
+
+-- name: GetTask :one
+SELECT * FROM ocfl_index_tasks WHERE id = ?1;
+
+-- name: ListTasks :many
+SELECT * FROM ocfl_index_tasks WHERE id < ?1 ORDER BY id DESC LIMIT ?2;
+
+-- name: FailUnfinishedTasks :exec
+UPDATE ocfl_index_tasks SET status = 'failed', ended_at = ?1, error = ?2
+WHERE status IN ('queued', 'running');
+
+--
+-- Validation Issues
+--
+-- name: DeleteValidationIssues :exec
+DELETE FROM ocfl_index_validation_issues WHERE root_id = ?1;
+
+-- name: InsertValidationIssue :exec
+INSERT INTO ocfl_index_validation_issues (root_id, severity, code, message, inventory_path, checked_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6);
+
+-- name: ListInvalidObjects :many
+SELECT roots.path, issues.severity, issues.code, issues.message, issues.inventory_path, issues.checked_at
+FROM ocfl_index_validation_issues issues
+INNER JOIN ocfl_index_object_roots roots ON issues.root_id = roots.id
+WHERE issues.root_id IN (
+    SELECT invalid.id FROM ocfl_index_object_roots invalid
+    INNER JOIN ocfl_index_storage_roots store ON invalid.storage_root_id = store.id
+    WHERE store.name = ?1 AND invalid.path > ?2 AND EXISTS (
+        SELECT 1 FROM ocfl_index_validation_issues i WHERE i.root_id = invalid.id
+    )
+    ORDER BY invalid.path ASC LIMIT ?3
+)
+ORDER BY roots.path ASC, issues.id ASC;
+
+--
+-- Fixity Checks
+--
+-- name: UpsertFixityCheck :exec
+INSERT INTO ocfl_index_fixity_checks (root_id, valid, codes, error, checked_at)
+VALUES (?1, ?2, ?3, ?4, ?5)
+    ON CONFLICT(root_id) DO UPDATE SET
+    valid=?2,
+    codes=?3,
+    error=?4,
+    checked_at=?5;
+
+-- name: GetFixityCheck :one
+SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+FROM ocfl_index_fixity_checks checks
+INNER JOIN ocfl_index_object_roots roots ON checks.root_id = roots.id
+INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+WHERE store.name = ?1 AND roots.path = ?2;
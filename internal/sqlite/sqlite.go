@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,7 +29,7 @@ const (
 var (
 	// expected schema for index file
 	// keep in sync with schema.sql
-	schemaVer = sqlc.OcflIndexSchema{Major: 0, Minor: 4}
+	schemaVer = sqlc.OcflIndexSchema{Major: 0, Minor: 11}
 
 	//go:embed schema.sql
 	querySchema string
@@ -39,12 +40,32 @@ var (
 	//go:embed get_node_children.sql
 	queryGetNodeChildren string
 
+	//go:embed find_by_digest.sql
+	queryFindByDigest string
+
+	//go:embed search.sql
+	querySearch string
+
+	// sqlc doesn't support FTS5 tables
+	queryInsertSearchEntry = `INSERT INTO ocfl_index_search
+    (inventory_id, version, ocfl_id, path, message, user_name, user_address)
+    VALUES (?, ?, ?, ?, ?, ?, ?);`
+	queryDeleteSearchEntries = `DELETE FROM ocfl_index_search WHERE inventory_id = ?;`
+
+	// sqlc doesn't treat columns from left joins as nullable
+	queryListFixityDue = `SELECT roots.path, checks.valid, checks.codes, checks.error, checks.checked_at
+    FROM ocfl_index_object_roots roots
+    INNER JOIN ocfl_index_storage_roots store ON roots.storage_root_id = store.id
+    LEFT JOIN ocfl_index_fixity_checks checks ON checks.root_id = roots.id
+    WHERE store.name = ?1
+    ORDER BY checks.checked_at ASC, roots.path ASC LIMIT ?2;`
+
 	queryListTables string = `SELECT name FROM sqlite_master WHERE type='table';`
 )
 
 // Backend is a sqlite-based implementation of index.Backend
 type Backend struct {
-	sql.DB
+	*sql.DB
 }
 
 var _ index.Backend = (*Backend)(nil)
@@ -60,7 +81,7 @@ func Open(conf string) (*Backend, error) {
 	}
 	db.Exec("PRAGMA case_sensitive_like=ON;")
 	db.Exec("PRAGMA foreign_keys=ON;")
-	return &Backend{DB: *db}, nil
+	return &Backend{DB: db}, nil
 }
 
 func (db *Backend) GetSchemaVersion(ctx context.Context) (int, int, error) {
@@ -88,6 +109,11 @@ func (db *Backend) InitSchema(ctx context.Context) (bool, error) {
 		if schema == schemaVer {
 			return false, nil
 		}
+		if schemaLess(schema, schemaVer) {
+			return false, fmt.Errorf("database uses schema v%d.%d, this version of ocfl-index requires v%d.%d: %w",
+				schema.Major, schema.Minor, schemaVer.Major, schemaVer.Minor, index.ErrSchemaOld,
+			)
+		}
 		return false, fmt.Errorf("database uses schema v%d.%d, this version of ocfl-index requires v%d.%d ",
 			schema.Major, schema.Minor, schemaVer.Major, schemaVer.Minor,
 		)
@@ -103,17 +129,17 @@ func (db *Backend) InitSchema(ctx context.Context) (bool, error) {
 	return true, tx.Commit()
 }
 
-func (db *Backend) GetIndexSummary(ctx context.Context) (index.IndexSummary, error) {
-	qry := sqlc.New(&db.DB)
-	invs, err := qry.CountInventories(ctx)
+func (db *Backend) GetIndexSummary(ctx context.Context, storageRoot string) (index.IndexSummary, error) {
+	qry := sqlc.New(db.DB)
+	invs, err := qry.CountInventories(ctx, storageRoot)
 	if err != nil {
 		return index.IndexSummary{}, err
 	}
-	objs, err := qry.CountObjectRoots(ctx)
+	objs, err := qry.CountObjectRoots(ctx, storageRoot)
 	if err != nil {
 		return index.IndexSummary{}, err
 	}
-	last, err := qry.GetObjectRootLastIndexedAt(ctx)
+	last, err := qry.GetObjectRootLastIndexedAt(ctx, storageRoot)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return index.IndexSummary{}, err
@@ -129,16 +155,20 @@ func (db *Backend) GetIndexSummary(ctx context.Context) (index.IndexSummary, err
 }
 
 // List entries for object roots table
-func (db *Backend) ListObjectRoots(ctx context.Context, limit int, cursor string) (*index.ObjectRootList, error) {
-	return listObjectRootsTx(ctx, sqlc.New(db), limit, cursor)
+func (db *Backend) ListObjectRoots(ctx context.Context, storageRoot string, limit int, cursor string) (*index.ObjectRootList, error) {
+	return listObjectRootsTx(ctx, sqlc.New(db), storageRoot, limit, cursor)
 }
 
-func listObjectRootsTx(ctx context.Context, qry *sqlc.Queries, limit int, cursor string) (*index.ObjectRootList, error) {
+func listObjectRootsTx(ctx context.Context, qry *sqlc.Queries, storageRoot string, limit int, cursor string) (*index.ObjectRootList, error) {
 	if limit < 1 || limit > 1000 {
 		limit = defaultLimit
 	}
 	// add 1 to limit to see if there are more items
-	roots, err := qry.ListObjectRoots(ctx, sqlc.ListObjectRootsParams{Path: cursor, Limit: int64(limit + 1)})
+	roots, err := qry.ListObjectRoots(ctx, sqlc.ListObjectRootsParams{
+		Name:  storageRoot,
+		Path:  cursor,
+		Limit: int64(limit + 1),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -160,15 +190,16 @@ func listObjectRootsTx(ctx context.Context, qry *sqlc.Queries, limit int, cursor
 }
 
 // We can't use sqlc here because we need to alter the query for different sort/cursor values.
-func (idx *Backend) ListObjects(ctx context.Context, prefix string, limit int, cursor string) (*index.ObjectList, error) {
+func (idx *Backend) ListObjects(ctx context.Context, storageRoot string, prefix string, limit int, cursor string) (*index.ObjectList, error) {
 	if limit < 1 || limit > 1000 {
 		limit = defaultLimit
 	}
-	qry := sqlc.New(&idx.DB)
+	qry := sqlc.New(idx.DB)
 	args := sqlc.ListInventoriesPrefixParams{
-		OcflID:   cursor,
-		OcflID_2: prefix,
-		Limit:    int64(limit + 1), // check for next page
+		Name:   storageRoot,
+		OcflID: cursor,
+		Name_2: prefix,
+		Limit:  int64(limit + 1), // check for next page
 	}
 	rows, err := qry.ListInventoriesPrefix(ctx, args)
 	if err != nil {
@@ -205,13 +236,17 @@ func (idx *Backend) ListObjects(ctx context.Context, prefix string, limit int, c
 	return list, nil
 }
 
-func (db *Backend) GetObject(ctx context.Context, objID string) (*index.Object, error) {
+func (db *Backend) GetObject(ctx context.Context, storageRoot string, objID string) (*index.Object, error) {
 	qry := sqlc.New(db)
-	obj, err := qry.GetInventoryID(ctx, objID)
+	obj, err := qry.GetInventoryID(ctx, sqlc.GetInventoryIDParams{
+		Name:   storageRoot,
+		OcflID: objID,
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("object id '%s': %w", objID, index.ErrNotFound)
 		}
+		return nil, err
 	}
 	ret, err := asIndexInventory(ctx, qry, (*sqlc.GetInventoryPathRow)(&obj))
 	if err != nil {
@@ -220,12 +255,15 @@ func (db *Backend) GetObject(ctx context.Context, objID string) (*index.Object,
 	return ret, nil
 }
 
-func (db *Backend) GetObjectByPath(ctx context.Context, p string) (*index.Object, error) {
-	return getObjectByPathTx(ctx, sqlc.New(db), p)
+func (db *Backend) GetObjectByPath(ctx context.Context, storageRoot string, p string) (*index.Object, error) {
+	return getObjectByPathTx(ctx, sqlc.New(db), storageRoot, p)
 }
 
-func getObjectByPathTx(ctx context.Context, tx *sqlc.Queries, p string) (*index.Object, error) {
-	obj, err := tx.GetInventoryPath(ctx, p)
+func getObjectByPathTx(ctx context.Context, tx *sqlc.Queries, storageRoot string, p string) (*index.Object, error) {
+	obj, err := tx.GetInventoryPath(ctx, sqlc.GetInventoryPathParams{
+		Name: storageRoot,
+		Path: p,
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("object path '%s': %w", p, index.ErrNotFound)
@@ -240,7 +278,7 @@ func getObjectByPathTx(ctx context.Context, tx *sqlc.Queries, p string) (*index.
 }
 
 func asIndexInventory(ctx context.Context, qry *sqlc.Queries, sqlInv *sqlc.GetInventoryPathRow) (*index.Object, error) {
-	rows, err := qry.ListVersions(ctx, sqlInv.OcflID)
+	rows, err := qry.ListVersions(ctx, sqlInv.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -251,6 +289,13 @@ func asIndexInventory(ctx context.Context, qry *sqlc.Queries, sqlInv *sqlc.GetIn
 			Created: rows[i].Created,
 			Size:    rows[i].Size.Int64,
 			HasSize: rows[i].Size.Valid,
+			Stats: index.VersionStats{
+				Files:    int(rows[i].NumFiles.Int64),
+				Added:    int(rows[i].NumAdded.Int64),
+				Removed:  int(rows[i].NumRemoved.Int64),
+				Modified: int(rows[i].NumModified.Int64),
+			},
+			HasStats: rows[i].NumFiles.Valid,
 		}
 		err := ocfl.ParseVNum(rows[i].Name, &ver.Num)
 		if err != nil {
@@ -268,6 +313,7 @@ func asIndexInventory(ctx context.Context, qry *sqlc.Queries, sqlInv *sqlc.GetIn
 		ID:              sqlInv.OcflID,
 		DigestAlgorithm: sqlInv.DigestAlgorithm,
 		InventoryDigest: sqlInv.InventoryDigest,
+		IndexedAt:       sqlInv.IndexedAt,
 		RootPath:        sqlInv.Path,
 		Versions:        vers,
 	}
@@ -280,7 +326,7 @@ func asIndexInventory(ctx context.Context, qry *sqlc.Queries, sqlInv *sqlc.GetIn
 	return result, nil
 }
 
-func (db *Backend) GetObjectState(ctx context.Context, id string, v ocfl.VNum, p string, recursive bool, lim int, cur string) (*index.PathInfo, error) {
+func (db *Backend) GetObjectState(ctx context.Context, storageRoot string, id string, v ocfl.VNum, p string, recursive bool, lim int, cur string) (*index.PathInfo, error) {
 	if lim == 0 {
 		lim = 1000
 	}
@@ -289,28 +335,15 @@ func (db *Backend) GetObjectState(ctx context.Context, id string, v ocfl.VNum, p
 	}
 	p = path.Clean(p)
 	if !fs.ValidPath(p) {
-		return nil, fmt.Errorf("invalid path: %s", p)
-	}
-	var vStr string
-	if !v.IsZero() {
-		vStr = v.String()
+		return nil, fmt.Errorf("invalid path '%s': %w", p, index.ErrInvalidArgs)
 	}
-	var baseNode = struct {
-		id     int64
-		sumbyt []byte
-		size   sql.NullInt64
-		isdir  bool
-	}{}
-	qry := sqlc.New(&db.DB)
+	qry := sqlc.New(db.DB)
 	errFn := func(err error) error {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("%s: %s: %w", id, p, index.ErrNotFound)
-		}
 		return fmt.Errorf("%s: %s: %w", id, p, err)
 	}
-	row := db.QueryRowContext(ctx, queryGetNodeByPath, id, vStr, p)
-	if err := row.Scan(&baseNode.id, &baseNode.sumbyt, &baseNode.isdir, &baseNode.size); err != nil {
-		return nil, errFn(err)
+	baseNode, err := db.getStateNode(ctx, storageRoot, id, v, p)
+	if err != nil {
+		return nil, err
 	}
 	result := &index.PathInfo{
 		Sum:     hex.EncodeToString(baseNode.sumbyt),
@@ -323,7 +356,6 @@ func (db *Backend) GetObjectState(ctx context.Context, id string, v ocfl.VNum, p
 	}
 	// base is a directory: get list of children
 	limParam := int64(lim + 1) // limit+1 to check for next page
-	var err error
 	if recursive {
 		// result includes all descendants of the node
 		result.Children, err = db.getNodeChildrenAll(ctx, baseNode.id, limParam, cur)
@@ -332,7 +364,7 @@ func (db *Backend) GetObjectState(ctx context.Context, id string, v ocfl.VNum, p
 		result.Children, err = db.getNodeChildren(ctx, qry, baseNode.id, limParam, cur)
 	}
 	if err != nil {
-		errFn(err)
+		return nil, errFn(err)
 	}
 	// check if there are additional results
 	if l := len(result.Children); l == lim+1 {
@@ -342,6 +374,32 @@ func (db *Backend) GetObjectState(ctx context.Context, id string, v ocfl.VNum, p
 	return result, nil
 }
 
+// stateNode is a node in an object version state
+type stateNode struct {
+	id     int64
+	sumbyt []byte
+	size   sql.NullInt64
+	isdir  bool
+}
+
+// getStateNode returns the node for the logical path p, which must be valid,
+// in the object version state.
+func (db *Backend) getStateNode(ctx context.Context, storageRoot string, id string, v ocfl.VNum, p string) (*stateNode, error) {
+	var vStr string
+	if !v.IsZero() {
+		vStr = v.String()
+	}
+	var node stateNode
+	row := db.QueryRowContext(ctx, queryGetNodeByPath, id, vStr, p, storageRoot)
+	if err := row.Scan(&node.id, &node.sumbyt, &node.isdir, &node.size); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %s: %w", id, p, index.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %s: %w", id, p, err)
+	}
+	return &node, nil
+}
+
 func (db *Backend) getNodeChildren(ctx context.Context, qry *sqlc.Queries, nodeID int64, limit int64, cursor string) ([]index.PathItem, error) {
 	rows, err := qry.NodeDirChildren(ctx, sqlc.NodeDirChildrenParams{
 		ParentID: nodeID,
@@ -391,17 +449,154 @@ func (db *Backend) getNodeChildrenAll(ctx context.Context, nodeID int64, limit i
 	return paths, nil
 }
 
-func (db *Backend) GetContentPath(ctx context.Context, sum string) (string, error) {
-	qry := sqlc.New(&db.DB)
+func (db *Backend) GetContentPath(ctx context.Context, storageRoot string, alg string, sum string) (*index.ContentPath, error) {
 	bytes, err := hex.DecodeString(sum)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("invalid digest '%s': %w", sum, index.ErrInvalidArgs)
 	}
-	result, err := qry.GetContentPath(ctx, bytes)
+	result, err := sqlc.New(db.DB).GetContentPath(ctx, sqlc.GetContentPathParams{
+		Name:            storageRoot,
+		DigestAlgorithm: alg,
+		Sum:             bytes,
+	})
 	if err != nil {
-		return "", err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("content with %s digest '%s': %w", alg, sum, index.ErrNotFound)
+		}
+		return nil, err
 	}
-	return path.Join(result.Path, result.FilePath), nil
+	return asContentPath((*sqlc.GetObjectContentPathRow)(&result)), nil
+}
+
+func (db *Backend) GetObjectContentPath(ctx context.Context, storageRoot string, objectID string, sum string) (*index.ContentPath, error) {
+	bytes, err := hex.DecodeString(sum)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest '%s': %w", sum, index.ErrInvalidArgs)
+	}
+	result, err := sqlc.New(db.DB).GetObjectContentPath(ctx, sqlc.GetObjectContentPathParams{
+		Name:   storageRoot,
+		OcflID: objectID,
+		Sum:    bytes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("content with digest '%s' in object '%s': %w", sum, objectID, index.ErrNotFound)
+		}
+		return nil, err
+	}
+	return asContentPath(&result), nil
+}
+
+func asContentPath(row *sqlc.GetObjectContentPathRow) *index.ContentPath {
+	return &index.ContentPath{
+		Path:    path.Join(row.Path, row.FilePath),
+		Size:    row.Size.Int64,
+		HasSize: row.Size.Valid,
+	}
+}
+
+func (db *Backend) Search(ctx context.Context, storageRoot string, query string, limit int, cursor string) (*index.SearchResults, error) {
+	if limit < 1 || limit > 1000 {
+		limit = defaultLimit
+	}
+	terms, err := index.ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var after int64
+	if cursor != "" {
+		after, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search cursor '%s': %w", cursor, index.ErrInvalidArgs)
+		}
+	}
+	// limit+1 to check for next page
+	rows, err := db.QueryContext(ctx, querySearch, fts5Query(terms), storageRoot, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := &index.SearchResults{}
+	var lastRow int64
+	for rows.Next() {
+		if len(results.Results) == limit {
+			results.NextCursor = strconv.FormatInt(lastRow, 10)
+			break
+		}
+		var (
+			r    index.SearchResult
+			vnum string
+		)
+		if err := rows.Scan(&lastRow, &r.ObjectID, &vnum, &r.Path); err != nil {
+			return nil, err
+		}
+		if err := ocfl.ParseVNum(vnum, &r.Version); err != nil {
+			return nil, fmt.Errorf("parsing indexed version value: %w", err)
+		}
+		results.Results = append(results.Results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// fts5Query returns the terms as an FTS5 query: all terms must match and each
+// term is a phrase.
+func fts5Query(terms []index.SearchTerm) string {
+	phrases := make([]string, len(terms))
+	for i, t := range terms {
+		// tokens only include letters and numbers: quotes aren't escaped
+		phrases[i] = `"` + strings.Join(t.Tokens, " ") + `"`
+		if t.Prefix {
+			phrases[i] += "*"
+		}
+	}
+	return strings.Join(phrases, " ")
+}
+
+func (db *Backend) FindByDigest(ctx context.Context, storageRoot string, sum string, limit int, cursor string) (*index.DigestMatchList, error) {
+	if limit < 1 || limit > 1000 {
+		limit = defaultLimit
+	}
+	sumBytes, err := hex.DecodeString(sum)
+	if err != nil || len(sumBytes) == 0 {
+		return nil, fmt.Errorf("invalid digest '%s': %w", sum, index.ErrInvalidArgs)
+	}
+	// limit+1 to check for next page
+	rows, err := db.QueryContext(ctx, queryFindByDigest, sumBytes, storageRoot, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := &index.DigestMatchList{}
+	for rows.Next() {
+		var (
+			id    string
+			vnum  string
+			match index.DigestMatch
+		)
+		if err := rows.Scan(&id, &vnum, &match.Path, &match.IsDir); err != nil {
+			return nil, err
+		}
+		if err := ocfl.ParseVNum(vnum, &match.Version); err != nil {
+			return nil, fmt.Errorf("parsing indexed version value: %w", err)
+		}
+		last := len(list.Objects) - 1
+		if last < 0 || list.Objects[last].ID != id {
+			list.Objects = append(list.Objects, index.DigestMatchObject{ID: id})
+			last++
+		}
+		list.Objects[last].Matches = append(list.Objects[last].Matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list.Objects) > limit {
+		list.Objects = list.Objects[:limit]
+		list.NextCursor = list.Objects[limit-1].ID
+	}
+	return list, nil
 }
 
 // existingTables returns list of table names in the database with the "ocfl_index_" prefix
@@ -2,9 +2,11 @@ package sqlite_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
-	"sort"
 	"testing"
 	"time"
 
@@ -12,14 +14,25 @@ import (
 
 	"github.com/srerickson/ocfl"
 	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/index/backendtest"
 	"github.com/srerickson/ocfl-index/internal/mock"
 	"github.com/srerickson/ocfl-index/internal/sqlite"
 	"github.com/srerickson/ocfl-index/internal/sqlite/sqlc"
 	"github.com/srerickson/ocfl/ocflv1"
+	"golang.org/x/exp/slices"
 )
 
+func TestConformance(t *testing.T) {
+	backendtest.RunConformance(t, func(t *testing.T) index.Backend {
+		idx, err := newSqliteIndex(context.Background(), t.Name())
+		expNil(t, err)
+		t.Cleanup(func() { idx.Close() })
+		return idx
+	})
+}
+
 func TestInitSchema(t *testing.T) {
-	expSchema := [2]int{0, 4}
+	expSchema := [2]int{0, 11}
 	ctx := context.Background()
 	idx, err := newSqliteIndex(ctx, t.Name())
 	expNil(t, err)
@@ -31,42 +44,128 @@ func TestInitSchema(t *testing.T) {
 	}
 }
 
-func TestGetIndexSummary(t *testing.T) {
+func TestMigrate(t *testing.T) {
 	ctx := context.Background()
-	t.Run("single object", func(t *testing.T) {
-		m := mock.NewIndexingObject(t.Name())
-		idx, err := setupSqliteIndex(ctx, t.Name(), func(tx index.BackendTx) error {
-			return tx.IndexObjectInventory(ctx, m.IndexedAt, index.ObjectInventory{
-				Path:      m.RootDir,
-				Inventory: m.Inventory,
-			})
-		})
-		expNil(t, err)
-		summary, err := idx.GetIndexSummary(ctx)
-		expNil(t, err)
-		exp := index.IndexSummary{
-			NumInventories: 1,
-			NumObjects:     1,
-			UpdatedAt:      m.IndexedAt.UTC(),
-		}
-		expEq(t, "indexed summary", summary, exp)
-	})
+	idx, err := newSqliteIndex(ctx, t.Name())
+	expNil(t, err)
+	defer idx.Close()
+	pending, err := idx.PendingMigrations(ctx)
+	expNil(t, err)
+	expEq(t, "pending migrations", len(pending), 0)
+	applied, err := idx.Migrate(ctx)
+	expNil(t, err)
+	expEq(t, "applied migrations", len(applied), 0)
+	// backup
+	backup := filepath.Join(t.TempDir(), "backup.sqlite")
+	expNil(t, idx.Backup(ctx, backup))
+	if err := idx.Backup(ctx, backup); err == nil {
+		t.Error("expected an error for existing backup file")
+	}
+	backupIdx, err := sqlite.Open("file:" + backup)
+	expNil(t, err)
+	defer backupIdx.Close()
+	major, minor, err := backupIdx.GetSchemaVersion(ctx)
+	expNil(t, err)
+	expEq(t, "backup schema", [2]int{major, minor}, [2]int{0, 11})
+}
+
+func TestMigrateV04(t *testing.T) {
+	ctx := context.Background()
+	fixture, err := os.ReadFile(filepath.Join("testdata", "index-v0.4.sql"))
+	expNil(t, err)
+	con := fmt.Sprintf("file:%s?mode=memory&_busy_timeout=10000&_journal=WAL&_sync=NORMAL&cache=shared", t.Name())
+	idx, err := sqlite.Open(con)
+	expNil(t, err)
+	defer idx.Close()
+	_, err = idx.ExecContext(ctx, string(fixture))
+	expNil(t, err)
+	_, err = idx.InitSchema(ctx)
+	expErrIs(t, "InitSchema with old schema", err, index.ErrSchemaOld)
+	applied, err := idx.Migrate(ctx)
+	expNil(t, err)
+	expEq(t, "applied migrations", len(applied), 7)
+	expEq(t, "first applied migration", applied[0].Name, "0.4-0.5")
+	major, minor, err := idx.GetSchemaVersion(ctx)
+	expNil(t, err)
+	expEq(t, "migrated schema", [2]int{major, minor}, [2]int{0, 11})
+	// existing objects belong to the default storage root
+	summary, err := idx.GetIndexSummary(ctx, index.DefaultStorageRoot)
+	expNil(t, err)
+	expEq(t, "object roots", summary.NumObjects, 2)
+	expEq(t, "inventories", summary.NumInventories, 1)
+	obj, err := idx.GetObject(ctx, index.DefaultStorageRoot, "object-1")
+	expNil(t, err)
+	expEq(t, "object root", obj.RootPath, "object-1")
+	expEq(t, "object versions", len(obj.Versions), 1)
+	// stats aren't set until the object is re-indexed
+	expEq(t, "migrated version has stats", obj.Versions[0].HasStats, false)
+	results, err := idx.Search(ctx, index.DefaultStorageRoot, "first version", 0, "")
+	expNil(t, err)
+	expEq(t, "search results", len(results.Results), 1)
+	// the same object can be indexed in another storage root
+	m := mock.NewIndexingObject("object-1")
+	tx, err := idx.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	expNil(t, tx.IndexObjectInventory(ctx, "other", m.IndexedAt, index.ObjectInventory{
+		Inventory: m.Inventory,
+		Path:      m.RootDir,
+	}))
+	expNil(t, tx.Commit())
+	obj, err = idx.GetObject(ctx, index.DefaultStorageRoot, "object-1")
+	expNil(t, err)
+	expEq(t, "object digest in default storage root", obj.InventoryDigest, "abcd")
+}
 
-	t.Run("empty", func(t *testing.T) {
-		idx, err := setupSqliteIndex(ctx, t.Name(), func(tx index.BackendTx) error {
-			return nil
-		})
+// TestMigrateV05 checks that search entries created by the 0.5-0.6 migration
+// match those created by indexing.
+func TestMigrateV05(t *testing.T) {
+	ctx := context.Background()
+	idx, err := newSqliteIndex(ctx, t.Name())
+	expNil(t, err)
+	defer idx.Close()
+	m := mock.NewIndexingObject("object-1", mock.WithHead(ocfl.V(3)), mock.BigDir("a/b", 3))
+	tx, err := idx.NewTx(ctx)
+	expNil(t, err)
+	defer tx.Rollback()
+	expNil(t, tx.IndexObjectInventory(ctx, index.DefaultStorageRoot, m.IndexedAt, index.ObjectInventory{
+		Inventory: m.Inventory,
+		Path:      m.RootDir,
+	}))
+	expNil(t, tx.Commit())
+	queries := []string{"object", "txt", "nobody", "change", "a/b"}
+	expResults := map[string]*index.SearchResults{}
+	for _, q := range queries {
+		expResults[q], err = idx.Search(ctx, index.DefaultStorageRoot, q, 0, "")
 		expNil(t, err)
-		summary, err := idx.GetIndexSummary(ctx)
+	}
+	// revert to the v0.5 schema
+	_, err = idx.ExecContext(ctx, `DROP TRIGGER ocfl_index_search_delete;
+		DROP TABLE ocfl_index_search;
+		DROP INDEX ocfl_index_names_node_id;
+		DROP INDEX ocfl_index_versions_node_id;
+		DROP TABLE ocfl_index_tasks;
+		DROP TABLE ocfl_index_validation_issues;
+		DROP TABLE ocfl_index_fixity_checks;
+		ALTER TABLE ocfl_index_versions DROP COLUMN num_files;
+		ALTER TABLE ocfl_index_versions DROP COLUMN num_added;
+		ALTER TABLE ocfl_index_versions DROP COLUMN num_removed;
+		ALTER TABLE ocfl_index_versions DROP COLUMN num_modified;
+		UPDATE ocfl_index_schema SET major = 0, minor = 5;`)
+	expNil(t, err)
+	applied, err := idx.Migrate(ctx)
+	expNil(t, err)
+	expEq(t, "applied migrations", len(applied), 6)
+	for _, q := range queries {
+		results, err := idx.Search(ctx, index.DefaultStorageRoot, q, 0, "")
 		expNil(t, err)
-		exp := index.IndexSummary{
-			NumInventories: 0,
-			NumObjects:     0,
-			UpdatedAt:      time.Time{},
+		expEq(t, fmt.Sprintf("number of results for %q", q), len(results.Results), len(expResults[q].Results))
+		for i, r := range expResults[q].Results {
+			if !slices.Contains(results.Results, r) {
+				t.Errorf("result %d for %q missing after migration: %v", i, q, r)
+			}
 		}
-		expEq(t, "indexed summary", summary, exp)
-	})
-
+	}
 }
 
 func TestIndexObject(t *testing.T) {
@@ -89,12 +188,12 @@ func TestIndexObject(t *testing.T) {
 	mock2.IndexedAt = mock1.IndexedAt.AddDate(0, 0, 1)
 	mock3 := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(3)))
 	mock3.IndexedAt = mock1.IndexedAt.AddDate(0, 0, 2)
-	expNil(t, tx.IndexObjectRoot(ctx, mock1.IndexedAt, index.ObjectRoot{Path: mock1.RootDir}))
-	expNil(t, tx.IndexObjectInventory(ctx, mock2.IndexedAt, index.ObjectInventory{
+	expNil(t, tx.IndexObjectRoot(ctx, index.DefaultStorageRoot, mock1.IndexedAt, index.ObjectRoot{Path: mock1.RootDir}))
+	expNil(t, tx.IndexObjectInventory(ctx, index.DefaultStorageRoot, mock2.IndexedAt, index.ObjectInventory{
 		Inventory: mock2.Inventory,
 		Path:      mock2.RootDir,
 	}))
-	expNil(t, tx.IndexObjectInventory(ctx, mock3.IndexedAt, index.ObjectInventory{
+	expNil(t, tx.IndexObjectInventory(ctx, index.DefaultStorageRoot, mock3.IndexedAt, index.ObjectInventory{
 		Inventory: mock3.Inventory,
 		Path:      mock3.RootDir,
 	}))
@@ -135,205 +234,6 @@ func compareInventory(t *testing.T, idxInv sqlc.OcflIndexInventory, idxVers []sq
 	}
 }
 
-func TestListObjectRoot(t *testing.T) {
-	ctx := context.Background()
-	idx, err := newSqliteIndex(ctx, t.Name())
-	expNil(t, err)
-	defer idx.Close()
-	tx, err := idx.NewTx(ctx)
-	expNil(t, err)
-	defer tx.Rollback()
-	numObjects := 721
-	for i := 0; i < numObjects; i++ {
-		id := fmt.Sprintf("test-listroots-%d", i)
-		// index successive versions of the same object
-		mock := mock.NewIndexingObject(id)
-		root := index.ObjectRoot{Path: mock.RootDir}
-		expNil(t, tx.IndexObjectRoot(ctx, mock.IndexedAt, root))
-	}
-	expNil(t, tx.Commit())
-	found := 0
-	cursor := ""
-	for {
-		items, err := idx.ListObjectRoots(ctx, 17, cursor)
-		if err != nil {
-			t.Fatal(err)
-		}
-		found += len(items.ObjectRoots)
-		if items.NextCursor == "" {
-			break
-		}
-		cursor = items.NextCursor
-	}
-	expEq(t, "indexed object roots", found, numObjects)
-}
-
-func TestRemoveObjectsBefore(t *testing.T) {
-	ctx := context.Background()
-	idx, err := newSqliteIndex(ctx, t.Name())
-	expNil(t, err)
-	defer idx.Close()
-	tx, err := idx.NewTx(ctx)
-	expNil(t, err)
-	defer tx.Rollback()
-	numObjects := 721
-	indexedAt := time.Now().Add(-7 * time.Hour) // seven hours ago
-	for i := 0; i < numObjects; i++ {
-		id := fmt.Sprintf("test-delroots-%d", i)
-		// index successive versions of the same object
-		mock := mock.NewIndexingObject(id)
-		root := index.ObjectRoot{Path: mock.RootDir}
-		expNil(t, tx.IndexObjectRoot(ctx, indexedAt, root))
-		indexedAt = indexedAt.Add(time.Hour)
-	}
-	expNil(t, tx.Commit())
-	roots, err := idx.DEBUG_AllObjecRootss(ctx)
-	expNil(t, err)
-	lenBefore := len(roots)
-	tx, err = idx.NewTx(ctx)
-	expNil(t, err)
-	// should remove seven
-	expNil(t, tx.RemoveObjectsBefore(ctx, time.Now()))
-	expNil(t, tx.Commit())
-	roots, err = idx.DEBUG_AllObjecRootss(ctx)
-	expNil(t, err)
-	lenAfter := len(roots)
-	// expect 8 deleted objects
-	expEq(t, "deleted object roots", lenAfter, lenBefore-8)
-}
-
-func TestListObjects(t *testing.T) {
-	ctx := context.Background()
-	t.Run("empty", func(t *testing.T) {
-		idx, err := setupSqliteIndex(ctx, t.Name(), func(tx index.BackendTx) error {
-			return nil
-		})
-		expNil(t, err)
-		_, err = idx.ListObjects(ctx, "", 10, "")
-		expNil(t, err)
-	})
-	t.Run("no prefix", func(t *testing.T) {
-		const numInvs = 27
-		mocks := make([]*mock.IndexingObject, numInvs)
-		idx, err := setupSqliteIndex(ctx, t.Name(), func(tx index.BackendTx) error {
-			letters := []rune("abcdefghijklmnopqrstuvqwxyz")
-			for i := 0; i < len(mocks); i++ {
-				id := fmt.Sprintf("%c-test-%d", letters[i%len(letters)], i)
-				m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
-				err := tx.IndexObjectInventory(ctx, m.IndexedAt, index.ObjectInventory{
-					Inventory: m.Inventory,
-					Path:      m.RootDir,
-				})
-				if err != nil {
-					return err
-				}
-				mocks[i] = m
-			}
-			return nil
-		})
-		expNil(t, err)
-		sort.Slice(mocks, func(i, j int) bool {
-			return mocks[i].Inventory.ID < mocks[j].Inventory.ID
-		})
-		objs := []index.ObjectListItem{}
-		cursor := ""
-		for {
-			results, err := idx.ListObjects(ctx, "", 5, cursor)
-			expNil(t, err)
-			objs = append(objs, results.Objects...)
-			cursor = results.NextCursor
-			if cursor == "" {
-				break
-			}
-		}
-		expEq(t, "number of objects in result", len(objs), len(mocks))
-		for i, m := range mocks {
-			expEq(t, "result id matches mock id", objs[i].ID, m.Inventory.ID)
-			expEq(t, "result root matches mock root", objs[i].RootPath, m.RootDir)
-			expEq(t, "result spec matches mock spec", objs[i].Spec, m.Inventory.Type.Spec)
-			expEq(t, "result head matches mock head", objs[i].Head, m.Inventory.Head)
-			expEq(t, "result v1 created match mock v1 created", objs[i].V1Created, m.Inventory.Versions[ocfl.V(1)].Created)
-			expEq(t, "result head created match mock v2 created", objs[i].HeadCreated, m.Inventory.Versions[ocfl.V(2)].Created)
-		}
-	})
-
-	t.Run("with prefix", func(t *testing.T) {
-		const numInvs = 54
-		idx, err := setupSqliteIndex(ctx, t.Name(), func(tx index.BackendTx) error {
-			letters := []rune("abcdefghijklmnopqrstuvqwxyz")
-			for i := 0; i < numInvs; i++ {
-				id := fmt.Sprintf("%c-test-%d", letters[i%len(letters)], i)
-				m := mock.NewIndexingObject(id, mock.WithHead(ocfl.V(2)))
-				err := tx.IndexObjectInventory(ctx, m.IndexedAt, index.ObjectInventory{
-					Inventory: m.Inventory,
-					Path:      m.RootDir,
-				})
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		})
-		expNil(t, err)
-		results, err := idx.ListObjects(ctx, "a", 5, "")
-		expNil(t, err)
-		expEq(t, "number of results", len(results.Objects), 2)
-		expEq(t, "next page cursor", results.NextCursor, "")
-	})
-
-}
-
-func TestGetObjectState(t *testing.T) {
-	ctx := context.Background()
-	idx, err := newSqliteIndex(ctx, t.Name())
-	expNil(t, err)
-	defer idx.Close()
-	tx, err := idx.NewTx(ctx)
-	expNil(t, err)
-	defer tx.Rollback()
-	// index an object with 1231 files in a directory called "long"
-	id := "object-1"
-	head := ocfl.V(1)
-	dir := "long"
-	dirsize := 1231
-	mock1 := mock.NewIndexingObject(id,
-		mock.WithHead(head),
-		mock.BigDir(dir, dirsize))
-	err = tx.IndexObjectInventory(ctx, mock1.IndexedAt, index.ObjectInventory{
-		Inventory: mock1.Inventory,
-		Path:      mock1.RootDir,
-	})
-	expNil(t, err)
-	expNil(t, tx.Commit())
-	// read long directory
-	cursor := ""
-	var allFiles []string
-	for {
-		// list of all files in head state
-		pathinfo, err := idx.GetObjectState(ctx, id, head, ".", true, 41, cursor)
-		expNil(t, err)
-		for _, ch := range pathinfo.Children {
-			allFiles = append(allFiles, ch.Name)
-		}
-		if pathinfo.NextCursor == "" {
-			break
-		}
-		cursor = pathinfo.NextCursor
-	}
-	expEq(t, "directory entries", len(allFiles), dirsize+4)
-	for _, f := range allFiles {
-		inf, err := idx.GetObjectState(ctx, id, head, f, false, 1, "")
-		expNil(t, err)
-		// expEq(t, "HasSize", inf.HasSize, true)
-		expEq(t, "IsDir", inf.IsDir, false)
-		expEq(t, "Sum len", len(inf.Sum), 128)
-		expEq(t, "Children len", len(inf.Children), 0)
-		// if inf.Size == 0 {
-		// 	t.Fatal("size is missing")
-		// }
-	}
-}
-
 func newSqliteIndex(ctx context.Context, name string) (*sqlite.Backend, error) {
 	con := fmt.Sprintf("file:%s?mode=memory&_busy_timeout=10000&_journal=WAL&_sync=NORMAL&cache=shared", name)
 	idx, err := sqlite.Open(con)
@@ -347,25 +247,6 @@ func newSqliteIndex(ctx context.Context, name string) (*sqlite.Backend, error) {
 	return idx, nil
 }
 
-func setupSqliteIndex(ctx context.Context, name string, setup func(tx index.BackendTx) error) (*sqlite.Backend, error) {
-	db, err := newSqliteIndex(ctx, name)
-	if err != nil {
-		return nil, err
-	}
-	tx, err := db.NewTx(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-	if err := setup(tx); err != nil {
-		return nil, err
-	}
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-	return db, nil
-}
-
 func expNil(t *testing.T, val any) {
 	t.Helper()
 	if val != nil {
@@ -373,6 +254,13 @@ func expNil(t *testing.T, val any) {
 	}
 }
 
+func expErrIs(t *testing.T, desc string, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: got error '%v', expected '%v'", desc, err, target)
+	}
+}
+
 func expNotNil(t *testing.T, desc string, val any) {
 	t.Helper()
 	if val == nil {
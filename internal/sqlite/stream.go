@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/srerickson/ocfl"
+	"github.com/srerickson/ocfl-index/internal/index"
+)
+
+var (
+	// sqlc loads all rows from :many queries into memory. These queries are
+	// used to iterate over large results with a cursor instead.
+	queryEachObject = `SELECT invs.ocfl_id, root.path, invs.spec, invs.head,
+    v1.created v1_created, head.created head_created
+    FROM ocfl_index_inventories invs
+    INNER JOIN ocfl_index_object_roots root ON invs.root_id = root.id
+    INNER JOIN ocfl_index_versions head ON invs.id = head.inventory_id AND invs.head = head.name
+    INNER JOIN ocfl_index_versions v1 ON invs.id = v1.inventory_id AND v1.num = 1
+    INNER JOIN ocfl_index_storage_roots store ON invs.storage_root_id = store.id
+    WHERE store.name = ?1 AND invs.ocfl_id LIKE ?2 || '%' ESCAPE '\'
+    ORDER BY invs.ocfl_id ASC;`
+	queryEachNodeChild = `SELECT names.name, child.dir, child.sum, child.size
+    FROM ocfl_index_nodes child
+    INNER JOIN ocfl_index_names names ON child.id = names.node_id
+    WHERE names.parent_id = ? ORDER BY names.name ASC;`
+)
+
+// EachObject implements index.Backend
+func (db *Backend) EachObject(ctx context.Context, storageRoot string, prefix string, fn func(*index.ObjectListItem) error) error {
+	rows, err := db.QueryContext(ctx, queryEachObject, storageRoot, prefix)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			obj        index.ObjectListItem
+			spec, head string
+		)
+		if err := rows.Scan(&obj.ID, &obj.RootPath, &spec, &head, &obj.V1Created, &obj.HeadCreated); err != nil {
+			return err
+		}
+		if err := ocfl.ParseVNum(head, &obj.Head); err != nil {
+			return fmt.Errorf("parsing indexed inventory head value: %w", err)
+		}
+		if err := ocfl.ParseSpec(spec, &obj.Spec); err != nil {
+			return fmt.Errorf("parsing indexed inventory spec value: %w", err)
+		}
+		if err := fn(&obj); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachObjectStateItem implements index.Backend
+func (db *Backend) EachObjectStateItem(ctx context.Context, storageRoot string, id string, v ocfl.VNum, p string, recursive bool, fn func(*index.PathItem) error) error {
+	p = path.Clean(p)
+	if !fs.ValidPath(p) {
+		return fmt.Errorf("invalid path '%s': %w", p, index.ErrInvalidArgs)
+	}
+	baseNode, err := db.getStateNode(ctx, storageRoot, id, v, p)
+	if err != nil {
+		return err
+	}
+	if !baseNode.isdir {
+		return nil
+	}
+	var rows *sql.Rows
+	if recursive {
+		// no cursor and no limit (-1)
+		rows, err = db.QueryContext(ctx, queryGetNodeChildren, baseNode.id, "", -1)
+	} else {
+		rows, err = db.QueryContext(ctx, queryEachNodeChild, baseNode.id)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", id, p, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			item     index.PathItem
+			nodeID   int64
+			sumBytes []byte
+			size     sql.NullInt64
+		)
+		if recursive {
+			// only files are included
+			err = rows.Scan(&nodeID, &item.Name, &sumBytes, &size)
+		} else {
+			err = rows.Scan(&item.Name, &item.IsDir, &sumBytes, &size)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", id, p, err)
+		}
+		item.Sum = hex.EncodeToString(sumBytes)
+		item.Size = size.Int64
+		item.HasSize = size.Valid
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %s: %w", id, p, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/sqlite/sqlc"
+)
+
+// CreateTask implements index.TaskStore
+func (db *Backend) CreateTask(ctx context.Context, task *index.Task) error {
+	opts, err := json.Marshal(task.Options)
+	if err != nil {
+		return err
+	}
+	counts, err := json.Marshal(task.Counts)
+	if err != nil {
+		return err
+	}
+	id, err := sqlc.New(db).InsertTask(ctx, sqlc.InsertTaskParams{
+		Name:        task.Name,
+		StorageRoot: task.StorageRoot,
+		Options:     string(opts),
+		Status:      string(task.Status),
+		StartedAt:   task.StartedAt.UTC(),
+		Counts:      string(counts),
+		Error:       task.Error,
+	})
+	if err != nil {
+		return err
+	}
+	task.ID = id
+	return nil
+}
+
+// UpdateTask implements index.TaskStore
+func (db *Backend) UpdateTask(ctx context.Context, task *index.Task) error {
+	counts, err := json.Marshal(task.Counts)
+	if err != nil {
+		return err
+	}
+	return sqlc.New(db).UpdateTask(ctx, sqlc.UpdateTaskParams{
+		ID:        task.ID,
+		Status:    string(task.Status),
+		StartedAt: task.StartedAt.UTC(),
+		EndedAt:   sql.NullTime{Time: task.EndedAt.UTC(), Valid: !task.EndedAt.IsZero()},
+		Counts:    string(counts),
+		Error:     task.Error,
+	})
+}
+
+// FailUnfinishedTasks implements index.TaskStore
+func (db *Backend) FailUnfinishedTasks(ctx context.Context, endedAt time.Time, msg string) error {
+	return sqlc.New(db).FailUnfinishedTasks(ctx, sqlc.FailUnfinishedTasksParams{
+		EndedAt: sql.NullTime{Time: endedAt.UTC(), Valid: true},
+		Error:   msg,
+	})
+}
+
+// GetTask implements index.TaskStore
+func (db *Backend) GetTask(ctx context.Context, id int64) (*index.Task, error) {
+	row, err := sqlc.New(db).GetTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task %d: %w", id, index.ErrNotFound)
+		}
+		return nil, err
+	}
+	return asIndexTask(&row)
+}
+
+// ListTasks implements index.TaskStore. The cursor is the ID of the last task
+// in the previous page.
+func (db *Backend) ListTasks(ctx context.Context, limit int, cursor string) (*index.TaskList, error) {
+	if limit < 1 || limit > 1000 {
+		limit = defaultLimit
+	}
+	before := int64(math.MaxInt64)
+	if cursor != "" {
+		var err error
+		if before, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid cursor '%s': %w", cursor, index.ErrInvalidArgs)
+		}
+	}
+	// add 1 to limit to see if there are more items
+	rows, err := sqlc.New(db).ListTasks(ctx, sqlc.ListTasksParams{
+		ID:    before,
+		Limit: int64(limit + 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &index.TaskList{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		list.NextCursor = strconv.FormatInt(rows[limit-1].ID, 10)
+	}
+	list.Tasks = make([]index.Task, len(rows))
+	for i := range rows {
+		task, err := asIndexTask(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		list.Tasks[i] = *task
+	}
+	return list, nil
+}
+
+func asIndexTask(row *sqlc.OcflIndexTask) (*index.Task, error) {
+	task := &index.Task{
+		ID:          row.ID,
+		Name:        row.Name,
+		StorageRoot: row.StorageRoot,
+		Status:      index.TaskStatus(row.Status),
+		StartedAt:   row.StartedAt,
+		Error:       row.Error,
+	}
+	if row.EndedAt.Valid {
+		task.EndedAt = row.EndedAt.Time
+	}
+	if err := json.Unmarshal([]byte(row.Options), &task.Options); err != nil {
+		return nil, fmt.Errorf("parsing indexed task options: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.Counts), &task.Counts); err != nil {
+		return nil, fmt.Errorf("parsing indexed task counts: %w", err)
+	}
+	return task, nil
+}
@@ -42,48 +42,90 @@ func (tx *Tx) Commit() error {
 	return tx.tx.Commit()
 }
 
-func (tx *Tx) GetObjectByPath(ctx context.Context, p string) (*index.Object, error) {
+func (tx *Tx) GetObjectByPath(ctx context.Context, storageRoot string, p string) (*index.Object, error) {
 	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
-	return getObjectByPathTx(ctx, qryTx, p)
+	return getObjectByPathTx(ctx, qryTx, storageRoot, p)
 }
 
-func (tx *Tx) ListObjectRoots(ctx context.Context, limit int, cursor string) (*index.ObjectRootList, error) {
+func (tx *Tx) ListObjectRoots(ctx context.Context, storageRoot string, limit int, cursor string) (*index.ObjectRootList, error) {
 	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
-	return listObjectRootsTx(ctx, qryTx, limit, cursor)
+	return listObjectRootsTx(ctx, qryTx, storageRoot, limit, cursor)
 }
 
-func (tx *Tx) IndexObjectRoot(ctx context.Context, indexedAt time.Time, roots ...index.ObjectRoot) error {
+func (tx *Tx) IndexObjectRoot(ctx context.Context, storageRoot string, indexedAt time.Time, roots ...index.ObjectRoot) error {
 	qryTx := sqlc.New(tx.db).WithTx(tx.tx)
+	storeRow, err := indexStorageRootTx(ctx, qryTx, storageRoot)
+	if err != nil {
+		return err
+	}
 	for _, r := range roots {
-		if _, err := indexObjectRootTx(ctx, qryTx, r.Path, indexedAt); err != nil {
+		if _, err := indexObjectRootTx(ctx, qryTx, storeRow, r.Path, indexedAt); err != nil {
 			return fmt.Errorf("indexing object root: %w", err)
 		}
 	}
 	return nil
 }
 
-func (tx *Tx) IndexObjectInventory(ctx context.Context, idxAt time.Time, inv ...index.ObjectInventory) error {
+func (tx *Tx) IndexObjectInventory(ctx context.Context, storageRoot string, idxAt time.Time, inv ...index.ObjectInventory) error {
 	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	storeRow, err := indexStorageRootTx(ctx, qry, storageRoot)
+	if err != nil {
+		return err
+	}
 	for i := range inv {
-		rootrow, err := indexObjectRootTx(ctx, qry, inv[i].Path, idxAt)
+		rootrow, err := indexObjectRootTx(ctx, qry, storeRow, inv[i].Path, idxAt)
 		if err != nil {
 			return fmt.Errorf("indexing object root: %w", err)
 		}
-		if err := indexInventoryTx(ctx, qry, rootrow, idxAt, inv[i].Inventory, nil); err != nil {
+		if err := indexInventoryTx(ctx, qry, tx.tx, storeRow, rootrow, idxAt, inv[i].Inventory, inv[i].FileSizes); err != nil {
 			return fmt.Errorf("indexing inventory: %w", err)
 		}
 	}
 	return nil
 }
 
-// Remove all objects with indexed_at values older than before.
-func (tx *Tx) RemoveObjectsBefore(ctx context.Context, indexedBefore time.Time) error {
+// ListObjectContentSize returns indexed sizes for content files in the object
+// with the given ID.
+func (tx *Tx) ListObjectContentSize(ctx context.Context, storageRoot string, objID string) (map[string]int64, error) {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	rows, err := qry.ListObjectContentSize(ctx, sqlc.ListObjectContentSizeParams{
+		Name:   storageRoot,
+		OcflID: objID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		sizes[r.FilePath] = r.Size.Int64
+	}
+	return sizes, nil
+}
+
+// Remove all objects in the storage root with indexed_at values older than
+// before.
+func (tx *Tx) RemoveObjectsBefore(ctx context.Context, storageRoot string, indexedBefore time.Time) error {
 	qry := sqlc.New(tx.db).WithTx(tx.tx)
-	// indexed_at is always UTC
-	return qry.DeleteObjectRootsBefore(ctx, indexedBefore.UTC())
+	return qry.DeleteObjectRootsBefore(ctx, sqlc.DeleteObjectRootsBeforeParams{
+		Name:      storageRoot,
+		IndexedAt: indexedBefore.UTC(), // indexed_at is always UTC
+	})
+}
+
+// indexStorageRootTx adds the storage root name to the index, if necessary, and
+// returns its row id.
+func indexStorageRootTx(ctx context.Context, qry *sqlc.Queries, name string) (int64, error) {
+	if name == "" {
+		return 0, fmt.Errorf("storage root name is required: %w", index.ErrInvalidArgs)
+	}
+	store, err := qry.UpsertStorageRoot(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("upsert storage root: %w", err)
+	}
+	return store.ID, nil
 }
 
-func indexObjectRootTx(ctx context.Context, qry *sqlc.Queries, root string, idxAt time.Time) (int64, error) {
+func indexObjectRootTx(ctx context.Context, qry *sqlc.Queries, storeRow int64, root string, idxAt time.Time) (int64, error) {
 	if root == "" {
 		return 0, fmt.Errorf("object root is required: %w", index.ErrInvalidArgs)
 	}
@@ -91,8 +133,9 @@ func indexObjectRootTx(ctx context.Context, qry *sqlc.Queries, root string, idxA
 		idxAt = time.Now()
 	}
 	idxobj, err := qry.UpsertObjectRoot(ctx, sqlc.UpsertObjectRootParams{
-		Path:      root,
-		IndexedAt: idxAt.UTC(), // always use UTC
+		StorageRootID: storeRow,
+		Path:          root,
+		IndexedAt:     idxAt.UTC(), // always use UTC
 	})
 	if err != nil {
 		return 0, fmt.Errorf("upsert object root: %w", err)
@@ -101,20 +144,31 @@ func indexObjectRootTx(ctx context.Context, qry *sqlc.Queries, root string, idxA
 }
 
 // index the inventory. To index without filesize checks, sizes must be nil.
-func indexInventoryTx(ctx context.Context, qry *sqlc.Queries, rootRow int64, idxAt time.Time, inv *ocflv1.Inventory, sizes map[string]int64) error {
+// Queries that sqlc doesn't support are run with db, which should be the
+// transaction used by qry.
+func indexInventoryTx(ctx context.Context, qry *sqlc.Queries, db sqlc.DBTX, storeRow int64, rootRow int64, idxAt time.Time, inv *ocflv1.Inventory, sizes map[string]int64) error {
+	if idxAt.IsZero() {
+		idxAt = time.Now()
+	}
 	idxInv, err := qry.UpsertInventory(ctx, sqlc.UpsertInventoryParams{
 		OcflID:          inv.ID,
 		RootID:          rootRow,
+		StorageRootID:   storeRow,
 		Head:            inv.Head.String(),
 		Spec:            inv.Type.Spec.String(),
 		DigestAlgorithm: inv.DigestAlgorithm,
 		InventoryDigest: inv.Digest(),
+		IndexedAt:       idxAt.UTC(), // always use UTC
 	})
 	if err != nil {
 		return err
 	}
 	invrow := idxInv.ID
 	// versions table
+	stats, err := index.InventoryVersionStats(inv)
+	if err != nil {
+		return err
+	}
 	for vnum, ver := range inv.Versions {
 		// sizes may be nil
 		tree, err := index.PathTree(inv, vnum, sizes)
@@ -129,7 +183,7 @@ func indexInventoryTx(ctx context.Context, qry *sqlc.Queries, rootRow int64, idx
 		if err != nil {
 			return fmt.Errorf("indexing version state: %w", err)
 		}
-		if err := insertVersion(ctx, qry, vnum, ver, invrow, nodeRow); err != nil {
+		if err := insertVersion(ctx, qry, vnum, ver, stats[vnum], invrow, nodeRow); err != nil {
 			return fmt.Errorf("indexing inventory versions: %w", err)
 		}
 	}
@@ -137,15 +191,38 @@ func indexInventoryTx(ctx context.Context, qry *sqlc.Queries, rootRow int64, idx
 	if err := insertContent(ctx, qry, inv.Manifest, invrow); err != nil {
 		return fmt.Errorf("indexing content files: %w", err)
 	}
+	// full-text search
+	if err := insertSearchEntries(ctx, db, inv, invrow); err != nil {
+		return fmt.Errorf("indexing search entries: %w", err)
+	}
+	return nil
+}
+
+// insertSearchEntries replaces the inventory's full-text search entries.
+func insertSearchEntries(ctx context.Context, db sqlc.DBTX, inv *ocflv1.Inventory, invRow int64) error {
+	entries, err := index.SearchEntries(inv)
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, queryDeleteSearchEntries, invRow); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		_, err := db.ExecContext(ctx, queryInsertSearchEntry,
+			invRow, e.Version.String(), e.ObjectID, e.Path, e.Message, e.UserName, e.UserAddress)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // insertVersion adds a new row to the versions table as part of the object
 // indexing. invRow is the row id for the parent inventory, nodeID is the root
-// node for the version state. Currently, rows in the version table are never
-// updated. If a row exists for the version, an error is returned if indexed
-// values don't match those in the given version.
-func insertVersion(ctx context.Context, qry *sqlc.Queries, vnum ocfl.VNum, ver *ocflv1.Version, invRow int64, nodeID int64) error {
+// node for the version state. If a row exists for the version, an error is
+// returned if indexed values don't match those in the given version. Existing
+// rows are only updated to add missing stats.
+func insertVersion(ctx context.Context, qry *sqlc.Queries, vnum ocfl.VNum, ver *ocflv1.Version, stats index.VersionStats, invRow int64, nodeID int64) error {
 	idxV, err := qry.GetVersion(ctx, sqlc.GetVersionParams{
 		InventoryID: invRow,
 		Num:         int64(vnum.Num()),
@@ -160,6 +237,10 @@ func insertVersion(ctx context.Context, qry *sqlc.Queries, vnum ocfl.VNum, ver *
 			Message:     ver.Message,
 			Created:     ver.Created.UTC(), // location prevents errors reading from db
 			NodeID:      nodeID,
+			NumFiles:    sql.NullInt64{Int64: int64(stats.Files), Valid: true},
+			NumAdded:    sql.NullInt64{Int64: int64(stats.Added), Valid: true},
+			NumRemoved:  sql.NullInt64{Int64: int64(stats.Removed), Valid: true},
+			NumModified: sql.NullInt64{Int64: int64(stats.Modified), Valid: true},
 		}
 		if ver.User != nil {
 			params.UserAddress = ver.User.Address
@@ -187,6 +268,20 @@ func insertVersion(ctx context.Context, qry *sqlc.Queries, vnum ocfl.VNum, ver *
 			return fmt.Errorf("user name for version '%s' has changed: %w", vnum, index.ErrIndexValue)
 		}
 	}
+	if !idxV.NumFiles.Valid {
+		// version was indexed before stats were added to the schema
+		err := qry.SetVersionStats(ctx, sqlc.SetVersionStatsParams{
+			InventoryID: invRow,
+			Num:         int64(vnum.Num()),
+			NumFiles:    sql.NullInt64{Int64: int64(stats.Files), Valid: true},
+			NumAdded:    sql.NullInt64{Int64: int64(stats.Added), Valid: true},
+			NumRemoved:  sql.NullInt64{Int64: int64(stats.Removed), Valid: true},
+			NumModified: sql.NullInt64{Int64: int64(stats.Modified), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("setting stats for version '%s': %w", vnum, err)
+		}
+	}
 	return nil
 }
 
@@ -232,8 +327,8 @@ func addPathtreeChild(ctx context.Context, tx *sqlc.Queries, tree *pathtree.Node
 		}
 	}
 	if !isNew {
-		// if getInserNode didn't create a new node, the children have already
-		// been created.
+		// if getSetNode didn't create or update the node, the children have
+		// already been created.
 		return nodeID, nil
 	}
 	for _, e := range tree.DirEntries() {
@@ -271,11 +366,16 @@ func getSetNode(ctx context.Context, qry *sqlc.Queries, val index.IndexingVal, i
 	}
 	if val.HasSize && !node.Size.Valid {
 		// update node size when possible
-		qry.SetNodeSize(ctx, sqlc.SetNodeSizeParams{
+		err := qry.SetNodeSize(ctx, sqlc.SetNodeSizeParams{
 			Size: sql.NullInt64{Int64: val.Size, Valid: val.HasSize},
 			Sum:  val.Sum,
 			Dir:  isdir,
 		})
+		if err != nil {
+			return 0, false, err
+		}
+		// children may also need sizes
+		return node.ID, true, nil
 	}
 	return node.ID, false, nil
 }
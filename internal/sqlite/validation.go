@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/srerickson/ocfl-index/internal/index"
+	"github.com/srerickson/ocfl-index/internal/sqlite/sqlc"
+)
+
+// SetValidationIssues implements index.BackendTx
+func (tx *Tx) SetValidationIssues(ctx context.Context, storageRoot string, idxAt time.Time, root string, issues []index.ValidationIssue) error {
+	qry := sqlc.New(tx.db).WithTx(tx.tx)
+	storeRow, err := indexStorageRootTx(ctx, qry, storageRoot)
+	if err != nil {
+		return err
+	}
+	rootRow, err := indexObjectRootTx(ctx, qry, storeRow, root, idxAt)
+	if err != nil {
+		return fmt.Errorf("indexing object root: %w", err)
+	}
+	if err := qry.DeleteValidationIssues(ctx, rootRow); err != nil {
+		return fmt.Errorf("removing previous validation issues: %w", err)
+	}
+	for _, issue := range issues {
+		err := qry.InsertValidationIssue(ctx, sqlc.InsertValidationIssueParams{
+			RootID:        rootRow,
+			Severity:      string(issue.Severity),
+			Code:          issue.Code,
+			Message:       issue.Message,
+			InventoryPath: issue.InventoryPath,
+			CheckedAt:     issue.CheckedAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("indexing validation issue: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListInvalidObjects implements index.Backend. The cursor is the path of the
+// last object in the previous page.
+func (db *Backend) ListInvalidObjects(ctx context.Context, storageRoot string, limit int, cursor string) (*index.InvalidObjectList, error) {
+	if limit < 1 || limit > 1000 {
+		limit = defaultLimit
+	}
+	// add 1 to limit to see if there are more objects
+	rows, err := sqlc.New(db).ListInvalidObjects(ctx, sqlc.ListInvalidObjectsParams{
+		Name:  storageRoot,
+		Path:  cursor,
+		Limit: int64(limit + 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &index.InvalidObjectList{}
+	for _, row := range rows {
+		if n := len(list.Objects); n == 0 || list.Objects[n-1].RootPath != row.Path {
+			if n == limit {
+				// cursor is the path of the last object in the results
+				list.NextCursor = list.Objects[n-1].RootPath
+				break
+			}
+			list.Objects = append(list.Objects, index.InvalidObject{RootPath: row.Path})
+		}
+		obj := &list.Objects[len(list.Objects)-1]
+		obj.Issues = append(obj.Issues, index.ValidationIssue{
+			Severity:      index.ValidationSeverity(row.Severity),
+			Code:          row.Code,
+			Message:       row.Message,
+			InventoryPath: row.InventoryPath,
+			CheckedAt:     row.CheckedAt.UTC(),
+		})
+	}
+	return list, nil
+}